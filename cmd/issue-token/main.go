@@ -0,0 +1,61 @@
+// Command issue-token mints a staff bearer token signed with JWT_SECRET, for
+// local development and for bootstrapping the first API keys.
+//
+//	JWT_SECRET=dev go run ./cmd/issue-token -sub jane -name "Jane Doe" -role admin
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"AmarthaExample1/internal/services"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("JWT_SECRET"), "token signing secret")
+	issuer := flag.String("iss", os.Getenv("JWT_ISSUER"), "token issuer")
+	subject := flag.String("sub", "", "staff member the token is issued to")
+	name := flag.String("name", "", "display name")
+	role := flag.String("role", "", "role granted by the token")
+	officerID := flag.Uint("officer", 0, "field officer ID, for field officer tokens")
+	tenantID := flag.Uint("tenant", 0, "tenant ID, the default tenant when not given")
+	branchID := flag.Uint("branch", 0, "branch ID, for tokens limited to one branch")
+	ttl := flag.Duration("ttl", 8*time.Hour, "how long the token is valid for")
+	flag.Parse()
+
+	if *subject == "" || *role == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	now := time.Now()
+	claims := services.TokenClaims{
+		Subject:   *subject,
+		Name:      *name,
+		Role:      *role,
+		Issuer:    *issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(*ttl).Unix(),
+	}
+	if *officerID != 0 {
+		id := uint(*officerID)
+		claims.OfficerID = &id
+	}
+	if *tenantID != 0 {
+		id := uint(*tenantID)
+		claims.TenantID = &id
+	}
+	if *branchID != 0 {
+		id := uint(*branchID)
+		claims.BranchID = &id
+	}
+
+	token, err := services.SignToken(*secret, claims)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(token)
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"AmarthaExample1/internal/config"
 	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
 	"AmarthaExample1/internal/models"
 	"AmarthaExample1/internal/repositories"
 	"AmarthaExample1/internal/routes"
@@ -15,6 +19,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func main() {
@@ -28,40 +33,247 @@ func main() {
 	}
 	db := config.GetDBInstance(dbConfig)
 	defer db.Close()
-	db.Conn.AutoMigrate(&models.Loan{}, &models.Payment{}, &models.Borrower{})
+	db.Conn.AutoMigrate(
+		&models.Loan{}, &models.Payment{}, &models.Borrower{},
+		&models.WriteOff{}, &models.Recovery{}, &models.Restructure{}, &models.PaymentHoliday{},
+		&models.Product{}, &models.CalendarHoliday{}, &models.Group{}, &models.GroupMember{},
+		&models.Officer{}, &models.PaymentTransaction{}, &models.CollectionBatch{}, &models.CollectionBatchLine{},
+		&models.StatementImport{}, &models.StatementLine{}, &models.GatewayPayment{},
+		&models.ReconciliationRun{}, &models.ReconciliationItem{},
+		&models.Lender{}, &models.Funding{}, &models.LenderPayout{},
+		&models.ProductFee{}, &models.LoanCharge{}, &models.LoanApplication{},
+		&models.APIKey{}, &models.AuditLog{}, &models.AuditChainHead{},
+		&models.Tenant{}, &models.Branch{}, &models.OutboxEvent{},
+		&models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.WebhookAttempt{},
+		&models.NotificationTemplate{}, &models.NotificationOptOut{}, &models.Notification{},
+		&models.CollectionCase{}, &models.CollectionActivity{},
+	)
+	if err := repositories.InstallAuditGuards(db.Conn); err != nil {
+		log.Fatalf("Error installing audit log guards: %v", err)
+	}
+	if err := repositories.RegisterTenantCallbacks(db.Conn); err != nil {
+		log.Fatalf("Error registering tenant callbacks: %v", err)
+	}
+	if err := repositories.RegisterAuditCallbacks(db.Conn); err != nil {
+		log.Fatalf("Error registering audit callbacks: %v", err)
+	}
 
 	// Initialize repositories
 	loanRepo := repositories.NewLoanRepository(db.Conn)
+	writeOffRepo := repositories.NewWriteOffRepository(db.Conn)
+	restructureRepo := repositories.NewRestructureRepository(db.Conn)
+	paymentHolidayRepo := repositories.NewPaymentHolidayRepository(db.Conn)
+	calendarRepo := repositories.NewCalendarRepository(db.Conn)
+	productRepo := repositories.NewProductRepository(db.Conn)
+	borrowerRepo := repositories.NewBorrowerRepository(db.Conn)
+	groupRepo := repositories.NewGroupRepository(db.Conn)
+	officerRepo := repositories.NewOfficerRepository(db.Conn)
+	collectionRepo := repositories.NewCollectionRepository(db.Conn)
+	statementRepo := repositories.NewStatementRepository(db.Conn)
+	gatewayRepo := repositories.NewGatewayRepository(db.Conn)
+	reconciliationRepo := repositories.NewReconciliationRepository(db.Conn)
+	lenderRepo := repositories.NewLenderRepository(db.Conn)
+	applicationRepo := repositories.NewApplicationRepository(db.Conn)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db.Conn)
+	auditRepo := repositories.NewAuditRepository(db.Conn)
+	branchRepo := repositories.NewBranchRepository(db.Conn)
+	outboxRepo := repositories.NewOutboxRepository(db.Conn)
+	webhookRepo := repositories.NewWebhookRepository(db.Conn)
+	notificationRepo := repositories.NewNotificationRepository(db.Conn)
+	caseRepo := repositories.NewCollectionCaseRepository(db.Conn)
+	transactor := repositories.NewTransactor(db.Conn)
+
+	if err := branchRepo.EnsureDefaultTenant(repositories.WithAuditActor(context.Background(), "system:startup", "system")); err != nil {
+		log.Fatalf("Error creating the default tenant: %v", err)
+	}
+
+	statementFormats, err := services.LoadStatementFormats(os.Getenv("STATEMENT_FORMATS_FILE"))
+	if err != nil {
+		log.Fatalf("Error loading statement formats: %v", err)
+	}
+	scorecard, err := services.LoadScorecard(os.Getenv("SCORECARD_FILE"))
+	if err != nil {
+		log.Fatalf("Error loading scorecard: %v", err)
+	}
+	accessPolicy, err := services.LoadAccessPolicy(os.Getenv("ACCESS_POLICY_FILE"))
+	if err != nil {
+		log.Fatalf("Error loading access policy: %v", err)
+	}
+	eventPublisher, err := services.NewEventPublisher(os.Getenv("EVENT_PUBLISHER"))
+	if err != nil {
+		log.Fatalf("Error creating event publisher: %v", err)
+	}
+	notificationSenders, err := services.NewNotificationSenders(os.Getenv("NOTIFICATION_SENDER"), os.Getenv("NOTIFICATION_FILE"))
+	if err != nil {
+		log.Fatalf("Error creating notification senders: %v", err)
+	}
 
 	// Initialize services
-	loanService := services.NewLoanService(loanRepo)
+	currency := services.Currency{
+		Code:     getEnv("PAYMENT_CURRENCY", "IDR"),
+		Decimals: getEnvInt("PAYMENT_CURRENCY_DECIMALS", 2),
+	}
+	calendarService := services.NewCalendarService(calendarRepo, productRepo, borrowerRepo)
+	productService := services.NewProductService(productRepo)
+	lenderService := services.NewLenderService(lenderRepo, loanRepo, transactor, services.FundingPolicy{
+		ServiceFeeRate: getEnvFloat("LENDER_SERVICE_FEE_RATE", 0.10),
+	}, currency)
+	eligibilityService := services.NewEligibilityService(loanRepo, borrowerRepo, services.DefaultEligibilityRules(services.EligibilityPolicy{
+		MaxActiveLoans:      getEnvInt("ELIGIBILITY_MAX_ACTIVE_LOANS", 1),
+		BaseCreditLimit:     getEnvFloat("ELIGIBILITY_BASE_CREDIT_LIMIT", 5000000),
+		CycleStepUp:         getEnvFloat("ELIGIBILITY_CYCLE_STEP_UP", 1.5),
+		MaxCreditLimit:      getEnvFloat("ELIGIBILITY_MAX_CREDIT_LIMIT", 0),
+		MinPaidInstallments: getEnvInt("ELIGIBILITY_MIN_PAID_INSTALLMENTS", 10),
+		MinOnTimeRate:       getEnvFloat("ELIGIBILITY_MIN_ON_TIME_RATE", 0.8),
+		Currency:            currency,
+	})...)
+	applicationService := services.NewApplicationService(applicationRepo, loanRepo, groupRepo, services.NewScorecardScorer(scorecard))
+	loanService := services.NewLoanService(loanRepo, groupRepo, productRepo, transactor, calendarService, lenderService, eligibilityService, applicationService, services.VirtualAccountPolicy{
+		Prefix: getEnv("VIRTUAL_ACCOUNT_PREFIX", "8808"),
+		Length: getEnvInt("VIRTUAL_ACCOUNT_LENGTH", 16),
+	}, currency)
+	groupService := services.NewGroupService(groupRepo, loanRepo, borrowerRepo, officerRepo)
+	collectionService := services.NewCollectionService(officerRepo, groupRepo, collectionRepo, loanService, groupService, transactor)
+	writeOffService := services.NewWriteOffService(loanRepo, writeOffRepo, transactor, services.DefaultRules{
+		DaysPastDue: getEnvInt("AUTO_DEFAULT_DPD", 90),
+	})
+	statementService := services.NewStatementService(statementRepo, loanRepo, loanService, writeOffService, transactor, statementFormats)
+	if os.Getenv("PAYMENT_WEBHOOK_SECRET") == "" {
+		log.Println("PAYMENT_WEBHOOK_SECRET is not set: payment webhooks will be rejected")
+	}
+	gatewayService := services.NewGatewayService(gatewayRepo, loanRepo, loanService, writeOffService, transactor, services.GatewayConfig{
+		Secret:   os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+		Currency: currency.Code,
+	})
+	restructureService := services.NewRestructureService(loanRepo, restructureRepo, calendarService, currency)
+	paymentHolidayService := services.NewPaymentHolidayService(loanRepo, paymentHolidayRepo, calendarService, services.HolidayPolicy{
+		AccrueInterest: getEnv("HOLIDAY_ACCRUE_INTEREST", "false") == "true",
+	}, currency)
+
+	reconciliationService := services.NewReconciliationService(reconciliationRepo, branchRepo, currency)
+	accountStatementService := services.NewAccountStatementService(loanRepo, borrowerRepo, currency)
+	if os.Getenv("JWT_SECRET") == "" {
+		log.Println("JWT_SECRET is not set: only API keys will be accepted")
+	}
+	authService := services.NewAuthService(apiKeyRepo, branchRepo, services.AuthConfig{
+		JWTSecret: os.Getenv("JWT_SECRET"),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+	}, accessPolicy)
+	accessService := services.NewAccessService(loanRepo, groupRepo, borrowerRepo, officerRepo, collectionRepo, caseRepo)
+	auditService := services.NewAuditService(auditRepo)
+	branchService := services.NewBranchService(branchRepo)
+	webhookService := services.NewWebhookService(webhookRepo, services.WebhookConfig{
+		MaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		RetryDelay:  getEnvDuration("WEBHOOK_RETRY_DELAY", 30*time.Second),
+		MaxDelay:    getEnvDuration("WEBHOOK_MAX_RETRY_DELAY", 6*time.Hour),
+		Timeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		BatchSize:   getEnvInt("WEBHOOK_BATCH_SIZE", 50),
+	})
+	// Every domain event also fans out to the partner webhook subscriptions
+	outboxService := services.NewOutboxService(outboxRepo, services.MultiPublisher{eventPublisher, webhookService}, services.OutboxConfig{
+		BatchSize:  getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		RetryDelay: getEnvDuration("OUTBOX_RETRY_DELAY", 5*time.Second),
+		MaxDelay:   getEnvDuration("OUTBOX_MAX_RETRY_DELAY", 10*time.Minute),
+	})
+	arrearsService := services.NewArrearsService(loanRepo)
+	notificationService := services.NewNotificationService(notificationRepo, productRepo, notificationSenders, services.NotificationConfig{
+		MaxAttempts: getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 3),
+		StaleDays:   getEnvInt("NOTIFICATION_STALE_DAYS", 2),
+	})
+	caseService := services.NewCollectionCaseService(caseRepo, loanRepo, groupRepo, officerRepo, currency)
 
 	// Initialize handlers
 	loanHandler := handlers.NewLoanHandler(loanService)
+	writeOffHandler := handlers.NewWriteOffHandler(writeOffService, loanService)
+	restructureHandler := handlers.NewRestructureHandler(restructureService, loanService)
+	paymentHolidayHandler := handlers.NewPaymentHolidayHandler(paymentHolidayService, loanService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	productHandler := handlers.NewProductHandler(productService)
+	groupHandler := handlers.NewGroupHandler(groupService)
+	collectionHandler := handlers.NewCollectionHandler(collectionService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	gatewayHandler := handlers.NewGatewayHandler(gatewayService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+	lenderHandler := handlers.NewLenderHandler(lenderService)
+	eligibilityHandler := handlers.NewEligibilityHandler(eligibilityService)
+	applicationHandler := handlers.NewApplicationHandler(applicationService)
+	accountStatementHandler := handlers.NewAccountStatementHandler(accountStatementService)
+	authHandler := handlers.NewAuthHandler(authService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	branchHandler := handlers.NewBranchHandler(branchService)
+	eventHandler := handlers.NewEventHandler(outboxService, arrearsService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	caseHandler := handlers.NewCollectionCaseHandler(caseService)
+	guard := middleware.NewGuard(accessService)
+
+	// Background jobs
+	if interval := getEnvDuration("AUTO_DEFAULT_INTERVAL", 24*time.Hour); interval > 0 {
+		go writeOffService.StartDefaultEvaluator(repositories.WithAuditActor(context.Background(), "system:auto-default", "system"), interval)
+	}
+	if interval := getEnvDuration("RECONCILIATION_INTERVAL", 24*time.Hour); interval > 0 {
+		go reconciliationService.StartEndOfDayRun(repositories.WithAuditActor(context.Background(), "system:end-of-day", "system"), interval)
+	}
+	if interval := getEnvDuration("AUDIT_SEAL_INTERVAL", 5*time.Second); interval > 0 {
+		go auditService.StartSealer(context.Background(), interval)
+	}
+	if interval := getEnvDuration("ARREARS_INTERVAL", time.Hour); interval > 0 {
+		go arrearsService.StartArrearsEvaluator(repositories.WithAuditActor(context.Background(), "system:arrears", "system"), interval)
+	}
+	if interval := getEnvDuration("OUTBOX_RELAY_INTERVAL", 2*time.Second); interval > 0 {
+		go outboxService.StartRelay(context.Background(), interval)
+	}
+	if interval := getEnvDuration("WEBHOOK_DISPATCH_INTERVAL", 2*time.Second); interval > 0 {
+		go webhookService.StartDispatcher(context.Background(), interval)
+	}
+	if interval := getEnvDuration("NOTIFICATION_INTERVAL", time.Hour); interval > 0 {
+		go notificationService.StartReminderJob(repositories.WithAuditActor(context.Background(), "system:reminders", "system"), interval)
+	}
+	if interval := getEnvDuration("COLLECTION_CASE_INTERVAL", time.Hour); interval > 0 {
+		go caseService.StartCaseEvaluator(repositories.WithAuditActor(context.Background(), "system:collection-cases", "system"), interval)
+	}
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
+		ErrorHandler: middleware.ErrorHandler,
 	})
+	app.Use(requestid.New())
+	app.Use(middleware.RequestContext())
 	app.Use(logger.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept",
-	}))
+	// Cross-origin requests are only allowed from the configured staff app origins
+	if origins := os.Getenv("CORS_ALLOW_ORIGINS"); origins != "" {
+		app.Use(cors.New(cors.Config{
+			AllowOrigins: origins,
+			AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
+			AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-API-Key",
+		}))
+	}
 	app.Use(recover.New())
+	// Payment webhooks are authenticated by their signature instead
+	app.Use("/api", middleware.Authenticate(authService, "/api/webhooks/payments"))
 
-	routes.SetupLoanRoutes(app, loanHandler)
+	routes.SetupLoanRoutes(app, loanHandler, guard)
+	routes.SetupWriteOffRoutes(app, writeOffHandler, guard)
+	routes.SetupRestructureRoutes(app, restructureHandler, guard)
+	routes.SetupPaymentHolidayRoutes(app, paymentHolidayHandler, guard)
+	routes.SetupCalendarRoutes(app, calendarHandler, guard)
+	routes.SetupProductRoutes(app, productHandler, guard)
+	routes.SetupGroupRoutes(app, groupHandler, guard)
+	routes.SetupCollectionRoutes(app, collectionHandler, guard)
+	routes.SetupStatementRoutes(app, statementHandler, guard)
+	routes.SetupGatewayRoutes(app, gatewayHandler, guard)
+	routes.SetupReconciliationRoutes(app, reconciliationHandler, guard)
+	routes.SetupLenderRoutes(app, lenderHandler, guard)
+	routes.SetupEligibilityRoutes(app, eligibilityHandler, guard)
+	routes.SetupApplicationRoutes(app, applicationHandler, guard)
+	routes.SetupAccountStatementRoutes(app, accountStatementHandler, guard)
+	routes.SetupAuthRoutes(app, authHandler, guard)
+	routes.SetupAuditRoutes(app, auditHandler, guard)
+	routes.SetupBranchRoutes(app, branchHandler, guard)
+	routes.SetupEventRoutes(app, eventHandler, guard)
+	routes.SetupWebhookRoutes(app, webhookHandler, guard)
+	routes.SetupNotificationRoutes(app, notificationHandler, guard)
+	routes.SetupCollectionCaseRoutes(app, caseHandler, guard)
 
 	port := getEnv("PORT", "8080")
 	log.Printf("Server starting on port %s", port)
@@ -78,3 +290,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt gets an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat gets a float environment variable or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "24h") or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
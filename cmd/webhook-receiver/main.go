@@ -0,0 +1,92 @@
+// Command webhook-receiver plays a partner endpoint locally: it checks the
+// signature of every webhook delivery it receives and prints the event.
+// Failures can be simulated to watch retries and dead-lettering.
+//
+//	go run ./cmd/webhook-receiver -secret whsec_... -addr :9090
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/services"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", "", "subscription secret (signatures are not checked when empty)")
+	status := flag.Int("status", http.StatusOK, "status code to answer with")
+	failRate := flag.Float64("fail-rate", 0, "share of deliveries, 0 to 1, answered with 503 instead")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "maximum age of a delivery's timestamp")
+	flag.Parse()
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		timestamp := r.Header.Get("X-Webhook-Timestamp")
+		if *secret != "" {
+			expected := services.SignWebhook(*secret, timestamp, body)
+			if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Webhook-Signature"))) {
+				log.Printf("Rejected delivery %s: bad signature", r.Header.Get("X-Webhook-ID"))
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+			sent, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil || time.Since(time.Unix(sent, 0)) > *tolerance {
+				log.Printf("Rejected delivery %s: stale timestamp %q", r.Header.Get("X-Webhook-ID"), timestamp)
+				http.Error(w, "stale timestamp", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if *failRate > 0 && rand.Float64() < *failRate {
+			log.Printf("Failing delivery %s on purpose", r.Header.Get("X-Webhook-ID"))
+			http.Error(w, "simulated failure", http.StatusServiceUnavailable)
+			return
+		}
+
+		var envelope dto.EventEnvelope
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		duplicate := seen[envelope.ID]
+		seen[envelope.ID] = true
+		mu.Unlock()
+
+		note := ""
+		if duplicate {
+			note = " (duplicate)"
+		}
+		fmt.Printf("%s v%d %s %s/%s%s\n%s\n", envelope.Type, envelope.Version, envelope.ID,
+			envelope.AggregateType, envelope.AggregateID, note, envelope.Data)
+
+		w.WriteHeader(*status)
+	})
+
+	log.Printf("Webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
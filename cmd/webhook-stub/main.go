@@ -0,0 +1,77 @@
+// Command webhook-stub plays the payment gateway locally: it signs a payment
+// notification with the shared secret and posts it to the webhook endpoint.
+//
+//	PAYMENT_WEBHOOK_SECRET=dev go run ./cmd/webhook-stub -va 8808123456789012 -amount 22000
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/services"
+)
+
+func main() {
+	url := flag.String("url", "http://localhost:8080/api/webhooks/payments", "webhook endpoint")
+	secret := flag.String("secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "shared HMAC secret")
+	account := flag.String("va", "", "virtual account the payment was made into")
+	amount := flag.Float64("amount", 0, "amount paid")
+	currency := flag.String("currency", "IDR", "currency code")
+	transactionID := flag.String("txn", "", "gateway transaction ID (random when empty; reuse one to test deduplication)")
+	badSignature := flag.Bool("bad-signature", false, "send an invalid signature")
+	flag.Parse()
+
+	if *account == "" || *amount <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *transactionID == "" {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatal(err)
+		}
+		*transactionID = "stub-" + hex.EncodeToString(b)
+	}
+
+	body, err := json.Marshal(dto.GatewayPaymentNotification{
+		TransactionID:  *transactionID,
+		VirtualAccount: *account,
+		Amount:         *amount,
+		Currency:       *currency,
+		PaidAt:         time.Now(),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signature := services.SignPayload(*secret, body)
+	if *badSignature {
+		signature = services.SignPayload(*secret+"-wrong", body)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, *url, bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", signature)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("Error sending notification: %v", err)
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(resp.Body)
+	fmt.Printf("> %s\n< %s\n%s\n", body, resp.Status, response)
+}
@@ -0,0 +1,265 @@
+// Package dbtest runs GORM against a scripted stand-in for MySQL, so that
+// repositories and services can be tested without a database server. The
+// stand-in records every statement it receives, including the BEGIN, COMMIT
+// and ROLLBACK of transactions, and answers queries with the rows registered
+// for them. Inserts and updates affect one row unless told otherwise
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Statement is one statement received by the database
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Has reports whether the statement's SQL contains every fragment
+func (s Statement) Has(fragments ...string) bool {
+	for _, fragment := range fragments {
+		if !strings.Contains(s.SQL, fragment) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasArg reports whether the statement was given the value as an argument
+func (s Statement) HasArg(value interface{}) bool {
+	want := fmt.Sprint(value)
+	for _, arg := range s.Args {
+		if fmt.Sprint(arg) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Statement) String() string {
+	return fmt.Sprintf("%s %v", s.SQL, s.Args)
+}
+
+// Rows are the columns and values a query answers with. Values may be of
+// any type database/sql accepts as an argument, such as uint or *string
+type Rows struct {
+	Columns []string
+	Values  [][]interface{}
+}
+
+// Row builds the answer of a query returning a single row
+func Row(columns []string, values ...interface{}) Rows {
+	return Rows{Columns: columns, Values: [][]interface{}{values}}
+}
+
+// Answer returns the rows of a query, given its SQL and arguments
+type Answer func(statement Statement) Rows
+
+type rule struct {
+	fragments []string
+	answer    Answer
+}
+
+// DB is the scripted database behind a *gorm.DB opened by Open
+type DB struct {
+	mu         sync.Mutex
+	statements []Statement
+	queries    []rule
+	affected   []rule
+	lastID     int64
+}
+
+// Open returns a GORM connection to a new scripted database
+func Open(t testing.TB) (*gorm.DB, *DB) {
+	t.Helper()
+	db := &DB{}
+	conn, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(connector{db: db}),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		Logger:                 logger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("opening scripted database: %v", err)
+	}
+	return conn, db
+}
+
+// On answers the queries whose SQL contains every fragment with the rows the
+// answer returns. The latest matching registration wins; queries nothing
+// answers return no rows
+func (db *DB) On(answer Answer, fragments ...string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queries = append(db.queries, rule{fragments: fragments, answer: answer})
+}
+
+// Returning answers the queries whose SQL contains every fragment with rows
+func (db *DB) Returning(rows Rows, fragments ...string) {
+	db.On(func(Statement) Rows { return rows }, fragments...)
+}
+
+// Affects makes inserts, updates and deletes whose SQL contains every
+// fragment report the given number of affected rows
+func (db *DB) Affects(count int64, fragments ...string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.affected = append(db.affected, rule{fragments: fragments, answer: func(Statement) Rows {
+		return Rows{Values: [][]interface{}{{count}}}
+	}})
+}
+
+// Statements returns every statement received so far
+func (db *DB) Statements() []Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Statement(nil), db.statements...)
+}
+
+// Find returns the statements whose SQL contains every fragment
+func (db *DB) Find(fragments ...string) []Statement {
+	var found []Statement
+	for _, statement := range db.Statements() {
+		if statement.Has(fragments...) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+// Reset forgets the statements received so far, keeping the answers
+func (db *DB) Reset() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = nil
+}
+
+func (db *DB) record(query string, args []driver.NamedValue) Statement {
+	statement := Statement{SQL: query}
+	for _, arg := range args {
+		statement.Args = append(statement.Args, arg.Value)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, statement)
+	return statement
+}
+
+func match(rules []rule, statement Statement) (Answer, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if statement.Has(rules[i].fragments...) {
+			return rules[i].answer, true
+		}
+	}
+	return nil, false
+}
+
+func (db *DB) query(statement Statement) Rows {
+	db.mu.Lock()
+	answer, ok := match(db.queries, statement)
+	db.mu.Unlock()
+	if !ok {
+		return Rows{}
+	}
+	return answer(statement)
+}
+
+func (db *DB) exec(statement Statement) driver.Result {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	affected := int64(1)
+	if answer, ok := match(db.affected, statement); ok {
+		affected = answer(statement).Values[0][0].(int64)
+	}
+	var id int64
+	if strings.HasPrefix(statement.SQL, "INSERT") {
+		db.lastID++
+		id = db.lastID
+	}
+	return result{id: id, affected: affected}
+}
+
+type connector struct{ db *DB }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{db: c.db}, nil }
+func (c connector) Driver() driver.Driver                        { return nil }
+
+type conn struct{ db *DB }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("dbtest: prepared statements are not supported")
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return tx{db: c.db}, nil
+}
+
+func (c *conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *conn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.db.exec(c.db.record(query, args)), nil
+}
+
+func (c *conn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &rows{Rows: c.db.query(c.db.record(query, args))}, nil
+}
+
+type tx struct{ db *DB }
+
+func (t tx) Commit() error {
+	t.db.record("COMMIT", nil)
+	return nil
+}
+
+func (t tx) Rollback() error {
+	t.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type result struct{ id, affected int64 }
+
+func (r result) LastInsertId() (int64, error) { return r.id, nil }
+func (r result) RowsAffected() (int64, error) { return r.affected, nil }
+
+type rows struct {
+	Rows
+	next int
+}
+
+func (r *rows) Columns() []string { return r.Rows.Columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.Values) {
+		return io.EOF
+	}
+	for i, value := range r.Values[r.next] {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return err
+		}
+		dest[i] = converted
+	}
+	r.next++
+	return nil
+}
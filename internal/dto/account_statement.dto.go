@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// AccountStatementResponse represents a loan account statement over a period.
+// Balances are on an arrears basis: what has fallen due and not been paid
+type AccountStatementResponse struct {
+	LoanID         uint                    `json:"loan_id"`
+	BorrowerID     uint                    `json:"borrower_id"`
+	BorrowerName   string                  `json:"borrower_name,omitempty"`
+	LoanAmount     float64                 `json:"loan_amount"`
+	LoanStatus     string                  `json:"loan_status"`
+	From           time.Time               `json:"from"`
+	To             time.Time               `json:"to"`
+	OpeningBalance float64                 `json:"opening_balance"`
+	TotalDebits    float64                 `json:"total_debits"`
+	TotalCredits   float64                 `json:"total_credits"`
+	ClosingBalance float64                 `json:"closing_balance"`
+	Entries        []AccountStatementEntry `json:"entries"`
+}
+
+// AccountStatementEntry represents one line of a loan account statement
+type AccountStatementEntry struct {
+	Date          time.Time `json:"date"`
+	Type          string    `json:"type"` // disbursement, due, fee, payoff, payment, reversal, write_off
+	Description   string    `json:"description"`
+	Reference     string    `json:"reference,omitempty"`
+	TransactionID *uint     `json:"transaction_id,omitempty"`
+	Debit         float64   `json:"debit"`
+	Credit        float64   `json:"credit"`
+	Balance       float64   `json:"balance"`
+}
+
+// ReversePaymentRequest represents the request to reverse a posted payment transaction
+type ReversePaymentRequest struct {
+	Reason     string `json:"reason" validate:"required"`
+	ReversedBy string `json:"reversed_by"`
+}
+
+// ReversalResponse represents a reversed payment transaction
+type ReversalResponse struct {
+	TransactionID uint      `json:"transaction_id"`
+	LoanID        uint      `json:"loan_id"`
+	Amount        float64   `json:"amount"`
+	Status        string    `json:"status"`
+	ReversedAt    time.Time `json:"reversed_at"`
+	ReversedBy    string    `json:"reversed_by"`
+	Reason        string    `json:"reason"`
+	RemainingDue  float64   `json:"remaining_due"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLogResponse represents an audit log entry. Before and after hold the
+// row's columns; changes maps each changed column to its from and to values
+type AuditLogResponse struct {
+	ID         uint            `json:"id"`
+	Sequence   *uint64         `json:"sequence,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	ActorType  string          `json:"actor_type"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Action     string          `json:"action"`
+	Entity     string          `json:"entity"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Changes    json.RawMessage `json:"changes,omitempty"`
+	PrevHash   string          `json:"prev_hash,omitempty"`
+	Hash       string          `json:"hash,omitempty"`
+	SealedAt   *time.Time      `json:"sealed_at,omitempty"`
+}
+
+// AuditVerificationResponse represents the result of checking the audit hash chain
+type AuditVerificationResponse struct {
+	Valid        bool    `json:"valid"`
+	Checked      uint64  `json:"checked"`
+	HeadSequence uint64  `json:"head_sequence"`
+	HeadHash     string  `json:"head_hash,omitempty"`
+	BrokenAt     *uint64 `json:"broken_at,omitempty"`
+	Error        string  `json:"error,omitempty"`
+	Unsealed     int64   `json:"unsealed"`
+}
@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+// APIKeyRequest represents the request to issue an API key to a machine client
+type APIKeyRequest struct {
+	Name      string     `json:"name" validate:"required"`
+	Role      string     `json:"role" validate:"required"`
+	BranchID  *uint      `json:"branch_id"` // limits the key to one branch of the tenant
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key. Key is only set in the response to
+// the request that created it
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       string     `json:"role"`
+	TenantID   uint       `json:"tenant_id"`
+	BranchID   *uint      `json:"branch_id,omitempty"`
+	Key        string     `json:"key,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy  string     `json:"revoked_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// PrincipalResponse represents the caller an authenticated request was made by
+type PrincipalResponse struct {
+	Type        string   `json:"type"`
+	Subject     string   `json:"subject"`
+	Name        string   `json:"name,omitempty"`
+	Role        string   `json:"role"`
+	OfficerID   *uint    `json:"officer_id,omitempty"`
+	APIKeyID    *uint    `json:"api_key_id,omitempty"`
+	TenantID    uint     `json:"tenant_id"`
+	BranchID    *uint    `json:"branch_id,omitempty"`
+	Permissions []string `json:"permissions"`
+	Scope       string   `json:"scope"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// BranchRequest represents the request to open a branch
+type BranchRequest struct {
+	Code   string `json:"code" validate:"required"`
+	Name   string `json:"name" validate:"required"`
+	Region string `json:"region"`
+}
+
+// BranchResponse represents a branch of the caller's tenant
+type BranchResponse struct {
+	ID       uint      `json:"id"`
+	TenantID uint      `json:"tenant_id"`
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	Region   string    `json:"region,omitempty"`
+	Status   string    `json:"status"`
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+// BranchReportItem represents the portfolio of one branch. BranchID is not
+// set for the records not assigned to a branch
+type BranchReportItem struct {
+	BranchID          *uint   `json:"branch_id,omitempty"`
+	Code              string  `json:"code,omitempty"`
+	Name              string  `json:"name"`
+	Region            string  `json:"region,omitempty"`
+	Borrowers         int64   `json:"borrowers"`
+	Loans             int64   `json:"loans"`
+	ActiveLoans       int64   `json:"active_loans"`
+	DisbursedAmount   float64 `json:"disbursed_amount"`
+	OutstandingAmount float64 `json:"outstanding_amount"`
+	OverdueAmount     float64 `json:"overdue_amount"`
+	OverdueRate       float64 `json:"overdue_rate"` // overdue share of the outstanding amount
+	CollectedAmount   float64 `json:"collected_amount"`
+	WrittenOffAmount  float64 `json:"written_off_amount"`
+}
+
+// BranchReportResponse represents the portfolio of every branch the caller
+// can reach, with collections received between From and To
+type BranchReportResponse struct {
+	AsOf     time.Time          `json:"as_of"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Branches []BranchReportItem `json:"branches"`
+	Total    BranchReportItem   `json:"total"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+// HolidayRequest represents the request to create or update a calendar holiday
+type HolidayRequest struct {
+	Date   string `json:"date" validate:"required"` // YYYY-MM-DD
+	Name   string `json:"name" validate:"required"`
+	Region string `json:"region"` // empty for a national holiday
+}
+
+// HolidayResponse represents a calendar holiday
+type HolidayResponse struct {
+	ID     uint      `json:"id"`
+	Date   time.Time `json:"date"`
+	Name   string    `json:"name"`
+	Region string    `json:"region,omitempty"`
+}
+
+// HolidayImportResponse represents the result of a calendar file import
+type HolidayImportResponse struct {
+	Imported int `json:"imported"`
+}
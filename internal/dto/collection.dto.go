@@ -0,0 +1,69 @@
+package dto
+
+import "time"
+
+// CreateOfficerRequest represents the request to register a field officer
+type CreateOfficerRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Phone    string `json:"phone"`
+	Region   string `json:"region"`
+	BranchID *uint  `json:"branch_id,omitempty"` // the creator's branch when they are limited to one
+}
+
+// OfficerCollectionSheetResponse represents everything an officer is expected
+// to collect on a date across the group meetings they run
+type OfficerCollectionSheetResponse struct {
+	OfficerID   uint                           `json:"officer_id"`
+	OfficerName string                         `json:"officer_name"`
+	Date        time.Time                      `json:"date"`
+	TotalDue    float64                        `json:"total_due"`
+	Groups      []GroupCollectionSheetResponse `json:"groups"`
+}
+
+// CollectionBatchLineRequest represents the outcome for one loan on a collection sheet
+type CollectionBatchLineRequest struct {
+	LoanID uint    `json:"loan_id" validate:"required"`
+	Result string  `json:"result" validate:"required,oneof=paid unpaid partial"`
+	Amount float64 `json:"amount" validate:"gte=0"`
+}
+
+// CollectionBatchRequest represents a collection sheet posted by a field officer
+type CollectionBatchRequest struct {
+	OfficerID uint                         `json:"officer_id" validate:"required"`
+	Date      string                       `json:"date" validate:"required"` // YYYY-MM-DD
+	Lines     []CollectionBatchLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+// OfficerResponse represents the field officer response
+type OfficerResponse struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone,omitempty"`
+	Region   string `json:"region,omitempty"`
+	BranchID *uint  `json:"branch_id,omitempty"`
+	Status   string `json:"status"`
+}
+
+// CollectionBatchLineResponse represents the result of posting one sheet line
+type CollectionBatchLineResponse struct {
+	LineNo           int     `json:"line_no"`
+	LoanID           uint    `json:"loan_id"`
+	Result           string  `json:"result"`
+	Amount           float64 `json:"amount"`
+	Status           string  `json:"status"` // posted, recorded, failed
+	TransactionID    *uint   `json:"transaction_id,omitempty"`
+	InstallmentsPaid int     `json:"installments_paid"`
+	UnappliedAmount  float64 `json:"unapplied_amount"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// CollectionBatchResponse represents a posted collection sheet and its per-line report
+type CollectionBatchResponse struct {
+	ID             uint                          `json:"id"`
+	OfficerID      uint                          `json:"officer_id"`
+	CollectionDate time.Time                     `json:"collection_date"`
+	TotalCollected float64                       `json:"total_collected"`
+	PostedLines    int                           `json:"posted_lines"`
+	FailedLines    int                           `json:"failed_lines"`
+	Lines          []CollectionBatchLineResponse `json:"lines"`
+}
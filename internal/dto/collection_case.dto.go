@@ -0,0 +1,72 @@
+package dto
+
+import "time"
+
+// AssignCaseRequest represents the request to assign a collection case to a
+// collector
+type AssignCaseRequest struct {
+	CollectorID uint `json:"collector_id" validate:"required"`
+}
+
+// CaseActivityRequest represents a visit, call, promise to pay or note logged
+// on a collection case
+type CaseActivityRequest struct {
+	Type          string     `json:"type" validate:"required,oneof=visit call promise_to_pay note"`
+	Outcome       string     `json:"outcome"`
+	Notes         string     `json:"notes"`
+	PromiseDate   string     `json:"promise_date"`   // YYYY-MM-DD, promise_to_pay only
+	PromiseAmount float64    `json:"promise_amount"` // promise_to_pay only
+	NextActionAt  *time.Time `json:"next_action_at"` // overrides the follow-up date
+	OfficerID     *uint      `json:"officer_id"`     // defaults to the case's collector
+}
+
+// CloseCaseRequest represents the request to close a collection case by hand
+type CloseCaseRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// CollectionCaseResponse represents a collection case
+type CollectionCaseResponse struct {
+	ID             uint                         `json:"id"`
+	LoanID         uint                         `json:"loan_id"`
+	BorrowerID     uint                         `json:"borrower_id"`
+	GroupID        *uint                        `json:"group_id,omitempty"`
+	BranchID       *uint                        `json:"branch_id,omitempty"`
+	CollectorID    *uint                        `json:"collector_id,omitempty"`
+	Status         string                       `json:"status"`
+	DaysPastDue    int                          `json:"days_past_due"`
+	OverdueAmount  float64                      `json:"overdue_amount"`
+	BrokenPromises int                          `json:"broken_promises"`
+	NextActionAt   *time.Time                   `json:"next_action_at,omitempty"`
+	OpenedAt       time.Time                    `json:"opened_at"`
+	AssignedAt     *time.Time                   `json:"assigned_at,omitempty"`
+	AssignedBy     string                       `json:"assigned_by,omitempty"`
+	ClosedAt       *time.Time                   `json:"closed_at,omitempty"`
+	CloseReason    string                       `json:"close_reason,omitempty"`
+	ClosedBy       string                       `json:"closed_by,omitempty"`
+	Activities     []CollectionActivityResponse `json:"activities,omitempty"`
+}
+
+// CollectionActivityResponse represents an activity logged on a collection case
+type CollectionActivityResponse struct {
+	ID                 uint       `json:"id"`
+	Type               string     `json:"type"`
+	Outcome            string     `json:"outcome,omitempty"`
+	Notes              string     `json:"notes,omitempty"`
+	PromiseDate        *time.Time `json:"promise_date,omitempty"`
+	PromiseAmount      float64    `json:"promise_amount,omitempty"`
+	PromiseStatus      string     `json:"promise_status,omitempty"`
+	PromiseCollected   float64    `json:"promise_collected,omitempty"`
+	PromiseEvaluatedAt *time.Time `json:"promise_evaluated_at,omitempty"`
+	OfficerID          *uint      `json:"officer_id,omitempty"`
+	PerformedBy        string     `json:"performed_by"`
+	PerformedAt        time.Time  `json:"performed_at"`
+}
+
+// CaseEvaluationResponse represents the result of a collection case run
+type CaseEvaluationResponse struct {
+	OpenedCaseIDs    []uint `json:"opened_case_ids"`
+	ClosedCaseIDs    []uint `json:"closed_case_ids"`
+	KeptPromiseIDs   []uint `json:"kept_promise_ids"`
+	BrokenPromiseIDs []uint `json:"broken_promise_ids"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+// ErrorResponse represents a failed request. Code is stable for clients to
+// branch on, Message is for people
+type ErrorResponse struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
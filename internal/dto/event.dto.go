@@ -0,0 +1,138 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types published through the outbox
+const (
+	EventLoanCreated       = "loan.created"
+	EventPaymentReceived   = "payment.received"
+	EventInstallmentMissed = "installment.missed"
+	EventLoanDelinquent    = "loan.delinquent"
+	EventLoanCompleted     = "loan.completed"
+)
+
+// Schema versions of the event types. Fields may be added to a version;
+// removing or changing the meaning of a field makes a new version
+const (
+	LoanCreatedEventVersion       = 1
+	PaymentReceivedEventVersion   = 1
+	InstallmentMissedEventVersion = 1
+	LoanDelinquentEventVersion    = 1
+	LoanCompletedEventVersion     = 1
+)
+
+// EventEnvelope wraps every published event. ID is stable across
+// redeliveries, so consumers can drop events they have already handled
+type EventEnvelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	TenantID      uint            `json:"tenant_id"`
+	BranchID      *uint           `json:"branch_id,omitempty"`
+	AggregateType string          `json:"aggregate_type"` // loan
+	AggregateID   string          `json:"aggregate_id"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// LoanCreatedEvent is the data of loan.created, version 1
+type LoanCreatedEvent struct {
+	LoanID          uint      `json:"loan_id"`
+	BorrowerID      uint      `json:"borrower_id"`
+	ProductID       *uint     `json:"product_id,omitempty"`
+	GroupID         *uint     `json:"group_id,omitempty"`
+	RefinancesID    *uint     `json:"refinances_id,omitempty"`
+	Amount          float64   `json:"amount"`
+	InterestRate    float64   `json:"interest_rate"`
+	TotalAmount     float64   `json:"total_amount"`
+	FeeAmount       float64   `json:"fee_amount"`
+	DeductedFees    float64   `json:"deducted_fees"`
+	DisbursedAmount float64   `json:"disbursed_amount"`
+	WeeklyPayment   float64   `json:"weekly_payment"`
+	TotalWeeks      int       `json:"total_weeks"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	VirtualAccount  string    `json:"virtual_account,omitempty"`
+	Reference       string    `json:"reference,omitempty"`
+}
+
+// EventInstallment identifies an installment in an event
+type EventInstallment struct {
+	PaymentID uint      `json:"payment_id"`
+	WeekNum   int       `json:"week_num"`
+	DueDate   time.Time `json:"due_date"`
+	Amount    float64   `json:"amount"`
+}
+
+// PaymentReceivedEvent is the data of payment.received, version 1
+type PaymentReceivedEvent struct {
+	TransactionID   uint               `json:"transaction_id"`
+	LoanID          uint               `json:"loan_id"`
+	BorrowerID      uint               `json:"borrower_id"`
+	Amount          float64            `json:"amount"`
+	AppliedAmount   float64            `json:"applied_amount"`
+	FeeAmount       float64            `json:"fee_amount"`
+	UnappliedAmount float64            `json:"unapplied_amount"`
+	SuspenseApplied float64            `json:"suspense_applied"`
+	Channel         string             `json:"channel"`
+	Reference       string             `json:"reference,omitempty"`
+	ReceivedAt      time.Time          `json:"received_at"`
+	Installments    []EventInstallment `json:"installments"`
+}
+
+// InstallmentMissedEvent is the data of installment.missed, version 1
+type InstallmentMissedEvent struct {
+	LoanID      uint             `json:"loan_id"`
+	BorrowerID  uint             `json:"borrower_id"`
+	GroupID     *uint            `json:"group_id,omitempty"`
+	Installment EventInstallment `json:"installment"`
+	MissedAt    time.Time        `json:"missed_at"`
+}
+
+// LoanDelinquentEvent is the data of loan.delinquent, version 1
+type LoanDelinquentEvent struct {
+	LoanID             uint      `json:"loan_id"`
+	BorrowerID         uint      `json:"borrower_id"`
+	GroupID            *uint     `json:"group_id,omitempty"`
+	MissedInstallments int       `json:"missed_installments"` // consecutive
+	OverdueAmount      float64   `json:"overdue_amount"`
+	DaysPastDue        int       `json:"days_past_due"`
+	DelinquentSince    time.Time `json:"delinquent_since"`
+}
+
+// LoanCompletedEvent is the data of loan.completed, version 1
+type LoanCompletedEvent struct {
+	LoanID            uint      `json:"loan_id"`
+	BorrowerID        uint      `json:"borrower_id"`
+	TotalAmount       float64   `json:"total_amount"`
+	LastTransactionID uint      `json:"last_transaction_id"`
+	CompletedAt       time.Time `json:"completed_at"`
+}
+
+// OutboxEventResponse represents an outbox event and its delivery state
+type OutboxEventResponse struct {
+	ID            uint            `json:"id"`
+	EventID       string          `json:"event_id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	Event         json.RawMessage `json:"event"`
+}
+
+// ArrearsEvaluationResponse represents the result of an arrears run: the
+// installments found missed, the loans found delinquent and the delinquent
+// loans that caught up
+type ArrearsEvaluationResponse struct {
+	MissedPaymentIDs  []uint `json:"missed_payment_ids"`
+	DelinquentLoanIDs []uint `json:"delinquent_loan_ids"`
+	CuredLoanIDs      []uint `json:"cured_loan_ids"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// GatewayPaymentNotification represents the JSON body the payment gateway posts
+// when money is paid into a virtual account
+type GatewayPaymentNotification struct {
+	TransactionID  string    `json:"transaction_id" validate:"required"`
+	VirtualAccount string    `json:"virtual_account" validate:"required"`
+	Amount         float64   `json:"amount" validate:"required,gt=0"`
+	Currency       string    `json:"currency" validate:"required,len=3"`
+	PaidAt         time.Time `json:"paid_at" validate:"required"`
+}
+
+// GatewayPaymentResponse represents the outcome of a gateway notification
+type GatewayPaymentResponse struct {
+	GatewayTransactionID string    `json:"gateway_transaction_id"`
+	VirtualAccount       string    `json:"virtual_account"`
+	Amount               float64   `json:"amount"`
+	Currency             string    `json:"currency"`
+	PaidAt               time.Time `json:"paid_at"`
+	Status               string    `json:"status"` // posted, unmatched, failed
+	LoanID               *uint     `json:"loan_id,omitempty"`
+	TransactionID        *uint     `json:"transaction_id,omitempty"`
+	Error                string    `json:"error,omitempty"`
+	Duplicate            bool      `json:"duplicate"`
+	ReceivedAt           time.Time `json:"received_at"`
+}
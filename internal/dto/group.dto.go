@@ -0,0 +1,116 @@
+package dto
+
+import "time"
+
+// CreateGroupRequest represents the request to create a borrower group
+type CreateGroupRequest struct {
+	Name       string `json:"name" validate:"required"`
+	Region     string `json:"region"`
+	MeetingDay string `json:"meeting_day" validate:"required,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	OfficerID  *uint  `json:"officer_id,omitempty"`
+	BranchID   *uint  `json:"branch_id,omitempty"` // the creator's branch when they are limited to one
+}
+
+// AssignOfficerRequest represents the request to make an officer responsible for a group
+type AssignOfficerRequest struct {
+	OfficerID uint `json:"officer_id" validate:"required"`
+}
+
+// AddGroupMemberRequest represents the request to add a borrower to a group
+type AddGroupMemberRequest struct {
+	BorrowerID uint   `json:"borrower_id" validate:"required"`
+	Role       string `json:"role" validate:"omitempty,oneof=leader member"`
+}
+
+// GroupMemberResponse represents a member of a group
+type GroupMemberResponse struct {
+	BorrowerID uint      `json:"borrower_id"`
+	Name       string    `json:"name,omitempty"`
+	Role       string    `json:"role"`
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// GroupResponse represents the group response
+type GroupResponse struct {
+	ID         uint                  `json:"id"`
+	Name       string                `json:"name"`
+	Region     string                `json:"region,omitempty"`
+	MeetingDay string                `json:"meeting_day"`
+	OfficerID  *uint                 `json:"officer_id,omitempty"`
+	BranchID   *uint                 `json:"branch_id,omitempty"`
+	Status     string                `json:"status"`
+	Members    []GroupMemberResponse `json:"members,omitempty"`
+}
+
+// GroupScheduleItem represents everything the group owes on one meeting date
+type GroupScheduleItem struct {
+	DueDate          time.Time `json:"due_date"`
+	AmountDue        float64   `json:"amount_due"`
+	AmountPaid       float64   `json:"amount_paid"`
+	Installments     int       `json:"installments"`
+	PaidInstallments int       `json:"paid_installments"`
+}
+
+// GroupScheduleResponse represents the combined schedule of a group's loans
+type GroupScheduleResponse struct {
+	GroupID  uint                `json:"group_id"`
+	Schedule []GroupScheduleItem `json:"schedule"`
+}
+
+// GroupLoanOutstanding represents the outstanding amount on one member loan
+type GroupLoanOutstanding struct {
+	LoanID            uint    `json:"loan_id"`
+	BorrowerID        uint    `json:"borrower_id"`
+	TotalAmount       float64 `json:"total_amount"`
+	OutstandingAmount float64 `json:"outstanding_amount"`
+}
+
+// GroupOutstandingResponse represents the outstanding amount across a group's loans
+type GroupOutstandingResponse struct {
+	GroupID           uint                   `json:"group_id"`
+	TotalAmount       float64                `json:"total_amount"`
+	AmountPaid        float64                `json:"amount_paid"`
+	OutstandingAmount float64                `json:"outstanding_amount"`
+	Loans             []GroupLoanOutstanding `json:"loans"`
+}
+
+// GroupLoanDelinquency represents the delinquency state of one member loan
+type GroupLoanDelinquency struct {
+	LoanID            uint    `json:"loan_id"`
+	BorrowerID        uint    `json:"borrower_id"`
+	IsDelinquent      bool    `json:"is_delinquent"`
+	MissedPayments    int     `json:"missed_payments"`
+	DaysPastDue       int     `json:"days_past_due"`
+	ArrearsAmount     float64 `json:"arrears_amount"`
+	OutstandingAmount float64 `json:"outstanding_amount"`
+}
+
+// GroupDelinquencyResponse represents group health: member delinquency, the
+// arrears the group is jointly liable for and portfolio at risk by DPD bucket
+type GroupDelinquencyResponse struct {
+	GroupID              uint                   `json:"group_id"`
+	DelinquentMembers    int                    `json:"delinquent_members"`
+	JointLiabilityAmount float64                `json:"joint_liability_amount"`
+	OutstandingAmount    float64                `json:"outstanding_amount"`
+	PAR                  map[string]float64     `json:"par"` // e.g. par_1, par_7, par_30 as a ratio of outstanding
+	Loans                []GroupLoanDelinquency `json:"loans"`
+}
+
+// CollectionSheetLine represents what one member is expected to pay at a meeting
+type CollectionSheetLine struct {
+	BorrowerID     uint    `json:"borrower_id"`
+	BorrowerName   string  `json:"borrower_name"`
+	LoanID         uint    `json:"loan_id"`
+	InstallmentDue float64 `json:"installment_due"`
+	ArrearsDue     float64 `json:"arrears_due"`
+	TotalDue       float64 `json:"total_due"`
+}
+
+// GroupCollectionSheetResponse represents the collection sheet of one group meeting
+type GroupCollectionSheetResponse struct {
+	GroupID   uint                  `json:"group_id"`
+	GroupName string                `json:"group_name"`
+	Date      time.Time             `json:"date"`
+	TotalDue  float64               `json:"total_due"`
+	Lines     []CollectionSheetLine `json:"lines"`
+}
@@ -0,0 +1,100 @@
+package dto
+
+import "time"
+
+// CreateLenderRequest represents the request to register a lender
+type CreateLenderRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"omitempty,email"`
+	Type  string `json:"type" validate:"omitempty,oneof=individual institution"`
+}
+
+// LenderResponse represents the lender response
+type LenderResponse struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email,omitempty"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// FundLoanRequest represents the request for a lender to fund part of a loan
+type FundLoanRequest struct {
+	LenderID uint    `json:"lender_id" validate:"required"`
+	Amount   float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// FundingResponse represents a lender's funding of a loan
+type FundingResponse struct {
+	ID       uint      `json:"id"`
+	LoanID   uint      `json:"loan_id"`
+	LenderID uint      `json:"lender_id"`
+	Amount   float64   `json:"amount"`
+	Share    float64   `json:"share"`
+	FundedAt time.Time `json:"funded_at"`
+}
+
+// LoanFundingResponse represents how much of a loan has been funded and by whom
+type LoanFundingResponse struct {
+	LoanID        uint              `json:"loan_id"`
+	LoanAmount    float64           `json:"loan_amount"`
+	FundedAmount  float64           `json:"funded_amount"`
+	FundedShare   float64           `json:"funded_share"`
+	UnfundedShare float64           `json:"unfunded_share"`
+	Fundings      []FundingResponse `json:"fundings"`
+}
+
+// LenderPortfolioItem represents one loan in a lender's portfolio
+type LenderPortfolioItem struct {
+	FundingID            uint      `json:"funding_id"`
+	LoanID               uint      `json:"loan_id"`
+	LoanStatus           string    `json:"loan_status"`
+	FundedAmount         float64   `json:"funded_amount"`
+	Share                float64   `json:"share"`
+	FundedAt             time.Time `json:"funded_at"`
+	PrincipalRepaid      float64   `json:"principal_repaid"`
+	NetReceived          float64   `json:"net_received"`
+	OutstandingPrincipal float64   `json:"outstanding_principal"`
+}
+
+// LenderPortfolioResponse represents every loan a lender has funded
+type LenderPortfolioResponse struct {
+	LenderID             uint                  `json:"lender_id"`
+	LenderName           string                `json:"lender_name"`
+	ActiveLoans          int                   `json:"active_loans"`
+	FundedAmount         float64               `json:"funded_amount"`
+	OutstandingPrincipal float64               `json:"outstanding_principal"`
+	Loans                []LenderPortfolioItem `json:"loans"`
+}
+
+// LenderCashFlowItem represents the payouts a lender expects on one due date
+type LenderCashFlowItem struct {
+	DueDate         time.Time `json:"due_date"`
+	Installments    int       `json:"installments"`
+	GrossAmount     float64   `json:"gross_amount"`
+	PrincipalAmount float64   `json:"principal_amount"`
+	InterestAmount  float64   `json:"interest_amount"`
+	FeeAmount       float64   `json:"fee_amount"`
+	NetAmount       float64   `json:"net_amount"`
+}
+
+// LenderCashFlowResponse represents a lender's expected payouts by due date
+type LenderCashFlowResponse struct {
+	LenderID  uint                 `json:"lender_id"`
+	TotalNet  float64              `json:"total_net"`
+	CashFlows []LenderCashFlowItem `json:"cash_flows"`
+}
+
+// LenderReturnsResponse represents what a lender has realised on their fundings
+type LenderReturnsResponse struct {
+	LenderID        uint    `json:"lender_id"`
+	FundedAmount    float64 `json:"funded_amount"`
+	GrossReceived   float64 `json:"gross_received"`
+	PrincipalRepaid float64 `json:"principal_repaid"`
+	InterestEarned  float64 `json:"interest_earned"`
+	FeesPaid        float64 `json:"fees_paid"`
+	NetReceived     float64 `json:"net_received"`
+	PrincipalLost   float64 `json:"principal_lost"` // unrepaid principal on written-off loans
+	NetIncome       float64 `json:"net_income"`     // interest less fees and losses
+	ReturnOnFunded  float64 `json:"return_on_funded"`
+}
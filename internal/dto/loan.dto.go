@@ -6,40 +6,94 @@ import "time"
 type CreateLoanRequest struct {
 	BorrowerID uint    `json:"borrower_id" validate:"required"`
 	Amount     float64 `json:"amount" validate:"required,gt=0"`
+	ProductID  *uint   `json:"product_id,omitempty"`
+	GroupID    *uint   `json:"group_id,omitempty"`
+	// VirtualAccount and Reference identify the loan on bank statements
+	VirtualAccount string `json:"virtual_account,omitempty"`
+	Reference      string `json:"reference,omitempty"`
+}
+
+// LoanQuoteRequest represents the request to price a loan before booking it
+type LoanQuoteRequest struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	ProductID *uint   `json:"product_id,omitempty"`
+}
+
+// ChargeDTO represents a fee charged on a loan
+type ChargeDTO struct {
+	Code        string  `json:"code"`
+	Name        string  `json:"name"`
+	Collection  string  `json:"collection"` // deducted, installment
+	Amount      float64 `json:"amount"`
+	VATAmount   float64 `json:"vat_amount"`
+	TotalAmount float64 `json:"total_amount"`
+	PaidAmount  float64 `json:"paid_amount"`
+	Status      string  `json:"status,omitempty"` // pending, partially_paid, paid, written_off
+}
+
+// LoanQuoteResponse represents the terms a loan would be booked on
+type LoanQuoteResponse struct {
+	Amount          float64     `json:"amount"`
+	InterestRate    float64     `json:"interest_rate"`
+	InterestAmount  float64     `json:"interest_amount"`
+	TotalWeeks      int         `json:"total_weeks"`
+	DeductedFees    float64     `json:"deducted_fees"`
+	InstallmentFees float64     `json:"installment_fees"`
+	DisbursedAmount float64     `json:"disbursed_amount"`
+	TotalAmount     float64     `json:"total_amount"`
+	WeeklyPayment   float64     `json:"weekly_payment"`
+	WeeklyFee       float64     `json:"weekly_fee"` // part of the weekly payment that pays fees
+	Fees            []ChargeDTO `json:"fees"`
 }
 
 // LoanResponse represents the loan response
 type LoanResponse struct {
-	ID            uint      `json:"id"`
-	BorrowerID    uint      `json:"borrower_id"`
-	Amount        float64   `json:"amount"`
-	InterestRate  float64   `json:"interest_rate"`
-	TotalAmount   float64   `json:"total_amount"`
-	WeeklyPayment float64   `json:"weekly_payment"`
-	TotalWeeks    int       `json:"total_weeks"`
-	StartDate     time.Time `json:"start_date"`
-	EndDate       time.Time `json:"end_date"`
-	Status        string    `json:"status"`
+	ID              uint      `json:"id"`
+	BorrowerID      uint      `json:"borrower_id"`
+	ProductID       *uint     `json:"product_id,omitempty"`
+	GroupID         *uint     `json:"group_id,omitempty"`
+	VirtualAccount  *string   `json:"virtual_account,omitempty"`
+	Reference       string    `json:"reference,omitempty"`
+	Amount          float64   `json:"amount"`
+	InterestRate    float64   `json:"interest_rate"`
+	TotalAmount     float64   `json:"total_amount"`
+	FeeAmount       float64   `json:"fee_amount"`
+	DeductedFees    float64   `json:"deducted_fees"`
+	DisbursedAmount float64   `json:"disbursed_amount"`
+	PayoffAmount    float64   `json:"payoff_amount,omitempty"`
+	RefinancesID    *uint     `json:"refinances_id,omitempty"`
+	RefinancedByID  *uint     `json:"refinanced_by_id,omitempty"`
+	SuspenseAmount  float64   `json:"suspense_amount"` // received but not yet applied to an installment
+	WeeklyPayment   float64   `json:"weekly_payment"`
+	TotalWeeks      int       `json:"total_weeks"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	Status          string    `json:"status"`
 }
 
 // PaymentRequest represents a payment request
 type PaymentRequest struct {
-	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Amount   float64 `json:"amount" validate:"required,gt=0"`
+	Currency string  `json:"currency,omitempty" validate:"omitempty,len=3"` // defaults to the loans' currency
 }
 
 // ScheduleResponse represents the loan schedule response
 type ScheduleResponse struct {
 	LoanID   uint              `json:"loan_id"`
+	Version  int               `json:"version"`
 	Schedule []ScheduleItemDTO `json:"schedule"`
 }
 
 // ScheduleItemDTO represents a single item in the loan schedule
 type ScheduleItemDTO struct {
-	WeekNum     int        `json:"week_num"`
-	DueDate     time.Time  `json:"due_date"`
-	Amount      float64    `json:"amount"`
-	Status      string     `json:"status"`
-	PaymentDate *time.Time `json:"payment_date,omitempty"`
+	WeekNum         int        `json:"week_num"`
+	DueDate         time.Time  `json:"due_date"`
+	Amount          float64    `json:"amount"`
+	FeeAmount       float64    `json:"fee_amount"`
+	Status          string     `json:"status"`
+	PaymentDate     *time.Time `json:"payment_date,omitempty"`
+	ScheduleVersion int        `json:"schedule_version"`
+	SupersededIn    int        `json:"superseded_in_version,omitempty"` // version that replaced the installment
 }
 
 // OutstandingResponse represents the outstanding amount response
@@ -48,6 +102,14 @@ type OutstandingResponse struct {
 	TotalAmount       float64 `json:"total_amount"`
 	AmountPaid        float64 `json:"amount_paid"`
 	OutstandingAmount float64 `json:"outstanding_amount"`
+	FeeOutstanding    float64 `json:"fee_outstanding"` // part of the outstanding amount that is fees
+	WrittenOffAmount  float64 `json:"written_off_amount,omitempty"`
+}
+
+// LoanChargesResponse represents the fee charges of a loan
+type LoanChargesResponse struct {
+	LoanID  uint        `json:"loan_id"`
+	Charges []ChargeDTO `json:"charges"`
 }
 
 // DelinquencyResponse represents the delinquency status response
@@ -64,3 +126,76 @@ type PaymentResponse struct {
 	PaymentID    uint    `json:"payment_id,omitempty"`
 	RemainingDue float64 `json:"remaining_due"`
 }
+
+// EligibilityRejection explains why a borrower cannot take a loan
+type EligibilityRejection struct {
+	Rule    string                 `json:"rule"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// EligibilityResponse represents the outcome of the eligibility checks for a loan
+type EligibilityResponse struct {
+	BorrowerID uint                   `json:"borrower_id"`
+	Amount     float64                `json:"amount"`
+	LoanCycle  int                    `json:"loan_cycle"`
+	Eligible   bool                   `json:"eligible"`
+	Rejections []EligibilityRejection `json:"rejections"`
+}
+
+// ScoreReasonDTO represents the points one characteristic added to a credit score
+type ScoreReasonDTO struct {
+	Characteristic string   `json:"characteristic"`
+	Code           string   `json:"code"`
+	Value          *float64 `json:"value"`
+	Points         float64  `json:"points"`
+}
+
+// LoanApplicationResponse represents a scored loan application
+type LoanApplicationResponse struct {
+	ID         uint             `json:"id"`
+	BorrowerID uint             `json:"borrower_id"`
+	ProductID  *uint            `json:"product_id,omitempty"`
+	GroupID    *uint            `json:"group_id,omitempty"`
+	Amount     float64          `json:"amount"`
+	LoanCycle  int              `json:"loan_cycle"`
+	Score      float64          `json:"score"`
+	Band       string           `json:"band"`
+	Decision   string           `json:"decision"`
+	Scorer     string           `json:"scorer"`
+	Reasons    []ScoreReasonDTO `json:"reasons"`
+	LoanID     *uint            `json:"loan_id,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// PayoffResponse represents what it takes to settle a loan early
+type PayoffResponse struct {
+	LoanID                uint      `json:"loan_id"`
+	AsOf                  time.Time `json:"as_of"`
+	DueAmount             float64   `json:"due_amount"`          // installments due up to today, paid in full
+	PrincipalRemaining    float64   `json:"principal_remaining"` // principal of later installments
+	InterestWaived        float64   `json:"interest_waived"`
+	FeesWaived            float64   `json:"fees_waived"`
+	SuspenseAmount        float64   `json:"suspense_amount"` // money already held for the loan, taken off the payoff
+	PayoffAmount          float64   `json:"payoff_amount"`
+	InstallmentsDue       int       `json:"installments_due"`
+	InstallmentsRemaining int       `json:"installments_remaining"`
+}
+
+// TopUpRequest represents the request to refinance an active loan with a larger one
+type TopUpRequest struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	ProductID *uint   `json:"product_id,omitempty"` // defaults to the product of the loan being topped up
+	Reference string  `json:"reference,omitempty"`
+}
+
+// TopUpResponse represents a top-up loan and the payoff of the loan it replaced
+type TopUpResponse struct {
+	LoanID           uint    `json:"loan_id"`
+	RefinancedLoanID uint    `json:"refinanced_loan_id"`
+	Amount           float64 `json:"amount"`
+	PayoffAmount     float64 `json:"payoff_amount"`
+	DeductedFees     float64 `json:"deducted_fees"`
+	NetDisbursement  float64 `json:"net_disbursement"` // paid out to the borrower
+}
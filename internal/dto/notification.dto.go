@@ -0,0 +1,73 @@
+package dto
+
+import "time"
+
+// NotificationTemplateRequest represents the request to create or replace a
+// notification template
+type NotificationTemplateRequest struct {
+	ProductID  *uint  `json:"product_id"` // none for a default template
+	Stage      string `json:"stage" validate:"required,oneof=reminder due dunning"`
+	OffsetDays int    `json:"offset_days" validate:"gte=0"`
+	Channel    string `json:"channel" validate:"required,oneof=sms whatsapp email"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body" validate:"required"`
+	Active     *bool  `json:"active"` // defaults to true
+}
+
+// NotificationTemplateResponse represents a notification template
+type NotificationTemplateResponse struct {
+	ID         uint      `json:"id"`
+	ProductID  *uint     `json:"product_id,omitempty"`
+	Stage      string    `json:"stage"`
+	OffsetDays int       `json:"offset_days"`
+	Channel    string    `json:"channel"`
+	Subject    string    `json:"subject,omitempty"`
+	Body       string    `json:"body"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// NotificationOptOutRequest represents the request to opt a borrower out of
+// a channel, or of every channel with *
+type NotificationOptOutRequest struct {
+	Channel string `json:"channel" validate:"required,oneof=sms whatsapp email *"`
+	Reason  string `json:"reason"`
+}
+
+// NotificationOptOutResponse represents a borrower's opt-out
+type NotificationOptOutResponse struct {
+	BorrowerID uint      `json:"borrower_id"`
+	Channel    string    `json:"channel"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NotificationResponse represents an entry of the send history
+type NotificationResponse struct {
+	ID          uint       `json:"id"`
+	BorrowerID  uint       `json:"borrower_id"`
+	LoanID      uint       `json:"loan_id"`
+	PaymentID   uint       `json:"payment_id"`
+	TemplateID  uint       `json:"template_id"`
+	Stage       string     `json:"stage"`
+	OffsetDays  int        `json:"offset_days"`
+	Channel     string     `json:"channel"`
+	Recipient   string     `json:"recipient"`
+	Subject     string     `json:"subject,omitempty"`
+	Body        string     `json:"body"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	ProviderRef string     `json:"provider_ref,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// NotificationRunResponse represents the result of a reminder run
+type NotificationRunResponse struct {
+	Sent     int `json:"sent"`
+	Failed   int `json:"failed"`
+	OptedOut int `json:"opted_out"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+// PaymentHolidayRequest represents a pause in repayments for one loan or a whole region
+type PaymentHolidayRequest struct {
+	StartDate      time.Time `json:"start_date" validate:"required"`
+	EndDate        time.Time `json:"end_date" validate:"required"`
+	Region         string    `json:"region,omitempty"`          // bulk requests only
+	AccrueInterest *bool     `json:"accrue_interest,omitempty"` // overrides the configured policy
+	Reason         string    `json:"reason" validate:"required"`
+	RequestedBy    string    `json:"requested_by"`
+}
+
+// PaymentHolidayResponse represents a payment holiday applied to a loan
+type PaymentHolidayResponse struct {
+	ID              uint      `json:"id"`
+	LoanID          uint      `json:"loan_id"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	ShiftWeeks      int       `json:"shift_weeks"`
+	AccrueInterest  bool      `json:"accrue_interest"`
+	AccruedInterest float64   `json:"accrued_interest"`
+	Region          string    `json:"region,omitempty"`
+	Reason          string    `json:"reason"`
+	RequestedBy     string    `json:"requested_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// BulkPaymentHolidayResult represents the outcome of a regional holiday for one loan
+type BulkPaymentHolidayResult struct {
+	LoanID          uint    `json:"loan_id"`
+	Applied         bool    `json:"applied"`
+	HolidayID       uint    `json:"holiday_id,omitempty"`
+	ShiftWeeks      int     `json:"shift_weeks,omitempty"`
+	AccruedInterest float64 `json:"accrued_interest,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// BulkPaymentHolidayResponse represents the outcome of a regional payment holiday
+type BulkPaymentHolidayResponse struct {
+	Region  string                     `json:"region"`
+	Applied int                        `json:"applied"`
+	Skipped int                        `json:"skipped"`
+	Results []BulkPaymentHolidayResult `json:"results"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// ProductRequest represents the request to create or update a loan product
+type ProductRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	DueDateRule string `json:"due_date_rule" validate:"omitempty,oneof=roll_forward roll_backward keep"`
+	// MinPrincipal and MaxPrincipal are left as they are when omitted; zero
+	// removes the bound
+	MinPrincipal *float64 `json:"min_principal,omitempty" validate:"omitempty,gte=0"`
+	MaxPrincipal *float64 `json:"max_principal,omitempty" validate:"omitempty,gte=0"`
+}
+
+// ProductResponse represents the product response
+type ProductResponse struct {
+	ID           uint      `json:"id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	DueDateRule  string    `json:"due_date_rule"`
+	MinPrincipal float64   `json:"min_principal"`
+	MaxPrincipal float64   `json:"max_principal"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ProductFeeRequest represents the request to add a fee to a loan product
+type ProductFeeRequest struct {
+	Code       string  `json:"code" validate:"required"`
+	Name       string  `json:"name" validate:"required"`
+	Type       string  `json:"type" validate:"required,oneof=fixed percentage"`
+	Value      float64 `json:"value" validate:"required,gt=0"`
+	Collection string  `json:"collection" validate:"required,oneof=deducted installment"`
+	VATRate    float64 `json:"vat_rate" validate:"gte=0,lte=1"`
+}
+
+// ProductFeeResponse represents a fee defined on a loan product
+type ProductFeeResponse struct {
+	ID         uint    `json:"id"`
+	ProductID  uint    `json:"product_id"`
+	Code       string  `json:"code"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Value      float64 `json:"value"`
+	Collection string  `json:"collection"`
+	VATRate    float64 `json:"vat_rate"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// ReconciliationRunRequest represents the request to reconcile a business date
+type ReconciliationRunRequest struct {
+	Date  string `json:"date" validate:"required"` // YYYY-MM-DD
+	RunBy string `json:"run_by"`
+}
+
+// ReconciliationItemResponse represents one mismatch found by a reconciliation run
+type ReconciliationItemResponse struct {
+	Type             string  `json:"type"`
+	LoanID           *uint   `json:"loan_id,omitempty"`
+	TransactionID    *uint   `json:"transaction_id,omitempty"`
+	StatementLineID  *uint   `json:"statement_line_id,omitempty"`
+	GatewayPaymentID *uint   `json:"gateway_payment_id,omitempty"`
+	PaymentID        *uint   `json:"payment_id,omitempty"`
+	Expected         float64 `json:"expected"`
+	Actual           float64 `json:"actual"`
+	Difference       float64 `json:"difference"`
+	Description      string  `json:"description"`
+}
+
+// ReconciliationRunResponse represents the reconciliation report of a business date
+type ReconciliationRunResponse struct {
+	BusinessDate     time.Time                    `json:"business_date"`
+	Status           string                       `json:"status"` // balanced, mismatched
+	StatementCount   int                          `json:"statement_count"`
+	StatementTotal   float64                      `json:"statement_total"`
+	TransactionCount int                          `json:"transaction_count"`
+	TransactionTotal float64                      `json:"transaction_total"`
+	AppliedTotal     float64                      `json:"applied_total"`
+	UnappliedTotal   float64                      `json:"unapplied_total"`
+	InstallmentCount int                          `json:"installment_count"`
+	InstallmentTotal float64                      `json:"installment_total"`
+	MismatchCount    int                          `json:"mismatch_count"`
+	MismatchesByType map[string]int               `json:"mismatches_by_type,omitempty"`
+	RunBy            string                       `json:"run_by,omitempty"`
+	RunAt            time.Time                    `json:"run_at"`
+	Items            []ReconciliationItemResponse `json:"items,omitempty"`
+}
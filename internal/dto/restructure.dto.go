@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// RestructureRequest represents the new terms for a loan in hardship
+type RestructureRequest struct {
+	ExtendWeeks       int    `json:"extend_weeks" validate:"gte=0"`
+	HolidayWeeks      int    `json:"holiday_weeks" validate:"gte=0"`
+	CapitaliseArrears bool   `json:"capitalise_arrears"`
+	Reason            string `json:"reason" validate:"required"`
+	RequestedBy       string `json:"requested_by"`
+}
+
+// RestructureResponse represents a single restructure of a loan
+type RestructureResponse struct {
+	ID                    uint      `json:"id"`
+	LoanID                uint      `json:"loan_id"`
+	FromVersion           int       `json:"from_version"`
+	ToVersion             int       `json:"to_version"`
+	ExtendWeeks           int       `json:"extend_weeks"`
+	HolidayWeeks          int       `json:"holiday_weeks"`
+	CapitaliseArrears     bool      `json:"capitalise_arrears"`
+	CapitalisedAmount     float64   `json:"capitalised_amount"`
+	RescheduledAmount     float64   `json:"rescheduled_amount"`
+	PreviousWeeklyPayment float64   `json:"previous_weekly_payment"`
+	NewWeeklyPayment      float64   `json:"new_weekly_payment"`
+	PreviousEndDate       time.Time `json:"previous_end_date"`
+	NewEndDate            time.Time `json:"new_end_date"`
+	Reason                string    `json:"reason"`
+	RequestedBy           string    `json:"requested_by"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// RestructureHistoryResponse represents every restructure of a loan
+type RestructureHistoryResponse struct {
+	LoanID         uint                  `json:"loan_id"`
+	CurrentVersion int                   `json:"current_version"`
+	Restructures   []RestructureResponse `json:"restructures"`
+}
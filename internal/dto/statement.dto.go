@@ -0,0 +1,62 @@
+package dto
+
+import "time"
+
+// ResolveStatementLineRequest represents the request to post a queued statement line to a loan
+type ResolveStatementLineRequest struct {
+	LoanID     uint   `json:"loan_id" validate:"required"`
+	ResolvedBy string `json:"resolved_by"`
+	Note       string `json:"note"`
+}
+
+// IgnoreStatementLineRequest represents the request to take a statement line off the queue without posting it
+type IgnoreStatementLineRequest struct {
+	ResolvedBy string `json:"resolved_by"`
+	Note       string `json:"note" validate:"required"`
+}
+
+// StatementFormatResponse represents a statement format that can be imported
+type StatementFormatResponse struct {
+	Name       string            `json:"name"`
+	Delimiter  string            `json:"delimiter"`
+	DateLayout string            `json:"date_layout"`
+	Columns    map[string]string `json:"columns"`
+}
+
+// StatementLineResponse represents one credit on an imported statement
+type StatementLineResponse struct {
+	ID            uint       `json:"id"`
+	ImportID      uint       `json:"import_id"`
+	LineNo        int        `json:"line_no"`
+	ValueDate     time.Time  `json:"value_date"`
+	Amount        float64    `json:"amount"`
+	Account       string     `json:"account,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	MatchStatus   string     `json:"match_status"` // auto_matched, ambiguous, unmatched
+	Candidates    []uint     `json:"candidates,omitempty"`
+	LoanID        *uint      `json:"loan_id,omitempty"`
+	TransactionID *uint      `json:"transaction_id,omitempty"`
+	Status        string     `json:"status"` // posted, open, resolved, ignored
+	Error         string     `json:"error,omitempty"`
+	ResolvedBy    string     `json:"resolved_by,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	Note          string     `json:"note,omitempty"`
+}
+
+// StatementImportResponse represents an imported statement and its match results
+type StatementImportResponse struct {
+	ID             uint                    `json:"id"`
+	Format         string                  `json:"format"`
+	FileName       string                  `json:"file_name,omitempty"`
+	Source         string                  `json:"source"`
+	TotalLines     int                     `json:"total_lines"`
+	DuplicateLines int                     `json:"duplicate_lines"`
+	SkippedLines   int                     `json:"skipped_lines"`
+	AutoMatched    int                     `json:"auto_matched"`
+	Ambiguous      int                     `json:"ambiguous"`
+	Unmatched      int                     `json:"unmatched"`
+	TotalAmount    float64                 `json:"total_amount"`
+	CreatedAt      time.Time               `json:"created_at"`
+	Lines          []StatementLineResponse `json:"lines,omitempty"`
+}
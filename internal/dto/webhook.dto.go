@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookSubscriptionRequest represents the request to create or replace a
+// webhook subscription. Without a secret one is generated
+type WebhookSubscriptionRequest struct {
+	Name       string   `json:"name" validate:"required"`
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"` // event types, or * for every type
+	Secret     string   `json:"secret" validate:"omitempty,min=16"`    // only read on create
+	Status     string   `json:"status" validate:"omitempty,oneof=active paused"`
+}
+
+// WebhookSubscriptionResponse represents a webhook subscription. Secret is
+// only set in the response to the request that created it
+type WebhookSubscriptionResponse struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Status     string    `json:"status"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryResponse represents a delivery of an event to a subscription.
+// Event and the attempt log are only set when a single delivery is read
+type WebhookDeliveryResponse struct {
+	ID             uint                     `json:"id"`
+	SubscriptionID uint                     `json:"subscription_id"`
+	EventID        string                   `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time               `json:"last_attempt_at,omitempty"`
+	LastStatusCode int                      `json:"last_status_code,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	RedeliveredAt  *time.Time               `json:"redelivered_at,omitempty"`
+	RedeliveredBy  string                   `json:"redelivered_by,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	Event          json.RawMessage          `json:"event,omitempty"`
+	AttemptLog     []WebhookAttemptResponse `json:"attempt_log,omitempty"`
+}
+
+// WebhookAttemptResponse represents one HTTP request made for a delivery
+type WebhookAttemptResponse struct {
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+// WriteOffRequest represents a request to write off a defaulted loan
+type WriteOffRequest struct {
+	Reason      string `json:"reason" validate:"required"`
+	RequestedBy string `json:"requested_by"`
+}
+
+// WriteOffReviewRequest represents an approval or rejection of a write-off
+type WriteOffReviewRequest struct {
+	ReviewedBy string `json:"reviewed_by"`
+	Note       string `json:"note"`
+}
+
+// WriteOffResponse represents the write-off response
+type WriteOffResponse struct {
+	ID          uint       `json:"id"`
+	LoanID      uint       `json:"loan_id"`
+	Amount      float64    `json:"amount"`
+	Reason      string     `json:"reason"`
+	RequestedBy string     `json:"requested_by"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	Status      string     `json:"status"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// RecoveryRequest represents money received on a written-off loan
+type RecoveryRequest struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	Reference string  `json:"reference"`
+}
+
+// RecoveryResponse represents a single recovery booking
+type RecoveryResponse struct {
+	ID         uint      `json:"id"`
+	LoanID     uint      `json:"loan_id"`
+	Amount     float64   `json:"amount"`
+	Reference  string    `json:"reference,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// RecoveriesResponse represents all recoveries booked on a loan
+type RecoveriesResponse struct {
+	LoanID           uint               `json:"loan_id"`
+	WrittenOffAmount float64            `json:"written_off_amount"`
+	TotalRecovered   float64            `json:"total_recovered"`
+	Recoveries       []RecoveryResponse `json:"recoveries"`
+}
+
+// DefaultEvaluationResponse represents the result of an auto-default run
+type DefaultEvaluationResponse struct {
+	DefaultedLoanIDs []uint `json:"defaulted_loan_ids"`
+}
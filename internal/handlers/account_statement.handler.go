@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/services"
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccountStatementHandler handles HTTP requests for loan account statements
+type AccountStatementHandler struct {
+	service *services.AccountStatementService
+}
+
+// NewAccountStatementHandler creates a new account statement handler instance
+func NewAccountStatementHandler(service *services.AccountStatementService) *AccountStatementHandler {
+	return &AccountStatementHandler{service: service}
+}
+
+// GetStatement handles retrieving a loan account statement as JSON, CSV or HTML
+func (h *AccountStatementHandler) GetStatement(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var from, to time.Time
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid to date, expected YYYY-MM-DD")
+		}
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" && format != "html" {
+		return services.Validation("format must be json, csv or html")
+	}
+
+	statement, err := h.service.GetStatement(c.UserContext(), uint(id), from, to)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	filename := fmt.Sprintf("loan-%d-statement-%s-%s", statement.LoanID, statement.From.Format("20060102"), statement.To.Format("20060102"))
+	switch format {
+	case "csv":
+		if err := services.WriteAccountStatementCSV(&body, statement); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+	case "html":
+		if err := services.WriteAccountStatementHTML(&body, statement); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	default:
+		return c.Status(fiber.StatusOK).JSON(statement)
+	}
+
+	return c.Status(fiber.StatusOK).Send(body.Bytes())
+}
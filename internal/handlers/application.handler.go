@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApplicationHandler handles HTTP requests for scored loan applications
+type ApplicationHandler struct {
+	service *services.ApplicationService
+}
+
+// NewApplicationHandler creates a new application handler instance
+func NewApplicationHandler(service *services.ApplicationService) *ApplicationHandler {
+	return &ApplicationHandler{service: service}
+}
+
+// ListApplications handles listing loan applications, optionally by borrower and decision
+func (h *ApplicationHandler) ListApplications(c *fiber.Ctx) error {
+	var borrowerID uint64
+	if raw := c.Query("borrower_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return services.Validation("Invalid borrower ID")
+		}
+		borrowerID = parsed
+	}
+
+	applications, err := h.service.ListApplications(c.UserContext(), uint(borrowerID), c.Query("decision"))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.LoanApplicationResponse, len(applications))
+	for i := range applications {
+		reasons, err := h.service.Reasons(&applications[i])
+		if err != nil {
+			return err
+		}
+		response[i] = toLoanApplicationResponse(&applications[i], reasons)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetApplication handles retrieving a loan application with its score reasons
+func (h *ApplicationHandler) GetApplication(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid application ID")
+	}
+
+	application, err := h.service.GetApplication(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	reasons, err := h.service.Reasons(application)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toLoanApplicationResponse(application, reasons))
+}
+
+func toLoanApplicationResponse(application *models.LoanApplication, reasons []services.ScoreReason) dto.LoanApplicationResponse {
+	response := dto.LoanApplicationResponse{
+		ID:         application.ID,
+		BorrowerID: application.BorrowerID,
+		ProductID:  application.ProductID,
+		GroupID:    application.GroupID,
+		Amount:     application.Amount,
+		LoanCycle:  application.LoanCycle,
+		Score:      application.Score,
+		Band:       application.Band,
+		Decision:   application.Decision,
+		Scorer:     application.Scorer,
+		Reasons:    make([]dto.ScoreReasonDTO, len(reasons)),
+		LoanID:     application.LoanID,
+		CreatedAt:  application.CreatedAt,
+	}
+	for i, reason := range reasons {
+		response.Reasons[i] = dto.ScoreReasonDTO{
+			Characteristic: reason.Characteristic,
+			Code:           reason.Code,
+			Value:          reason.Value,
+			Points:         reason.Points,
+		}
+	}
+	return response
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler handles HTTP requests for the audit log
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+// NewAuditHandler creates a new audit handler instance
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// ListEntries handles searching the audit log by entity, actor, action,
+// request and the days from..to inclusive
+func (h *AuditHandler) ListEntries(c *fiber.Ctx) error {
+	filter := repositories.AuditFilter{
+		Entity:    c.Query("entity"),
+		EntityID:  c.Query("entity_id"),
+		Actor:     c.Query("actor"),
+		Action:    c.Query("action"),
+		RequestID: c.Query("request_id"),
+		Limit:     c.QueryInt("limit", 100),
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid to date, expected YYYY-MM-DD")
+		}
+		filter.To = filter.To.AddDate(0, 0, 1)
+	}
+
+	entries, err := h.service.ListEntries(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.AuditLogResponse, len(entries))
+	for i := range entries {
+		response[i] = toAuditLogResponse(&entries[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetEntry handles retrieving an audit entry by ID
+func (h *AuditHandler) GetEntry(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid audit entry ID")
+	}
+
+	entry, err := h.service.GetEntry(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toAuditLogResponse(entry))
+}
+
+// VerifyChain handles checking that no audit entry was altered or removed
+func (h *AuditHandler) VerifyChain(c *fiber.Ctx) error {
+	result, err := h.service.Verify(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+func toAuditLogResponse(entry *models.AuditLog) dto.AuditLogResponse {
+	raw := func(value string) json.RawMessage {
+		if value == "" {
+			return nil
+		}
+		return json.RawMessage(value)
+	}
+
+	return dto.AuditLogResponse{
+		ID:         entry.ID,
+		Sequence:   entry.Sequence,
+		OccurredAt: entry.OccurredAt,
+		Actor:      entry.Actor,
+		ActorType:  entry.ActorType,
+		RequestID:  entry.RequestID,
+		Action:     entry.Action,
+		Entity:     entry.Entity,
+		EntityID:   entry.EntityID,
+		Before:     raw(entry.Before),
+		After:      raw(entry.After),
+		Changes:    raw(entry.Changes),
+		PrevHash:   entry.PrevHash,
+		Hash:       entry.Hash,
+		SealedAt:   entry.SealedAt,
+	}
+}
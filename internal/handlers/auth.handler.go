@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"strconv"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthHandler handles HTTP requests about the caller and API key management
+type AuthHandler struct {
+	service *services.AuthService
+}
+
+// NewAuthHandler creates a new auth handler instance
+func NewAuthHandler(service *services.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Me handles returning the principal the request was authenticated as
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	principal := services.PrincipalFrom(c.UserContext())
+	if principal == nil {
+		return services.ErrUnauthenticated
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.PrincipalResponse{
+		Type:        principal.Type,
+		Subject:     principal.Subject,
+		Name:        principal.Name,
+		Role:        principal.Role,
+		OfficerID:   principal.OfficerID,
+		TenantID:    principal.TenantID,
+		BranchID:    principal.BranchID,
+		APIKeyID:    principal.APIKeyID,
+		Permissions: principal.Permissions,
+		Scope:       principal.Scope,
+	})
+}
+
+// CreateAPIKey handles issuing an API key. The key is only ever shown in this response
+func (h *AuthHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req dto.APIKeyRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	apiKey, key, err := h.service.CreateAPIKey(c.UserContext(), req, actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	response := toAPIKeyResponse(apiKey)
+	response.Key = key
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// ListAPIKeys handles listing the API keys issued
+func (h *AuthHandler) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.service.ListAPIKeys(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = toAPIKeyResponse(&keys[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// RevokeAPIKey handles revoking an API key
+func (h *AuthHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid API key ID")
+	}
+
+	apiKey, err := h.service.RevokeAPIKey(c.UserContext(), uint(id), actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toAPIKeyResponse(apiKey))
+}
+
+// actor returns who is making a request: the authenticated principal when
+// there is one, otherwise the name the client gave
+func actor(c *fiber.Ctx, claimed string) string {
+	if principal := services.PrincipalFrom(c.UserContext()); principal != nil {
+		return principal.Actor()
+	}
+	return claimed
+}
+
+func toAPIKeyResponse(apiKey *models.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Role:       apiKey.Role,
+		TenantID:   apiKey.TenantID,
+		BranchID:   apiKey.BranchID,
+		CreatedBy:  apiKey.CreatedBy,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		RevokedAt:  apiKey.RevokedAt,
+		RevokedBy:  apiKey.RevokedBy,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
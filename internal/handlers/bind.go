@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/services"
+	"AmarthaExample1/internal/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bind parses the request body into req and checks it against the validate
+// tags of its fields, failing with every field that breaks its rules
+func bind(c *fiber.Ctx, req interface{}) error {
+	if err := c.BodyParser(req); err != nil {
+		return services.Validation("Invalid request body")
+	}
+	return services.Invalid(validation.Struct(req))
+}
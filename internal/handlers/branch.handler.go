@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BranchHandler handles HTTP requests for branches and branch reports
+type BranchHandler struct {
+	service *services.BranchService
+}
+
+// NewBranchHandler creates a new branch handler instance
+func NewBranchHandler(service *services.BranchService) *BranchHandler {
+	return &BranchHandler{service: service}
+}
+
+// CreateBranch handles opening a branch in the caller's tenant
+func (h *BranchHandler) CreateBranch(c *fiber.Ctx) error {
+	var req dto.BranchRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	branch, err := h.service.CreateBranch(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toBranchResponse(branch))
+}
+
+// ListBranches handles listing the caller's branches, optionally filtered by region
+func (h *BranchHandler) ListBranches(c *fiber.Ctx) error {
+	branches, err := h.service.ListBranches(c.UserContext(), c.Query("region"))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.BranchResponse, len(branches))
+	for i := range branches {
+		response[i] = toBranchResponse(&branches[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetBranch handles retrieving a branch by ID
+func (h *BranchHandler) GetBranch(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid branch ID")
+	}
+
+	branch, err := h.service.GetBranch(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toBranchResponse(branch))
+}
+
+// GetBranchReport handles the per-branch portfolio report. Collections are
+// counted from the from date up to and including the to date, by default
+// the current month to date
+func (h *BranchHandler) GetBranchReport(c *fiber.Ctx) error {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	for param, date := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return services.Validation("Invalid " + param + " date, expected YYYY-MM-DD")
+		}
+		*date = parsed
+	}
+
+	report, err := h.service.GetBranchReport(c.UserContext(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+func toBranchResponse(branch *models.Branch) dto.BranchResponse {
+	return dto.BranchResponse{
+		ID:       branch.ID,
+		TenantID: branch.TenantID,
+		Code:     branch.Code,
+		Name:     branch.Name,
+		Region:   branch.Region,
+		Status:   branch.Status,
+		OpenedAt: branch.CreatedAt,
+	}
+}
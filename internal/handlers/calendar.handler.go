@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CalendarHandler handles HTTP requests for the holiday calendar
+type CalendarHandler struct {
+	service *services.CalendarService
+}
+
+// NewCalendarHandler creates a new calendar handler instance
+func NewCalendarHandler(service *services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: service}
+}
+
+// ListHolidays handles listing holidays for a year, optionally for one region
+func (h *CalendarHandler) ListHolidays(c *fiber.Ctx) error {
+	year := c.QueryInt("year", time.Now().Year())
+
+	holidays, err := h.service.ListHolidays(c.UserContext(), c.Query("region"), year)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.HolidayResponse, len(holidays))
+	for i := range holidays {
+		response[i] = toHolidayResponse(&holidays[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetHoliday handles retrieving a holiday by ID
+func (h *CalendarHandler) GetHoliday(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid holiday ID")
+	}
+
+	holiday, err := h.service.GetHoliday(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toHolidayResponse(holiday))
+}
+
+// CreateHoliday handles adding a holiday to the calendar
+func (h *CalendarHandler) CreateHoliday(c *fiber.Ctx) error {
+	var req dto.HolidayRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		return services.Validation("Invalid date, expected YYYY-MM-DD")
+	}
+
+	holiday, err := h.service.CreateHoliday(c.UserContext(), date, req.Name, req.Region)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toHolidayResponse(holiday))
+}
+
+// UpdateHoliday handles changing a holiday
+func (h *CalendarHandler) UpdateHoliday(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid holiday ID")
+	}
+
+	var req dto.HolidayRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	var date time.Time
+	if req.Date != "" {
+		date, err = time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			return services.Validation("Invalid date, expected YYYY-MM-DD")
+		}
+	}
+
+	if _, err := h.service.GetHoliday(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	holiday, err := h.service.UpdateHoliday(c.UserContext(), uint(id), date, req.Name, req.Region)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toHolidayResponse(holiday))
+}
+
+// DeleteHoliday handles removing a holiday
+func (h *CalendarHandler) DeleteHoliday(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid holiday ID")
+	}
+
+	if err := h.service.DeleteHoliday(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ImportHolidays handles importing holidays from an uploaded CSV file
+func (h *CalendarHandler) ImportHolidays(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return services.Validation("A CSV file is required in the \"file\" field")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Holidays upserted before a bad line are kept, so the count goes with the error
+	imported, err := h.service.ImportHolidays(c.UserContext(), file)
+	var coded *services.Error
+	if errors.As(err, &coded) {
+		return coded.WithDetails(map[string]int{"imported": imported})
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.HolidayImportResponse{
+		Imported: imported,
+	})
+}
+
+func toHolidayResponse(holiday *models.CalendarHoliday) dto.HolidayResponse {
+	return dto.HolidayResponse{
+		ID:     holiday.ID,
+		Date:   holiday.Date,
+		Name:   holiday.Name,
+		Region: holiday.Region,
+	}
+}
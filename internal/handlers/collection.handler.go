@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CollectionHandler handles HTTP requests for field officers and collection sheets
+type CollectionHandler struct {
+	service *services.CollectionService
+}
+
+// NewCollectionHandler creates a new collection handler instance
+func NewCollectionHandler(service *services.CollectionService) *CollectionHandler {
+	return &CollectionHandler{service: service}
+}
+
+// CreateOfficer handles registering a field officer
+func (h *CollectionHandler) CreateOfficer(c *fiber.Ctx) error {
+	var req dto.CreateOfficerRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	officer, err := h.service.CreateOfficer(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toOfficerResponse(officer))
+}
+
+// ListOfficers handles listing field officers, optionally filtered by region
+func (h *CollectionHandler) ListOfficers(c *fiber.Ctx) error {
+	officers, err := h.service.ListOfficers(c.UserContext(), c.Query("region"))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.OfficerResponse, len(officers))
+	for i := range officers {
+		response[i] = toOfficerResponse(&officers[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetOfficer handles retrieving a field officer by ID
+func (h *CollectionHandler) GetOfficer(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid officer ID")
+	}
+
+	officer, err := h.service.GetOfficer(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toOfficerResponse(officer))
+}
+
+// GetCollectionSheet handles retrieving an officer's expected collections for a date
+func (h *CollectionHandler) GetCollectionSheet(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid officer ID")
+	}
+
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		date, err = time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return services.Validation("Invalid date, expected YYYY-MM-DD")
+		}
+	}
+
+	sheet, err := h.service.GetCollectionSheet(c.UserContext(), uint(id), date)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sheet)
+}
+
+// PostBatch handles posting a whole collection sheet of paid, unpaid and partial results
+func (h *CollectionHandler) PostBatch(c *fiber.Ctx) error {
+	var req dto.CollectionBatchRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	// Field officers may only post the sheets of their own groups
+	if principal := services.PrincipalFrom(c.UserContext()); principal != nil && !principal.ActsFor(req.OfficerID) {
+		return services.ErrForbidden
+	}
+
+	if _, err := h.service.GetOfficer(c.UserContext(), req.OfficerID); err != nil {
+		return err
+	}
+
+	batch, err := h.service.PostBatch(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toCollectionBatchResponse(batch))
+}
+
+// GetBatch handles retrieving a posted collection sheet and its line results
+func (h *CollectionHandler) GetBatch(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid batch ID")
+	}
+
+	batch, err := h.service.GetBatch(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toCollectionBatchResponse(batch))
+}
+
+func toOfficerResponse(officer *models.Officer) dto.OfficerResponse {
+	return dto.OfficerResponse{
+		ID:       officer.ID,
+		Name:     officer.Name,
+		Phone:    officer.Phone,
+		Region:   officer.Region,
+		BranchID: officer.BranchID,
+		Status:   officer.Status,
+	}
+}
+
+func toCollectionBatchResponse(batch *models.CollectionBatch) dto.CollectionBatchResponse {
+	response := dto.CollectionBatchResponse{
+		ID:             batch.ID,
+		OfficerID:      batch.OfficerID,
+		CollectionDate: batch.CollectionDate,
+		TotalCollected: batch.TotalCollected,
+		PostedLines:    batch.PostedLines,
+		FailedLines:    batch.FailedLines,
+		Lines:          make([]dto.CollectionBatchLineResponse, len(batch.Lines)),
+	}
+	for i, line := range batch.Lines {
+		response.Lines[i] = dto.CollectionBatchLineResponse{
+			LineNo:           line.LineNo,
+			LoanID:           line.LoanID,
+			Result:           line.Result,
+			Amount:           line.Amount,
+			Status:           line.Status,
+			TransactionID:    line.TransactionID,
+			InstallmentsPaid: line.InstallmentsPaid,
+			UnappliedAmount:  line.UnappliedAmount,
+			Error:            line.Error,
+		}
+	}
+	return response
+}
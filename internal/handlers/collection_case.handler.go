@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CollectionCaseHandler handles HTTP requests for the collection cases of
+// delinquent loans and the collectors' work queues
+type CollectionCaseHandler struct {
+	service *services.CollectionCaseService
+}
+
+// NewCollectionCaseHandler creates a new collection case handler instance
+func NewCollectionCaseHandler(service *services.CollectionCaseService) *CollectionCaseHandler {
+	return &CollectionCaseHandler{service: service}
+}
+
+// ListCases handles searching the collection cases
+func (h *CollectionCaseHandler) ListCases(c *fiber.Ctx) error {
+	filter := repositories.CaseFilter{
+		Status:      c.Query("status"),
+		CollectorID: uint(c.QueryInt("collector_id", 0)),
+		Unassigned:  c.QueryBool("unassigned", false),
+		LoanID:      uint(c.QueryInt("loan_id", 0)),
+		Limit:       c.QueryInt("limit", 100),
+	}
+
+	cases, err := h.service.ListCases(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toCollectionCaseResponses(cases))
+}
+
+// GetCase handles retrieving a case with its activities
+func (h *CollectionCaseHandler) GetCase(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid case ID")
+	}
+
+	collectionCase, activities, err := h.service.GetCase(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := toCollectionCaseResponse(collectionCase)
+	response.Activities = make([]dto.CollectionActivityResponse, len(activities))
+	for i := range activities {
+		response.Activities[i] = toCollectionActivityResponse(&activities[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetWorkQueue handles listing an officer's open cases in the order they
+// should be worked
+func (h *CollectionCaseHandler) GetWorkQueue(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid officer ID")
+	}
+
+	cases, err := h.service.WorkQueue(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toCollectionCaseResponses(cases))
+}
+
+// AssignCase handles handing a case to a collector
+func (h *CollectionCaseHandler) AssignCase(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid case ID")
+	}
+
+	var req dto.AssignCaseRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	collectionCase, err := h.service.Assign(c.UserContext(), uint(id), req, actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toCollectionCaseResponse(collectionCase))
+}
+
+// LogActivity handles recording a visit, call, promise to pay or note on a case
+func (h *CollectionCaseHandler) LogActivity(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid case ID")
+	}
+
+	var req dto.CaseActivityRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	activity, err := h.service.LogActivity(c.UserContext(), uint(id), req, actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toCollectionActivityResponse(activity))
+}
+
+// CloseCase handles closing a case by hand
+func (h *CollectionCaseHandler) CloseCase(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid case ID")
+	}
+
+	var req dto.CloseCaseRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	collectionCase, err := h.service.CloseCase(c.UserContext(), uint(id), req, actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toCollectionCaseResponse(collectionCase))
+}
+
+// EvaluateCases handles running the collection case job on demand
+func (h *CollectionCaseHandler) EvaluateCases(c *fiber.Ctx) error {
+	result, err := h.service.EvaluateCases(c.UserContext(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+func toCollectionCaseResponses(cases []models.CollectionCase) []dto.CollectionCaseResponse {
+	response := make([]dto.CollectionCaseResponse, len(cases))
+	for i := range cases {
+		response[i] = toCollectionCaseResponse(&cases[i])
+	}
+	return response
+}
+
+func toCollectionCaseResponse(collectionCase *models.CollectionCase) dto.CollectionCaseResponse {
+	return dto.CollectionCaseResponse{
+		ID:             collectionCase.ID,
+		LoanID:         collectionCase.LoanID,
+		BorrowerID:     collectionCase.BorrowerID,
+		GroupID:        collectionCase.GroupID,
+		BranchID:       collectionCase.BranchID,
+		CollectorID:    collectionCase.CollectorID,
+		Status:         collectionCase.Status,
+		DaysPastDue:    collectionCase.DaysPastDue,
+		OverdueAmount:  collectionCase.OverdueAmount,
+		BrokenPromises: collectionCase.BrokenPromises,
+		NextActionAt:   collectionCase.NextActionAt,
+		OpenedAt:       collectionCase.OpenedAt,
+		AssignedAt:     collectionCase.AssignedAt,
+		AssignedBy:     collectionCase.AssignedBy,
+		ClosedAt:       collectionCase.ClosedAt,
+		CloseReason:    collectionCase.CloseReason,
+		ClosedBy:       collectionCase.ClosedBy,
+	}
+}
+
+func toCollectionActivityResponse(activity *models.CollectionActivity) dto.CollectionActivityResponse {
+	return dto.CollectionActivityResponse{
+		ID:                 activity.ID,
+		Type:               activity.Type,
+		Outcome:            activity.Outcome,
+		Notes:              activity.Notes,
+		PromiseDate:        activity.PromiseDate,
+		PromiseAmount:      activity.PromiseAmount,
+		PromiseStatus:      activity.PromiseStatus,
+		PromiseCollected:   activity.PromiseCollected,
+		PromiseEvaluatedAt: activity.PromiseEvaluatedAt,
+		OfficerID:          activity.OfficerID,
+		PerformedBy:        activity.PerformedBy,
+		PerformedAt:        activity.PerformedAt,
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EligibilityHandler handles HTTP requests for borrower eligibility checks
+type EligibilityHandler struct {
+	service *services.EligibilityService
+}
+
+// NewEligibilityHandler creates a new eligibility handler instance
+func NewEligibilityHandler(service *services.EligibilityService) *EligibilityHandler {
+	return &EligibilityHandler{service: service}
+}
+
+// CheckEligibility handles checking whether a borrower may take a loan of a given amount
+func (h *EligibilityHandler) CheckEligibility(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid borrower ID")
+	}
+
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil || amount <= 0 {
+		return services.Validation("amount must be a positive number")
+	}
+
+	var productID *uint
+	if raw := c.Query("product_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return services.Validation("Invalid product ID")
+		}
+		id := uint(parsed)
+		productID = &id
+	}
+
+	result, err := h.service.Check(c.UserContext(), uint(id), amount, productID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
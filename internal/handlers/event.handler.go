@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EventHandler handles HTTP requests for domain events and the arrears runs
+// that raise them
+type EventHandler struct {
+	outbox  *services.OutboxService
+	arrears *services.ArrearsService
+}
+
+// NewEventHandler creates a new event handler instance
+func NewEventHandler(outbox *services.OutboxService, arrears *services.ArrearsService) *EventHandler {
+	return &EventHandler{outbox: outbox, arrears: arrears}
+}
+
+// ListEvents handles listing outbox events, filtered by type, aggregate and
+// status (pending or published)
+func (h *EventHandler) ListEvents(c *fiber.Ctx) error {
+	filter := repositories.OutboxFilter{
+		Type:        c.Query("type"),
+		AggregateID: c.Query("aggregate_id"),
+		Status:      c.Query("status"),
+		Limit:       c.QueryInt("limit", 100),
+	}
+	if filter.Status != "" && filter.Status != "pending" && filter.Status != "published" {
+		return services.Validation("status must be pending or published")
+	}
+
+	events, err := h.outbox.ListEvents(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(events)
+}
+
+// GetEvent handles retrieving an outbox event by ID
+func (h *EventHandler) GetEvent(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid event ID")
+	}
+
+	event, err := h.outbox.GetEvent(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(event)
+}
+
+// EvaluateArrears handles running the arrears evaluation on demand
+func (h *EventHandler) EvaluateArrears(c *fiber.Ctx) error {
+	result, err := h.arrears.EvaluateArrears(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
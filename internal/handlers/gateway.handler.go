@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GatewayHandler handles HTTP requests from the payment gateway
+type GatewayHandler struct {
+	service *services.GatewayService
+}
+
+// NewGatewayHandler creates a new gateway handler instance
+func NewGatewayHandler(service *services.GatewayService) *GatewayHandler {
+	return &GatewayHandler{service: service}
+}
+
+// ReceivePayment handles a signed payment notification. Any notification that
+// was recorded is acknowledged with 200, including duplicates and ones that
+// could not be posted, so the gateway stops redelivering it
+func (h *GatewayHandler) ReceivePayment(c *fiber.Ctx) error {
+	body := c.Body()
+	if err := h.service.VerifySignature(body, c.Get("X-Signature")); err != nil {
+		return err
+	}
+
+	payment, duplicate, err := h.service.ReceivePayment(c.UserContext(), body)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toGatewayPaymentResponse(payment, duplicate))
+}
+
+// ListPayments handles listing received gateway notifications
+func (h *GatewayHandler) ListPayments(c *fiber.Ctx) error {
+	payments, err := h.service.ListPayments(c.UserContext(), c.Query("status"))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.GatewayPaymentResponse, len(payments))
+	for i := range payments {
+		response[i] = toGatewayPaymentResponse(&payments[i], false)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func toGatewayPaymentResponse(payment *models.GatewayPayment, duplicate bool) dto.GatewayPaymentResponse {
+	return dto.GatewayPaymentResponse{
+		GatewayTransactionID: payment.GatewayTransactionID,
+		VirtualAccount:       payment.VirtualAccount,
+		Amount:               payment.Amount,
+		Currency:             payment.Currency,
+		PaidAt:               payment.PaidAt,
+		Status:               payment.Status,
+		LoanID:               payment.LoanID,
+		TransactionID:        payment.TransactionID,
+		Error:                payment.Error,
+		Duplicate:            duplicate,
+		ReceivedAt:           payment.CreatedAt,
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GroupHandler handles HTTP requests for borrower groups
+type GroupHandler struct {
+	service *services.GroupService
+}
+
+// NewGroupHandler creates a new group handler instance
+func NewGroupHandler(service *services.GroupService) *GroupHandler {
+	return &GroupHandler{service: service}
+}
+
+// CreateGroup handles the creation of a new group
+func (h *GroupHandler) CreateGroup(c *fiber.Ctx) error {
+	var req dto.CreateGroupRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	group, err := h.service.CreateGroup(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toGroupResponse(group))
+}
+
+// ListGroups handles listing groups, optionally by region
+func (h *GroupHandler) ListGroups(c *fiber.Ctx) error {
+	groups, err := h.service.ListGroups(c.UserContext(), c.Query("region"))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.GroupResponse, len(groups))
+	for i := range groups {
+		response[i] = toGroupResponse(&groups[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetGroup handles retrieving a group and its members
+func (h *GroupHandler) GetGroup(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	group, err := h.service.GetGroup(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toGroupResponse(group))
+}
+
+// AssignOfficer handles making a field officer responsible for a group
+func (h *GroupHandler) AssignOfficer(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	var req dto.AssignOfficerRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := h.service.GetGroup(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	group, err := h.service.AssignOfficer(c.UserContext(), uint(id), req.OfficerID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toGroupResponse(group))
+}
+
+// AddMember handles adding a borrower to a group
+func (h *GroupHandler) AddMember(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	var req dto.AddGroupMemberRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := h.service.GetGroup(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	member, err := h.service.AddMember(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.GroupMemberResponse{
+		BorrowerID: member.BorrowerID,
+		Role:       member.Role,
+		JoinedAt:   member.JoinedAt,
+	})
+}
+
+// RemoveMember handles ending a borrower's group membership
+func (h *GroupHandler) RemoveMember(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	borrowerID, err := strconv.ParseUint(c.Params("borrowerId"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid borrower ID")
+	}
+
+	if _, err := h.service.GetGroup(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	if err := h.service.RemoveMember(c.UserContext(), uint(id), uint(borrowerID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetSchedule handles retrieving the combined schedule of a group's loans
+func (h *GroupHandler) GetSchedule(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	schedule, err := h.service.GetSchedule(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.GroupScheduleResponse{
+		GroupID:  uint(id),
+		Schedule: schedule,
+	})
+}
+
+// GetOutstanding handles retrieving the outstanding amount across a group's loans
+func (h *GroupHandler) GetOutstanding(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	outstanding, err := h.service.GetOutstanding(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(outstanding)
+}
+
+// GetDelinquency handles retrieving member delinquency, joint liability and PAR for a group
+func (h *GroupHandler) GetDelinquency(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	delinquency, err := h.service.GetDelinquency(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(delinquency)
+}
+
+// GetCollectionSheet handles retrieving the collection sheet of a group meeting
+func (h *GroupHandler) GetCollectionSheet(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid group ID")
+	}
+
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		date, err = time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return services.Validation("Invalid date, expected YYYY-MM-DD")
+		}
+	}
+
+	sheet, err := h.service.GetCollectionSheet(c.UserContext(), uint(id), date)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sheet)
+}
+
+func toGroupResponse(group *models.Group) dto.GroupResponse {
+	response := dto.GroupResponse{
+		ID:         group.ID,
+		Name:       group.Name,
+		Region:     group.Region,
+		MeetingDay: group.MeetingDay,
+		OfficerID:  group.OfficerID,
+		BranchID:   group.BranchID,
+		Status:     group.Status,
+	}
+	for _, member := range group.Members {
+		item := dto.GroupMemberResponse{
+			BorrowerID: member.BorrowerID,
+			Role:       member.Role,
+			JoinedAt:   member.JoinedAt,
+		}
+		if member.Borrower != nil {
+			item.Name = strings.TrimSpace(member.Borrower.FirstName + " " + member.Borrower.LastName)
+		}
+		response.Members = append(response.Members, item)
+	}
+	return response
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LenderHandler handles HTTP requests for lenders and loan fundings
+type LenderHandler struct {
+	service *services.LenderService
+}
+
+// NewLenderHandler creates a new lender handler instance
+func NewLenderHandler(service *services.LenderService) *LenderHandler {
+	return &LenderHandler{service: service}
+}
+
+// CreateLender handles registering a lender
+func (h *LenderHandler) CreateLender(c *fiber.Ctx) error {
+	var req dto.CreateLenderRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	lender, err := h.service.CreateLender(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toLenderResponse(lender))
+}
+
+// ListLenders handles listing all lenders
+func (h *LenderHandler) ListLenders(c *fiber.Ctx) error {
+	lenders, err := h.service.ListLenders(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.LenderResponse, len(lenders))
+	for i := range lenders {
+		response[i] = toLenderResponse(&lenders[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetLender handles retrieving a lender by ID
+func (h *LenderHandler) GetLender(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid lender ID")
+	}
+
+	lender, err := h.service.GetLender(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toLenderResponse(lender))
+}
+
+// FundLoan handles a lender funding part of a loan
+func (h *LenderHandler) FundLoan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var req dto.FundLoanRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	funding, err := h.service.FundLoan(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toFundingResponse(funding))
+}
+
+// GetLoanFundings handles listing the fundings of a loan
+func (h *LenderHandler) GetLoanFundings(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	loan, fundings, err := h.service.GetLoanFundings(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := dto.LoanFundingResponse{
+		LoanID:     loan.ID,
+		LoanAmount: loan.Amount,
+		Fundings:   make([]dto.FundingResponse, len(fundings)),
+	}
+	for i := range fundings {
+		response.Fundings[i] = toFundingResponse(&fundings[i])
+		response.FundedAmount += fundings[i].Amount
+		response.FundedShare += fundings[i].Share
+	}
+	response.UnfundedShare = 1 - response.FundedShare
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetPortfolio handles retrieving every loan a lender has funded
+func (h *LenderHandler) GetPortfolio(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid lender ID")
+	}
+
+	portfolio, err := h.service.GetPortfolio(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(portfolio)
+}
+
+// GetCashFlows handles retrieving a lender's expected payouts by due date
+func (h *LenderHandler) GetCashFlows(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid lender ID")
+	}
+
+	var from, to time.Time
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid to date, expected YYYY-MM-DD")
+		}
+	}
+
+	cashFlows, err := h.service.GetCashFlows(c.UserContext(), uint(id), from, to)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(cashFlows)
+}
+
+// GetReturns handles retrieving a lender's realised returns
+func (h *LenderHandler) GetReturns(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid lender ID")
+	}
+
+	returns, err := h.service.GetReturns(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(returns)
+}
+
+func toLenderResponse(lender *models.Lender) dto.LenderResponse {
+	return dto.LenderResponse{
+		ID:     lender.ID,
+		Name:   lender.Name,
+		Email:  lender.Email,
+		Type:   lender.Type,
+		Status: lender.Status,
+	}
+}
+
+func toFundingResponse(funding *models.Funding) dto.FundingResponse {
+	return dto.FundingResponse{
+		ID:       funding.ID,
+		LoanID:   funding.LoanID,
+		LenderID: funding.LenderID,
+		Amount:   funding.Amount,
+		Share:    funding.Share,
+		FundedAt: funding.FundedAt,
+	}
+}
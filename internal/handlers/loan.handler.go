@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
 	"AmarthaExample1/internal/services"
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -21,91 +23,77 @@ func NewLoanHandler(service *services.LoanService) *LoanHandler {
 // CreateLoan handles the creation of a new loan
 func (h *LoanHandler) CreateLoan(c *fiber.Ctx) error {
 	var req dto.CreateLoanRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	loan, err := h.service.CreateLoan(req.BorrowerID, req.Amount)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(dto.LoanResponse{
-		ID:            loan.ID,
-		BorrowerID:    loan.BorrowerID,
-		Amount:        loan.Amount,
-		InterestRate:  loan.InterestRate,
-		TotalAmount:   loan.TotalAmount,
-		WeeklyPayment: loan.WeeklyPayment,
-		TotalWeeks:    loan.TotalWeeks,
-		StartDate:     loan.StartDate,
-		EndDate:       loan.EndDate,
-		Status:        loan.Status,
-	})
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	loan, err := h.service.CreateLoan(c.UserContext(), req)
+	if err != nil {
+		return loanRefusal(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toLoanResponse(loan))
+}
+
+// QuoteLoan handles pricing a loan, fees included, without booking it
+func (h *LoanHandler) QuoteLoan(c *fiber.Ctx) error {
+	var req dto.LoanQuoteRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	quote, err := h.service.QuoteLoan(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(quote)
 }
 
 // GetLoan handles retrieving a loan by ID
 func (h *LoanHandler) GetLoan(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid loan ID",
-		})
+		return services.Validation("Invalid loan ID")
 	}
 
-	loan, err := h.service.GetLoanByID(uint(id))
+	loan, err := h.service.GetLoanByID(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(dto.LoanResponse{
-		ID:            loan.ID,
-		BorrowerID:    loan.BorrowerID,
-		Amount:        loan.Amount,
-		InterestRate:  loan.InterestRate,
-		TotalAmount:   loan.TotalAmount,
-		WeeklyPayment: loan.WeeklyPayment,
-		TotalWeeks:    loan.TotalWeeks,
-		StartDate:     loan.StartDate,
-		EndDate:       loan.EndDate,
-		Status:        loan.Status,
-	})
+	return c.Status(fiber.StatusOK).JSON(toLoanResponse(loan))
 }
 
 // GetOutstanding handles retrieving the outstanding amount for a loan
 func (h *LoanHandler) GetOutstanding(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid loan ID",
-		})
+		return services.Validation("Invalid loan ID")
+	}
+
+	loan, err := h.service.GetLoanByID(c.UserContext(), uint(id))
+	if err != nil {
+		return err
 	}
 
-	loan, err := h.service.GetLoanByID(uint(id))
+	outstanding, err := h.service.GetOutstanding(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	outstanding, err := h.service.GetOutstanding(uint(id))
+	feeOutstanding, err := h.service.GetFeeOutstanding(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(dto.OutstandingResponse{
 		LoanID:            loan.ID,
 		TotalAmount:       loan.TotalAmount,
-		AmountPaid:        loan.TotalAmount - outstanding,
+		AmountPaid:        loan.TotalAmount - outstanding - loan.WrittenOffAmount,
 		OutstandingAmount: outstanding,
+		FeeOutstanding:    feeOutstanding,
+		WrittenOffAmount:  loan.WrittenOffAmount,
 	})
 }
 
@@ -113,23 +101,17 @@ func (h *LoanHandler) GetOutstanding(c *fiber.Ctx) error {
 func (h *LoanHandler) IsDelinquent(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid loan ID",
-		})
+		return services.Validation("Invalid loan ID")
 	}
 
-	_, err = h.service.GetLoanByID(uint(id))
+	_, err = h.service.GetLoanByID(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	isDelinquent, err := h.service.IsDelinquent(uint(id))
+	isDelinquent, err := h.service.IsDelinquent(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	response := dto.DelinquencyResponse{
@@ -148,38 +130,25 @@ func (h *LoanHandler) IsDelinquent(c *fiber.Ctx) error {
 func (h *LoanHandler) MakePayment(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid loan ID",
-		})
+		return services.Validation("Invalid loan ID")
 	}
 
 	var req dto.PaymentRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bind(c, &req); err != nil {
+		return err
 	}
 
-	// Use a different variable to avoid redeclaration
-	var loanErr error
-	_, loanErr = h.service.GetLoanByID(uint(id))
-	if loanErr != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": loanErr.Error(),
-		})
-	}
-
-	if err := h.service.MakePayment(uint(id), req.Amount); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	transaction, err := h.service.MakePayment(c.UserContext(), uint(id), req.Amount, req.Currency)
+	if err != nil {
+		return err
 	}
 
-	outstanding, _ := h.service.GetOutstanding(uint(id))
+	outstanding, _ := h.service.GetOutstanding(c.UserContext(), uint(id))
 
 	return c.Status(fiber.StatusOK).JSON(dto.PaymentResponse{
 		Success:      true,
 		Message:      "Payment processed successfully",
+		PaymentID:    transaction.ID,
 		RemainingDue: outstanding,
 	})
 }
@@ -188,38 +157,187 @@ func (h *LoanHandler) MakePayment(c *fiber.Ctx) error {
 func (h *LoanHandler) GetLoanSchedule(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid loan ID",
-		})
+		return services.Validation("Invalid loan ID")
 	}
 
-	_, err = h.service.GetLoanByID(uint(id))
+	loan, err := h.service.GetLoanByID(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	schedule, err := h.service.GetLoanSchedule(uint(id))
+	// An explicit version returns that historical schedule, superseded installments included
+	version := c.QueryInt("version", loan.ScheduleVersion)
+	if version < 1 || version > loan.ScheduleVersion {
+		return services.Validation("Invalid schedule version")
+	}
+
+	schedule, err := h.service.GetLoanScheduleVersion(c.UserContext(), uint(id), version)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	scheduleItems := make([]dto.ScheduleItemDTO, len(schedule))
 	for i, item := range schedule {
 		scheduleItems[i] = dto.ScheduleItemDTO{
-			WeekNum:     item.WeekNum,
-			DueDate:     item.DueDate,
-			Amount:      item.Amount,
-			Status:      item.Status,
-			PaymentDate: item.PaymentDate,
+			WeekNum:         item.WeekNum,
+			DueDate:         item.DueDate,
+			Amount:          item.Amount,
+			Status:          item.Status,
+			PaymentDate:     item.PaymentDate,
+			ScheduleVersion: item.ScheduleVersion,
 		}
 	}
 
 	return c.Status(fiber.StatusOK).JSON(dto.ScheduleResponse{
 		LoanID:   uint(id),
+		Version:  version,
 		Schedule: scheduleItems,
 	})
 }
+
+// GetCharges handles retrieving the fee charges of a loan
+func (h *LoanHandler) GetCharges(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	charges, err := h.service.GetCharges(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := dto.LoanChargesResponse{LoanID: uint(id), Charges: make([]dto.ChargeDTO, len(charges))}
+	for i, charge := range charges {
+		response.Charges[i] = dto.ChargeDTO{
+			Code:        charge.Code,
+			Name:        charge.Name,
+			Collection:  charge.Collection,
+			Amount:      charge.Amount,
+			VATAmount:   charge.VATAmount,
+			TotalAmount: charge.TotalAmount,
+			PaidAmount:  charge.PaidAmount,
+			Status:      charge.Status,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetPayoff handles retrieving the amount that would settle a loan today
+func (h *LoanHandler) GetPayoff(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	payoff, err := h.service.GetPayoff(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(payoff)
+}
+
+// TopUp handles refinancing an active loan with a larger one
+func (h *LoanHandler) TopUp(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var req dto.TopUpRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	if req.Amount <= 0 {
+		return services.Validation("amount must be greater than zero")
+	}
+
+	result, err := h.service.TopUp(c.UserContext(), uint(id), req)
+	if err != nil {
+		return loanRefusal(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// ReversePayment handles reversing a posted payment transaction of a loan
+func (h *LoanHandler) ReversePayment(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	transactionID, err := strconv.ParseUint(c.Params("transactionId"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid transaction ID")
+	}
+
+	var req dto.ReversePaymentRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	transaction, err := h.service.ReversePayment(c.UserContext(), uint(id), uint(transactionID), req.Reason, actor(c, req.ReversedBy))
+	if err != nil {
+		return err
+	}
+
+	outstanding, _ := h.service.GetOutstanding(c.UserContext(), uint(id))
+
+	return c.Status(fiber.StatusOK).JSON(dto.ReversalResponse{
+		TransactionID: transaction.ID,
+		LoanID:        transaction.LoanID,
+		Amount:        transaction.Amount,
+		Status:        transaction.Status,
+		ReversedAt:    *transaction.ReversedAt,
+		ReversedBy:    transaction.ReversedBy,
+		Reason:        transaction.ReversalReason,
+		RemainingDue:  outstanding,
+	})
+}
+
+// loanRefusal returns why a loan request was turned down by the eligibility
+// rules or scoring, with the rejections or the scored application as
+// details. Other errors are returned as they are
+func loanRefusal(err error) error {
+	var ineligible *services.EligibilityError
+	if errors.As(err, &ineligible) {
+		return services.NewError(services.CodeNotEligible, "borrower is not eligible for this loan").
+			WithDetails(fiber.Map{"rejections": ineligible.Rejections})
+	}
+
+	var declined *services.DeclinedError
+	if errors.As(err, &declined) {
+		return services.NewError(services.CodeApplicationDeclined, declined.Error()).
+			WithDetails(fiber.Map{"application": toLoanApplicationResponse(declined.Application, declined.Result.Reasons)})
+	}
+	return err
+}
+
+func toLoanResponse(loan *models.Loan) dto.LoanResponse {
+	return dto.LoanResponse{
+		ID:              loan.ID,
+		BorrowerID:      loan.BorrowerID,
+		ProductID:       loan.ProductID,
+		GroupID:         loan.GroupID,
+		VirtualAccount:  loan.VirtualAccount,
+		Reference:       loan.Reference,
+		Amount:          loan.Amount,
+		InterestRate:    loan.InterestRate,
+		TotalAmount:     loan.TotalAmount,
+		FeeAmount:       loan.FeeAmount,
+		DeductedFees:    loan.DeductedFees,
+		DisbursedAmount: loan.DisbursedAmount,
+		PayoffAmount:    loan.PayoffAmount,
+		RefinancesID:    loan.RefinancesID,
+		RefinancedByID:  loan.RefinancedByID,
+		SuspenseAmount:  loan.SuspenseAmount,
+		WeeklyPayment:   loan.WeeklyPayment,
+		TotalWeeks:      loan.TotalWeeks,
+		StartDate:       loan.StartDate,
+		EndDate:         loan.EndDate,
+		Status:          loan.Status,
+	}
+}
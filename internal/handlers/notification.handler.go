@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationHandler handles HTTP requests for reminder templates, borrower
+// opt-outs and the send history
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler instance
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// CreateTemplate handles adding a step to a product's reminder schedule
+func (h *NotificationHandler) CreateTemplate(c *fiber.Ctx) error {
+	var req dto.NotificationTemplateRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	template, err := h.service.CreateTemplate(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toNotificationTemplateResponse(template))
+}
+
+// ListTemplates handles listing a product's reminder schedule, or the default
+// schedule without product_id
+func (h *NotificationHandler) ListTemplates(c *fiber.Ctx) error {
+	var productID *uint
+	if value := c.Query("product_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return services.Validation("Invalid product ID")
+		}
+		pid := uint(id)
+		productID = &pid
+	}
+
+	templates, err := h.service.ListTemplates(c.UserContext(), productID)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.NotificationTemplateResponse, len(templates))
+	for i := range templates {
+		response[i] = toNotificationTemplateResponse(&templates[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetTemplate handles retrieving a template by ID
+func (h *NotificationHandler) GetTemplate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid template ID")
+	}
+
+	template, err := h.service.GetTemplate(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toNotificationTemplateResponse(template))
+}
+
+// UpdateTemplate handles replacing a template
+func (h *NotificationHandler) UpdateTemplate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid template ID")
+	}
+
+	var req dto.NotificationTemplateRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	template, err := h.service.UpdateTemplate(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toNotificationTemplateResponse(template))
+}
+
+// DeleteTemplate handles removing a template
+func (h *NotificationHandler) DeleteTemplate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid template ID")
+	}
+
+	if err := h.service.DeleteTemplate(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// OptOut handles opting a borrower out of a channel
+func (h *NotificationHandler) OptOut(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid borrower ID")
+	}
+
+	var req dto.NotificationOptOutRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	optOut, err := h.service.OptOut(c.UserContext(), uint(id), req, actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toNotificationOptOutResponse(optOut))
+}
+
+// GetOptOuts handles listing a borrower's opt-outs
+func (h *NotificationHandler) GetOptOuts(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid borrower ID")
+	}
+
+	optOuts, err := h.service.GetOptOuts(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.NotificationOptOutResponse, len(optOuts))
+	for i := range optOuts {
+		response[i] = toNotificationOptOutResponse(&optOuts[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// OptIn handles opting a borrower back in to a channel
+func (h *NotificationHandler) OptIn(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid borrower ID")
+	}
+
+	if err := h.service.OptIn(c.UserContext(), uint(id), c.Params("channel")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListNotifications handles searching the send history
+func (h *NotificationHandler) ListNotifications(c *fiber.Ctx) error {
+	filter := repositories.NotificationFilter{
+		BorrowerID: uint(c.QueryInt("borrower_id", 0)),
+		LoanID:     uint(c.QueryInt("loan_id", 0)),
+		Stage:      c.Query("stage"),
+		Channel:    c.Query("channel"),
+		Status:     c.Query("status"),
+		Limit:      c.QueryInt("limit", 100),
+	}
+	return h.listNotifications(c, filter)
+}
+
+// GetLoanNotifications handles listing the messages sent about a loan
+func (h *NotificationHandler) GetLoanNotifications(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+	return h.listNotifications(c, repositories.NotificationFilter{LoanID: uint(id), Limit: c.QueryInt("limit", 100)})
+}
+
+func (h *NotificationHandler) listNotifications(c *fiber.Ctx, filter repositories.NotificationFilter) error {
+	notifications, err := h.service.ListNotifications(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.NotificationResponse, len(notifications))
+	for i := range notifications {
+		response[i] = toNotificationResponse(&notifications[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// SendReminders handles running the reminder job on demand
+func (h *NotificationHandler) SendReminders(c *fiber.Ctx) error {
+	result, err := h.service.SendReminders(c.UserContext(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+func toNotificationTemplateResponse(template *models.NotificationTemplate) dto.NotificationTemplateResponse {
+	return dto.NotificationTemplateResponse{
+		ID:         template.ID,
+		ProductID:  template.ProductID,
+		Stage:      template.Stage,
+		OffsetDays: template.OffsetDays,
+		Channel:    template.Channel,
+		Subject:    template.Subject,
+		Body:       template.Body,
+		Active:     template.Active,
+		CreatedAt:  template.CreatedAt,
+		UpdatedAt:  template.UpdatedAt,
+	}
+}
+
+func toNotificationOptOutResponse(optOut *models.NotificationOptOut) dto.NotificationOptOutResponse {
+	return dto.NotificationOptOutResponse{
+		BorrowerID: optOut.BorrowerID,
+		Channel:    optOut.Channel,
+		Reason:     optOut.Reason,
+		CreatedBy:  optOut.CreatedBy,
+		CreatedAt:  optOut.CreatedAt,
+	}
+}
+
+func toNotificationResponse(notification *models.Notification) dto.NotificationResponse {
+	return dto.NotificationResponse{
+		ID:          notification.ID,
+		BorrowerID:  notification.BorrowerID,
+		LoanID:      notification.LoanID,
+		PaymentID:   notification.PaymentID,
+		TemplateID:  notification.TemplateID,
+		Stage:       notification.Stage,
+		OffsetDays:  notification.OffsetDays,
+		Channel:     notification.Channel,
+		Recipient:   notification.Recipient,
+		Subject:     notification.Subject,
+		Body:        notification.Body,
+		Status:      notification.Status,
+		Attempts:    notification.Attempts,
+		Error:       notification.Error,
+		ProviderRef: notification.ProviderRef,
+		SentAt:      notification.SentAt,
+		CreatedAt:   notification.CreatedAt,
+	}
+}
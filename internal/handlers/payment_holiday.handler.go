@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PaymentHolidayHandler handles HTTP requests for payment holidays
+type PaymentHolidayHandler struct {
+	service     *services.PaymentHolidayService
+	loanService *services.LoanService
+}
+
+// NewPaymentHolidayHandler creates a new payment holiday handler instance
+func NewPaymentHolidayHandler(service *services.PaymentHolidayService, loanService *services.LoanService) *PaymentHolidayHandler {
+	return &PaymentHolidayHandler{service: service, loanService: loanService}
+}
+
+// ApplyToLoan handles pausing repayments on a single loan
+func (h *PaymentHolidayHandler) ApplyToLoan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var req dto.PaymentHolidayRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	req.RequestedBy = actor(c, req.RequestedBy)
+
+	if _, err := h.loanService.GetLoanByID(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	holiday, err := h.service.ApplyToLoan(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toPaymentHolidayResponse(holiday))
+}
+
+// ApplyToRegion handles pausing repayments on every loan in a region
+func (h *PaymentHolidayHandler) ApplyToRegion(c *fiber.Ctx) error {
+	var req dto.PaymentHolidayRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	req.RequestedBy = actor(c, req.RequestedBy)
+
+	results, err := h.service.ApplyToRegion(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	response := dto.BulkPaymentHolidayResponse{
+		Region:  req.Region,
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Applied {
+			response.Applied++
+		} else {
+			response.Skipped++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetHolidays handles listing the payment holidays applied to a loan
+func (h *PaymentHolidayHandler) GetHolidays(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	if _, err := h.loanService.GetLoanByID(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	holidays, err := h.service.GetHolidays(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.PaymentHolidayResponse, len(holidays))
+	for i := range holidays {
+		response[i] = toPaymentHolidayResponse(&holidays[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func toPaymentHolidayResponse(holiday *models.PaymentHoliday) dto.PaymentHolidayResponse {
+	return dto.PaymentHolidayResponse{
+		ID:              holiday.ID,
+		LoanID:          holiday.LoanID,
+		StartDate:       holiday.StartDate,
+		EndDate:         holiday.EndDate,
+		ShiftWeeks:      holiday.ShiftWeeks,
+		AccrueInterest:  holiday.AccrueInterest,
+		AccruedInterest: holiday.AccruedInterest,
+		Region:          holiday.Region,
+		Reason:          holiday.Reason,
+		RequestedBy:     holiday.RequestedBy,
+		CreatedAt:       holiday.CreatedAt,
+	}
+}
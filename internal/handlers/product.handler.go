@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProductHandler handles HTTP requests for loan products
+type ProductHandler struct {
+	service *services.ProductService
+}
+
+// NewProductHandler creates a new product handler instance
+func NewProductHandler(service *services.ProductService) *ProductHandler {
+	return &ProductHandler{service: service}
+}
+
+// CreateProduct handles the creation of a new loan product
+func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
+	var req dto.ProductRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	product, err := h.service.CreateProduct(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toProductResponse(product))
+}
+
+// ListProducts handles listing all loan products
+func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
+	products, err := h.service.ListProducts(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.ProductResponse, len(products))
+	for i := range products {
+		response[i] = toProductResponse(&products[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetProduct handles retrieving a loan product by ID
+func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid product ID")
+	}
+
+	product, err := h.service.GetProduct(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toProductResponse(product))
+}
+
+// UpdateProduct handles changing a loan product
+func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid product ID")
+	}
+
+	var req dto.ProductRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := h.service.GetProduct(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	product, err := h.service.UpdateProduct(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toProductResponse(product))
+}
+
+// AddFee handles adding a fee to a loan product
+func (h *ProductHandler) AddFee(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid product ID")
+	}
+
+	var req dto.ProductFeeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	fee, err := h.service.AddFee(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toProductFeeResponse(fee))
+}
+
+// GetFees handles listing the fees of a loan product
+func (h *ProductHandler) GetFees(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid product ID")
+	}
+
+	fees, err := h.service.GetFees(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.ProductFeeResponse, len(fees))
+	for i := range fees {
+		response[i] = toProductFeeResponse(&fees[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// DeleteFee handles removing a fee from a loan product
+func (h *ProductHandler) DeleteFee(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid product ID")
+	}
+
+	feeID, err := strconv.ParseUint(c.Params("feeId"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid fee ID")
+	}
+
+	if err := h.service.DeleteFee(c.UserContext(), uint(id), uint(feeID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func toProductResponse(product *models.Product) dto.ProductResponse {
+	return dto.ProductResponse{
+		ID:           product.ID,
+		Code:         product.Code,
+		Name:         product.Name,
+		DueDateRule:  product.DueDateRule,
+		MinPrincipal: product.MinPrincipal,
+		MaxPrincipal: product.MaxPrincipal,
+		CreatedAt:    product.CreatedAt,
+		UpdatedAt:    product.UpdatedAt,
+	}
+}
+
+func toProductFeeResponse(fee *models.ProductFee) dto.ProductFeeResponse {
+	return dto.ProductFeeResponse{
+		ID:         fee.ID,
+		ProductID:  fee.ProductID,
+		Code:       fee.Code,
+		Name:       fee.Name,
+		Type:       fee.Type,
+		Value:      fee.Value,
+		Collection: fee.Collection,
+		VATRate:    fee.VATRate,
+	}
+}
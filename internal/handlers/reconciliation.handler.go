@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReconciliationHandler handles HTTP requests for end-of-day reconciliation
+type ReconciliationHandler struct {
+	service *services.ReconciliationService
+}
+
+// NewReconciliationHandler creates a new reconciliation handler instance
+func NewReconciliationHandler(service *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{service: service}
+}
+
+// RunReconciliation handles reconciling a business date
+func (h *ReconciliationHandler) RunReconciliation(c *fiber.Ctx) error {
+	var req dto.ReconciliationRunRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		return services.Validation("Invalid date, expected YYYY-MM-DD")
+	}
+
+	run, err := h.service.Run(c.UserContext(), date, actor(c, req.RunBy))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toReconciliationRunResponse(run))
+}
+
+// ListRuns handles listing reconciliation runs between two business dates
+func (h *ReconciliationHandler) ListRuns(c *fiber.Ctx) error {
+	var from, to time.Time
+	var err error
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid from date, expected YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return services.Validation("Invalid to date, expected YYYY-MM-DD")
+		}
+	}
+
+	runs, err := h.service.ListRuns(c.UserContext(), from, to)
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.ReconciliationRunResponse, len(runs))
+	for i := range runs {
+		response[i] = toReconciliationRunResponse(&runs[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetRun handles retrieving the reconciliation report of a business date
+func (h *ReconciliationHandler) GetRun(c *fiber.Ctx) error {
+	date, err := time.ParseInLocation("2006-01-02", c.Params("date"), time.Local)
+	if err != nil {
+		return services.Validation("Invalid date, expected YYYY-MM-DD")
+	}
+
+	run, err := h.service.GetRun(c.UserContext(), date)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toReconciliationRunResponse(run))
+}
+
+func toReconciliationRunResponse(run *models.ReconciliationRun) dto.ReconciliationRunResponse {
+	response := dto.ReconciliationRunResponse{
+		BusinessDate:     run.BusinessDate,
+		Status:           run.Status,
+		StatementCount:   run.StatementCount,
+		StatementTotal:   run.StatementTotal,
+		TransactionCount: run.TransactionCount,
+		TransactionTotal: run.TransactionTotal,
+		AppliedTotal:     run.AppliedTotal,
+		UnappliedTotal:   run.UnappliedTotal,
+		InstallmentCount: run.InstallmentCount,
+		InstallmentTotal: run.InstallmentTotal,
+		MismatchCount:    run.MismatchCount,
+		RunBy:            run.RunBy,
+		RunAt:            run.CreatedAt,
+	}
+	for _, item := range run.Items {
+		if response.MismatchesByType == nil {
+			response.MismatchesByType = map[string]int{}
+		}
+		response.MismatchesByType[item.Type]++
+		response.Items = append(response.Items, dto.ReconciliationItemResponse{
+			Type:             item.Type,
+			LoanID:           item.LoanID,
+			TransactionID:    item.TransactionID,
+			StatementLineID:  item.StatementLineID,
+			GatewayPaymentID: item.GatewayPaymentID,
+			PaymentID:        item.PaymentID,
+			Expected:         item.Expected,
+			Actual:           item.Actual,
+			Difference:       item.Difference,
+			Description:      item.Description,
+		})
+	}
+	return response
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RestructureHandler handles HTTP requests for loan restructuring
+type RestructureHandler struct {
+	service     *services.RestructureService
+	loanService *services.LoanService
+}
+
+// NewRestructureHandler creates a new restructure handler instance
+func NewRestructureHandler(service *services.RestructureService, loanService *services.LoanService) *RestructureHandler {
+	return &RestructureHandler{service: service, loanService: loanService}
+}
+
+// RestructureLoan handles rescheduling a loan under new terms
+func (h *RestructureHandler) RestructureLoan(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var req dto.RestructureRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	req.RequestedBy = actor(c, req.RequestedBy)
+
+	if _, err := h.loanService.GetLoanByID(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	restructure, err := h.service.Restructure(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toRestructureResponse(restructure))
+}
+
+// GetRestructures handles retrieving the restructure history of a loan
+func (h *RestructureHandler) GetRestructures(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	loan, err := h.loanService.GetLoanByID(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	restructures, err := h.service.GetRestructures(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := dto.RestructureHistoryResponse{
+		LoanID:         loan.ID,
+		CurrentVersion: loan.ScheduleVersion,
+		Restructures:   make([]dto.RestructureResponse, len(restructures)),
+	}
+	for i := range restructures {
+		response.Restructures[i] = toRestructureResponse(&restructures[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func toRestructureResponse(restructure *models.Restructure) dto.RestructureResponse {
+	return dto.RestructureResponse{
+		ID:                    restructure.ID,
+		LoanID:                restructure.LoanID,
+		FromVersion:           restructure.FromVersion,
+		ToVersion:             restructure.ToVersion,
+		ExtendWeeks:           restructure.ExtendWeeks,
+		HolidayWeeks:          restructure.HolidayWeeks,
+		CapitaliseArrears:     restructure.CapitaliseArrears,
+		CapitalisedAmount:     restructure.CapitalisedAmount,
+		RescheduledAmount:     restructure.RescheduledAmount,
+		PreviousWeeklyPayment: restructure.PreviousWeeklyPayment,
+		NewWeeklyPayment:      restructure.NewWeeklyPayment,
+		PreviousEndDate:       restructure.PreviousEndDate,
+		NewEndDate:            restructure.NewEndDate,
+		Reason:                restructure.Reason,
+		RequestedBy:           restructure.RequestedBy,
+		CreatedAt:             restructure.CreatedAt,
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatementHandler handles HTTP requests for bank statement imports and reconciliation
+type StatementHandler struct {
+	service *services.StatementService
+}
+
+// NewStatementHandler creates a new statement handler instance
+func NewStatementHandler(service *services.StatementService) *StatementHandler {
+	return &StatementHandler{service: service}
+}
+
+// ListFormats handles listing the statement formats that can be imported
+func (h *StatementHandler) ListFormats(c *fiber.Ctx) error {
+	formats := h.service.Formats()
+	response := make([]dto.StatementFormatResponse, len(formats))
+	for i, format := range formats {
+		delimiter := format.Delimiter
+		if delimiter == "" {
+			delimiter = ","
+		}
+		dateLayout := format.DateLayout
+		if dateLayout == "" {
+			dateLayout = "2006-01-02"
+		}
+
+		columns := map[string]string{}
+		for name, column := range map[string]string{
+			"date":        format.Columns.Date,
+			"amount":      format.Columns.Amount,
+			"account":     format.Columns.Account,
+			"reference":   format.Columns.Reference,
+			"description": format.Columns.Description,
+			"direction":   format.Columns.Direction,
+		} {
+			if column != "" {
+				columns[name] = column
+			}
+		}
+
+		response[i] = dto.StatementFormatResponse{
+			Name:       format.Name,
+			Delimiter:  delimiter,
+			DateLayout: dateLayout,
+			Columns:    columns,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ImportStatement handles importing a bank statement from an uploaded CSV file
+func (h *StatementHandler) ImportStatement(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return services.Validation("A CSV file is required in the \"file\" field")
+	}
+
+	format := c.FormValue("format", c.Query("format", "generic"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	statement, err := h.service.ImportStatement(c.UserContext(), file, format, fileHeader.Filename, "api")
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toStatementImportResponse(statement))
+}
+
+// ListImports handles listing statement imports
+func (h *StatementHandler) ListImports(c *fiber.Ctx) error {
+	statements, err := h.service.ListImports(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.StatementImportResponse, len(statements))
+	for i := range statements {
+		response[i] = toStatementImportResponse(&statements[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetImport handles retrieving a statement import with its lines
+func (h *StatementHandler) GetImport(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid statement import ID")
+	}
+
+	statement, err := h.service.GetImport(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toStatementImportResponse(statement))
+}
+
+// GetReconciliationQueue handles listing statement lines waiting for manual resolution
+func (h *StatementHandler) GetReconciliationQueue(c *fiber.Ctx) error {
+	var importID uint64
+	if value := c.Query("import_id"); value != "" {
+		var err error
+		importID, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return services.Validation("Invalid import_id")
+		}
+	}
+
+	lines, err := h.service.GetReconciliationQueue(c.UserContext(), uint(importID))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.StatementLineResponse, len(lines))
+	for i := range lines {
+		response[i] = toStatementLineResponse(&lines[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ResolveLine handles posting a queued statement line to a loan chosen by a reviewer
+func (h *StatementHandler) ResolveLine(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid statement line ID")
+	}
+
+	var req dto.ResolveStatementLineRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	req.ResolvedBy = actor(c, req.ResolvedBy)
+
+	line, err := h.service.ResolveLine(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toStatementLineResponse(line))
+}
+
+// IgnoreLine handles taking a statement line off the queue without posting it
+func (h *StatementHandler) IgnoreLine(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid statement line ID")
+	}
+
+	var req dto.IgnoreStatementLineRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+	req.ResolvedBy = actor(c, req.ResolvedBy)
+
+	line, err := h.service.IgnoreLine(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toStatementLineResponse(line))
+}
+
+func toStatementImportResponse(statement *models.StatementImport) dto.StatementImportResponse {
+	response := dto.StatementImportResponse{
+		ID:             statement.ID,
+		Format:         statement.Format,
+		FileName:       statement.FileName,
+		Source:         statement.Source,
+		TotalLines:     statement.TotalLines,
+		DuplicateLines: statement.DuplicateLines,
+		SkippedLines:   statement.SkippedLines,
+		AutoMatched:    statement.AutoMatched,
+		Ambiguous:      statement.Ambiguous,
+		Unmatched:      statement.Unmatched,
+		TotalAmount:    statement.TotalAmount,
+		CreatedAt:      statement.CreatedAt,
+	}
+	for i := range statement.Lines {
+		response.Lines = append(response.Lines, toStatementLineResponse(&statement.Lines[i]))
+	}
+	return response
+}
+
+func toStatementLineResponse(line *models.StatementLine) dto.StatementLineResponse {
+	response := dto.StatementLineResponse{
+		ID:            line.ID,
+		ImportID:      line.ImportID,
+		LineNo:        line.LineNo,
+		ValueDate:     line.ValueDate,
+		Amount:        line.Amount,
+		Account:       line.Account,
+		Reference:     line.Reference,
+		Description:   line.Description,
+		MatchStatus:   line.MatchStatus,
+		LoanID:        line.LoanID,
+		TransactionID: line.TransactionID,
+		Status:        line.Status,
+		Error:         line.Error,
+		ResolvedBy:    line.ResolvedBy,
+		ResolvedAt:    line.ResolvedAt,
+		Note:          line.Note,
+	}
+	if line.Candidates != "" {
+		for _, value := range strings.Split(line.Candidates, ",") {
+			if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+				response.Candidates = append(response.Candidates, uint(id))
+			}
+		}
+	}
+	return response
+}
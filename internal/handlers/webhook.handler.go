@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/services"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookHandler handles HTTP requests for partner webhook subscriptions and
+// their delivery log
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler instance
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateSubscription handles registering a webhook endpoint. The signing
+// secret is only ever shown in this response
+func (h *WebhookHandler) CreateSubscription(c *fiber.Ctx) error {
+	var req dto.WebhookSubscriptionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	subscription, err := h.service.CreateSubscription(c.UserContext(), req, actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	response := toWebhookSubscriptionResponse(subscription)
+	response.Secret = subscription.Secret
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// ListSubscriptions handles listing the webhook subscriptions
+func (h *WebhookHandler) ListSubscriptions(c *fiber.Ctx) error {
+	subscriptions, err := h.service.ListSubscriptions(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.WebhookSubscriptionResponse, len(subscriptions))
+	for i := range subscriptions {
+		response[i] = toWebhookSubscriptionResponse(&subscriptions[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetSubscription handles retrieving a webhook subscription by ID
+func (h *WebhookHandler) GetSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid subscription ID")
+	}
+
+	subscription, err := h.service.GetSubscription(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toWebhookSubscriptionResponse(subscription))
+}
+
+// UpdateSubscription handles changing a subscription's name, URL, event
+// types or status
+func (h *WebhookHandler) UpdateSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid subscription ID")
+	}
+
+	var req dto.WebhookSubscriptionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	subscription, err := h.service.UpdateSubscription(c.UserContext(), uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toWebhookSubscriptionResponse(subscription))
+}
+
+// DeleteSubscription handles removing a webhook subscription
+func (h *WebhookHandler) DeleteSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid subscription ID")
+	}
+
+	if err := h.service.DeleteSubscription(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListDeliveries handles searching the delivery log, filtered by
+// subscription, status, event type and event ID
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	filter := repositories.DeliveryFilter{
+		SubscriptionID: uint(c.QueryInt("subscription_id", 0)),
+		Status:         c.Query("status"),
+		EventType:      c.Query("event_type"),
+		EventID:        c.Query("event_id"),
+		Limit:          c.QueryInt("limit", 100),
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(deliveries)
+}
+
+// GetDelivery handles retrieving a delivery with the event sent and every
+// attempt made
+func (h *WebhookHandler) GetDelivery(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid delivery ID")
+	}
+
+	delivery, err := h.service.GetDelivery(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(delivery)
+}
+
+// Redeliver handles queueing a delivery to be sent again
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid delivery ID")
+	}
+
+	delivery, err := h.service.Redeliver(c.UserContext(), uint(id), actor(c, ""))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(delivery)
+}
+
+func toWebhookSubscriptionResponse(subscription *models.WebhookSubscription) dto.WebhookSubscriptionResponse {
+	return dto.WebhookSubscriptionResponse{
+		ID:         subscription.ID,
+		Name:       subscription.Name,
+		URL:        subscription.URL,
+		EventTypes: strings.Split(subscription.EventTypes, ","),
+		Status:     subscription.Status,
+		CreatedBy:  subscription.CreatedBy,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/services"
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WriteOffHandler handles HTTP requests for defaults, write-offs and recoveries
+type WriteOffHandler struct {
+	service     *services.WriteOffService
+	loanService *services.LoanService
+}
+
+// NewWriteOffHandler creates a new write-off handler instance
+func NewWriteOffHandler(service *services.WriteOffService, loanService *services.LoanService) *WriteOffHandler {
+	return &WriteOffHandler{service: service, loanService: loanService}
+}
+
+// EvaluateDefaults handles running the auto-default rules on demand
+func (h *WriteOffHandler) EvaluateDefaults(c *fiber.Ctx) error {
+	defaulted, err := h.service.EvaluateDefaults(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.DefaultEvaluationResponse{
+		DefaultedLoanIDs: defaulted,
+	})
+}
+
+// RequestWriteOff handles opening a write-off request for a loan
+func (h *WriteOffHandler) RequestWriteOff(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var req dto.WriteOffRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := h.loanService.GetLoanByID(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	writeOff, err := h.service.RequestWriteOff(c.UserContext(), uint(id), req.Reason, actor(c, req.RequestedBy))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toWriteOffResponse(writeOff))
+}
+
+// ListWriteOffs handles listing write-off requests, optionally by status
+func (h *WriteOffHandler) ListWriteOffs(c *fiber.Ctx) error {
+	writeOffs, err := h.service.ListWriteOffs(c.UserContext(), c.Query("status"))
+	if err != nil {
+		return err
+	}
+
+	response := make([]dto.WriteOffResponse, len(writeOffs))
+	for i := range writeOffs {
+		response[i] = toWriteOffResponse(&writeOffs[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ApproveWriteOff handles approving a pending write-off
+func (h *WriteOffHandler) ApproveWriteOff(c *fiber.Ctx) error {
+	return h.review(c, h.service.ApproveWriteOff)
+}
+
+// RejectWriteOff handles rejecting a pending write-off
+func (h *WriteOffHandler) RejectWriteOff(c *fiber.Ctx) error {
+	return h.review(c, h.service.RejectWriteOff)
+}
+
+// RecordRecovery handles booking a payment received after write-off
+func (h *WriteOffHandler) RecordRecovery(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	var req dto.RecoveryRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := h.loanService.GetLoanByID(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	recovery, err := h.service.RecordRecovery(c.UserContext(), uint(id), req.Amount, req.Reference)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(toRecoveryResponse(recovery))
+}
+
+// GetRecoveries handles listing recoveries booked on a loan
+func (h *WriteOffHandler) GetRecoveries(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid loan ID")
+	}
+
+	loan, err := h.loanService.GetLoanByID(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	recoveries, err := h.service.GetRecoveries(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	response := dto.RecoveriesResponse{
+		LoanID:           loan.ID,
+		WrittenOffAmount: loan.WrittenOffAmount,
+		Recoveries:       make([]dto.RecoveryResponse, len(recoveries)),
+	}
+	for i := range recoveries {
+		response.TotalRecovered += recoveries[i].Amount
+		response.Recoveries[i] = toRecoveryResponse(&recoveries[i])
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// review parses a write-off review request and applies the given decision
+func (h *WriteOffHandler) review(c *fiber.Ctx, decide func(context.Context, uint, string, string) (*models.WriteOff, error)) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return services.Validation("Invalid write-off ID")
+	}
+
+	var req dto.WriteOffReviewRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	writeOff, err := decide(c.UserContext(), uint(id), actor(c, req.ReviewedBy), req.Note)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(toWriteOffResponse(writeOff))
+}
+
+func toWriteOffResponse(writeOff *models.WriteOff) dto.WriteOffResponse {
+	return dto.WriteOffResponse{
+		ID:          writeOff.ID,
+		LoanID:      writeOff.LoanID,
+		Amount:      writeOff.Amount,
+		Reason:      writeOff.Reason,
+		RequestedBy: writeOff.RequestedBy,
+		ReviewedBy:  writeOff.ReviewedBy,
+		ReviewNote:  writeOff.ReviewNote,
+		Status:      writeOff.Status,
+		ReviewedAt:  writeOff.ReviewedAt,
+		CreatedAt:   writeOff.CreatedAt,
+	}
+}
+
+func toRecoveryResponse(recovery *models.Recovery) dto.RecoveryResponse {
+	return dto.RecoveryResponse{
+		ID:         recovery.ID,
+		LoanID:     recovery.LoanID,
+		Amount:     recovery.Amount,
+		Reference:  recovery.Reference,
+		ReceivedAt: recovery.ReceivedAt,
+	}
+}
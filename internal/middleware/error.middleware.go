@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// errorStatuses maps each error code to the HTTP status it is answered with
+var errorStatuses = map[services.ErrorCode]int{
+	services.CodeValidation:                fiber.StatusBadRequest,
+	services.CodeNotFound:                  fiber.StatusNotFound,
+	services.CodeConflict:                  fiber.StatusConflict,
+	services.CodeUnauthenticated:           fiber.StatusUnauthorized,
+	services.CodeForbidden:                 fiber.StatusForbidden,
+	services.CodeLoanNotPayable:            fiber.StatusUnprocessableEntity,
+	services.CodeInstallmentAmountMismatch: fiber.StatusUnprocessableEntity,
+	services.CodeDelinquentAmountMismatch:  fiber.StatusUnprocessableEntity,
+	services.CodeNotEligible:               fiber.StatusUnprocessableEntity,
+	services.CodeApplicationDeclined:       fiber.StatusUnprocessableEntity,
+	services.CodeInternal:                  fiber.StatusInternalServerError,
+}
+
+// statusCodes maps the statuses of fiber's own errors, such as an unknown
+// route, to an error code
+var statusCodes = map[int]services.ErrorCode{
+	fiber.StatusBadRequest:   services.CodeValidation,
+	fiber.StatusUnauthorized: services.CodeUnauthenticated,
+	fiber.StatusForbidden:    services.CodeForbidden,
+	fiber.StatusNotFound:     services.CodeNotFound,
+	fiber.StatusConflict:     services.CodeConflict,
+}
+
+// ErrorHandler answers every error returned by a handler or middleware with
+// its status and an error body carrying a code, a message, any details and
+// the request ID. Errors without a code are internal: they are logged under
+// the request ID and their message is not shown to the client
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	response := dto.ErrorResponse{RequestID: requestID(c)}
+	status := fiber.StatusInternalServerError
+
+	var fiberErr *fiber.Error
+	var coded *services.Error
+	switch {
+	case errors.As(err, &fiberErr):
+		status = fiberErr.Code
+		response.Code = string(statusCode(status))
+		response.Message = fiberErr.Message
+	case services.CodeOf(err) == services.CodeInternal:
+		log.Printf("request %s failed: %v", response.RequestID, err)
+		response.Code = string(services.CodeInternal)
+		response.Message = "internal server error"
+	default:
+		code := services.CodeOf(err)
+		status = errorStatuses[code]
+		response.Code = string(code)
+		response.Message = err.Error()
+		if errors.As(err, &coded) {
+			response.Details = coded.Details
+		}
+	}
+
+	return c.Status(status).JSON(response)
+}
+
+// statusCode returns the error code of a status fiber answered with itself
+func statusCode(status int) services.ErrorCode {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= fiber.StatusInternalServerError {
+		return services.CodeInternal
+	}
+	return services.ErrorCode(strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_"))
+}
+
+// requestID returns the ID the requestid middleware gave the request
+func requestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals("requestid").(string)
+	return requestID
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		err         error
+		wantStatus  int
+		wantCode    services.ErrorCode
+		wantMessage string
+		wantDetails bool
+	}{
+		{"validation", fiber.MethodGet, "/fails", services.Validation("amount must be greater than zero"), fiber.StatusBadRequest, services.CodeValidation, "amount must be greater than zero", false},
+		{"repository not found", fiber.MethodGet, "/fails", fmt.Errorf("loading loan: %w", repositories.ErrNotFound), fiber.StatusNotFound, services.CodeNotFound, "", false},
+		{"conflict with details", fiber.MethodGet, "/fails", services.Conflict("only the latest payment can be reversed").WithDetails(map[string]interface{}{"latest_transaction_id": 71}), fiber.StatusConflict, services.CodeConflict, "only the latest payment can be reversed", true},
+		{"wrapped domain error", fiber.MethodGet, "/fails", fmt.Errorf("posting: %w", services.NewError(services.CodeLoanNotPayable, "loan has been written off")), fiber.StatusUnprocessableEntity, services.CodeLoanNotPayable, "", false},
+		{"forbidden", fiber.MethodGet, "/fails", services.NewError(services.CodeForbidden, "missing permission"), fiber.StatusForbidden, services.CodeForbidden, "missing permission", false},
+		{"internal error", fiber.MethodGet, "/fails", errors.New("dial tcp 10.0.0.1:3306: connection refused"), fiber.StatusInternalServerError, services.CodeInternal, "internal server error", false},
+		{"fiber error", fiber.MethodGet, "/fails", fiber.NewError(fiber.StatusTooManyRequests, "slow down"), fiber.StatusTooManyRequests, "too_many_requests", "slow down", false},
+		{"unknown route", fiber.MethodGet, "/missing", nil, fiber.StatusNotFound, services.CodeNotFound, "", false},
+		{"unknown method", fiber.MethodPost, "/fails", nil, fiber.StatusMethodNotAllowed, "method_not_allowed", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Use(requestid.New())
+			app.Get("/fails", func(c *fiber.Ctx) error { return tt.err })
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			var body dto.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding the error body: %v", err)
+			}
+			if body.Code != string(tt.wantCode) {
+				t.Errorf("code %q, want %q", body.Code, tt.wantCode)
+			}
+			if tt.wantMessage != "" && body.Message != tt.wantMessage {
+				t.Errorf("message %q, want %q", body.Message, tt.wantMessage)
+			}
+			if strings.Contains(body.Message, "10.0.0.1") {
+				t.Errorf("the internal error leaked to the client: %q", body.Message)
+			}
+			if (body.Details != nil) != tt.wantDetails {
+				t.Errorf("details %v, want details %v", body.Details, tt.wantDetails)
+			}
+			if body.RequestID == "" || body.RequestID != resp.Header.Get(fiber.HeaderXRequestID) {
+				t.Errorf("request id %q, want the response's %q", body.RequestID, resp.Header.Get(fiber.HeaderXRequestID))
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"AmarthaExample1/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext carries the request ID set by the requestid middleware into
+// the request's user context, so the changes made while serving the request
+// are recorded under it
+func RequestContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if requestID := requestID(c); requestID != "" {
+			c.SetUserContext(repositories.WithRequestID(c.UserContext(), requestID))
+		}
+		return c.Next()
+	}
+}
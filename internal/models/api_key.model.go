@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey represents a key issued to a machine client. Only the SHA-256 hash of
+// the key is stored; the key itself is shown once when it is created
+type APIKey struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	TenantID   uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID   *uint          `gorm:"index" json:"branch_id,omitempty"`
+	Name       string         `gorm:"not null" json:"name"`
+	Prefix     string         `gorm:"size:16;not null;index" json:"prefix"`
+	KeyHash    string         `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Role       string         `gorm:"not null" json:"role"`
+	CreatedBy  string         `gorm:"not null" json:"created_by"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+	RevokedBy  string         `json:"revoked_by,omitempty"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoanApplication records a loan request and the credit score it was decided on
+type LoanApplication struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	TenantID   uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID   *uint          `gorm:"index" json:"branch_id,omitempty"`
+	BorrowerID uint           `gorm:"not null;index" json:"borrower_id"`
+	ProductID  *uint          `json:"product_id,omitempty"`
+	GroupID    *uint          `json:"group_id,omitempty"`
+	Amount     float64        `gorm:"not null" json:"amount"`
+	LoanCycle  int            `gorm:"not null" json:"loan_cycle"`
+	Score      float64        `gorm:"not null" json:"score"`
+	Band       string         `gorm:"size:16" json:"band"`
+	Decision   string         `gorm:"not null;index" json:"decision"` // approved, declined
+	Scorer     string         `gorm:"size:64" json:"scorer"`          // scorer and version that produced the score
+	Reasons    string         `gorm:"type:text" json:"-"`             // JSON encoded score reasons
+	LoanID     *uint          `gorm:"index" json:"loan_id,omitempty"` // loan booked from an approved application
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// AuditLog records one change to one row: who made it, under which request,
+// and the row before and after. Entries are append-only and are linked into a
+// hash chain when sealed, so any later edit or removal is detectable
+type AuditLog struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   uint       `gorm:"not null;default:1;index" json:"tenant_id"` // tenant of the changed row
+	Sequence   *uint64    `gorm:"uniqueIndex" json:"sequence,omitempty"`     // position in the hash chain, set when sealed
+	OccurredAt time.Time  `gorm:"not null;index" json:"occurred_at"`
+	Actor      string     `gorm:"size:128;not null;index" json:"actor"`
+	ActorType  string     `gorm:"size:16;not null" json:"actor_type"` // user, api_key, system
+	RequestID  string     `gorm:"size:64;index" json:"request_id,omitempty"`
+	Action     string     `gorm:"size:16;not null" json:"action"` // create, update, delete
+	Entity     string     `gorm:"size:64;not null;index:idx_audit_entity" json:"entity"`
+	EntityID   string     `gorm:"size:64;not null;index:idx_audit_entity" json:"entity_id"`
+	Before     string     `gorm:"type:longtext" json:"before,omitempty"`
+	After      string     `gorm:"type:longtext" json:"after,omitempty"`
+	Changes    string     `gorm:"type:longtext" json:"changes,omitempty"`
+	PrevHash   string     `gorm:"size:64" json:"prev_hash,omitempty"`
+	Hash       string     `gorm:"size:64" json:"hash,omitempty"`
+	SealedAt   *time.Time `json:"sealed_at,omitempty"`
+}
+
+// AuditChainHead holds the last sealed entry of the audit hash chain. Its
+// single row is locked while entries are sealed
+type AuditChainHead struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Sequence  uint64    `gorm:"not null" json:"sequence"`
+	Hash      string    `gorm:"size:64" json:"hash"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
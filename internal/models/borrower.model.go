@@ -8,13 +8,17 @@ import (
 
 // Borrower represents a borrower entity
 type Borrower struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	FirstName string         `gorm:"not null" json:"first_name"`
-	LastName  string         `gorm:"not null" json:"last_name"`
-	Email     string         `gorm:"not null;unique" json:"email"`
-	Phone     string         `gorm:"not null" json:"phone"`
-	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
-	Loans     []Loan         `gorm:"foreignKey:BorrowerID" json:"loans,omitempty"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	TenantID    uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID    *uint          `gorm:"index" json:"branch_id,omitempty"`
+	FirstName   string         `gorm:"not null" json:"first_name"`
+	LastName    string         `gorm:"not null" json:"last_name"`
+	Email       string         `gorm:"not null;unique" json:"email"`
+	Phone       string         `gorm:"not null" json:"phone"`
+	Region      string         `gorm:"index" json:"region"`
+	CreditLimit float64        `gorm:"not null;default:0" json:"credit_limit"` // overrides the loan cycle credit limit when set
+	CreatedAt   time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Loans       []Loan         `gorm:"foreignKey:BorrowerID" json:"loans,omitempty"`
 }
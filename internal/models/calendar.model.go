@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarHoliday represents a day without collections, either national
+// (empty region) or observed only by branches in one region
+type CalendarHoliday struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TenantID  uint           `gorm:"not null;default:1;uniqueIndex:idx_holiday_date_region" json:"tenant_id"`
+	Date      time.Time      `gorm:"type:date;not null;uniqueIndex:idx_holiday_date_region" json:"date"`
+	Region    string         `gorm:"size:100;not null;default:'';uniqueIndex:idx_holiday_date_region" json:"region"`
+	Name      string         `gorm:"not null" json:"name"`
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// CollectionCase tracks the work of bringing a delinquent loan back up to
+// date. A case is opened when a loan becomes delinquent and closed when it
+// cures or leaves the active book
+type CollectionCase struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	TenantID       uint       `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID       *uint      `gorm:"index" json:"branch_id,omitempty"`
+	LoanID         uint       `gorm:"not null;index" json:"loan_id"`
+	BorrowerID     uint       `gorm:"not null;index" json:"borrower_id"`
+	GroupID        *uint      `gorm:"index" json:"group_id,omitempty"`
+	CollectorID    *uint      `gorm:"index" json:"collector_id,omitempty"`         // officer working the case
+	Status         string     `gorm:"not null;default:'open';index" json:"status"` // open, closed
+	DaysPastDue    int        `gorm:"not null;default:0" json:"days_past_due"`     // as of the last evaluation
+	OverdueAmount  float64    `gorm:"not null;default:0" json:"overdue_amount"`    // as of the last evaluation
+	BrokenPromises int        `gorm:"not null;default:0" json:"broken_promises"`
+	NextActionAt   *time.Time `gorm:"index" json:"next_action_at,omitempty"` // when the collector should follow up
+	OpenedAt       time.Time  `gorm:"not null" json:"opened_at"`
+	AssignedAt     *time.Time `json:"assigned_at,omitempty"`
+	AssignedBy     string     `json:"assigned_by,omitempty"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CloseReason    string     `json:"close_reason,omitempty"` // cured, completed, refinanced, written_off, manual
+	ClosedBy       string     `json:"closed_by,omitempty"`
+	CreatedAt      time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+// CollectionActivity is a visit, call, promise to pay or note logged on a case
+type CollectionActivity struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	CaseID             uint       `gorm:"not null;index" json:"case_id"`
+	Type               string     `gorm:"size:32;not null" json:"type"` // visit, call, promise_to_pay, note
+	Outcome            string     `json:"outcome,omitempty"`            // e.g. reached, not_reached, refused
+	Notes              string     `gorm:"type:text" json:"notes,omitempty"`
+	PromiseDate        *time.Time `gorm:"type:date;index" json:"promise_date,omitempty"`
+	PromiseAmount      float64    `gorm:"not null;default:0" json:"promise_amount"`
+	PromiseStatus      string     `gorm:"size:16;index" json:"promise_status,omitempty"` // pending, kept, broken
+	PromiseCollected   float64    `gorm:"not null;default:0" json:"promise_collected"`   // paid from the promise up to its date
+	PromiseEvaluatedAt *time.Time `json:"promise_evaluated_at,omitempty"`
+	OfficerID          *uint      `json:"officer_id,omitempty"`
+	PerformedBy        string     `gorm:"not null" json:"performed_by"`
+	PerformedAt        time.Time  `gorm:"not null" json:"performed_at"`
+	CreatedAt          time.Time  `gorm:"not null" json:"created_at"`
+}
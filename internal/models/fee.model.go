@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductFee represents a fee charged on every loan booked under a product
+type ProductFee struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	TenantID   uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	ProductID  uint           `gorm:"not null;index" json:"product_id"`
+	Code       string         `gorm:"size:50;not null" json:"code"` // e.g. provision, insurance
+	Name       string         `gorm:"not null" json:"name"`
+	Type       string         `gorm:"not null" json:"type"`       // fixed, percentage
+	Value      float64        `gorm:"not null" json:"value"`      // amount, or fraction of the principal
+	Collection string         `gorm:"not null" json:"collection"` // deducted, installment
+	VATRate    float64        `gorm:"not null;default:0" json:"vat_rate"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// LoanCharge represents a fee charged on one loan. Deducted charges are paid
+// at disbursement; installment charges are paid as installments carrying them
+// are settled
+type LoanCharge struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	TenantID     uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID     *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID       uint           `gorm:"not null;index" json:"loan_id"`
+	ProductFeeID *uint          `json:"product_fee_id,omitempty"`
+	Code         string         `gorm:"size:50;not null" json:"code"`
+	Name         string         `gorm:"not null" json:"name"`
+	Collection   string         `gorm:"not null" json:"collection"` // deducted, installment
+	Amount       float64        `gorm:"not null" json:"amount"`     // before VAT
+	VATAmount    float64        `gorm:"not null" json:"vat_amount"`
+	TotalAmount  float64        `gorm:"not null" json:"total_amount"`
+	PaidAmount   float64        `gorm:"not null;default:0" json:"paid_amount"`
+	Status       string         `gorm:"not null;index" json:"status"` // pending, partially_paid, paid, written_off, waived
+	PaidAt       *time.Time     `json:"paid_at,omitempty"`
+	CreatedAt    time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GatewayPayment represents a payment notification received from the payment
+// gateway. The gateway transaction ID is unique so redelivered notifications
+// are only posted once. A notification belongs to the tenant of the loan
+// that owns its virtual account
+type GatewayPayment struct {
+	ID                   uint           `gorm:"primaryKey" json:"id"`
+	TenantID             uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	GatewayTransactionID string         `gorm:"size:64;not null;uniqueIndex" json:"gateway_transaction_id"`
+	VirtualAccount       string         `gorm:"size:32;not null;index" json:"virtual_account"`
+	Amount               float64        `gorm:"not null" json:"amount"`
+	Currency             string         `gorm:"size:3;not null" json:"currency"`
+	PaidAt               time.Time      `gorm:"not null" json:"paid_at"`
+	Payload              string         `gorm:"type:text" json:"-"`
+	Status               string         `gorm:"not null;index" json:"status"` // received, posted, unmatched, failed
+	LoanID               *uint          `gorm:"index" json:"loan_id,omitempty"`
+	TransactionID        *uint          `json:"transaction_id,omitempty"`
+	RecoveryID           *uint          `json:"recovery_id,omitempty"` // set instead of the transaction for a written-off loan
+	Error                string         `json:"error,omitempty"`
+	CreatedAt            time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt            time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Group represents a borrower group (majelis) that meets weekly and shares
+// joint liability for its members' loans
+type Group struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	TenantID   uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID   *uint          `gorm:"index" json:"branch_id,omitempty"`
+	Name       string         `gorm:"not null" json:"name"`
+	Region     string         `gorm:"index" json:"region"`
+	OfficerID  *uint          `gorm:"index" json:"officer_id,omitempty"`
+	MeetingDay string         `gorm:"not null" json:"meeting_day"`             // monday ... sunday
+	Status     string         `gorm:"not null;default:'active'" json:"status"` // active, inactive
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Members    []GroupMember  `gorm:"foreignKey:GroupID" json:"members,omitempty"`
+}
+
+// GroupMember represents a borrower's membership of a group
+type GroupMember struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	GroupID    uint           `gorm:"not null;index" json:"group_id"`
+	BorrowerID uint           `gorm:"not null;index" json:"borrower_id"`
+	Role       string         `gorm:"not null;default:'member'" json:"role"` // leader, member
+	JoinedAt   time.Time      `gorm:"not null" json:"joined_at"`
+	LeftAt     *time.Time     `json:"left_at,omitempty"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Borrower   *Borrower      `gorm:"foreignKey:BorrowerID" json:"borrower,omitempty"`
+}
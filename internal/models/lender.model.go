@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Lender represents an investor who funds loans on the platform
+type Lender struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TenantID  uint           `gorm:"not null;default:1;uniqueIndex:idx_lender_email" json:"tenant_id"`
+	Name      string         `gorm:"not null" json:"name"`
+	Email     string         `gorm:"uniqueIndex:idx_lender_email;size:191" json:"email"`
+	Type      string         `gorm:"not null;default:'individual'" json:"type"` // individual, institution
+	Status    string         `gorm:"not null;default:'active'" json:"status"`   // active, inactive
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// Funding represents the portion of a loan's principal put up by one lender
+type Funding struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TenantID  uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID  *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID    uint           `gorm:"not null;index" json:"loan_id"`
+	LenderID  uint           `gorm:"not null;index" json:"lender_id"`
+	Amount    float64        `gorm:"not null" json:"amount"`
+	Share     float64        `gorm:"not null" json:"share"` // Amount as a fraction of the loan principal
+	FundedAt  time.Time      `gorm:"not null" json:"funded_at"`
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Loan      *Loan          `gorm:"foreignKey:LoanID" json:"loan,omitempty"`
+}
+
+// LenderPayout represents a lender's pro-rata share of one collected installment
+type LenderPayout struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	TenantID        uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID        *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LenderID        uint           `gorm:"not null;index" json:"lender_id"`
+	FundingID       uint           `gorm:"not null;index" json:"funding_id"`
+	LoanID          uint           `gorm:"not null;index" json:"loan_id"`
+	PaymentID       uint           `gorm:"not null;index" json:"payment_id"` // installment collected
+	TransactionID   uint           `gorm:"not null;index" json:"transaction_id"`
+	GrossAmount     float64        `gorm:"not null" json:"gross_amount"`
+	PrincipalAmount float64        `gorm:"not null" json:"principal_amount"`
+	InterestAmount  float64        `gorm:"not null" json:"interest_amount"`
+	FeeAmount       float64        `gorm:"not null" json:"fee_amount"` // platform service fee
+	NetAmount       float64        `gorm:"not null" json:"net_amount"`
+	PaidAt          time.Time      `gorm:"not null;index" json:"paid_at"`
+	CreatedAt       time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
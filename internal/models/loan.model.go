@@ -8,34 +8,59 @@ import (
 
 // Loan represents a loan entity
 type Loan struct {
-	ID            uint           `gorm:"primaryKey" json:"id"`
-	BorrowerID    uint           `gorm:"not null" json:"borrower_id"`
-	Amount        float64        `gorm:"not null" json:"amount"`
-	InterestRate  float64        `gorm:"not null" json:"interest_rate"`
-	TotalAmount   float64        `gorm:"not null" json:"total_amount"`
-	WeeklyPayment float64        `gorm:"not null" json:"weekly_payment"`
-	TotalWeeks    int            `gorm:"not null" json:"total_weeks"`
-	StartDate     time.Time      `gorm:"not null" json:"start_date"`
-	EndDate       time.Time      `gorm:"not null" json:"end_date"`
-	Status        string         `gorm:"not null;default:'active'" json:"status"` // active, completed, defaulted
-	CreatedAt     time.Time      `gorm:"not null" json:"created_at"`
-	UpdatedAt     time.Time      `gorm:"not null" json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
-	Payments      []Payment      `gorm:"foreignKey:LoanID" json:"payments,omitempty"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	TenantID         uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID         *uint          `gorm:"index" json:"branch_id,omitempty"`
+	BorrowerID       uint           `gorm:"not null" json:"borrower_id"`
+	ProductID        *uint          `gorm:"index" json:"product_id,omitempty"`
+	GroupID          *uint          `gorm:"index" json:"group_id,omitempty"`
+	VirtualAccount   *string        `gorm:"size:32;uniqueIndex" json:"virtual_account,omitempty"` // bank virtual account repayments are paid into
+	Reference        string         `gorm:"size:64;index" json:"reference,omitempty"`             // payment reference quoted on bank transfers
+	Amount           float64        `gorm:"not null" json:"amount"`
+	InterestRate     float64        `gorm:"not null" json:"interest_rate"`
+	TotalAmount      float64        `gorm:"not null" json:"total_amount"`         // principal, interest and installment fees
+	FeeAmount        float64        `gorm:"not null;default:0" json:"fee_amount"` // fees and VAT collected with installments
+	DeductedFees     float64        `gorm:"not null;default:0" json:"deducted_fees"`
+	DisbursedAmount  float64        `gorm:"not null;default:0" json:"disbursed_amount"` // principal less deducted fees and any payoff
+	PayoffAmount     float64        `gorm:"not null;default:0" json:"payoff_amount"`    // balance of the refinanced loan settled from the principal
+	RefinancesID     *uint          `gorm:"index" json:"refinances_id,omitempty"`       // loan this top-up paid off
+	RefinancedByID   *uint          `gorm:"index" json:"refinanced_by_id,omitempty"`    // top-up loan that paid this one off
+	SuspenseAmount   float64        `gorm:"not null;default:0" json:"suspense_amount"`  // money received but not yet enough for a whole installment
+	WeeklyPayment    float64        `gorm:"not null" json:"weekly_payment"`
+	TotalWeeks       int            `gorm:"not null" json:"total_weeks"`
+	StartDate        time.Time      `gorm:"not null" json:"start_date"`
+	EndDate          time.Time      `gorm:"not null" json:"end_date"`
+	Status           string         `gorm:"not null;default:'active'" json:"status"` // active, completed, defaulted, written_off, refinanced
+	DefaultedAt      *time.Time     `json:"defaulted_at,omitempty"`
+	WrittenOffAt     *time.Time     `json:"written_off_at,omitempty"`
+	WrittenOffAmount float64        `gorm:"not null;default:0" json:"written_off_amount"`
+	DelinquentSince  *time.Time     `json:"delinquent_since,omitempty"` // set while the loan is delinquent
+	ScheduleVersion  int            `gorm:"not null;default:1" json:"schedule_version"`
+	CreatedAt        time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	Payments         []Payment      `gorm:"foreignKey:LoanID" json:"payments,omitempty"`
 }
 
 // Payment represents a payment made for a loan
 type Payment struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	LoanID      uint           `gorm:"not null" json:"loan_id"`
-	Amount      float64        `gorm:"not null" json:"amount"`
-	WeekNum     int            `gorm:"not null" json:"week_num"`
-	DueDate     time.Time      `gorm:"not null" json:"due_date"`
-	PaidDate    *time.Time     `json:"paid_date"`
-	PaymentDate *time.Time     `json:"payment_date"`
-	Status      string         `gorm:"not null;default:'pending'" json:"status"` // pending, paid, missed
-	CreatedAt   time.Time      `gorm:"not null" json:"created_at"`
-	UpdatedAt   time.Time      `gorm:"not null" json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID                  uint           `gorm:"primaryKey" json:"id"`
+	TenantID            uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID            *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID              uint           `gorm:"not null" json:"loan_id"`
+	Amount              float64        `gorm:"not null" json:"amount"`
+	FeeAmount           float64        `gorm:"not null;default:0" json:"fee_amount"` // part of Amount that pays fees
+	WeekNum             int            `gorm:"not null" json:"week_num"`
+	DueDate             time.Time      `gorm:"not null" json:"due_date"`
+	PaidDate            *time.Time     `json:"paid_date"`
+	PaymentDate         *time.Time     `json:"payment_date"`
+	Status              string         `gorm:"not null;default:'pending'" json:"status"`              // pending, paid, missed, written_off, refinanced
+	ScheduleVersion     int            `gorm:"not null;default:1" json:"schedule_version"`            // schedule version that created this installment
+	SupersededInVersion int            `gorm:"not null;default:0;index" json:"superseded_in_version"` // version that replaced it, 0 while current
+	HeldUntil           *time.Time     `json:"held_until,omitempty"`                                  // end of the payment holiday covering this installment
+	MissedAt            *time.Time     `json:"missed_at,omitempty"`                                   // when the installment was found overdue
+	TransactionID       *uint          `gorm:"index" json:"transaction_id,omitempty"`                 // payment transaction that settled this installment
+	CreatedAt           time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt           time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
-
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationTemplate is one step of a product's reminder and dunning
+// schedule: the message sent on one channel a number of days before the due
+// date (reminder), on it (due) or after a missed installment (dunning).
+// Templates without a product apply to products that have none of their own
+type NotificationTemplate struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	ProductID  *uint          `gorm:"index" json:"product_id,omitempty"`
+	Stage      string         `gorm:"size:16;not null" json:"stage"`   // reminder, due, dunning
+	OffsetDays int            `gorm:"not null" json:"offset_days"`     // days before (reminder) or after (dunning) the due date
+	Channel    string         `gorm:"size:16;not null" json:"channel"` // sms, whatsapp, email
+	Subject    string         `json:"subject,omitempty"`               // email only
+	Body       string         `gorm:"type:text;not null" json:"body"`  // text/template
+	Active     bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// NotificationOptOut records that a borrower does not want reminders on a
+// channel, or on any channel when Channel is *
+type NotificationOptOut struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	TenantID   uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID   *uint          `gorm:"index" json:"branch_id,omitempty"`
+	BorrowerID uint           `gorm:"not null;index" json:"borrower_id"`
+	Channel    string         `gorm:"size:16;not null" json:"channel"`
+	Reason     string         `json:"reason,omitempty"`
+	CreatedBy  string         `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"` // set when the borrower opts back in
+}
+
+// Notification is the send history: one template sent, or withheld, for one
+// installment. Each template is sent at most once per installment
+type Notification struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TenantID    uint       `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID    *uint      `gorm:"index" json:"branch_id,omitempty"`
+	BorrowerID  uint       `gorm:"not null;index" json:"borrower_id"`
+	LoanID      uint       `gorm:"not null;index" json:"loan_id"`
+	PaymentID   uint       `gorm:"not null;uniqueIndex:idx_notification_template" json:"payment_id"`
+	TemplateID  uint       `gorm:"not null;uniqueIndex:idx_notification_template" json:"template_id"`
+	Stage       string     `gorm:"size:16;not null" json:"stage"`
+	OffsetDays  int        `gorm:"not null" json:"offset_days"`
+	Channel     string     `gorm:"size:16;not null;index" json:"channel"`
+	Recipient   string     `json:"recipient"`
+	Subject     string     `json:"subject,omitempty"`
+	Body        string     `gorm:"type:text" json:"body"`
+	Status      string     `gorm:"not null;index" json:"status"` // sent, failed, opted_out
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	ProviderRef string     `json:"provider_ref,omitempty"` // message ID given by the channel
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Officer represents a field officer who runs group meetings and collects repayments
+type Officer struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TenantID  uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID  *uint          `gorm:"index" json:"branch_id,omitempty"`
+	Name      string         `gorm:"not null" json:"name"`
+	Phone     string         `json:"phone"`
+	Region    string         `gorm:"index" json:"region"`
+	Status    string         `gorm:"not null;default:'active'" json:"status"` // active, inactive
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event written in the same transaction as the state
+// change it describes, and published afterwards by the outbox relay
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	EventID       string     `gorm:"size:36;not null;uniqueIndex" json:"event_id"` // stable across redeliveries
+	TenantID      uint       `gorm:"not null;default:1;index" json:"tenant_id"`
+	Type          string     `gorm:"size:64;not null;index" json:"type"` // loan.created, payment.received, ...
+	Version       int        `gorm:"not null" json:"version"`            // schema version of the event type
+	AggregateType string     `gorm:"size:32;not null;index:idx_outbox_aggregate" json:"aggregate_type"`
+	AggregateID   string     `gorm:"size:64;not null;index:idx_outbox_aggregate" json:"aggregate_id"`
+	Payload       string     `gorm:"type:longtext;not null" json:"-"` // the JSON envelope as published
+	OccurredAt    time.Time  `gorm:"not null" json:"occurred_at"`
+	PublishedAt   *time.Time `gorm:"index" json:"published_at,omitempty"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // earliest retry after a failed publish
+	CreatedAt     time.Time  `gorm:"not null" json:"created_at"`
+}
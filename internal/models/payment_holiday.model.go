@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentHoliday represents a pause in a loan's repayments over a date range
+type PaymentHoliday struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	TenantID        uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID        *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID          uint           `gorm:"not null;index" json:"loan_id"`
+	StartDate       time.Time      `gorm:"not null" json:"start_date"`
+	EndDate         time.Time      `gorm:"not null" json:"end_date"`
+	ShiftWeeks      int            `gorm:"not null" json:"shift_weeks"` // weeks every affected installment moved forward
+	AccrueInterest  bool           `gorm:"not null;default:false" json:"accrue_interest"`
+	AccruedInterest float64        `gorm:"not null;default:0" json:"accrued_interest"`
+	Region          string         `json:"region,omitempty"` // set when applied in bulk by region
+	Reason          string         `gorm:"not null" json:"reason"`
+	RequestedBy     string         `gorm:"not null" json:"requested_by"`
+	CreatedAt       time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Product represents a loan product and the rules loans booked under it follow
+type Product struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	TenantID    uint   `gorm:"not null;default:1;uniqueIndex:idx_product_code" json:"tenant_id"`
+	Code        string `gorm:"size:50;not null;uniqueIndex:idx_product_code" json:"code"`
+	Name        string `gorm:"not null" json:"name"`
+	DueDateRule string `gorm:"not null;default:'keep'" json:"due_date_rule"` // roll_forward, roll_backward, keep
+	// MinPrincipal and MaxPrincipal bound the amount of loans booked under
+	// the product; zero leaves that side unbounded
+	MinPrincipal float64        `gorm:"not null;default:0" json:"min_principal"`
+	MaxPrincipal float64        `gorm:"not null;default:0" json:"max_principal"`
+	CreatedAt    time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationRun represents the end-of-day comparison of bank statements,
+// payment transactions and installments of one tenant for one business date.
+// Re-running a date replaces the tenant's earlier run
+type ReconciliationRun struct {
+	ID               uint                 `gorm:"primaryKey" json:"id"`
+	TenantID         uint                 `gorm:"not null;default:1;uniqueIndex:idx_run_business_date" json:"tenant_id"`
+	BusinessDate     time.Time            `gorm:"type:date;not null;uniqueIndex:idx_run_business_date" json:"business_date"`
+	StatementCount   int                  `gorm:"not null" json:"statement_count"`
+	StatementTotal   float64              `gorm:"not null" json:"statement_total"`
+	TransactionCount int                  `gorm:"not null" json:"transaction_count"`
+	TransactionTotal float64              `gorm:"not null" json:"transaction_total"`
+	AppliedTotal     float64              `gorm:"not null" json:"applied_total"`
+	UnappliedTotal   float64              `gorm:"not null" json:"unapplied_total"`
+	InstallmentCount int                  `gorm:"not null" json:"installment_count"`
+	InstallmentTotal float64              `gorm:"not null" json:"installment_total"`
+	MismatchCount    int                  `gorm:"not null" json:"mismatch_count"`
+	Status           string               `gorm:"not null" json:"status"` // balanced, mismatched
+	RunBy            string               `json:"run_by,omitempty"`
+	CreatedAt        time.Time            `gorm:"not null" json:"created_at"`
+	UpdatedAt        time.Time            `gorm:"not null" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt       `gorm:"index" json:"deleted_at,omitempty"`
+	Items            []ReconciliationItem `gorm:"foreignKey:RunID" json:"items,omitempty"`
+}
+
+// ReconciliationItem represents one mismatch found by a reconciliation run
+type ReconciliationItem struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	TenantID         uint      `gorm:"not null;default:1;index" json:"tenant_id"`
+	RunID            uint      `gorm:"not null;index" json:"run_id"`
+	Type             string    `gorm:"not null;index" json:"type"` // missing_posting, missing_statement, duplicate, amount_difference, unlinked_installment
+	LoanID           *uint     `json:"loan_id,omitempty"`
+	TransactionID    *uint     `json:"transaction_id,omitempty"`
+	StatementLineID  *uint     `json:"statement_line_id,omitempty"`
+	GatewayPaymentID *uint     `json:"gateway_payment_id,omitempty"`
+	PaymentID        *uint     `json:"payment_id,omitempty"`
+	Expected         float64   `gorm:"not null" json:"expected"`
+	Actual           float64   `gorm:"not null" json:"actual"`
+	Difference       float64   `gorm:"not null" json:"difference"`
+	Description      string    `gorm:"not null" json:"description"`
+	CreatedAt        time.Time `gorm:"not null" json:"created_at"`
+}
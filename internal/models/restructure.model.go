@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Restructure records a change to a loan's terms and the schedule versions it moved between
+type Restructure struct {
+	ID                    uint           `gorm:"primaryKey" json:"id"`
+	TenantID              uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID              *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID                uint           `gorm:"not null;index" json:"loan_id"`
+	FromVersion           int            `gorm:"not null" json:"from_version"`
+	ToVersion             int            `gorm:"not null" json:"to_version"`
+	ExtendWeeks           int            `gorm:"not null;default:0" json:"extend_weeks"`
+	HolidayWeeks          int            `gorm:"not null;default:0" json:"holiday_weeks"`
+	CapitaliseArrears     bool           `gorm:"not null;default:false" json:"capitalise_arrears"`
+	CapitalisedAmount     float64        `gorm:"not null;default:0" json:"capitalised_amount"`
+	RescheduledAmount     float64        `gorm:"not null" json:"rescheduled_amount"` // unpaid amount moved onto the new schedule
+	PreviousWeeklyPayment float64        `gorm:"not null" json:"previous_weekly_payment"`
+	NewWeeklyPayment      float64        `gorm:"not null" json:"new_weekly_payment"`
+	PreviousEndDate       time.Time      `gorm:"not null" json:"previous_end_date"`
+	NewEndDate            time.Time      `gorm:"not null" json:"new_end_date"`
+	Reason                string         `gorm:"not null" json:"reason"`
+	RequestedBy           string         `gorm:"not null" json:"requested_by"`
+	CreatedAt             time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt             time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
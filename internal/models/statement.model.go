@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatementImport represents one bank statement file imported for repayment matching
+type StatementImport struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	TenantID       uint            `gorm:"not null;default:1;index" json:"tenant_id"`
+	Format         string          `gorm:"not null" json:"format"`
+	FileName       string          `json:"file_name,omitempty"`
+	Source         string          `gorm:"not null" json:"source"` // api, cli
+	TotalLines     int             `gorm:"not null" json:"total_lines"`
+	DuplicateLines int             `gorm:"not null" json:"duplicate_lines"` // already imported from an earlier file
+	SkippedLines   int             `gorm:"not null" json:"skipped_lines"`   // debits and zero amounts
+	AutoMatched    int             `gorm:"not null" json:"auto_matched"`
+	Ambiguous      int             `gorm:"not null" json:"ambiguous"`
+	Unmatched      int             `gorm:"not null" json:"unmatched"`
+	Failed         int             `gorm:"not null" json:"failed"` // matched one loan but could not be posted
+	TotalAmount    float64         `gorm:"not null" json:"total_amount"`
+	CreatedAt      time.Time       `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"not null" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt  `gorm:"index" json:"deleted_at,omitempty"`
+	Lines          []StatementLine `gorm:"foreignKey:ImportID" json:"lines,omitempty"`
+}
+
+// StatementLine represents one credit on an imported bank statement and how it was matched
+type StatementLine struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	TenantID      uint           `gorm:"not null;default:1;uniqueIndex:idx_statement_fingerprint" json:"tenant_id"`
+	ImportID      uint           `gorm:"not null;index" json:"import_id"`
+	LineNo        int            `gorm:"not null" json:"line_no"`
+	Sequence      string         `gorm:"size:64" json:"sequence,omitempty"` // the bank's transaction number, when the format has one
+	ValueDate     time.Time      `gorm:"type:date;not null;index" json:"value_date"`
+	Amount        float64        `gorm:"not null" json:"amount"`
+	Account       string         `gorm:"size:32;index" json:"account,omitempty"`
+	Reference     string         `gorm:"size:64" json:"reference,omitempty"`
+	Description   string         `json:"description,omitempty"`
+	Fingerprint   string         `gorm:"size:64;not null;uniqueIndex:idx_statement_fingerprint" json:"-"`
+	MatchStatus   string         `gorm:"not null;index" json:"match_status"` // auto_matched, ambiguous, unmatched
+	Candidates    string         `json:"candidates,omitempty"`               // comma separated loan IDs of an ambiguous match
+	LoanID        *uint          `gorm:"index" json:"loan_id,omitempty"`
+	TransactionID *uint          `json:"transaction_id,omitempty"`
+	RecoveryID    *uint          `json:"recovery_id,omitempty"`        // set instead of the transaction for a written-off loan
+	Status        string         `gorm:"not null;index" json:"status"` // posted, open, resolved, ignored
+	Error         string         `json:"error,omitempty"`
+	ResolvedBy    string         `json:"resolved_by,omitempty"`
+	ResolvedAt    *time.Time     `json:"resolved_at,omitempty"`
+	Note          string         `json:"note,omitempty"`
+	CreatedAt     time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
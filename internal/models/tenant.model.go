@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTenantID is the tenant that records and credentials without one belong to
+const DefaultTenantID uint = 1
+
+// Tenant represents an organisation whose data is kept apart from every other
+// tenant's in the same deployment, such as a white-label partner
+type Tenant struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Code      string         `gorm:"size:32;not null;uniqueIndex" json:"code"`
+	Name      string         `gorm:"not null" json:"name"`
+	Status    string         `gorm:"not null;default:'active'" json:"status"` // active, suspended
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// Branch represents a branch office of a tenant. Borrowers, groups and
+// officers belong to a branch, and their loans and payments with them
+type Branch struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TenantID  uint           `gorm:"not null;default:1;uniqueIndex:idx_branch_code" json:"tenant_id"`
+	Code      string         `gorm:"size:32;not null;uniqueIndex:idx_branch_code" json:"code"`
+	Name      string         `gorm:"not null" json:"name"`
+	Region    string         `gorm:"index" json:"region"`
+	Status    string         `gorm:"not null;default:'active'" json:"status"` // active, closed
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentTransaction represents money received for a loan and posted against its schedule
+type PaymentTransaction struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	TenantID        uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID        *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID          uint           `gorm:"not null;index" json:"loan_id"`
+	Amount          float64        `gorm:"not null" json:"amount"`
+	AppliedAmount   float64        `gorm:"not null" json:"applied_amount"`             // settled installments
+	FeeAmount       float64        `gorm:"not null;default:0" json:"fee_amount"`       // part of AppliedAmount that paid fees
+	UnappliedAmount float64        `gorm:"not null" json:"unapplied_amount"`           // added to the loan's suspense balance
+	SuspenseApplied float64        `gorm:"not null;default:0" json:"suspense_applied"` // taken from the loan's suspense balance into AppliedAmount
+	Channel         string         `gorm:"not null;index" json:"channel"`              // api, field, bank, gateway, refinance
+	Reference       string         `gorm:"index" json:"reference,omitempty"`
+	ReceivedBy      string         `json:"received_by,omitempty"`
+	ReceivedAt      time.Time      `gorm:"not null;index" json:"received_at"`
+	Status          string         `gorm:"not null;default:'posted'" json:"status"` // posted, reversed
+	ReversedAt      *time.Time     `json:"reversed_at,omitempty"`
+	ReversedBy      string         `json:"reversed_by,omitempty"`
+	ReversalReason  string         `json:"reversal_reason,omitempty"`
+	CreatedAt       time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// CollectionBatch represents a collection sheet posted by a field officer
+type CollectionBatch struct {
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	OfficerID      uint                  `gorm:"not null;uniqueIndex:idx_collection_batch_sheet" json:"officer_id"`
+	CollectionDate time.Time             `gorm:"type:date;not null;uniqueIndex:idx_collection_batch_sheet" json:"collection_date"` // one sheet per officer and day
+	TotalCollected float64               `gorm:"not null" json:"total_collected"`
+	PostedLines    int                   `gorm:"not null" json:"posted_lines"`
+	FailedLines    int                   `gorm:"not null" json:"failed_lines"`
+	CreatedAt      time.Time             `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time             `gorm:"not null" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt        `gorm:"index" json:"deleted_at,omitempty"`
+	Lines          []CollectionBatchLine `gorm:"foreignKey:BatchID" json:"lines,omitempty"`
+}
+
+// CollectionBatchLine represents the outcome of one loan on a posted collection sheet
+type CollectionBatchLine struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	BatchID          uint           `gorm:"not null;index" json:"batch_id"`
+	LineNo           int            `gorm:"not null" json:"line_no"`
+	LoanID           uint           `gorm:"not null;index" json:"loan_id"`
+	Result           string         `gorm:"not null" json:"result"` // paid, unpaid, partial
+	Amount           float64        `gorm:"not null" json:"amount"`
+	Status           string         `gorm:"not null" json:"status"` // posted, recorded, failed
+	TransactionID    *uint          `json:"transaction_id,omitempty"`
+	InstallmentsPaid int            `gorm:"not null" json:"installments_paid"`
+	UnappliedAmount  float64        `gorm:"not null" json:"unapplied_amount"`
+	Error            string         `json:"error,omitempty"`
+	CreatedAt        time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is a partner endpoint that domain events of the chosen
+// types are delivered to. The secret signs every delivery and is shown once
+// when the subscription is created
+type WebhookSubscription struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	TenantID   uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	Name       string         `gorm:"not null" json:"name"`
+	URL        string         `gorm:"size:2048;not null" json:"url"`
+	EventTypes string         `gorm:"type:text;not null" json:"event_types"` // comma separated, or * for every type
+	Secret     string         `gorm:"size:128;not null" json:"-"`
+	Status     string         `gorm:"not null;default:'active'" json:"status"` // active, paused
+	CreatedBy  string         `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// WebhookDelivery is one domain event to be delivered to one subscription.
+// Failed deliveries are retried with exponential backoff until they succeed
+// or run out of attempts and are dead-lettered
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	TenantID       uint       `gorm:"not null;default:1;index" json:"tenant_id"`
+	SubscriptionID uint       `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"subscription_id"`
+	EventID        string     `gorm:"size:36;not null;uniqueIndex:idx_webhook_delivery_event" json:"event_id"`
+	EventType      string     `gorm:"size:64;not null;index" json:"event_type"`
+	Payload        string     `gorm:"type:longtext;not null" json:"-"`                // the event envelope as sent
+	Status         string     `gorm:"not null;default:'pending';index" json:"status"` // pending, retrying, delivered, dead
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	RedeliveredAt  *time.Time `json:"redelivered_at,omitempty"` // last manual redelivery, which resets Attempts
+	RedeliveredBy  string     `json:"redelivered_by,omitempty"`
+	CreatedAt      time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+// WebhookAttempt records one HTTP request made for a delivery. Attempt counts
+// from 1 again after a manual redelivery
+type WebhookAttempt struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	DeliveryID   uint      `gorm:"not null;index" json:"delivery_id"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	StatusCode   int       `json:"status_code,omitempty"` // 0 when no response was received
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	ResponseBody string    `gorm:"type:text" json:"response_body,omitempty"` // truncated
+	DurationMs   int64     `gorm:"not null" json:"duration_ms"`
+	AttemptedAt  time.Time `gorm:"not null" json:"attempted_at"`
+}
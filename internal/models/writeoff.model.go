@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WriteOff represents a request to write off a defaulted loan
+type WriteOff struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	TenantID    uint           `gorm:"not null;default:1;index" json:"tenant_id"`
+	BranchID    *uint          `gorm:"index" json:"branch_id,omitempty"`
+	LoanID      uint           `gorm:"not null;index" json:"loan_id"`
+	Amount      float64        `gorm:"not null" json:"amount"` // receivable at the time of the request
+	Reason      string         `gorm:"not null" json:"reason"`
+	RequestedBy string         `gorm:"not null" json:"requested_by"`
+	ReviewedBy  string         `json:"reviewed_by,omitempty"`
+	ReviewNote  string         `json:"review_note,omitempty"`
+	Status      string         `gorm:"not null;default:'pending'" json:"status"` // pending, approved, rejected
+	ReviewedAt  *time.Time     `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// Recovery represents money received on a loan after it was written off
+type Recovery struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	LoanID     uint           `gorm:"not null;index" json:"loan_id"`
+	Amount     float64        `gorm:"not null" json:"amount"`
+	Reference  string         `json:"reference,omitempty"`
+	ReceivedAt time.Time      `gorm:"not null" json:"received_at"`
+	CreatedAt  time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ApplicationRepository handles database operations for loan applications
+type ApplicationRepository struct {
+	db *gorm.DB
+}
+
+// NewApplicationRepository creates a new application repository instance
+func NewApplicationRepository(db *gorm.DB) *ApplicationRepository {
+	return &ApplicationRepository{db: db}
+}
+
+// Create creates a new loan application in the tenant and branch of its borrower
+func (r *ApplicationRepository) Create(ctx context.Context, application *models.LoanApplication) error {
+	owner, err := partitionOf(conn(ctx, r.db), &models.Borrower{}, application.BorrowerID, "borrower not found")
+	if err != nil {
+		return err
+	}
+	application.TenantID = owner.TenantID
+	application.BranchID = owner.BranchID
+	return conn(ctx, r.db).Create(application).Error
+}
+
+// Update updates a loan application
+func (r *ApplicationRepository) Update(ctx context.Context, application *models.LoanApplication) error {
+	return conn(ctx, r.db).Save(application).Error
+}
+
+// GetByID retrieves a loan application by its ID
+func (r *ApplicationRepository) GetByID(ctx context.Context, id uint) (*models.LoanApplication, error) {
+	var application models.LoanApplication
+	if err := conn(ctx, r.db).First(&application, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("loan application not found")
+		}
+		return nil, err
+	}
+	return &application, nil
+}
+
+// List retrieves loan applications, newest first, optionally filtered by borrower and decision
+func (r *ApplicationRepository) List(ctx context.Context, borrowerID uint, decision string) ([]models.LoanApplication, error) {
+	query := conn(ctx, r.db).Order("id DESC")
+	if borrowerID != 0 {
+		query = query.Where("borrower_id = ?", borrowerID)
+	}
+	if decision != "" {
+		query = query.Where("decision = ?", decision)
+	}
+
+	var applications []models.LoanApplication
+	if err := query.Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
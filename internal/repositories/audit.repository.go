@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuditFilter narrows an audit log query. Zero fields match everything
+type AuditFilter struct {
+	Entity    string
+	EntityID  string
+	Actor     string
+	Action    string
+	RequestID string
+	From      time.Time
+	To        time.Time // exclusive
+	Limit     int
+}
+
+// AuditRepository handles database operations for the audit log. Entries
+// are written by the audit callbacks; this repository reads and seals them
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository instance
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// List retrieves audit entries matching the filter, newest first
+func (r *AuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, error) {
+	query := conn(ctx, r.db).Order("id DESC").Limit(filter.Limit)
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("occurred_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("occurred_at < ?", filter.To)
+	}
+
+	var entries []models.AuditLog
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetByID retrieves an audit entry by its ID
+func (r *AuditRepository) GetByID(ctx context.Context, id uint) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	if err := conn(ctx, r.db).First(&entry, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("audit entry not found")
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetChain retrieves up to limit sealed entries after a chain position, in
+// chain order. The chain links the entries of every tenant
+func (r *AuditRepository) GetChain(ctx context.Context, afterSequence uint64, limit int) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	if err := r.db.WithContext(acrossTenants(ctx)).
+		Where("sequence > ?", afterSequence).
+		Order("sequence").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetHead retrieves the last sealed position of the chain
+func (r *AuditRepository) GetHead(ctx context.Context) (*models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	if err := conn(ctx, r.db).Where("id = ?", 1).Limit(1).Find(&head).Error; err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+// CountUnsealed counts the entries of every tenant not yet linked into the chain
+func (r *AuditRepository) CountUnsealed(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(acrossTenants(ctx)).Model(&models.AuditLog{}).Where("sequence IS NULL").Count(&count).Error
+	return count, err
+}
+
+// Seal links up to limit committed but unsealed entries into the hash chain,
+// in the order they were written. The chain head is locked meanwhile, so
+// concurrent sealers extend the chain one after the other
+func (r *AuditRepository) Seal(ctx context.Context, limit int) (int, error) {
+	tx := r.db.WithContext(acrossTenants(ctx)).Set(auditSealKey, true).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var head models.AuditChainHead
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Attrs(models.AuditChainHead{UpdatedAt: time.Now()}).
+		FirstOrCreate(&head, models.AuditChainHead{ID: 1}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var entries []models.AuditLog
+	if err := tx.Where("sequence IS NULL").Order("id").Limit(limit).Find(&entries).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if len(entries) == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	now := time.Now()
+	for i := range entries {
+		entry := &entries[i]
+		sequence := head.Sequence + 1
+		entry.Sequence = &sequence
+		entry.PrevHash = head.Hash
+		entry.Hash = AuditHash(head.Hash, entry)
+
+		if err := tx.Model(entry).Where("sequence IS NULL").Updates(map[string]interface{}{
+			"sequence":  sequence,
+			"prev_hash": entry.PrevHash,
+			"hash":      entry.Hash,
+			"sealed_at": now,
+		}).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		head.Sequence = sequence
+		head.Hash = entry.Hash
+	}
+
+	head.UpdatedAt = now
+	if err := tx.Save(&head).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
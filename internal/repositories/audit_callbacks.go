@@ -0,0 +1,462 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrAuditImmutable is returned when a statement tries to change or remove audit entries
+var ErrAuditImmutable = errors.New("audit log entries cannot be changed or removed")
+
+const (
+	auditBeforeKey = "audit:before"
+	auditSealKey   = "audit:seal"
+)
+
+// unaudited tables are the audit trail itself, and the event outbox, webhook
+// delivery log and notification send history, which are logs of their own
+var unaudited = map[string]bool{
+	"audit_logs":         true,
+	"audit_chain_heads":  true,
+	"outbox_events":      true,
+	"webhook_deliveries": true,
+	"webhook_attempts":   true,
+	"notifications":      true,
+}
+
+type auditActorKey struct{}
+
+type requestIDKey struct{}
+
+type auditActor struct {
+	name      string
+	actorType string
+}
+
+// WithAuditActor returns a copy of ctx whose changes are recorded as made by the actor
+func WithAuditActor(ctx context.Context, actor, actorType string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, auditActor{name: actor, actorType: actorType})
+}
+
+// WithRequestID returns a copy of ctx whose changes are recorded under the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID carried by ctx, or ""
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RegisterAuditCallbacks records every row created, updated or deleted
+// through db in the audit log, in the same transaction as the change. The
+// actor and request ID come from the statement's context; changes made
+// without one, such as by background jobs, are recorded as "system"
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("audit:before_create", beforeCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").Register("audit:after_create", afterCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", beforeUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register("audit:after_update", afterUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", beforeDelete); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register("audit:after_delete", afterDelete)
+}
+
+// audited reports whether a statement's rows are recorded in the audit log
+func audited(db *gorm.DB) bool {
+	return db.Error == nil && db.Statement.Schema != nil && !db.DryRun &&
+		db.Statement.Schema.PrioritizedPrimaryField != nil && !unaudited[db.Statement.Table]
+}
+
+// beforeCreate keeps the rows an upsert is about to overwrite. Saving a
+// record's associations upserts them, and those rows are not new
+func beforeCreate(db *gorm.DB) {
+	if _, upsert := db.Statement.Clauses["ON CONFLICT"]; !upsert || !audited(db) {
+		return
+	}
+	keepAffected(db, false)
+}
+
+// beforeUpdate keeps the rows an update is about to change. The audit log
+// itself may only be updated to seal its entries
+func beforeUpdate(db *gorm.DB) {
+	if db.Error == nil && db.Statement.Table == "audit_logs" {
+		if seal, _ := db.Get(auditSealKey); seal != true {
+			db.AddError(ErrAuditImmutable)
+		}
+		return
+	}
+	if audited(db) {
+		keepAffected(db, true)
+	}
+}
+
+// beforeDelete keeps the rows a delete is about to remove. The audit log
+// itself can never be deleted from
+func beforeDelete(db *gorm.DB) {
+	if db.Error == nil && db.Statement.Table == "audit_logs" {
+		db.AddError(ErrAuditImmutable)
+		return
+	}
+	if audited(db) {
+		keepAffected(db, true)
+	}
+}
+
+// auditGuards are the database triggers that hold the audit log to the same
+// rules as the callbacks for statements that bypass the application: an
+// unsealed entry may only be sealed, and nothing else may change or remove an
+// entry. Entries are therefore fixed from the moment they are written, not
+// only once the sealer has linked them into the hash chain
+var auditGuards = []string{
+	"DROP TRIGGER IF EXISTS audit_logs_guard_update",
+	`CREATE TRIGGER audit_logs_guard_update BEFORE UPDATE ON audit_logs FOR EACH ROW
+BEGIN
+	IF OLD.sequence IS NOT NULL OR NEW.sequence IS NULL
+		OR NOT (NEW.tenant_id <=> OLD.tenant_id AND NEW.occurred_at <=> OLD.occurred_at
+			AND NEW.actor <=> OLD.actor AND NEW.actor_type <=> OLD.actor_type
+			AND NEW.request_id <=> OLD.request_id AND NEW.action <=> OLD.action
+			AND NEW.entity <=> OLD.entity AND NEW.entity_id <=> OLD.entity_id
+			AND NEW.` + "`before`" + ` <=> OLD.` + "`before`" + ` AND NEW.` + "`after`" + ` <=> OLD.` + "`after`" + `
+			AND NEW.changes <=> OLD.changes) THEN
+		SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log entries cannot be changed or removed';
+	END IF;
+END`,
+	"DROP TRIGGER IF EXISTS audit_logs_guard_delete",
+	`CREATE TRIGGER audit_logs_guard_delete BEFORE DELETE ON audit_logs FOR EACH ROW
+	SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit log entries cannot be changed or removed'`,
+}
+
+// InstallAuditGuards creates the database triggers that refuse changes to
+// audit entries other than sealing them. Run it after migrating audit_logs
+func InstallAuditGuards(db *gorm.DB) error {
+	for _, statement := range auditGuards {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("installing audit log guards: %w", err)
+		}
+	}
+	return nil
+}
+
+func keepAffected(db *gorm.DB, withConditions bool) {
+	before, err := loadAffected(db, withConditions)
+	if err != nil {
+		db.AddError(fmt.Errorf("reading rows for the audit log: %w", err))
+		return
+	}
+	db.InstanceSet(auditBeforeKey, before)
+}
+
+func afterCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	before := beforeRows(db)
+	existing := map[string]bool{}
+	for _, row := range before {
+		existing[primaryKey(db, row)] = true
+	}
+
+	var entries []models.AuditLog
+	for _, row := range rows(db.Statement.ReflectValue) {
+		if existing[primaryKey(db, row)] {
+			continue
+		}
+		entry := newAuditEntry(db, "create", row)
+		after, err := json.Marshal(snapshot(db.Statement.Context, db.Statement.Schema, row))
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		entry.After = string(after)
+		entries = append(entries, entry)
+	}
+
+	updates, err := updateEntries(db, before)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	writeAuditEntries(db, append(entries, updates...))
+}
+
+func afterUpdate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	entries, err := updateEntries(db, beforeRows(db))
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	writeAuditEntries(db, entries)
+}
+
+func afterDelete(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	var entries []models.AuditLog
+	for _, row := range beforeRows(db) {
+		entry := newAuditEntry(db, "delete", row)
+		previous, err := json.Marshal(snapshot(db.Statement.Context, db.Statement.Schema, row))
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		entry.Before = string(previous)
+		entries = append(entries, entry)
+	}
+	writeAuditEntries(db, entries)
+}
+
+// beforeRows returns the rows kept before the statement ran
+func beforeRows(db *gorm.DB) []reflect.Value {
+	value, _ := db.InstanceGet(auditBeforeKey)
+	before, _ := value.([]reflect.Value)
+	return before
+}
+
+// updateEntries reads the rows kept before a change again and returns an
+// update entry for each one whose columns changed
+func updateEntries(db *gorm.DB, before []reflect.Value) ([]models.AuditLog, error) {
+	if len(before) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]interface{}, len(before))
+	for i, row := range before {
+		ids[i], _ = db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, row)
+	}
+	after, err := findRows(db, ids, clause.Where{}, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading rows for the audit log: %w", err)
+	}
+	afterByID := map[string]map[string]interface{}{}
+	for _, row := range after {
+		afterByID[primaryKey(db, row)] = snapshot(db.Statement.Context, db.Statement.Schema, row)
+	}
+
+	var entries []models.AuditLog
+	for _, row := range before {
+		current, found := afterByID[primaryKey(db, row)]
+		if !found {
+			continue
+		}
+		previous := snapshot(db.Statement.Context, db.Statement.Schema, row)
+		changes := diff(previous, current)
+		if len(changes) == 0 {
+			continue
+		}
+
+		entry := newAuditEntry(db, "update", row)
+		if err := setStates(&entry, previous, current, changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// loadAffected reads the rows a statement applies to: the rows of its model
+// value that have primary keys and, with conditions, the rows its WHERE
+// clause selects
+func loadAffected(db *gorm.DB, withConditions bool) ([]reflect.Value, error) {
+	stmt := db.Statement
+
+	var ids []interface{}
+	for _, row := range rows(stmt.ReflectValue) {
+		if id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, row); !zero {
+			ids = append(ids, id)
+		}
+	}
+	var where clause.Where
+	if c, ok := stmt.Clauses["WHERE"]; ok && withConditions {
+		where, _ = c.Expression.(clause.Where)
+	}
+	if len(ids) == 0 && len(where.Exprs) == 0 {
+		return nil, nil
+	}
+	return findRows(db, ids, where, stmt.Unscoped)
+}
+
+// findRows reads rows of the statement's model by primary key and conditions,
+// through the statement's connection so that a transaction sees its own changes
+func findRows(db *gorm.DB, ids []interface{}, where clause.Where, unscoped bool) ([]reflect.Value, error) {
+	stmt := db.Statement
+	query := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(stmt.Table)
+	if len(where.Exprs) > 0 {
+		query = query.Clauses(where)
+	}
+	if len(ids) > 0 {
+		query = query.Where(clause.IN{Column: clause.Column{Table: stmt.Table, Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: ids})
+	}
+	if unscoped {
+		query = query.Unscoped()
+	}
+
+	found := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := query.Find(found.Interface()).Error; err != nil {
+		return nil, err
+	}
+	return rows(found.Elem()), nil
+}
+
+// rows returns the struct values held by a statement's model value
+func rows(value reflect.Value) []reflect.Value {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{value}
+	case reflect.Slice, reflect.Array:
+		result := make([]reflect.Value, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if row := reflect.Indirect(value.Index(i)); row.Kind() == reflect.Struct {
+				result = append(result, row)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// snapshot returns the column values of a row, leaving out associations and
+// fields hidden from JSON such as key hashes and raw payloads
+func snapshot(ctx context.Context, s *schema.Schema, row reflect.Value) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		value, _ := field.ValueOf(ctx, row)
+		values[field.DBName] = value
+	}
+	return values
+}
+
+// diff returns the columns whose values differ, ignoring the update timestamp
+func diff(before, after map[string]interface{}) map[string][2]interface{} {
+	changes := map[string][2]interface{}{}
+	for column, value := range after {
+		if column == "updated_at" {
+			continue
+		}
+		previous, _ := json.Marshal(before[column])
+		current, _ := json.Marshal(value)
+		if string(previous) != string(current) {
+			changes[column] = [2]interface{}{before[column], value}
+		}
+	}
+	return changes
+}
+
+func primaryKey(db *gorm.DB, row reflect.Value) string {
+	id, _ := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, row)
+	return fmt.Sprint(id)
+}
+
+func newAuditEntry(db *gorm.DB, action string, row reflect.Value) models.AuditLog {
+	ctx := db.Statement.Context
+	actor, ok := ctx.Value(auditActorKey{}).(auditActor)
+	if !ok {
+		actor = auditActor{name: "system", actorType: "system"}
+	}
+
+	return models.AuditLog{
+		// Stored with millisecond precision, so truncate before hashing sees it
+		OccurredAt: time.Now().Truncate(time.Millisecond),
+		TenantID:   tenantOf(db, row),
+		Actor:      actor.name,
+		ActorType:  actor.actorType,
+		RequestID:  RequestIDFrom(ctx),
+		Action:     action,
+		Entity:     db.Statement.Table,
+		EntityID:   primaryKey(db, row),
+	}
+}
+
+// auditChange is how a changed column is written in an entry's changes
+type auditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// setStates writes the row before and after an update, and what changed, on an entry
+func setStates(entry *models.AuditLog, before, after map[string]interface{}, changes map[string][2]interface{}) error {
+	changed := make(map[string]auditChange, len(changes))
+	for column, change := range changes {
+		changed[column] = auditChange{From: change[0], To: change[1]}
+	}
+
+	for target, value := range map[*string]interface{}{&entry.Before: before, &entry.After: after, &entry.Changes: changed} {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		*target = string(encoded)
+	}
+	return nil
+}
+
+// writeAuditEntries adds entries to the audit log through the statement's
+// connection, so they commit or roll back with the change they record
+func writeAuditEntries(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&entries).Error; err != nil {
+		db.AddError(fmt.Errorf("writing audit log: %w", err))
+	}
+}
+
+// AuditHash returns the chain hash of a sealed entry: the SHA-256 of the
+// previous entry's hash and the entry's content, including its tenant
+func AuditHash(prevHash string, entry *models.AuditLog) string {
+	var sequence uint64
+	if entry.Sequence != nil {
+		sequence = *entry.Sequence
+	}
+	content, _ := json.Marshal([]interface{}{
+		sequence,
+		entry.TenantID,
+		entry.OccurredAt.UTC().Format(time.RFC3339Nano),
+		entry.Actor,
+		entry.ActorType,
+		entry.RequestID,
+		entry.Action,
+		entry.Entity,
+		entry.EntityID,
+		entry.Before,
+		entry.After,
+		entry.Changes,
+	})
+
+	sum := sha256.Sum256(append([]byte(prevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
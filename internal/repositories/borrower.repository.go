@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BorrowerRepository handles database operations for borrowers
+type BorrowerRepository struct {
+	db *gorm.DB
+}
+
+// NewBorrowerRepository creates a new borrower repository instance
+func NewBorrowerRepository(db *gorm.DB) *BorrowerRepository {
+	return &BorrowerRepository{db: db}
+}
+
+// GetByID retrieves a borrower by its ID
+func (r *BorrowerRepository) GetByID(ctx context.Context, id uint) (*models.Borrower, error) {
+	var borrower models.Borrower
+	if err := conn(ctx, r.db).First(&borrower, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("borrower not found")
+		}
+		return nil, err
+	}
+	return &borrower, nil
+}
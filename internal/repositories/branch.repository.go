@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BranchRepository handles database operations for tenants and their branches
+type BranchRepository struct {
+	db *gorm.DB
+}
+
+// NewBranchRepository creates a new branch repository instance
+func NewBranchRepository(db *gorm.DB) *BranchRepository {
+	return &BranchRepository{db: db}
+}
+
+// EnsureDefaultTenant creates the default tenant that existing records and
+// credentials without a tenant belong to, unless it already exists
+func (r *BranchRepository) EnsureDefaultTenant(ctx context.Context) error {
+	tenant := models.Tenant{
+		ID:        models.DefaultTenantID,
+		Code:      "default",
+		Name:      "Default",
+		Status:    "active",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	return conn(ctx, r.db).Where(models.Tenant{ID: models.DefaultTenantID}).FirstOrCreate(&tenant).Error
+}
+
+// ListTenants retrieves the active tenants, for background jobs that work
+// through every tenant in its own scope
+func (r *BranchRepository) ListTenants(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	if err := conn(ctx, r.db).Where("status = ?", "active").Order("id").Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+// Create creates a new branch
+func (r *BranchRepository) Create(ctx context.Context, branch *models.Branch) error {
+	return conn(ctx, r.db).Create(branch).Error
+}
+
+// GetByID retrieves a branch by its ID
+func (r *BranchRepository) GetByID(ctx context.Context, id uint) (*models.Branch, error) {
+	var branch models.Branch
+	if err := conn(ctx, r.db).First(&branch, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("branch not found")
+		}
+		return nil, err
+	}
+	return &branch, nil
+}
+
+// List retrieves the branches, optionally filtered by region
+func (r *BranchRepository) List(ctx context.Context, region string) ([]models.Branch, error) {
+	var branches []models.Branch
+	query := conn(ctx, r.db).Order("code")
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	if err := query.Find(&branches).Error; err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+// BranchTotals are the portfolio figures of one branch. BranchID is nil for
+// the records not assigned to a branch
+type BranchTotals struct {
+	BranchID          *uint
+	Borrowers         int64
+	Loans             int64
+	ActiveLoans       int64   // active and defaulted loans
+	DisbursedAmount   float64 // principal of every loan booked
+	WrittenOffAmount  float64
+	OutstandingAmount float64 // unpaid installments of the current schedules
+	OverdueAmount     float64 // the part of OutstandingAmount due before asOf
+	CollectedAmount   float64 // posted payments received from from up to to
+}
+
+// GetBranchTotals aggregates borrowers, loans, installments and collections
+// by branch. Like every other query it only covers ctx's tenant and branch
+func (r *BranchRepository) GetBranchTotals(ctx context.Context, asOf, from, to time.Time) ([]BranchTotals, error) {
+	totals := map[uint]*BranchTotals{}
+	var order []uint
+	row := func(branchID *uint) *BranchTotals {
+		var key uint
+		if branchID != nil {
+			key = *branchID
+		}
+		if totals[key] == nil {
+			totals[key] = &BranchTotals{BranchID: branchID}
+			order = append(order, key)
+		}
+		return totals[key]
+	}
+
+	var borrowers []BranchTotals
+	if err := conn(ctx, r.db).Model(&models.Borrower{}).
+		Select("branch_id, COUNT(*) AS borrowers").
+		Group("branch_id").
+		Scan(&borrowers).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range borrowers {
+		row(b.BranchID).Borrowers = b.Borrowers
+	}
+
+	var loans []BranchTotals
+	if err := conn(ctx, r.db).Model(&models.Loan{}).
+		Select("branch_id, COUNT(*) AS loans, " +
+			"COALESCE(SUM(CASE WHEN status IN ('active', 'defaulted') THEN 1 ELSE 0 END), 0) AS active_loans, " +
+			"COALESCE(SUM(amount), 0) AS disbursed_amount, COALESCE(SUM(written_off_amount), 0) AS written_off_amount").
+		Group("branch_id").
+		Scan(&loans).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range loans {
+		t := row(l.BranchID)
+		t.Loans, t.ActiveLoans = l.Loans, l.ActiveLoans
+		t.DisbursedAmount, t.WrittenOffAmount = l.DisbursedAmount, l.WrittenOffAmount
+	}
+
+	var installments []BranchTotals
+	if err := conn(ctx, r.db).Model(&models.Payment{}).
+		Select("branch_id, COALESCE(SUM(amount), 0) AS outstanding_amount, "+
+			"COALESCE(SUM(CASE WHEN due_date < ? THEN amount ELSE 0 END), 0) AS overdue_amount", asOf).
+		Where("status = ? AND superseded_in_version = ?", "pending", 0).
+		Group("branch_id").
+		Scan(&installments).Error; err != nil {
+		return nil, err
+	}
+	for _, i := range installments {
+		t := row(i.BranchID)
+		t.OutstandingAmount, t.OverdueAmount = i.OutstandingAmount, i.OverdueAmount
+	}
+
+	var collections []BranchTotals
+	if err := conn(ctx, r.db).Model(&models.PaymentTransaction{}).
+		Select("branch_id, COALESCE(SUM(amount), 0) AS collected_amount").
+		Where("status = ? AND received_at >= ? AND received_at < ?", "posted", from, to).
+		Group("branch_id").
+		Scan(&collections).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range collections {
+		row(c.BranchID).CollectedAmount = c.CollectedAmount
+	}
+
+	result := make([]BranchTotals, len(order))
+	for i, key := range order {
+		result[i] = *totals[key]
+	}
+	return result, nil
+}
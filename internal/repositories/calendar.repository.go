@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CalendarRepository handles database operations for the holiday calendar
+type CalendarRepository struct {
+	db *gorm.DB
+}
+
+// NewCalendarRepository creates a new calendar repository instance
+func NewCalendarRepository(db *gorm.DB) *CalendarRepository {
+	return &CalendarRepository{db: db}
+}
+
+// Create stores a new holiday
+func (r *CalendarRepository) Create(ctx context.Context, holiday *models.CalendarHoliday) error {
+	return conn(ctx, r.db).Create(holiday).Error
+}
+
+// Upsert stores a holiday, replacing the name of an existing one of the same
+// tenant on the same date and region
+func (r *CalendarRepository) Upsert(ctx context.Context, holiday *models.CalendarHoliday) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "date"}, {Name: "region"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+	}).Create(holiday).Error
+}
+
+// GetByID retrieves a holiday by its ID
+func (r *CalendarRepository) GetByID(ctx context.Context, id uint) (*models.CalendarHoliday, error) {
+	var holiday models.CalendarHoliday
+	if err := conn(ctx, r.db).First(&holiday, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("holiday not found")
+		}
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+// Update updates a holiday record
+func (r *CalendarRepository) Update(ctx context.Context, holiday *models.CalendarHoliday) error {
+	return conn(ctx, r.db).Save(holiday).Error
+}
+
+// Delete removes a holiday permanently so the date can be added again
+func (r *CalendarRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Unscoped().Delete(&models.CalendarHoliday{}, id).Error
+}
+
+// List retrieves holidays between two dates. A region returns its own
+// holidays together with the national ones
+func (r *CalendarRepository) List(ctx context.Context, region string, from, to time.Time) ([]models.CalendarHoliday, error) {
+	var holidays []models.CalendarHoliday
+	query := conn(ctx, r.db).Where("date BETWEEN ? AND ?", from, to).Order("date, region")
+	if region != "" {
+		query = query.Where("region IN ?", []string{"", region})
+	}
+	if err := query.Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// Load builds the business calendar a tenant observes in a region between
+// two dates. The tenant is named so that background jobs without a scope
+// read the calendar of the loan's tenant
+func (r *CalendarRepository) Load(ctx context.Context, tenantID uint, region string, from, to time.Time) (*BusinessCalendar, error) {
+	var holidays []models.CalendarHoliday
+	if err := conn(ctx, r.db).Where("tenant_id = ? AND date BETWEEN ? AND ? AND region IN ?", tenantID, from, to, []string{"", region}).
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+
+	calendar := &BusinessCalendar{holidays: make(map[string]bool, len(holidays))}
+	for _, holiday := range holidays {
+		calendar.holidays[holiday.Date.Format("2006-01-02")] = true
+	}
+	return calendar, nil
+}
+
+// BusinessCalendar answers business-day questions for one region. Weekends
+// and loaded holidays are non-business days
+type BusinessCalendar struct {
+	holidays map[string]bool
+	nominal  bool // due dates are kept as scheduled, without a business-day grace
+}
+
+// IsBusinessDay reports whether collections happen on the given day
+func (c *BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.holidays[t.Format("2006-01-02")]
+}
+
+// NextBusinessDay returns the given day if it is a business day, otherwise the
+// first business day after it, keeping the time of day
+func (c *BusinessCalendar) NextBusinessDay(t time.Time) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// PreviousBusinessDay returns the given day if it is a business day, otherwise
+// the last business day before it, keeping the time of day
+func (c *BusinessCalendar) PreviousBusinessDay(t time.Time) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t
+}
+
+// Adjust applies a product due date rule (roll_forward, roll_backward or keep)
+func (c *BusinessCalendar) Adjust(t time.Time, rule string) time.Time {
+	switch rule {
+	case "roll_forward":
+		return c.NextBusinessDay(t)
+	case "roll_backward":
+		return c.PreviousBusinessDay(t)
+	default:
+		return t
+	}
+}
+
+// IsOverdue reports whether an installment due on the given date is overdue.
+// A due date that is not a business day can still be collected on the next
+// one, unless the calendar keeps nominal due dates
+func (c *BusinessCalendar) IsOverdue(dueDate, now time.Time) bool {
+	if c.nominal {
+		return dueDate.Before(now)
+	}
+	return c.NextBusinessDay(dueDate).Before(now)
+}
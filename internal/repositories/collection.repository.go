@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CollectionRepository handles database operations for posted collection sheets
+type CollectionRepository struct {
+	db *gorm.DB
+}
+
+// NewCollectionRepository creates a new collection repository instance
+func NewCollectionRepository(db *gorm.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+// CreateBatch stores a collection sheet before its lines are posted. The
+// unique index on officer and date turns away a second sheet for the same day
+func (r *CollectionRepository) CreateBatch(ctx context.Context, batch *models.CollectionBatch) error {
+	return conn(ctx, r.db).Omit("Lines").Create(batch).Error
+}
+
+// CompleteBatch stores the line results and totals of a posted collection sheet
+func (r *CollectionRepository) CompleteBatch(ctx context.Context, batch *models.CollectionBatch) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines").Save(batch).Error; err != nil {
+			return err
+		}
+		for i := range batch.Lines {
+			batch.Lines[i].BatchID = batch.ID
+		}
+		if len(batch.Lines) == 0 {
+			return nil
+		}
+		return tx.Create(&batch.Lines).Error
+	})
+}
+
+// UpdateBatch stores the totals of a collection sheet together with the lines
+// posted again
+func (r *CollectionRepository) UpdateBatch(ctx context.Context, batch *models.CollectionBatch, lines []*models.CollectionBatchLine) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines").Save(batch).Error; err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := tx.Save(line).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetBatchBySheet retrieves the collection sheet an officer posted for a date
+func (r *CollectionRepository) GetBatchBySheet(ctx context.Context, officerID uint, date time.Time) (*models.CollectionBatch, error) {
+	var batch models.CollectionBatch
+	if err := conn(ctx, r.db).Where("officer_id = ? AND collection_date = ?", officerID, date).First(&batch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("collection batch not found")
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetBatchForUpdate retrieves a collection sheet with its line results and
+// locks it until the transaction carried by ctx ends
+func (r *CollectionRepository) GetBatchForUpdate(ctx context.Context, id uint) (*models.CollectionBatch, error) {
+	var batch models.CollectionBatch
+	if err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line_no")
+	}).First(&batch, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("collection batch not found")
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetBatchByID retrieves a posted collection sheet with its line results
+func (r *CollectionRepository) GetBatchByID(ctx context.Context, id uint) (*models.CollectionBatch, error) {
+	var batch models.CollectionBatch
+	if err := conn(ctx, r.db).Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line_no")
+	}).First(&batch, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("collection batch not found")
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
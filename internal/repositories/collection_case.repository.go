@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CollectionCaseRepository handles database operations for collection cases
+// and their activities
+type CollectionCaseRepository struct {
+	db *gorm.DB
+}
+
+// NewCollectionCaseRepository creates a new collection case repository instance
+func NewCollectionCaseRepository(db *gorm.DB) *CollectionCaseRepository {
+	return &CollectionCaseRepository{db: db}
+}
+
+// Create opens a case in its loan's tenant and branch
+func (r *CollectionCaseRepository) Create(ctx context.Context, collectionCase *models.CollectionCase) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		owner, err := partitionOf(tx, &models.Loan{}, collectionCase.LoanID, "loan not found")
+		if err != nil {
+			return err
+		}
+		collectionCase.TenantID = owner.TenantID
+		collectionCase.BranchID = owner.BranchID
+		return tx.Create(collectionCase).Error
+	})
+}
+
+// GetByID retrieves a collection case by its ID
+func (r *CollectionCaseRepository) GetByID(ctx context.Context, id uint) (*models.CollectionCase, error) {
+	var collectionCase models.CollectionCase
+	if err := conn(ctx, r.db).First(&collectionCase, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("collection case not found")
+		}
+		return nil, err
+	}
+	return &collectionCase, nil
+}
+
+// GetOpen retrieves every open case
+func (r *CollectionCaseRepository) GetOpen(ctx context.Context) ([]models.CollectionCase, error) {
+	var cases []models.CollectionCase
+	if err := conn(ctx, r.db).Where("status = ?", "open").Order("id").Find(&cases).Error; err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// HasOpenCase reports whether a loan has an open case
+func (r *CollectionCaseRepository) HasOpenCase(ctx context.Context, loanID uint) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.CollectionCase{}).
+		Where("loan_id = ? AND status = ?", loanID, "open").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CaseFilter selects collection cases; empty fields match everything
+type CaseFilter struct {
+	Status      string
+	CollectorID uint
+	Unassigned  bool
+	LoanID      uint
+	Limit       int
+}
+
+// List retrieves the cases matching the filter, most overdue first
+func (r *CollectionCaseRepository) List(ctx context.Context, filter CaseFilter) ([]models.CollectionCase, error) {
+	query := conn(ctx, r.db).Order("days_past_due DESC, id").Limit(filter.Limit)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CollectorID != 0 {
+		query = query.Where("collector_id = ?", filter.CollectorID)
+	}
+	if filter.Unassigned {
+		query = query.Where("collector_id IS NULL")
+	}
+	if filter.LoanID != 0 {
+		query = query.Where("loan_id = ?", filter.LoanID)
+	}
+
+	var cases []models.CollectionCase
+	if err := query.Find(&cases).Error; err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// GetWorkQueue retrieves a collector's open cases in the order they should be
+// worked: follow-ups that are due first, then the most overdue
+func (r *CollectionCaseRepository) GetWorkQueue(ctx context.Context, collectorID uint) ([]models.CollectionCase, error) {
+	var cases []models.CollectionCase
+	if err := conn(ctx, r.db).
+		Where("collector_id = ? AND status = ?", collectorID, "open").
+		Order("next_action_at IS NULL, next_action_at, days_past_due DESC, id").
+		Find(&cases).Error; err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// Update saves a collection case
+func (r *CollectionCaseRepository) Update(ctx context.Context, collectionCase *models.CollectionCase) error {
+	return conn(ctx, r.db).Save(collectionCase).Error
+}
+
+// AddActivity logs an activity on a case and saves the case, in one database
+// transaction
+func (r *CollectionCaseRepository) AddActivity(ctx context.Context, collectionCase *models.CollectionCase, activity *models.CollectionActivity) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		activity.CaseID = collectionCase.ID
+		if err := tx.Create(activity).Error; err != nil {
+			return err
+		}
+		return tx.Save(collectionCase).Error
+	})
+}
+
+// GetActivities retrieves the activities of a case, oldest first
+func (r *CollectionCaseRepository) GetActivities(ctx context.Context, caseID uint) ([]models.CollectionActivity, error) {
+	var activities []models.CollectionActivity
+	if err := conn(ctx, r.db).Where("case_id = ?", caseID).Order("performed_at, id").Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// GetPendingPromises retrieves the promises to pay of open cases that are
+// not yet kept or broken
+func (r *CollectionCaseRepository) GetPendingPromises(ctx context.Context) ([]models.CollectionActivity, error) {
+	var activities []models.CollectionActivity
+	if err := conn(ctx, r.db).
+		Joins("JOIN collection_cases ON collection_cases.id = collection_activities.case_id").
+		Where("collection_activities.promise_status = ? AND collection_cases.status = ?", "pending", "open").
+		Order("collection_activities.id").
+		Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// SettlePromise saves the outcome of a promise to pay together with its
+// case, in one database transaction
+func (r *CollectionCaseRepository) SettlePromise(ctx context.Context, collectionCase *models.CollectionCase, activity *models.CollectionActivity) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(activity).Error; err != nil {
+			return err
+		}
+		return tx.Save(collectionCase).Error
+	})
+}
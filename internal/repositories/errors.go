@@ -0,0 +1,24 @@
+package repositories
+
+import "errors"
+
+// ErrNotFound is matched by the errors returned for records that do not
+// exist or lie outside ctx's scope, e.g. errors.Is(err, ErrNotFound)
+var ErrNotFound = errors.New("not found")
+
+// notFoundError keeps the message naming what was not found, such as
+// "loan not found", while matching ErrNotFound
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+func (e notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// notFound returns a not found error with the given message
+func notFound(message string) error {
+	return notFoundError(message)
+}
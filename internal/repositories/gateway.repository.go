@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GatewayRepository handles database operations for payment gateway notifications
+type GatewayRepository struct {
+	db *gorm.DB
+}
+
+// NewGatewayRepository creates a new gateway repository instance
+func NewGatewayRepository(db *gorm.DB) *GatewayRepository {
+	return &GatewayRepository{db: db}
+}
+
+// Create records a new gateway notification in the tenant of the loan that
+// owns its virtual account, or in the default tenant when no loan does
+func (r *GatewayRepository) Create(ctx context.Context, payment *models.GatewayPayment) error {
+	var owner partition
+	if err := conn(ctx, r.db).Model(&models.Loan{}).Select("tenant_id", "branch_id").
+		Where("virtual_account = ?", payment.VirtualAccount).
+		Order("id DESC").Limit(1).Find(&owner).Error; err != nil {
+		return err
+	}
+	payment.TenantID = owner.TenantID
+	return conn(ctx, r.db).Create(payment).Error
+}
+
+// Update updates a gateway notification
+func (r *GatewayRepository) Update(ctx context.Context, payment *models.GatewayPayment) error {
+	return conn(ctx, r.db).Save(payment).Error
+}
+
+// GetByGatewayTransactionID retrieves the notification for a gateway transaction
+func (r *GatewayRepository) GetByGatewayTransactionID(ctx context.Context, transactionID string) (*models.GatewayPayment, error) {
+	var payment models.GatewayPayment
+	if err := conn(ctx, r.db).Where("gateway_transaction_id = ?", transactionID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("gateway payment not found")
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetForUpdate retrieves a notification and locks its row until the
+// transaction carried by ctx ends, so only one delivery processes it
+func (r *GatewayRepository) GetForUpdate(ctx context.Context, id uint) (*models.GatewayPayment, error) {
+	var payment models.GatewayPayment
+	if err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("gateway payment not found")
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// List retrieves gateway notifications, newest first, optionally filtered by status
+func (r *GatewayRepository) List(ctx context.Context, status string) ([]models.GatewayPayment, error) {
+	var payments []models.GatewayPayment
+	query := conn(ctx, r.db).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GroupRepository handles database operations for borrower groups
+type GroupRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupRepository creates a new group repository instance
+func NewGroupRepository(db *gorm.DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+// Create creates a new group
+func (r *GroupRepository) Create(ctx context.Context, group *models.Group) error {
+	if err := checkBranch(conn(ctx, r.db), group.BranchID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(group).Error
+}
+
+// GetByID retrieves a group by its ID together with its current members
+func (r *GroupRepository) GetByID(ctx context.Context, id uint) (*models.Group, error) {
+	var group models.Group
+	if err := conn(ctx, r.db).Preload("Members", "left_at IS NULL").
+		Preload("Members.Borrower").
+		First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("group not found")
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// List retrieves groups, optionally filtered by region
+func (r *GroupRepository) List(ctx context.Context, region string) ([]models.Group, error) {
+	var groups []models.Group
+	query := conn(ctx, r.db).Order("name")
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	if err := query.Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Update updates a group record
+func (r *GroupRepository) Update(ctx context.Context, group *models.Group) error {
+	return conn(ctx, r.db).Omit("Members").Save(group).Error
+}
+
+// AddMember adds a borrower to a group
+func (r *GroupRepository) AddMember(ctx context.Context, member *models.GroupMember) error {
+	return conn(ctx, r.db).Create(member).Error
+}
+
+// UpdateMember updates a membership record
+func (r *GroupRepository) UpdateMember(ctx context.Context, member *models.GroupMember) error {
+	return conn(ctx, r.db).Omit("Borrower").Save(member).Error
+}
+
+// GetActiveMembership retrieves the borrower's current group membership
+func (r *GroupRepository) GetActiveMembership(ctx context.Context, borrowerID uint) (*models.GroupMember, error) {
+	var member models.GroupMember
+	if err := conn(ctx, r.db).Where("borrower_id = ? AND left_at IS NULL", borrowerID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("borrower is not a member of any group")
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetLoans retrieves the group's loans in any of the given statuses
+func (r *GroupRepository) GetLoans(ctx context.Context, groupID uint, statuses ...string) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := conn(ctx, r.db).Where("group_id = ? AND status IN ?", groupID, statuses).
+		Order("borrower_id, id").
+		Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// GetInstallments retrieves the current-schedule installments of the given loans
+func (r *GroupRepository) GetInstallments(ctx context.Context, loanIDs []uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if len(loanIDs) == 0 {
+		return payments, nil
+	}
+	if err := conn(ctx, r.db).Where("loan_id IN ? AND superseded_in_version = ?", loanIDs, 0).
+		Order("due_date, loan_id").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
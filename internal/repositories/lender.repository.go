@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LenderRepository handles database operations for lenders, their fundings and payouts
+type LenderRepository struct {
+	db *gorm.DB
+}
+
+// NewLenderRepository creates a new lender repository instance
+func NewLenderRepository(db *gorm.DB) *LenderRepository {
+	return &LenderRepository{db: db}
+}
+
+// Create creates a new lender
+func (r *LenderRepository) Create(ctx context.Context, lender *models.Lender) error {
+	return conn(ctx, r.db).Create(lender).Error
+}
+
+// GetByID retrieves a lender by its ID
+func (r *LenderRepository) GetByID(ctx context.Context, id uint) (*models.Lender, error) {
+	var lender models.Lender
+	if err := conn(ctx, r.db).First(&lender, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("lender not found")
+		}
+		return nil, err
+	}
+	return &lender, nil
+}
+
+// List retrieves all lenders
+func (r *LenderRepository) List(ctx context.Context) ([]models.Lender, error) {
+	var lenders []models.Lender
+	if err := conn(ctx, r.db).Order("name").Find(&lenders).Error; err != nil {
+		return nil, err
+	}
+	return lenders, nil
+}
+
+// CreateFunding creates a new funding of a loan in the tenant and branch of the loan
+func (r *LenderRepository) CreateFunding(ctx context.Context, funding *models.Funding) error {
+	owner, err := partitionOf(conn(ctx, r.db), &models.Loan{}, funding.LoanID, "loan not found")
+	if err != nil {
+		return err
+	}
+	funding.TenantID = owner.TenantID
+	funding.BranchID = owner.BranchID
+	return conn(ctx, r.db).Omit("Loan").Create(funding).Error
+}
+
+// GetFundingsByLoanID retrieves the fundings of a loan
+func (r *LenderRepository) GetFundingsByLoanID(ctx context.Context, loanID uint) ([]models.Funding, error) {
+	var fundings []models.Funding
+	if err := conn(ctx, r.db).Where("loan_id = ?", loanID).Order("id").Find(&fundings).Error; err != nil {
+		return nil, err
+	}
+	return fundings, nil
+}
+
+// GetFundingsByLenderID retrieves a lender's fundings with their loans
+func (r *LenderRepository) GetFundingsByLenderID(ctx context.Context, lenderID uint) ([]models.Funding, error) {
+	var fundings []models.Funding
+	if err := conn(ctx, r.db).Preload("Loan").
+		Where("lender_id = ?", lenderID).
+		Order("funded_at").
+		Find(&fundings).Error; err != nil {
+		return nil, err
+	}
+	return fundings, nil
+}
+
+// GetPayoutsByLenderID retrieves the payouts credited to a lender
+func (r *LenderRepository) GetPayoutsByLenderID(ctx context.Context, lenderID uint) ([]models.LenderPayout, error) {
+	var payouts []models.LenderPayout
+	if err := conn(ctx, r.db).Where("lender_id = ?", lenderID).Order("paid_at, id").Find(&payouts).Error; err != nil {
+		return nil, err
+	}
+	return payouts, nil
+}
+
+// GetPayoutsByTransactionID retrieves the payouts credited from a payment transaction
+func (r *LenderRepository) GetPayoutsByTransactionID(ctx context.Context, transactionID uint) ([]models.LenderPayout, error) {
+	var payouts []models.LenderPayout
+	if err := conn(ctx, r.db).Where("transaction_id = ?", transactionID).Order("id").Find(&payouts).Error; err != nil {
+		return nil, err
+	}
+	return payouts, nil
+}
+
+// GetPendingInstallments retrieves the unpaid current-schedule installments of the given loans
+func (r *LenderRepository) GetPendingInstallments(ctx context.Context, loanIDs []uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if len(loanIDs) == 0 {
+		return payments, nil
+	}
+	if err := conn(ctx, r.db).Where("loan_id IN ? AND status = ? AND superseded_in_version = ?", loanIDs, "pending", 0).
+		Order("due_date, loan_id").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -1,117 +1,154 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"AmarthaExample1/internal/dto"
 	"AmarthaExample1/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LoanRepository handles database operations for loans
 type LoanRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	calendar *CalendarRepository
 }
 
 // NewLoanRepository creates a new loan repository instance
 func NewLoanRepository(db *gorm.DB) *LoanRepository {
-	return &LoanRepository{db: db}
+	return &LoanRepository{db: db, calendar: NewCalendarRepository(db)}
 }
 
-// Create creates a new loan and its payment schedule
-func (r *LoanRepository) Create(loan *models.Loan) error {
-	tx := r.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+// Create creates a new loan, its fee charges and its payment schedule
+func (r *LoanRepository) Create(ctx context.Context, loan *models.Loan, schedule []models.Payment, charges []models.LoanCharge) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return createLoan(tx, loan, schedule, charges)
+	})
+}
+
+// createLoan creates a loan with its charges and schedule inside a transaction
+func createLoan(tx *gorm.DB, loan *models.Loan, schedule []models.Payment, charges []models.LoanCharge) error {
+	// A loan is booked in its borrower's tenant and branch
+	owner, err := partitionOf(tx, &models.Borrower{}, loan.BorrowerID, "borrower not found")
+	if err != nil {
+		return err
+	}
+	loan.TenantID = owner.TenantID
+	loan.BranchID = owner.BranchID
 
 	if err := tx.Create(loan).Error; err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	// Create payment schedule
-	for i := 0; i < loan.TotalWeeks; i++ {
-		dueDate := loan.StartDate.AddDate(0, 0, 7*i)
-		payment := models.Payment{
-			LoanID:    loan.ID,
-			Amount:    loan.WeeklyPayment,
-			WeekNum:   i + 1,
-			DueDate:   dueDate,
-			Status:    "pending",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+	for i := range charges {
+		charges[i].TenantID = loan.TenantID
+		charges[i].BranchID = loan.BranchID
+		charges[i].LoanID = loan.ID
+		if err := tx.Create(&charges[i]).Error; err != nil {
+			return err
 		}
+	}
 
-		if err := tx.Create(&payment).Error; err != nil {
-			tx.Rollback()
+	for i := range schedule {
+		payment := &schedule[i]
+		payment.TenantID = loan.TenantID
+		payment.BranchID = loan.BranchID
+		payment.LoanID = loan.ID
+		payment.ScheduleVersion = loan.ScheduleVersion
+		if err := tx.Create(payment).Error; err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit().Error
+	return recordEvent(tx, loan, dto.EventLoanCreated, dto.LoanCreatedEventVersion, loan.CreatedAt, loanCreatedEvent(loan))
 }
 
 // GetByID retrieves a loan by its ID
-func (r *LoanRepository) GetByID(id uint) (*models.Loan, error) {
+func (r *LoanRepository) GetByID(ctx context.Context, id uint) (*models.Loan, error) {
 	var loan models.Loan
-	if err := r.db.Preload("Payments").First(&loan, id).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Payments", "superseded_in_version = ?", 0).First(&loan, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("loan not found")
+			return nil, notFound("loan not found")
 		}
 		return nil, err
 	}
 	return &loan, nil
 }
 
-// GetPaymentsByLoanID retrieves all payments for a loan
-func (r *LoanRepository) GetPaymentsByLoanID(loanID uint) ([]models.Payment, error) {
+// GetForUpdate retrieves a loan, without its schedule, and locks its row until
+// the transaction carried by ctx ends, so changes that depend on the loan's
+// other records are made one at a time
+func (r *LoanRepository) GetForUpdate(ctx context.Context, id uint) (*models.Loan, error) {
+	var loan models.Loan
+	if err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&loan, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("loan not found")
+		}
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// GetPaymentsByLoanID retrieves all payments in the loan's current schedule
+func (r *LoanRepository) GetPaymentsByLoanID(ctx context.Context, loanID uint) ([]models.Payment, error) {
 	var payments []models.Payment
-	if err := r.db.Where("loan_id = ?", loanID).Order("week_num").Find(&payments).Error; err != nil {
+	if err := conn(ctx, r.db).Where("loan_id = ? AND superseded_in_version = ?", loanID, 0).Order("week_num").Find(&payments).Error; err != nil {
 		return nil, err
 	}
 	return payments, nil
 }
 
 // UpdatePayment updates a payment record
-func (r *LoanRepository) UpdatePayment(payment *models.Payment) error {
-	return r.db.Save(payment).Error
+func (r *LoanRepository) UpdatePayment(ctx context.Context, payment *models.Payment) error {
+	return conn(ctx, r.db).Save(payment).Error
 }
 
-// GetOutstandingAmount calculates the outstanding amount for a loan
-func (r *LoanRepository) GetOutstandingAmount(loanID uint) (float64, error) {
+// GetOutstandingAmount calculates the outstanding amount for a loan: what its
+// unpaid installments come to, less the money held in its suspense balance
+func (r *LoanRepository) GetOutstandingAmount(ctx context.Context, loanID uint) (float64, error) {
 	var totalPaid float64
-	if err := r.db.Model(&models.Payment{}).
-		Where("loan_id = ? AND status = ?", loanID, "paid").
+	if err := conn(ctx, r.db).Model(&models.Payment{}).
+		Where("loan_id = ? AND status = ? AND superseded_in_version = ?", loanID, "paid", 0).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&totalPaid).Error; err != nil {
 		return 0, err
 	}
 
 	var loan models.Loan
-	if err := r.db.First(&loan, loanID).Error; err != nil {
+	if err := conn(ctx, r.db).First(&loan, loanID).Error; err != nil {
 		return 0, err
 	}
 
-	return loan.TotalAmount - totalPaid, nil
+	return loan.TotalAmount - totalPaid - loan.SuspenseAmount, nil
 }
 
 // GetMissedPaymentsCount returns the count of consecutive missed payments
-func (r *LoanRepository) GetMissedPaymentsCount(loanID uint) (int, error) {
+func (r *LoanRepository) GetMissedPaymentsCount(ctx context.Context, loanID uint) (int, error) {
 	var payments []models.Payment
-	if err := r.db.Where("loan_id = ?", loanID).Order("week_num DESC").Find(&payments).Error; err != nil {
+	if err := conn(ctx, r.db).Where("loan_id = ? AND superseded_in_version = ?", loanID, 0).Order("week_num DESC").Find(&payments).Error; err != nil {
 		return 0, err
 	}
 
 	consecutiveMissed := 0
 	currentTime := time.Now()
 
+	calendar, err := r.loadLoanCalendar(ctx, loanID, payments)
+	if err != nil {
+		return 0, err
+	}
+
 	for _, payment := range payments {
-		if payment.Status == "pending" && payment.DueDate.Before(currentTime) {
+		// Installments held by a payment holiday are not overdue until it ends
+		if payment.Status == "pending" && payment.HeldUntil != nil && currentTime.Before(*payment.HeldUntil) {
+			continue
+		}
+
+		if payment.Status == "pending" && calendar.IsOverdue(payment.DueDate, currentTime) {
 			consecutiveMissed++
 		} else if payment.Status == "paid" {
 			break
@@ -122,19 +159,41 @@ func (r *LoanRepository) GetMissedPaymentsCount(loanID uint) (int, error) {
 }
 
 // GetLoanSchedule returns the complete loan schedule with payment status
-func (r *LoanRepository) GetLoanSchedule(loanID uint) ([]dto.ScheduleItemDTO, error) {
+func (r *LoanRepository) GetLoanSchedule(ctx context.Context, loanID uint) ([]dto.ScheduleItemDTO, error) {
 	var payments []models.Payment
-	if err := r.db.Where("loan_id = ?", loanID).Order("week_num").Find(&payments).Error; err != nil {
+	if err := conn(ctx, r.db).Where("loan_id = ? AND superseded_in_version = ?", loanID, 0).Order("week_num").Find(&payments).Error; err != nil {
 		return nil, err
 	}
 
+	return toScheduleItems(payments), nil
+}
+
+// GetLoanScheduleVersion returns the loan schedule as it stood at the given
+// version, including installments that a later restructure superseded
+func (r *LoanRepository) GetLoanScheduleVersion(ctx context.Context, loanID uint, version int) ([]dto.ScheduleItemDTO, error) {
+	var payments []models.Payment
+	if err := conn(ctx, r.db).Where("loan_id = ? AND schedule_version <= ?", loanID, version).
+		Where("superseded_in_version = ? OR superseded_in_version > ?", 0, version).
+		Order("week_num, schedule_version").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	return toScheduleItems(payments), nil
+}
+
+// toScheduleItems maps installments to schedule items
+func toScheduleItems(payments []models.Payment) []dto.ScheduleItemDTO {
 	var schedule []dto.ScheduleItemDTO
 	for _, payment := range payments {
 		scheduleItem := dto.ScheduleItemDTO{
-			WeekNum:   payment.WeekNum,
-			DueDate:   payment.DueDate,
-			Amount:    payment.Amount,
-			Status:    payment.Status,
+			WeekNum:         payment.WeekNum,
+			DueDate:         payment.DueDate,
+			Amount:          payment.Amount,
+			FeeAmount:       payment.FeeAmount,
+			Status:          payment.Status,
+			ScheduleVersion: payment.ScheduleVersion,
+			SupersededIn:    payment.SupersededInVersion,
 		}
 
 		if payment.PaidDate != nil {
@@ -144,10 +203,475 @@ func (r *LoanRepository) GetLoanSchedule(loanID uint) ([]dto.ScheduleItemDTO, er
 		schedule = append(schedule, scheduleItem)
 	}
 
-	return schedule, nil
+	return schedule
 }
 
 // UpdateLoan updates a loan record
-func (r *LoanRepository) UpdateLoan(loan *models.Loan) error {
-	return r.db.Save(loan).Error
+func (r *LoanRepository) UpdateLoan(ctx context.Context, loan *models.Loan) error {
+	return conn(ctx, r.db).Save(loan).Error
+}
+
+// GetByStatus retrieves all loans in any of the given statuses
+func (r *LoanRepository) GetByStatus(ctx context.Context, statuses ...string) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := conn(ctx, r.db).Where("status IN ?", statuses).Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// payingStatuses are the statuses of loans that money paid in is matched to.
+// Money for a written-off loan is booked as a recovery
+var payingStatuses = []string{"active", "defaulted", "written_off"}
+
+// FindByVirtualAccount retrieves the active, defaulted or written-off loans
+// paid into a bank virtual account. The account of a refinanced loan keeps
+// working: it finds the loan that took the refinanced one over
+func (r *LoanRepository) FindByVirtualAccount(ctx context.Context, account string) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := conn(ctx, r.db).Where("virtual_account = ? AND status IN ?", account, append([]string{"refinanced"}, payingStatuses...)).
+		Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return r.successors(ctx, loans)
+}
+
+// successors replaces each refinanced loan by the loan that refinanced it,
+// following top-ups of top-ups, and leaves out the loans that no longer take
+// money, such as a successor already repaid
+func (r *LoanRepository) successors(ctx context.Context, loans []models.Loan) ([]models.Loan, error) {
+	found := make([]models.Loan, 0, len(loans))
+	for _, loan := range loans {
+		seen := map[uint]bool{loan.ID: true}
+		for loan.Status == "refinanced" && loan.RefinancedByID != nil && !seen[*loan.RefinancedByID] {
+			seen[*loan.RefinancedByID] = true
+			var next models.Loan
+			if err := conn(ctx, r.db).First(&next, *loan.RefinancedByID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					break
+				}
+				return nil, err
+			}
+			loan = next
+		}
+		for _, status := range payingStatuses {
+			if loan.Status == status {
+				found = append(found, loan)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// GetCharges retrieves the fee charges of a loan
+func (r *LoanRepository) GetCharges(ctx context.Context, loanID uint) ([]models.LoanCharge, error) {
+	var charges []models.LoanCharge
+	if err := conn(ctx, r.db).Where("loan_id = ?", loanID).Order("id").Find(&charges).Error; err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// GetTransactions retrieves the payment transactions of a loan in the order they were received
+func (r *LoanRepository) GetTransactions(ctx context.Context, loanID uint) ([]models.PaymentTransaction, error) {
+	var transactions []models.PaymentTransaction
+	if err := conn(ctx, r.db).Where("loan_id = ?", loanID).Order("received_at, id").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetSettledInstallments retrieves the installments a payment transaction settled
+func (r *LoanRepository) GetSettledInstallments(ctx context.Context, transactionID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := conn(ctx, r.db).Where("transaction_id = ?", transactionID).Order("week_num").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// VirtualAccountExists reports whether a virtual account number is assigned to any loan
+func (r *LoanRepository) VirtualAccountExists(ctx context.Context, account string) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Unscoped().Model(&models.Loan{}).
+		Where("virtual_account = ?", account).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// HasPaidInstallments reports whether any installment of a loan has been paid,
+// including installments of schedules replaced since
+func (r *LoanRepository) HasPaidInstallments(ctx context.Context, loanID uint) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.Payment{}).
+		Where("loan_id = ? AND status = ?", loanID, "paid").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindByReference retrieves the active, defaulted or written-off loans
+// carrying a payment reference. Like a virtual account, the reference of a
+// refinanced loan finds the loan that took it over
+func (r *LoanRepository) FindByReference(ctx context.Context, reference string) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := conn(ctx, r.db).Where("reference = ? AND status IN ?", reference, append([]string{"refinanced"}, payingStatuses...)).
+		Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return r.successors(ctx, loans)
+}
+
+// GetByBorrower retrieves every loan of a borrower with its current schedule, oldest first
+func (r *LoanRepository) GetByBorrower(ctx context.Context, borrowerID uint) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := conn(ctx, r.db).Preload("Payments", "superseded_in_version = ?", 0).
+		Where("borrower_id = ?", borrowerID).
+		Order("id").
+		Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// GetByBorrowerRegion retrieves the loans in the given statuses whose borrower belongs to a region
+func (r *LoanRepository) GetByBorrowerRegion(ctx context.Context, region string, statuses ...string) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := conn(ctx, r.db).Joins("JOIN borrowers ON borrowers.id = loans.borrower_id").
+		Where("borrowers.region = ? AND loans.status IN ?", region, statuses).
+		Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// GetDaysPastDue returns the number of days since the oldest overdue installment fell due
+func (r *LoanRepository) GetDaysPastDue(ctx context.Context, loanID uint) (int, error) {
+	var payments []models.Payment
+	now := time.Now()
+	if err := conn(ctx, r.db).Where("loan_id = ? AND status = ? AND superseded_in_version = ? AND due_date < ?", loanID, "pending", 0, now).
+		Where("held_until IS NULL OR held_until <= ?", now).
+		Order("due_date").
+		Find(&payments).Error; err != nil {
+		return 0, err
+	}
+
+	calendar, err := r.loadLoanCalendar(ctx, loanID, payments)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, payment := range payments {
+		if calendar.IsOverdue(payment.DueDate, now) {
+			return int(now.Sub(payment.DueDate).Hours() / 24), nil
+		}
+	}
+
+	return 0, nil
+}
+
+// GetNewlyMissed returns the installments of the loan's current schedule that
+// have become overdue since the last arrears evaluation, oldest first
+func (r *LoanRepository) GetNewlyMissed(ctx context.Context, loanID uint, now time.Time) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := conn(ctx, r.db).
+		Where("loan_id = ? AND status = ? AND superseded_in_version = ? AND missed_at IS NULL AND due_date < ?", loanID, "pending", 0, now).
+		Where("held_until IS NULL OR held_until <= ?", now).
+		Order("due_date").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	if len(payments) == 0 {
+		return nil, nil
+	}
+
+	calendar, err := r.loadLoanCalendar(ctx, loanID, payments)
+	if err != nil {
+		return nil, err
+	}
+
+	missed := payments[:0]
+	for _, payment := range payments {
+		if calendar.IsOverdue(payment.DueDate, now) {
+			missed = append(missed, payment)
+		}
+	}
+	return missed, nil
+}
+
+// MarkMissed records that the installments were found overdue and writes an
+// installment.missed event for each, in one database transaction
+func (r *LoanRepository) MarkMissed(ctx context.Context, loan *models.Loan, installments []models.Payment, missedAt time.Time) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range installments {
+			payment := &installments[i]
+			if err := tx.Model(payment).Update("missed_at", missedAt).Error; err != nil {
+				return err
+			}
+			payment.MissedAt = &missedAt
+
+			event := dto.InstallmentMissedEvent{
+				LoanID:      loan.ID,
+				BorrowerID:  loan.BorrowerID,
+				GroupID:     loan.GroupID,
+				Installment: eventInstallment(payment),
+				MissedAt:    missedAt,
+			}
+			if err := recordEvent(tx, loan, dto.EventInstallmentMissed, dto.InstallmentMissedEventVersion, missedAt, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MarkDelinquent flags the loan delinquent and writes the loan.delinquent
+// event, in one database transaction
+func (r *LoanRepository) MarkDelinquent(ctx context.Context, loan *models.Loan, event *dto.LoanDelinquentEvent) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(loan).Update("delinquent_since", event.DelinquentSince).Error; err != nil {
+			return err
+		}
+		loan.DelinquentSince = &event.DelinquentSince
+
+		return recordEvent(tx, loan, dto.EventLoanDelinquent, dto.LoanDelinquentEventVersion, event.DelinquentSince, event)
+	})
+}
+
+// ClearDelinquent clears the delinquent flag of a loan that has caught up
+func (r *LoanRepository) ClearDelinquent(ctx context.Context, loan *models.Loan) error {
+	if err := conn(ctx, r.db).Model(loan).Update("delinquent_since", nil).Error; err != nil {
+		return err
+	}
+	loan.DelinquentSince = nil
+	return nil
+}
+
+// loadLoanCalendar loads the business calendar of the loan's borrower region
+// covering the given installments. Loans whose product keeps due dates as
+// scheduled, and loans without a product, get a calendar that keeps them
+func (r *LoanRepository) loadLoanCalendar(ctx context.Context, loanID uint, payments []models.Payment) (*BusinessCalendar, error) {
+	var terms struct {
+		TenantID    uint
+		Region      string
+		DueDateRule *string
+	}
+	// Read through the loan so that the tenant scope applies
+	if err := conn(ctx, r.db).Model(&models.Loan{}).
+		Select("loans.tenant_id, borrowers.region, products.due_date_rule").
+		Joins("JOIN borrowers ON borrowers.id = loans.borrower_id").
+		Joins("LEFT JOIN products ON products.id = loans.product_id").
+		Where("loans.id = ?", loanID).
+		Scan(&terms).Error; err != nil {
+		return nil, err
+	}
+	if terms.DueDateRule == nil || *terms.DueDateRule == "keep" {
+		return &BusinessCalendar{nominal: true}, nil
+	}
+
+	from, to := time.Now(), time.Now()
+	for _, payment := range payments {
+		if payment.DueDate.Before(from) {
+			from = payment.DueDate
+		}
+		if payment.DueDate.After(to) {
+			to = payment.DueDate
+		}
+	}
+
+	// Leave room for a due date to roll over a long run of holidays
+	return r.calendar.Load(ctx, terms.TenantID, terms.Region, from, to.AddDate(0, 0, 31))
+}
+
+// Settlement is a payment transaction together with the installments it pays off
+type Settlement struct {
+	Loan         *models.Loan
+	Transaction  *models.PaymentTransaction
+	Installments []*models.Payment
+	Payouts      []*models.LenderPayout // lender shares of the installments
+	Charges      []*models.LoanCharge   // fee charges paid by the installments
+	CompleteLoan bool
+}
+
+// Settle records the transaction, marks its installments paid and the fee
+// charges they cover, credits the lender payouts and completes the loan when
+// asked to, all in one database transaction. The settlement is worked out
+// from the loan as read by GetForUpdate, in the transaction carried by ctx,
+// so that no other posting to the loan changes it in between
+func (r *LoanRepository) Settle(ctx context.Context, settlement *Settlement) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return settle(tx, settlement)
+	})
+}
+
+// settle writes a settlement inside a transaction
+func settle(tx *gorm.DB, settlement *Settlement) error {
+	settlement.Transaction.TenantID = settlement.Loan.TenantID
+	settlement.Transaction.BranchID = settlement.Loan.BranchID
+	if err := tx.Create(settlement.Transaction).Error; err != nil {
+		return err
+	}
+
+	if err := moveSuspense(tx, settlement.Loan, settlement.Transaction.UnappliedAmount-settlement.Transaction.SuspenseApplied); err != nil {
+		return err
+	}
+
+	for _, payment := range settlement.Installments {
+		payment.TransactionID = &settlement.Transaction.ID
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, charge := range settlement.Charges {
+		if err := tx.Save(charge).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, payout := range settlement.Payouts {
+		payout.TenantID = settlement.Loan.TenantID
+		payout.BranchID = settlement.Loan.BranchID
+		payout.TransactionID = settlement.Transaction.ID
+		if err := tx.Create(payout).Error; err != nil {
+			return err
+		}
+	}
+
+	loan, transaction := settlement.Loan, settlement.Transaction
+	if err := recordEvent(tx, loan, dto.EventPaymentReceived, dto.PaymentReceivedEventVersion, transaction.ReceivedAt, paymentReceivedEvent(settlement)); err != nil {
+		return err
+	}
+
+	if settlement.CompleteLoan {
+		loan.Status = "completed"
+		loan.DelinquentSince = nil
+		loan.UpdatedAt = transaction.ReceivedAt
+		if err := tx.Omit("Payments").Save(loan).Error; err != nil {
+			return err
+		}
+		completed := dto.LoanCompletedEvent{
+			LoanID:            loan.ID,
+			BorrowerID:        loan.BorrowerID,
+			TotalAmount:       loan.TotalAmount,
+			LastTransactionID: transaction.ID,
+			CompletedAt:       transaction.ReceivedAt,
+		}
+		if err := recordEvent(tx, loan, dto.EventLoanCompleted, dto.LoanCompletedEventVersion, transaction.ReceivedAt, completed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveSuspense adds to, or takes from, a loan's suspense balance. The change
+// is applied in the database so that concurrent postings do not overwrite it
+func moveSuspense(tx *gorm.DB, loan *models.Loan, delta float64) error {
+	if delta == 0 {
+		return nil
+	}
+	if err := tx.Model(loan).UpdateColumn("suspense_amount", gorm.Expr("suspense_amount + ?", delta)).Error; err != nil {
+		return err
+	}
+	loan.SuspenseAmount += delta
+	return nil
+}
+
+// Refinancing is a top-up loan together with the settlement paying off the loan it replaces
+type Refinancing struct {
+	Loan     *models.Loan // the top-up loan
+	Schedule []models.Payment
+	Charges  []models.LoanCharge
+	Payoff   *Settlement // settles the refinanced loan
+}
+
+// Refinance books the top-up loan, settles the old loan's payoff from it,
+// links the two and closes the old loan as refinanced, all in one database
+// transaction
+func (r *LoanRepository) Refinance(ctx context.Context, refinancing *Refinancing) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		old := refinancing.Payoff.Loan
+		refinancing.Loan.RefinancesID = &old.ID
+		if err := createLoan(tx, refinancing.Loan, refinancing.Schedule, refinancing.Charges); err != nil {
+			return err
+		}
+
+		refinancing.Payoff.Transaction.Reference = fmt.Sprintf("loan %d", refinancing.Loan.ID)
+		refinancing.Payoff.CompleteLoan = false
+		if err := settle(tx, refinancing.Payoff); err != nil {
+			return err
+		}
+
+		old.Status = "refinanced"
+		old.RefinancedByID = &refinancing.Loan.ID
+		old.UpdatedAt = refinancing.Payoff.Transaction.ReceivedAt
+		return tx.Omit("Payments").Save(old).Error
+	})
+}
+
+// Reversal is a reversed payment transaction together with the installments
+// it reopens, the fee charges it no longer pays and the offsetting lender payouts
+type Reversal struct {
+	Loan         *models.Loan
+	Transaction  *models.PaymentTransaction
+	Installments []models.Payment
+	Charges      []*models.LoanCharge
+	Payouts      []*models.LenderPayout
+	ReopenLoan   bool
+}
+
+// Reverse writes a payment reversal in one database transaction
+func (r *LoanRepository) Reverse(ctx context.Context, reversal *Reversal) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(reversal.Transaction).Error; err != nil {
+			return err
+		}
+
+		for i := range reversal.Installments {
+			payment := &reversal.Installments[i]
+			if err := tx.Model(payment).Updates(map[string]interface{}{
+				"status":         "pending",
+				"paid_date":      nil,
+				"payment_date":   nil,
+				"transaction_id": nil,
+				"updated_at":     reversal.Transaction.UpdatedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, charge := range reversal.Charges {
+			if err := tx.Save(charge).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, payout := range reversal.Payouts {
+			payout.TenantID = reversal.Loan.TenantID
+			payout.BranchID = reversal.Loan.BranchID
+			if err := tx.Create(payout).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := moveSuspense(tx, reversal.Loan, reversal.Transaction.SuspenseApplied-reversal.Transaction.UnappliedAmount); err != nil {
+			return err
+		}
+
+		if reversal.ReopenLoan {
+			reversal.Loan.Status = "active"
+			reversal.Loan.UpdatedAt = reversal.Transaction.UpdatedAt
+			if err := tx.Omit("Payments").Save(reversal.Loan).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/models"
+)
+
+// loansByID answers reads of one loan by ID from the given rows of id,
+// status and refinanced_by_id
+func loansByID(loans map[uint][]interface{}) dbtest.Answer {
+	return func(statement dbtest.Statement) dbtest.Rows {
+		rows := dbtest.Rows{Columns: []string{"id", "status", "refinanced_by_id"}}
+		for id, loan := range loans {
+			if statement.HasArg(id) {
+				rows.Values = append(rows.Values, loan)
+			}
+		}
+		return rows
+	}
+}
+
+func TestRefinancedAccountsFindTheirSuccessor(t *testing.T) {
+	first, second := uint(8), uint(9)
+	tests := []struct {
+		name  string
+		loans map[uint][]interface{}
+		want  []uint
+	}{
+		{"running loan", map[uint][]interface{}{
+			4: {int64(4), "active", nil},
+		}, []uint{4}},
+		{"topped up", map[uint][]interface{}{
+			4: {int64(4), "refinanced", &first},
+			8: {int64(8), "active", nil},
+		}, []uint{8}},
+		{"topped up twice", map[uint][]interface{}{
+			4: {int64(4), "refinanced", &first},
+			8: {int64(8), "refinanced", &second},
+			9: {int64(9), "defaulted", nil},
+		}, []uint{9}},
+		{"successor repaid", map[uint][]interface{}{
+			4: {int64(4), "refinanced", &first},
+			8: {int64(8), "paid", nil},
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, db := dbtest.Open(t)
+			repo := NewLoanRepository(conn)
+			db.Returning(dbtest.Row([]string{"id", "status", "refinanced_by_id"}, tt.loans[4]...), "virtual_account = ?")
+			db.Returning(dbtest.Row([]string{"id", "status", "refinanced_by_id"}, tt.loans[4]...), "reference = ?")
+			db.On(loansByID(tt.loans), "`loans`.`id` = ?")
+
+			finders := map[string]func() ([]models.Loan, error){
+				"virtual account": func() ([]models.Loan, error) {
+					return repo.FindByVirtualAccount(context.Background(), "8808000000000001")
+				},
+				"reference": func() ([]models.Loan, error) {
+					return repo.FindByReference(context.Background(), "REF-4")
+				},
+			}
+			for name, find := range finders {
+				loans, err := find()
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []uint
+				for _, loan := range loans {
+					got = append(got, loan.ID)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("%s found loans %v, want %v", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// NotificationRepository handles database operations for notification
+// templates, opt-outs and the send history
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository instance
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// CreateTemplate creates a new notification template
+func (r *NotificationRepository) CreateTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	return conn(ctx, r.db).Create(template).Error
+}
+
+// GetTemplate retrieves a notification template by its ID
+func (r *NotificationRepository) GetTemplate(ctx context.Context, id uint) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	if err := conn(ctx, r.db).First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("notification template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListTemplates retrieves the templates of a product, or the default
+// templates when productID is nil, in schedule order
+func (r *NotificationRepository) ListTemplates(ctx context.Context, productID *uint) ([]models.NotificationTemplate, error) {
+	query := conn(ctx, r.db)
+	if productID != nil {
+		query = query.Where("product_id = ?", *productID)
+	} else {
+		query = query.Where("product_id IS NULL")
+	}
+
+	var templates []models.NotificationTemplate
+	if err := query.Order("FIELD(stage, 'reminder', 'due', 'dunning'), " +
+		"CASE WHEN stage = 'reminder' THEN -offset_days ELSE offset_days END, channel").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetActiveTemplates retrieves every active template
+func (r *NotificationRepository) GetActiveTemplates(ctx context.Context) ([]models.NotificationTemplate, error) {
+	var templates []models.NotificationTemplate
+	if err := conn(ctx, r.db).Where("active = ?", true).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// TemplateExists reports whether another template already covers the same
+// product, stage, offset and channel
+func (r *NotificationRepository) TemplateExists(ctx context.Context, template *models.NotificationTemplate) (bool, error) {
+	query := conn(ctx, r.db).Model(&models.NotificationTemplate{}).
+		Where("stage = ? AND offset_days = ? AND channel = ? AND id <> ?", template.Stage, template.OffsetDays, template.Channel, template.ID)
+	if template.ProductID != nil {
+		query = query.Where("product_id = ?", *template.ProductID)
+	} else {
+		query = query.Where("product_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UpdateTemplate saves a notification template
+func (r *NotificationRepository) UpdateTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	return conn(ctx, r.db).Save(template).Error
+}
+
+// DeleteTemplate removes a notification template
+func (r *NotificationRepository) DeleteTemplate(ctx context.Context, template *models.NotificationTemplate) error {
+	return conn(ctx, r.db).Delete(template).Error
+}
+
+// CreateOptOut records a borrower's opt-out in the borrower's tenant and branch
+func (r *NotificationRepository) CreateOptOut(ctx context.Context, optOut *models.NotificationOptOut) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		owner, err := partitionOf(tx, &models.Borrower{}, optOut.BorrowerID, "borrower not found")
+		if err != nil {
+			return err
+		}
+		optOut.TenantID = owner.TenantID
+		optOut.BranchID = owner.BranchID
+		return tx.Create(optOut).Error
+	})
+}
+
+// GetOptOuts retrieves the opt-outs of the given borrowers
+func (r *NotificationRepository) GetOptOuts(ctx context.Context, borrowerIDs ...uint) ([]models.NotificationOptOut, error) {
+	var optOuts []models.NotificationOptOut
+	if len(borrowerIDs) == 0 {
+		return optOuts, nil
+	}
+	if err := conn(ctx, r.db).Where("borrower_id IN ?", borrowerIDs).Order("id").Find(&optOuts).Error; err != nil {
+		return nil, err
+	}
+	return optOuts, nil
+}
+
+// DeleteOptOut opts a borrower back in to a channel
+func (r *NotificationRepository) DeleteOptOut(ctx context.Context, borrowerID uint, channel string) error {
+	result := conn(ctx, r.db).Where("borrower_id = ? AND channel = ?", borrowerID, channel).Delete(&models.NotificationOptOut{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound("opt-out not found")
+	}
+	return nil
+}
+
+// ReminderTarget is an unpaid installment of an active loan with what is
+// needed to remind its borrower
+type ReminderTarget struct {
+	PaymentID      uint
+	TenantID       uint
+	BranchID       *uint
+	WeekNum        int
+	DueDate        time.Time
+	Amount         float64
+	MissedAt       *time.Time
+	LoanID         uint
+	ProductID      *uint
+	VirtualAccount *string
+	Reference      string
+	BorrowerID     uint
+	FirstName      string
+	LastName       string
+	Phone          string
+	Email          string
+}
+
+// GetReminderTargets retrieves the unpaid installments of active loans in
+// their current schedule that fall due from from up to to. Installments held
+// by a payment holiday are left out until it ends
+func (r *NotificationRepository) GetReminderTargets(ctx context.Context, from, to, now time.Time) ([]ReminderTarget, error) {
+	var targets []ReminderTarget
+	if err := conn(ctx, r.db).Model(&models.Payment{}).
+		Select("payments.id AS payment_id, payments.tenant_id, payments.branch_id, payments.week_num, "+
+			"payments.due_date, payments.amount, payments.missed_at, loans.id AS loan_id, loans.product_id, "+
+			"loans.virtual_account, loans.reference, borrowers.id AS borrower_id, borrowers.first_name, "+
+			"borrowers.last_name, borrowers.phone, borrowers.email").
+		Joins("JOIN loans ON loans.id = payments.loan_id AND loans.deleted_at IS NULL").
+		Joins("JOIN borrowers ON borrowers.id = loans.borrower_id AND borrowers.deleted_at IS NULL").
+		Where("payments.status = ? AND payments.superseded_in_version = ? AND loans.status = ?", "pending", 0, "active").
+		Where("payments.due_date >= ? AND payments.due_date < ?", from, to).
+		Where("payments.held_until IS NULL OR payments.held_until <= ?", now).
+		Order("payments.due_date, payments.id").
+		Scan(&targets).Error; err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// GetNotifications retrieves the send history of the given installments
+func (r *NotificationRepository) GetNotifications(ctx context.Context, paymentIDs []uint) ([]models.Notification, error) {
+	var notifications []models.Notification
+	if len(paymentIDs) == 0 {
+		return notifications, nil
+	}
+	if err := conn(ctx, r.db).Where("payment_id IN ?", paymentIDs).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// SaveNotification creates or updates a send history entry
+func (r *NotificationRepository) SaveNotification(ctx context.Context, notification *models.Notification) error {
+	return conn(ctx, r.db).Save(notification).Error
+}
+
+// NotificationFilter selects send history entries; empty fields match everything
+type NotificationFilter struct {
+	BorrowerID uint
+	LoanID     uint
+	Stage      string
+	Channel    string
+	Status     string
+	Limit      int
+}
+
+// ListNotifications retrieves send history entries matching the filter, newest first
+func (r *NotificationRepository) ListNotifications(ctx context.Context, filter NotificationFilter) ([]models.Notification, error) {
+	query := conn(ctx, r.db).Order("id DESC").Limit(filter.Limit)
+	if filter.BorrowerID != 0 {
+		query = query.Where("borrower_id = ?", filter.BorrowerID)
+	}
+	if filter.LoanID != 0 {
+		query = query.Where("loan_id = ?", filter.LoanID)
+	}
+	if filter.Stage != "" {
+		query = query.Where("stage = ?", filter.Stage)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var notifications []models.Notification
+	if err := query.Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
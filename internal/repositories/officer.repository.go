@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OfficerRepository handles database operations for field officers
+type OfficerRepository struct {
+	db *gorm.DB
+}
+
+// NewOfficerRepository creates a new officer repository instance
+func NewOfficerRepository(db *gorm.DB) *OfficerRepository {
+	return &OfficerRepository{db: db}
+}
+
+// Create creates a new officer
+func (r *OfficerRepository) Create(ctx context.Context, officer *models.Officer) error {
+	if err := checkBranch(conn(ctx, r.db), officer.BranchID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(officer).Error
+}
+
+// GetByID retrieves an officer by its ID
+func (r *OfficerRepository) GetByID(ctx context.Context, id uint) (*models.Officer, error) {
+	var officer models.Officer
+	if err := conn(ctx, r.db).First(&officer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("officer not found")
+		}
+		return nil, err
+	}
+	return &officer, nil
+}
+
+// List retrieves officers, optionally filtered by region
+func (r *OfficerRepository) List(ctx context.Context, region string) ([]models.Officer, error) {
+	var officers []models.Officer
+	query := conn(ctx, r.db).Order("name")
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	if err := query.Find(&officers).Error; err != nil {
+		return nil, err
+	}
+	return officers, nil
+}
+
+// GetGroups retrieves the active groups an officer is responsible for
+func (r *OfficerRepository) GetGroups(ctx context.Context, officerID uint) ([]models.Group, error) {
+	var groups []models.Group
+	if err := conn(ctx, r.db).Where("officer_id = ? AND status = ?", officerID, "active").
+		Order("name").
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
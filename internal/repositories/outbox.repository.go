@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository handles database operations for outbox events
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository instance
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// OutboxFilter selects outbox events; empty fields match everything
+type OutboxFilter struct {
+	Type        string
+	AggregateID string
+	Status      string // pending, published
+	Limit       int
+}
+
+// List retrieves outbox events matching the filter, newest first
+func (r *OutboxRepository) List(ctx context.Context, filter OutboxFilter) ([]models.OutboxEvent, error) {
+	query := conn(ctx, r.db).Order("id DESC").Limit(filter.Limit)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.AggregateID != "" {
+		query = query.Where("aggregate_id = ?", filter.AggregateID)
+	}
+	switch filter.Status {
+	case "pending":
+		query = query.Where("published_at IS NULL")
+	case "published":
+		query = query.Where("published_at IS NOT NULL")
+	}
+
+	var events []models.OutboxEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetByID retrieves an outbox event by its ID
+func (r *OutboxRepository) GetByID(ctx context.Context, id uint) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	if err := conn(ctx, r.db).First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("event not found")
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetPending retrieves up to limit unpublished events of every tenant, in the
+// order they were written
+func (r *OutboxRepository) GetPending(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	if err := r.db.WithContext(acrossTenants(ctx)).
+		Where("published_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MarkPublished records that an event was handed to the publisher
+func (r *OutboxRepository) MarkPublished(ctx context.Context, event *models.OutboxEvent, publishedAt time.Time) error {
+	return r.db.WithContext(acrossTenants(ctx)).Model(event).Updates(map[string]interface{}{
+		"published_at":    publishedAt,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      "",
+		"next_attempt_at": nil,
+	}).Error
+}
+
+// MarkFailed records a failed publish and when to try again
+func (r *OutboxRepository) MarkFailed(ctx context.Context, event *models.OutboxEvent, cause error, retryAt time.Time) error {
+	return r.db.WithContext(acrossTenants(ctx)).Model(event).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      cause.Error(),
+		"next_attempt_at": retryAt,
+	}).Error
+}
+
+// recordEvent writes a domain event about a loan to the outbox through tx, so
+// that it commits or rolls back with the change it describes
+func recordEvent(tx *gorm.DB, loan *models.Loan, eventType string, version int, occurredAt time.Time, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	id, err := newEventID()
+	if err != nil {
+		return err
+	}
+
+	envelope := dto.EventEnvelope{
+		ID:            id,
+		Type:          eventType,
+		Version:       version,
+		OccurredAt:    occurredAt,
+		TenantID:      loan.TenantID,
+		BranchID:      loan.BranchID,
+		AggregateType: "loan",
+		AggregateID:   strconv.FormatUint(uint64(loan.ID), 10),
+		Data:          body,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		EventID:       envelope.ID,
+		TenantID:      envelope.TenantID,
+		Type:          envelope.Type,
+		Version:       envelope.Version,
+		AggregateType: envelope.AggregateType,
+		AggregateID:   envelope.AggregateID,
+		Payload:       string(payload),
+		OccurredAt:    occurredAt,
+		CreatedAt:     time.Now(),
+	}).Error
+}
+
+// loanCreatedEvent is the data of the loan.created event of a new loan
+func loanCreatedEvent(loan *models.Loan) dto.LoanCreatedEvent {
+	event := dto.LoanCreatedEvent{
+		LoanID:          loan.ID,
+		BorrowerID:      loan.BorrowerID,
+		ProductID:       loan.ProductID,
+		GroupID:         loan.GroupID,
+		RefinancesID:    loan.RefinancesID,
+		Amount:          loan.Amount,
+		InterestRate:    loan.InterestRate,
+		TotalAmount:     loan.TotalAmount,
+		FeeAmount:       loan.FeeAmount,
+		DeductedFees:    loan.DeductedFees,
+		DisbursedAmount: loan.DisbursedAmount,
+		WeeklyPayment:   loan.WeeklyPayment,
+		TotalWeeks:      loan.TotalWeeks,
+		StartDate:       loan.StartDate,
+		EndDate:         loan.EndDate,
+		Reference:       loan.Reference,
+	}
+	if loan.VirtualAccount != nil {
+		event.VirtualAccount = *loan.VirtualAccount
+	}
+	return event
+}
+
+// paymentReceivedEvent is the data of the payment.received event of a settlement
+func paymentReceivedEvent(settlement *Settlement) dto.PaymentReceivedEvent {
+	transaction := settlement.Transaction
+	event := dto.PaymentReceivedEvent{
+		TransactionID:   transaction.ID,
+		LoanID:          settlement.Loan.ID,
+		BorrowerID:      settlement.Loan.BorrowerID,
+		Amount:          transaction.Amount,
+		AppliedAmount:   transaction.AppliedAmount,
+		FeeAmount:       transaction.FeeAmount,
+		UnappliedAmount: transaction.UnappliedAmount,
+		SuspenseApplied: transaction.SuspenseApplied,
+		Channel:         transaction.Channel,
+		Reference:       transaction.Reference,
+		ReceivedAt:      transaction.ReceivedAt,
+		Installments:    make([]dto.EventInstallment, 0, len(settlement.Installments)),
+	}
+	for _, payment := range settlement.Installments {
+		event.Installments = append(event.Installments, eventInstallment(payment))
+	}
+	return event
+}
+
+func eventInstallment(payment *models.Payment) dto.EventInstallment {
+	return dto.EventInstallment{
+		PaymentID: payment.ID,
+		WeekNum:   payment.WeekNum,
+		DueDate:   payment.DueDate,
+		Amount:    payment.Amount,
+	}
+}
+
+// newEventID returns a random (version 4) UUID
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32]), nil
+}
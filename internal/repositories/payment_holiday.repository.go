@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PaymentHolidayRepository handles database operations for payment holidays
+type PaymentHolidayRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentHolidayRepository creates a new payment holiday repository instance
+func NewPaymentHolidayRepository(db *gorm.DB) *PaymentHolidayRepository {
+	return &PaymentHolidayRepository{db: db}
+}
+
+// Create stores a payment holiday together with the shifted installments and loan terms
+func (r *PaymentHolidayRepository) Create(ctx context.Context, holiday *models.PaymentHoliday, loan *models.Loan, installments []models.Payment) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range installments {
+			if err := tx.Save(&installments[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit("Payments").Save(loan).Error; err != nil {
+			return err
+		}
+
+		holiday.TenantID = loan.TenantID
+		holiday.BranchID = loan.BranchID
+		return tx.Create(holiday).Error
+	})
+}
+
+// GetByLoanID retrieves all payment holidays applied to a loan
+func (r *PaymentHolidayRepository) GetByLoanID(ctx context.Context, loanID uint) ([]models.PaymentHoliday, error) {
+	var holidays []models.PaymentHoliday
+	if err := conn(ctx, r.db).Where("loan_id = ?", loanID).Order("start_date").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
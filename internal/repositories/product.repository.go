@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ProductRepository handles database operations for loan products
+type ProductRepository struct {
+	db *gorm.DB
+}
+
+// NewProductRepository creates a new product repository instance
+func NewProductRepository(db *gorm.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// Create stores a new product
+func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return conn(ctx, r.db).Create(product).Error
+}
+
+// GetByID retrieves a product by its ID
+func (r *ProductRepository) GetByID(ctx context.Context, id uint) (*models.Product, error) {
+	var product models.Product
+	if err := conn(ctx, r.db).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// List retrieves all products
+func (r *ProductRepository) List(ctx context.Context) ([]models.Product, error) {
+	var products []models.Product
+	if err := conn(ctx, r.db).Order("code").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Update updates a product record
+func (r *ProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return conn(ctx, r.db).Save(product).Error
+}
+
+// CreateFee adds a fee definition to a product
+func (r *ProductRepository) CreateFee(ctx context.Context, fee *models.ProductFee) error {
+	return conn(ctx, r.db).Create(fee).Error
+}
+
+// GetFees retrieves the fee definitions of a product
+func (r *ProductRepository) GetFees(ctx context.Context, productID uint) ([]models.ProductFee, error) {
+	var fees []models.ProductFee
+	if err := conn(ctx, r.db).Where("product_id = ?", productID).Order("id").Find(&fees).Error; err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+// DeleteFee removes a fee definition from a product. Loans already booked keep their charges
+func (r *ProductRepository) DeleteFee(ctx context.Context, productID, feeID uint) error {
+	result := conn(ctx, r.db).Where("product_id = ?", productID).Delete(&models.ProductFee{}, feeID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return notFound("product fee not found")
+	}
+	return nil
+}
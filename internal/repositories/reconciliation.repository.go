@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationRepository handles database operations for end-of-day reconciliation
+type ReconciliationRepository struct {
+	db *gorm.DB
+}
+
+// NewReconciliationRepository creates a new reconciliation repository instance
+func NewReconciliationRepository(db *gorm.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// GetStatementLines retrieves the statement credits with a value date in [from, to)
+func (r *ReconciliationRepository) GetStatementLines(ctx context.Context, from, to time.Time) ([]models.StatementLine, error) {
+	var lines []models.StatementLine
+	if err := conn(ctx, r.db).Where("value_date >= ? AND value_date < ?", from, to).
+		Order("id").
+		Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// GetGatewayPayments retrieves the gateway notifications paid in [from, to)
+func (r *ReconciliationRepository) GetGatewayPayments(ctx context.Context, from, to time.Time) ([]models.GatewayPayment, error) {
+	var payments []models.GatewayPayment
+	if err := conn(ctx, r.db).Where("paid_at >= ? AND paid_at < ?", from, to).
+		Order("id").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// GetTransactions retrieves the payment transactions received in [from, to)
+func (r *ReconciliationRepository) GetTransactions(ctx context.Context, from, to time.Time) ([]models.PaymentTransaction, error) {
+	var transactions []models.PaymentTransaction
+	if err := conn(ctx, r.db).Where("received_at >= ? AND received_at < ?", from, to).
+		Order("id").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetPaidInstallments retrieves installments paid in [from, to) together with
+// any installments settled by the given transactions, whatever their paid date
+func (r *ReconciliationRepository) GetPaidInstallments(ctx context.Context, from, to time.Time, transactionIDs []uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	query := conn(ctx, r.db).Where("status = ? AND paid_date >= ? AND paid_date < ?", "paid", from, to)
+	if len(transactionIDs) > 0 {
+		query = query.Or("transaction_id IN ?", transactionIDs)
+	}
+	if err := query.Order("id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// SaveRun stores a reconciliation run with its items in the tenant of ctx's
+// scope, or the default tenant, replacing that tenant's earlier run for the
+// same business date
+func (r *ReconciliationRepository) SaveRun(ctx context.Context, run *models.ReconciliationRun) error {
+	run.TenantID = models.DefaultTenantID
+	if scope, ok := TenantScopeFrom(ctx); ok {
+		run.TenantID = scope.TenantID
+	}
+	for i := range run.Items {
+		run.Items[i].TenantID = run.TenantID
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var previous []models.ReconciliationRun
+		if err := tx.Unscoped().Where("tenant_id = ? AND business_date = ?", run.TenantID, run.BusinessDate).
+			Find(&previous).Error; err != nil {
+			return err
+		}
+		for _, old := range previous {
+			if err := tx.Where("run_id = ?", old.ID).Delete(&models.ReconciliationItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&old).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(run).Error
+	})
+}
+
+// GetRunByDate retrieves the reconciliation run of a business date with its items
+func (r *ReconciliationRepository) GetRunByDate(ctx context.Context, date time.Time) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	if err := conn(ctx, r.db).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("type, id")
+	}).Where("business_date = ?", date).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("reconciliation run not found")
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns retrieves reconciliation runs between two business dates, newest first
+func (r *ReconciliationRepository) ListRuns(ctx context.Context, from, to time.Time) ([]models.ReconciliationRun, error) {
+	var runs []models.ReconciliationRun
+	query := conn(ctx, r.db).Order("business_date DESC")
+	if !from.IsZero() {
+		query = query.Where("business_date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("business_date <= ?", to)
+	}
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/models"
+)
+
+// runsTable answers reads of reconciliation_runs from the given rows, keeping
+// only those of the tenant a statement names, as MySQL would
+func runsTable(runs ...models.ReconciliationRun) dbtest.Answer {
+	return func(statement dbtest.Statement) dbtest.Rows {
+		rows := dbtest.Rows{Columns: []string{"id", "tenant_id", "business_date", "status"}}
+		for _, run := range runs {
+			if statement.Has("tenant_id") && !statement.HasArg(run.TenantID) {
+				continue
+			}
+			rows.Values = append(rows.Values, []interface{}{int64(run.ID), int64(run.TenantID), run.BusinessDate, run.Status})
+		}
+		return rows
+	}
+}
+
+func openTenantDB(t *testing.T) (*ReconciliationRepository, *dbtest.DB) {
+	t.Helper()
+	conn, db := dbtest.Open(t)
+	if err := RegisterTenantCallbacks(conn); err != nil {
+		t.Fatal(err)
+	}
+	return NewReconciliationRepository(conn), db
+}
+
+func TestReconciliationRunsStayInTheirTenant(t *testing.T) {
+	const tenantA, tenantB uint = 2, 3
+	date := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	runOfB := models.ReconciliationRun{ID: 7, TenantID: tenantB, BusinessDate: date, Status: "balanced"}
+	scopeA := WithTenantScope(context.Background(), TenantScope{TenantID: tenantA})
+
+	t.Run("tenant A cannot read tenant B's run", func(t *testing.T) {
+		repo, db := openTenantDB(t)
+		db.On(runsTable(runOfB), "FROM `reconciliation_runs`")
+
+		if _, err := repo.GetRunByDate(scopeA, date); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetRunByDate returned %v, want not found", err)
+		}
+		runs, err := repo.ListRuns(scopeA, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 0 {
+			t.Errorf("ListRuns returned %d run(s) of another tenant", len(runs))
+		}
+	})
+
+	t.Run("re-running a date leaves tenant B's run alone", func(t *testing.T) {
+		repo, db := openTenantDB(t)
+		db.On(runsTable(runOfB), "FROM `reconciliation_runs`")
+
+		run := &models.ReconciliationRun{
+			BusinessDate: date,
+			Status:       "mismatched",
+			Items:        []models.ReconciliationItem{{Type: "missing_posting", Description: "line 4"}},
+		}
+		if err := repo.SaveRun(scopeA, run); err != nil {
+			t.Fatal(err)
+		}
+
+		if deleted := db.Find("DELETE"); len(deleted) != 0 {
+			t.Errorf("saving tenant A's run deleted %v", deleted)
+		}
+		if run.TenantID != tenantA {
+			t.Errorf("run saved in tenant %d, want %d", run.TenantID, tenantA)
+		}
+		for _, item := range run.Items {
+			if item.TenantID != tenantA {
+				t.Errorf("item saved in tenant %d, want %d", item.TenantID, tenantA)
+			}
+		}
+		for _, insert := range db.Find("INSERT INTO") {
+			if insert.HasArg(tenantB) {
+				t.Errorf("insert %v carries tenant B", insert)
+			}
+		}
+	})
+
+	t.Run("re-running a date replaces the tenant's own run", func(t *testing.T) {
+		repo, db := openTenantDB(t)
+		runOfA := models.ReconciliationRun{ID: 5, TenantID: tenantA, BusinessDate: date, Status: "balanced"}
+		db.On(runsTable(runOfA, runOfB), "FROM `reconciliation_runs`")
+
+		if err := repo.SaveRun(scopeA, &models.ReconciliationRun{BusinessDate: date, Status: "balanced"}); err != nil {
+			t.Fatal(err)
+		}
+
+		deleted := db.Find("DELETE FROM `reconciliation_runs`")
+		if len(deleted) != 1 || !deleted[0].HasArg(runOfA.ID) || deleted[0].HasArg(runOfB.ID) {
+			t.Errorf("deleted %v, want only run %d", deleted, runOfA.ID)
+		}
+	})
+
+	t.Run("a run without a scope replaces only the default tenant's run", func(t *testing.T) {
+		repo, db := openTenantDB(t)
+		runOfDefault := models.ReconciliationRun{ID: 4, TenantID: models.DefaultTenantID, BusinessDate: date, Status: "balanced"}
+		db.On(runsTable(runOfDefault, runOfB), "FROM `reconciliation_runs`")
+
+		if err := repo.SaveRun(context.Background(), &models.ReconciliationRun{BusinessDate: date, Status: "balanced"}); err != nil {
+			t.Fatal(err)
+		}
+
+		deleted := db.Find("DELETE FROM `reconciliation_runs`")
+		if len(deleted) != 1 || !deleted[0].HasArg(runOfDefault.ID) {
+			t.Errorf("deleted %v, want only run %d", deleted, runOfDefault.ID)
+		}
+	})
+}
+
+func TestScopeCoversEveryBranchOfAnOr(t *testing.T) {
+	repo, db := openTenantDB(t)
+	scope := WithTenantScope(context.Background(), TenantScope{TenantID: 2})
+
+	if _, err := repo.GetPaidInstallments(scope, time.Now(), time.Now(), []uint{9}); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := db.Find("FROM `payments`")
+	if len(queries) != 1 {
+		t.Fatalf("got %d queries, want 1", len(queries))
+	}
+	if !queries[0].Has("OR transaction_id IN (?)) AND `payments`.`tenant_id` = ?") {
+		t.Errorf("the tenant condition does not cover the whole OR: %s", queries[0].SQL)
+	}
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RestructureRepository handles database operations for loan restructures
+type RestructureRepository struct {
+	db *gorm.DB
+}
+
+// NewRestructureRepository creates a new restructure repository instance
+func NewRestructureRepository(db *gorm.DB) *RestructureRepository {
+	return &RestructureRepository{db: db}
+}
+
+// Create stores a restructure together with the new schedule version. The
+// superseded installments keep their rows so earlier versions stay queryable
+func (r *RestructureRepository) Create(ctx context.Context, restructure *models.Restructure, loan *models.Loan, superseded []models.Payment, schedule []models.Payment) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range superseded {
+			if err := tx.Save(&superseded[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range schedule {
+			schedule[i].TenantID = loan.TenantID
+			schedule[i].BranchID = loan.BranchID
+			if err := tx.Create(&schedule[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit("Payments").Save(loan).Error; err != nil {
+			return err
+		}
+
+		restructure.TenantID = loan.TenantID
+		restructure.BranchID = loan.BranchID
+		return tx.Create(restructure).Error
+	})
+}
+
+// GetByLoanID retrieves all restructures of a loan, oldest first
+func (r *RestructureRepository) GetByLoanID(ctx context.Context, loanID uint) ([]models.Restructure, error) {
+	var restructures []models.Restructure
+	if err := conn(ctx, r.db).Where("loan_id = ?", loanID).Order("to_version").Find(&restructures).Error; err != nil {
+		return nil, err
+	}
+	return restructures, nil
+}
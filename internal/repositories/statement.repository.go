@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StatementRepository handles database operations for imported bank statements
+type StatementRepository struct {
+	db *gorm.DB
+}
+
+// NewStatementRepository creates a new statement repository instance
+func NewStatementRepository(db *gorm.DB) *StatementRepository {
+	return &StatementRepository{db: db}
+}
+
+// CreateImport creates a new statement import
+func (r *StatementRepository) CreateImport(ctx context.Context, statement *models.StatementImport) error {
+	return conn(ctx, r.db).Omit("Lines").Create(statement).Error
+}
+
+// UpdateImport updates the totals of a statement import
+func (r *StatementRepository) UpdateImport(ctx context.Context, statement *models.StatementImport) error {
+	return conn(ctx, r.db).Omit("Lines").Save(statement).Error
+}
+
+// GetImportByID retrieves a statement import with its lines
+func (r *StatementRepository) GetImportByID(ctx context.Context, id uint) (*models.StatementImport, error) {
+	var statement models.StatementImport
+	if err := conn(ctx, r.db).Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line_no")
+	}).First(&statement, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("statement import not found")
+		}
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// ListImports retrieves statement imports, newest first
+func (r *StatementRepository) ListImports(ctx context.Context) ([]models.StatementImport, error) {
+	var statements []models.StatementImport
+	if err := conn(ctx, r.db).Order("created_at DESC").Find(&statements).Error; err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// HasFingerprint reports whether a statement line was already imported
+func (r *StatementRepository) HasFingerprint(ctx context.Context, fingerprint string) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.StatementLine{}).
+		Where("fingerprint = ?", fingerprint).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateLine creates a new statement line
+func (r *StatementRepository) CreateLine(ctx context.Context, line *models.StatementLine) error {
+	return conn(ctx, r.db).Create(line).Error
+}
+
+// UpdateLine updates a statement line
+func (r *StatementRepository) UpdateLine(ctx context.Context, line *models.StatementLine) error {
+	return conn(ctx, r.db).Save(line).Error
+}
+
+// GetLineByID retrieves a statement line by its ID
+func (r *StatementRepository) GetLineByID(ctx context.Context, id uint) (*models.StatementLine, error) {
+	var line models.StatementLine
+	if err := conn(ctx, r.db).First(&line, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("statement line not found")
+		}
+		return nil, err
+	}
+	return &line, nil
+}
+
+// GetOpenLines retrieves the statement lines waiting for manual reconciliation,
+// optionally limited to one import
+func (r *StatementRepository) GetOpenLines(ctx context.Context, importID uint) ([]models.StatementLine, error) {
+	var lines []models.StatementLine
+	query := conn(ctx, r.db).Where("status = ?", "open")
+	if importID != 0 {
+		query = query.Where("import_id = ?", importID)
+	}
+	if err := query.Order("value_date, id").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,284 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantScope is the tenant, and optionally the branch, a request may reach
+type TenantScope struct {
+	TenantID uint
+	BranchID *uint // nil reaches every branch of the tenant
+}
+
+type tenantScopeKey struct{}
+
+type acrossTenantsKey struct{}
+
+// WithTenantScope returns a copy of ctx whose statements only reach the rows
+// of the scope's tenant and branch
+func WithTenantScope(ctx context.Context, scope TenantScope) context.Context {
+	return context.WithValue(ctx, tenantScopeKey{}, scope)
+}
+
+// TenantScopeFrom returns the scope carried by ctx and whether there is one
+func TenantScopeFrom(ctx context.Context) (TenantScope, bool) {
+	scope, ok := ctx.Value(tenantScopeKey{}).(TenantScope)
+	if !ok || ctx.Value(acrossTenantsKey{}) != nil {
+		return TenantScope{}, false
+	}
+	return scope, true
+}
+
+// acrossTenants lifts the scope of ctx for reads that must see every tenant,
+// such as walking the audit hash chain
+func acrossTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, acrossTenantsKey{}, true)
+}
+
+// RegisterTenantCallbacks partitions every table with a tenant_id column by
+// the scope carried by the statement's context. Reads, updates and deletes
+// only reach rows of the scope's tenant, and of its branch on tables with a
+// branch_id column; new rows are stamped with the scope's tenant and branch.
+// Statements without a scope, such as background jobs and the payment
+// gateway webhook, reach every tenant. Register before the audit callbacks
+// so that the rows read for the audit log are scoped the same way
+func RegisterTenantCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", stampTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeTenantUpdate); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeTenantChange)
+}
+
+// partitioned reports whether the statement reads or writes a table with a
+// tenant_id column under its own name
+func partitioned(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && stmt.Schema != nil && stmt.Schema.Table == stmt.Table &&
+		stmt.Schema.LookUpField("tenant_id") != nil
+}
+
+// scopeTenant adds the tenant and branch conditions to a read
+func scopeTenant(db *gorm.DB) {
+	if !partitioned(db) {
+		return
+	}
+	if scope, ok := TenantScopeFrom(db.Statement.Context); ok {
+		addScopeConditions(db, scope)
+	}
+}
+
+// scopeTenantChange adds the tenant and branch conditions to an update or
+// delete. A statement without conditions or primary keys is left alone, so
+// that GORM still refuses it as a global update
+func scopeTenantChange(db *gorm.DB) {
+	if !partitioned(db) {
+		return
+	}
+	scope, ok := TenantScopeFrom(db.Statement.Context)
+	if !ok {
+		return
+	}
+	if _, where := db.Statement.Clauses["WHERE"]; !where && !db.AllowGlobalUpdate && !hasPrimaryKeys(db) {
+		return
+	}
+	addScopeConditions(db, scope)
+}
+
+// scopeTenantUpdate scopes an update like scopeTenantChange and leaves the
+// tenant_id column out of it: a row never moves to another tenant
+func scopeTenantUpdate(db *gorm.DB) {
+	if !partitioned(db) {
+		return
+	}
+	db.Statement.Omits = append(db.Statement.Omits, "tenant_id")
+	scopeTenantChange(db)
+}
+
+func addScopeConditions(db *gorm.DB, scope TenantScope) {
+	stmt := db.Statement
+	// Group the statement's own conditions when they use OR, so that the
+	// scope applies to every branch of it and not only to the last one
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && hasOr(where.Exprs) {
+			c.Expression = clause.Where{Exprs: []clause.Expression{clause.AndConditions{Exprs: where.Exprs}}}
+			stmt.Clauses["WHERE"] = c
+		}
+	}
+
+	conditions := []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: stmt.Table, Name: "tenant_id"}, Value: scope.TenantID},
+	}
+	if scope.BranchID != nil && stmt.Schema.LookUpField("branch_id") != nil {
+		conditions = append(conditions, clause.Eq{Column: clause.Column{Table: stmt.Table, Name: "branch_id"}, Value: *scope.BranchID})
+	}
+	stmt.AddClause(clause.Where{Exprs: conditions})
+}
+
+func hasOr(exprs []clause.Expression) bool {
+	for _, expr := range exprs {
+		if _, ok := expr.(clause.OrConditions); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrimaryKeys(db *gorm.DB) bool {
+	stmt := db.Statement
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	for _, row := range rows(stmt.ReflectValue) {
+		if _, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, row); !zero {
+			return true
+		}
+	}
+	return false
+}
+
+// stampTenant sets the tenant of new rows to the scope's tenant, and their
+// branch to the scope's branch when the scope has one. Without a scope, rows
+// that name no tenant belong to the default tenant. An upsert, such as Save
+// falling back to an insert when its update reached no row, must not
+// overwrite a row of another partition
+func stampTenant(db *gorm.DB) {
+	if !partitioned(db) {
+		return
+	}
+	stmt := db.Statement
+	scope, scoped := TenantScopeFrom(stmt.Context)
+	tenantField := stmt.Schema.LookUpField("tenant_id")
+	branchField := stmt.Schema.LookUpField("branch_id")
+
+	for _, row := range rows(stmt.ReflectValue) {
+		switch _, zero := tenantField.ValueOf(stmt.Context, row); {
+		case scoped:
+			db.AddError(tenantField.Set(stmt.Context, row, scope.TenantID))
+		case zero:
+			db.AddError(tenantField.Set(stmt.Context, row, models.DefaultTenantID))
+		}
+		if branchField != nil && scoped && scope.BranchID != nil {
+			branchID := *scope.BranchID
+			db.AddError(branchField.Set(stmt.Context, row, &branchID))
+		}
+	}
+
+	if upsert(stmt) {
+		db.AddError(refuseForeignRows(db, scope, scoped))
+	}
+}
+
+// upsert reports whether a create overwrites the rows it conflicts with
+func upsert(stmt *gorm.Statement) bool {
+	c, ok := stmt.Clauses["ON CONFLICT"]
+	if !ok {
+		return false
+	}
+	onConflict, _ := c.Expression.(clause.OnConflict)
+	return onConflict.UpdateAll || len(onConflict.DoUpdates) > 0
+}
+
+// refuseForeignRows fails an upsert whose rows carry the primary key of a row
+// of another tenant, or of another branch than the scope's, including rows
+// that were soft deleted
+func refuseForeignRows(db *gorm.DB, scope TenantScope, scoped bool) error {
+	stmt := db.Statement
+	primaryField := stmt.Schema.PrioritizedPrimaryField
+	if primaryField == nil {
+		return nil
+	}
+	tenantField := stmt.Schema.LookUpField("tenant_id")
+	byBranch := scoped && scope.BranchID != nil && stmt.Schema.LookUpField("branch_id") != nil
+
+	for _, row := range rows(stmt.ReflectValue) {
+		id, zero := primaryField.ValueOf(stmt.Context, row)
+		if zero {
+			continue
+		}
+		var existing partition
+		result := db.Session(&gorm.Session{NewDB: true, SkipHooks: true, Context: acrossTenants(stmt.Context)}).
+			Table(stmt.Table).Select("tenant_id", "branch_id").
+			Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: primaryField.DBName}, Value: id}).
+			Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		tenantID, _ := tenantField.ValueOf(stmt.Context, row)
+		if existing.TenantID != tenantID ||
+			(byBranch && (existing.BranchID == nil || *existing.BranchID != *scope.BranchID)) {
+			return notFound("record not found")
+		}
+	}
+	return nil
+}
+
+// partition is the tenant and branch a row belongs to
+type partition struct {
+	TenantID uint
+	BranchID *uint
+}
+
+// partitionOf reads the tenant and branch of a record, so that the rows
+// created for it can be put in the same partition. It is not found when the
+// record lies outside ctx's scope
+func partitionOf(tx *gorm.DB, model interface{}, id uint, message string) (partition, error) {
+	var p partition
+	result := tx.Model(model).Select("tenant_id", "branch_id").Where("id = ?", id).Limit(1).Find(&p)
+	if result.Error != nil {
+		return p, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return p, notFound(message)
+	}
+	return p, nil
+}
+
+// checkBranch checks a branch, when one is given, belongs to ctx's tenant
+func checkBranch(tx *gorm.DB, branchID *uint) error {
+	if branchID == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Branch{}).Where("id = ?", *branchID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound("branch not found")
+	}
+	return nil
+}
+
+// tenantOf returns the tenant of a row with a tenant_id column, or the
+// tenant of ctx's scope, or the default tenant
+func tenantOf(db *gorm.DB, row reflect.Value) uint {
+	if field := db.Statement.Schema.LookUpField("tenant_id"); field != nil {
+		if value, zero := field.ValueOf(db.Statement.Context, row); !zero {
+			if tenantID, ok := value.(uint); ok {
+				return tenantID
+			}
+		}
+	}
+	if scope, ok := TenantScopeFrom(db.Statement.Context); ok {
+		return scope.TenantID
+	}
+	return models.DefaultTenantID
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/models"
+)
+
+func TestPartitionedTablesStayInTheirTenant(t *testing.T) {
+	const tenantA uint = 2
+	scopeA := WithTenantScope(context.Background(), TenantScope{TenantID: tenantA})
+
+	conn, db := dbtest.Open(t)
+	if err := RegisterTenantCallbacks(conn); err != nil {
+		t.Fatal(err)
+	}
+	products := NewProductRepository(conn)
+	lenders := NewLenderRepository(conn)
+	calendar := NewCalendarRepository(conn)
+	statements := NewStatementRepository(conn)
+	gateway := NewGatewayRepository(conn)
+
+	tests := []struct {
+		table string
+		read  func() error
+		write func() error
+	}{
+		{"products",
+			func() error { _, err := products.List(scopeA); return err },
+			func() error { return products.Create(scopeA, &models.Product{Name: "weekly"}) }},
+		{"lenders",
+			func() error { _, err := lenders.List(scopeA); return err },
+			func() error { return lenders.Create(scopeA, &models.Lender{Name: "fund"}) }},
+		{"calendar_holidays",
+			func() error { _, err := calendar.List(scopeA, "", time.Time{}, time.Time{}); return err },
+			func() error { return calendar.Create(scopeA, &models.CalendarHoliday{Name: "Nyepi"}) }},
+		{"statement_imports",
+			func() error { _, err := statements.ListImports(scopeA); return err },
+			func() error { return statements.CreateImport(scopeA, &models.StatementImport{Format: "generic"}) }},
+		{"statement_lines",
+			func() error { _, err := statements.HasFingerprint(scopeA, "f"); return err },
+			func() error { return statements.CreateLine(scopeA, &models.StatementLine{Fingerprint: "f"}) }},
+		{"gateway_payments",
+			func() error { _, err := gateway.List(scopeA, ""); return err },
+			func() error { return gateway.Create(scopeA, &models.GatewayPayment{GatewayTransactionID: "g"}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			db.Reset()
+			if err := tt.read(); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.write(); err != nil {
+				t.Fatal(err)
+			}
+
+			reads := db.Find("FROM `" + tt.table + "`")
+			if len(reads) == 0 {
+				t.Fatalf("no read of %s:\n%v", tt.table, db.Statements())
+			}
+			for _, read := range reads {
+				if !read.Has("`"+tt.table+"`.`tenant_id` = ?") || !read.HasArg(tenantA) {
+					t.Errorf("the read is not limited to tenant %d: %v", tenantA, read)
+				}
+			}
+			inserts := db.Find("INSERT INTO `" + tt.table + "`")
+			if len(inserts) != 1 || !inserts[0].Has("`tenant_id`") || !inserts[0].HasArg(tenantA) {
+				t.Errorf("the new row is not stamped with tenant %d: %v", tenantA, inserts)
+			}
+		})
+	}
+}
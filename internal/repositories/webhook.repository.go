@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository handles database operations for webhook subscriptions
+// and their deliveries
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository instance
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(subscription).Error
+}
+
+// GetSubscription retrieves a webhook subscription by its ID
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := conn(ctx, r.db).First(&subscription, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("webhook subscription not found")
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions retrieves every webhook subscription
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := conn(ctx, r.db).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription saves a webhook subscription
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Save(subscription).Error
+}
+
+// DeleteSubscription removes a webhook subscription and dead-letters its
+// undelivered deliveries, in one database transaction
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status IN ?", subscription.ID, []string{"pending", "retrying"}).
+			Updates(map[string]interface{}{
+				"status":          "dead",
+				"next_attempt_at": nil,
+				"last_error":      "subscription deleted",
+				"updated_at":      time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(subscription).Error
+	})
+}
+
+// GetActiveSubscriptions retrieves the active subscriptions of a tenant
+func (r *WebhookRepository) GetActiveSubscriptions(ctx context.Context, tenantID uint) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.WithContext(acrossTenants(ctx)).
+		Where("tenant_id = ? AND status = ?", tenantID, "active").
+		Order("id").
+		Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// CreateDeliveries queues deliveries, skipping any event already queued for
+// the same subscription so that republished events are delivered once
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// DueDelivery is a delivery due for an attempt together with its subscription
+type DueDelivery struct {
+	Delivery     models.WebhookDelivery
+	Subscription models.WebhookSubscription
+}
+
+// GetDueDeliveries retrieves up to limit deliveries of every tenant that are
+// due for an attempt, oldest first. Deliveries of paused subscriptions wait
+func (r *WebhookRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueDelivery, error) {
+	db := r.db.WithContext(acrossTenants(ctx))
+
+	var deliveries []models.WebhookDelivery
+	if err := db.
+		Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id").
+		Where("webhook_deliveries.status IN ?", []string{"pending", "retrying"}).
+		Where("webhook_deliveries.next_attempt_at IS NULL OR webhook_deliveries.next_attempt_at <= ?", now).
+		Where("webhook_subscriptions.status = ? AND webhook_subscriptions.deleted_at IS NULL", "active").
+		Order("webhook_deliveries.id").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	subscriptions := map[uint]*models.WebhookSubscription{}
+	due := make([]DueDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription = &models.WebhookSubscription{}
+			if err := db.First(subscription, delivery.SubscriptionID).Error; err != nil {
+				return nil, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		due = append(due, DueDelivery{Delivery: delivery, Subscription: *subscription})
+	}
+	return due, nil
+}
+
+// RecordAttempt saves the outcome of an attempt on the delivery and adds it
+// to the attempt log, in one database transaction
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	return r.db.WithContext(acrossTenants(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Save(delivery).Error
+	})
+}
+
+// DeliveryFilter selects deliveries; empty fields match everything
+type DeliveryFilter struct {
+	SubscriptionID uint
+	Status         string
+	EventType      string
+	EventID        string
+	Limit          int
+}
+
+// ListDeliveries retrieves deliveries matching the filter, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]models.WebhookDelivery, error) {
+	query := conn(ctx, r.db).Order("id DESC").Limit(filter.Limit)
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.EventID != "" {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery by its ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := conn(ctx, r.db).First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("webhook delivery not found")
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetAttempts retrieves the attempt log of a delivery, oldest first
+func (r *WebhookRepository) GetAttempts(ctx context.Context, deliveryID uint) ([]models.WebhookAttempt, error) {
+	var attempts []models.WebhookAttempt
+	if err := conn(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("id").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// UpdateDelivery saves a delivery
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return conn(ctx, r.db).Save(delivery).Error
+}
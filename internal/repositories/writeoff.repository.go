@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"AmarthaExample1/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WriteOffRepository handles database operations for write-offs and recoveries
+type WriteOffRepository struct {
+	db *gorm.DB
+}
+
+// NewWriteOffRepository creates a new write-off repository instance
+func NewWriteOffRepository(db *gorm.DB) *WriteOffRepository {
+	return &WriteOffRepository{db: db}
+}
+
+// Create stores a new write-off request in the tenant and branch of its loan
+func (r *WriteOffRepository) Create(ctx context.Context, writeOff *models.WriteOff) error {
+	owner, err := partitionOf(conn(ctx, r.db), &models.Loan{}, writeOff.LoanID, "loan not found")
+	if err != nil {
+		return err
+	}
+	writeOff.TenantID = owner.TenantID
+	writeOff.BranchID = owner.BranchID
+	return conn(ctx, r.db).Create(writeOff).Error
+}
+
+// GetByID retrieves a write-off request by its ID
+func (r *WriteOffRepository) GetByID(ctx context.Context, id uint) (*models.WriteOff, error) {
+	var writeOff models.WriteOff
+	if err := conn(ctx, r.db).First(&writeOff, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound("write-off not found")
+		}
+		return nil, err
+	}
+	return &writeOff, nil
+}
+
+// List retrieves write-off requests, optionally filtered by status
+func (r *WriteOffRepository) List(ctx context.Context, status string) ([]models.WriteOff, error) {
+	var writeOffs []models.WriteOff
+	query := conn(ctx, r.db).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&writeOffs).Error; err != nil {
+		return nil, err
+	}
+	return writeOffs, nil
+}
+
+// HasPending reports whether a loan already has a write-off awaiting review
+func (r *WriteOffRepository) HasPending(ctx context.Context, loanID uint) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.WriteOff{}).
+		Where("loan_id = ? AND status = ?", loanID, "pending").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Update updates a write-off record
+func (r *WriteOffRepository) Update(ctx context.Context, writeOff *models.WriteOff) error {
+	return conn(ctx, r.db).Save(writeOff).Error
+}
+
+// Approve marks the write-off approved, moves the loan to written_off and
+// flags its unpaid installments so they stay visible in the schedule
+func (r *WriteOffRepository) Approve(ctx context.Context, writeOff *models.WriteOff, loan *models.Loan) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(writeOff).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Payments").Save(loan).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Payment{}).
+			Where("loan_id = ? AND status = ? AND superseded_in_version = ?", loan.ID, "pending", 0).
+			Updates(map[string]interface{}{"status": "written_off", "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.LoanCharge{}).
+			Where("loan_id = ? AND status IN ?", loan.ID, []string{"pending", "partially_paid"}).
+			Updates(map[string]interface{}{"status": "written_off", "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// CreateRecovery stores a recovery received on a written-off loan
+func (r *WriteOffRepository) CreateRecovery(ctx context.Context, recovery *models.Recovery) error {
+	return conn(ctx, r.db).Create(recovery).Error
+}
+
+// GetRecoveriesByLoanID retrieves all recoveries for a loan
+func (r *WriteOffRepository) GetRecoveriesByLoanID(ctx context.Context, loanID uint) ([]models.Recovery, error) {
+	var recoveries []models.Recovery
+	if err := conn(ctx, r.db).Where("loan_id = ?", loanID).Order("received_at").Find(&recoveries).Error; err != nil {
+		return nil, err
+	}
+	return recoveries, nil
+}
+
+// GetTotalRecovered returns the sum of recoveries booked for a loan
+func (r *WriteOffRepository) GetTotalRecovered(ctx context.Context, loanID uint) (float64, error) {
+	var total float64
+	if err := conn(ctx, r.db).Model(&models.Recovery{}).
+		Where("loan_id = ?", loanID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
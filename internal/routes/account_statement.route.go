@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAccountStatementRoutes sets up loan account statement routes
+func SetupAccountStatementRoutes(app *fiber.App, handler *handlers.AccountStatementHandler, guard *middleware.Guard) {
+	app.Get("/api/loans/:id/statement", guard.Can("loans:read"), guard.Loan("id"), handler.GetStatement)
+}
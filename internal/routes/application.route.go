@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupApplicationRoutes sets up loan application routes
+func SetupApplicationRoutes(app *fiber.App, handler *handlers.ApplicationHandler, guard *middleware.Guard) {
+	applications := app.Group("/api/loan-applications")
+
+	applications.Get("/", guard.Can("applications:read"), handler.ListApplications)
+	applications.Get("/:id", guard.Can("applications:read"), handler.GetApplication)
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAuditRoutes sets up audit log routes
+func SetupAuditRoutes(app *fiber.App, handler *handlers.AuditHandler, guard *middleware.Guard) {
+	audit := app.Group("/api/audit-logs")
+
+	audit.Get("/", guard.Can("audit:read"), handler.ListEntries)
+	audit.Get("/verify", guard.Can("audit:read"), handler.VerifyChain)
+	audit.Get("/:id", guard.Can("audit:read"), handler.GetEntry)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAuthRoutes sets up the caller and API key management routes
+func SetupAuthRoutes(app *fiber.App, handler *handlers.AuthHandler, guard *middleware.Guard) {
+	auth := app.Group("/api/auth")
+
+	auth.Get("/me", handler.Me)
+	auth.Post("/api-keys", guard.Can("api_keys:manage"), handler.CreateAPIKey)
+	auth.Get("/api-keys", guard.Can("api_keys:read"), handler.ListAPIKeys)
+	auth.Delete("/api-keys/:id", guard.Can("api_keys:manage"), handler.RevokeAPIKey)
+}
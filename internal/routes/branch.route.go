@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupBranchRoutes sets up branch and branch report routes
+func SetupBranchRoutes(app *fiber.App, handler *handlers.BranchHandler, guard *middleware.Guard) {
+	branches := app.Group("/api/branches")
+
+	branches.Post("/", guard.Can("branches:manage"), handler.CreateBranch)
+	branches.Get("/", guard.Can("branches:list"), handler.ListBranches)
+	branches.Get("/:id", guard.Can("branches:read"), handler.GetBranch)
+
+	app.Get("/api/reports/branches", guard.Can("reports:read"), handler.GetBranchReport)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupCalendarRoutes sets up holiday calendar routes
+func SetupCalendarRoutes(app *fiber.App, handler *handlers.CalendarHandler, guard *middleware.Guard) {
+	holidays := app.Group("/api/calendar/holidays")
+
+	holidays.Get("/", guard.Can("calendar:read"), handler.ListHolidays)
+	holidays.Post("/", guard.Can("calendar:manage"), handler.CreateHoliday)
+	holidays.Post("/import", guard.Can("calendar:manage"), handler.ImportHolidays)
+	holidays.Get("/:id", guard.Can("calendar:read"), handler.GetHoliday)
+	holidays.Put("/:id", guard.Can("calendar:manage"), handler.UpdateHoliday)
+	holidays.Delete("/:id", guard.Can("calendar:manage"), handler.DeleteHoliday)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupCollectionRoutes sets up field officer and collection sheet routes
+func SetupCollectionRoutes(app *fiber.App, handler *handlers.CollectionHandler, guard *middleware.Guard) {
+	officers := app.Group("/api/officers")
+
+	officers.Post("/", guard.Can("officers:manage"), handler.CreateOfficer)
+	officers.Get("/", guard.Can("officers:list"), handler.ListOfficers)
+	officers.Get("/:id", guard.Can("officers:read"), guard.Officer("id"), handler.GetOfficer)
+	officers.Get("/:id/collection-sheet", guard.Can("collections:read"), guard.Officer("id"), handler.GetCollectionSheet)
+
+	collections := app.Group("/api/collections")
+
+	collections.Post("/batches", guard.Can("collections:post"), handler.PostBatch)
+	collections.Get("/batches/:id", guard.Can("collections:read"), guard.Batch("id"), handler.GetBatch)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupCollectionCaseRoutes sets up collection case and work queue routes
+func SetupCollectionCaseRoutes(app *fiber.App, handler *handlers.CollectionCaseHandler, guard *middleware.Guard) {
+	api := app.Group("/api")
+
+	cases := api.Group("/collection-cases")
+	cases.Get("/", guard.Can("cases:list"), handler.ListCases)
+	cases.Post("/evaluate", guard.Can("cases:evaluate"), handler.EvaluateCases)
+	cases.Get("/:id", guard.Can("cases:read"), guard.Case("id"), handler.GetCase)
+	cases.Post("/:id/assign", guard.Can("cases:assign"), guard.Case("id"), handler.AssignCase)
+	cases.Post("/:id/activities", guard.Can("cases:work"), guard.Case("id"), handler.LogActivity)
+	cases.Post("/:id/close", guard.Can("cases:assign"), guard.Case("id"), handler.CloseCase)
+
+	api.Get("/officers/:id/collection-cases", guard.Can("cases:read"), guard.Officer("id"), handler.GetWorkQueue)
+}
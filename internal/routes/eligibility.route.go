@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupEligibilityRoutes sets up borrower eligibility routes
+func SetupEligibilityRoutes(app *fiber.App, handler *handlers.EligibilityHandler, guard *middleware.Guard) {
+	app.Get("/api/borrowers/:id/eligibility", guard.Can("borrowers:read"), guard.Borrower("id"), handler.CheckEligibility)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupEventRoutes sets up domain event and arrears routes
+func SetupEventRoutes(app *fiber.App, handler *handlers.EventHandler, guard *middleware.Guard) {
+	api := app.Group("/api")
+
+	api.Post("/arrears/evaluate", guard.Can("arrears:evaluate"), handler.EvaluateArrears)
+
+	events := api.Group("/events")
+	events.Get("/", guard.Can("events:read"), handler.ListEvents)
+	events.Get("/:id", guard.Can("events:read"), handler.GetEvent)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupGatewayRoutes sets up payment gateway webhook routes
+func SetupGatewayRoutes(app *fiber.App, handler *handlers.GatewayHandler, guard *middleware.Guard) {
+	app.Post("/api/webhooks/payments", handler.ReceivePayment)
+	app.Get("/api/gateway-payments", guard.Can("gateway:read"), handler.ListPayments)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupGroupRoutes sets up borrower group routes
+func SetupGroupRoutes(app *fiber.App, handler *handlers.GroupHandler, guard *middleware.Guard) {
+	groups := app.Group("/api/groups")
+
+	groups.Post("/", guard.Can("groups:manage"), handler.CreateGroup)
+	groups.Get("/", guard.Can("groups:list"), handler.ListGroups)
+	groups.Get("/:id", guard.Can("groups:read"), guard.Group("id"), handler.GetGroup)
+	groups.Put("/:id/officer", guard.Can("groups:manage"), guard.Group("id"), handler.AssignOfficer)
+	groups.Post("/:id/members", guard.Can("groups:manage"), guard.Group("id"), handler.AddMember)
+	groups.Delete("/:id/members/:borrowerId", guard.Can("groups:manage"), guard.Group("id"), handler.RemoveMember)
+	groups.Get("/:id/schedule", guard.Can("groups:read"), guard.Group("id"), handler.GetSchedule)
+	groups.Get("/:id/outstanding", guard.Can("groups:read"), guard.Group("id"), handler.GetOutstanding)
+	groups.Get("/:id/delinquency", guard.Can("groups:read"), guard.Group("id"), handler.GetDelinquency)
+	groups.Get("/:id/collection-sheet", guard.Can("groups:read"), guard.Group("id"), handler.GetCollectionSheet)
+}
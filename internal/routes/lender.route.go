@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupLenderRoutes sets up lender and loan funding routes
+func SetupLenderRoutes(app *fiber.App, handler *handlers.LenderHandler, guard *middleware.Guard) {
+	lenders := app.Group("/api/lenders")
+
+	lenders.Post("/", guard.Can("lenders:manage"), handler.CreateLender)
+	lenders.Get("/", guard.Can("lenders:read"), handler.ListLenders)
+	lenders.Get("/:id", guard.Can("lenders:read"), handler.GetLender)
+	lenders.Get("/:id/portfolio", guard.Can("lenders:read"), handler.GetPortfolio)
+	lenders.Get("/:id/cash-flows", guard.Can("lenders:read"), handler.GetCashFlows)
+	lenders.Get("/:id/returns", guard.Can("lenders:read"), handler.GetReturns)
+
+	app.Post("/api/loans/:id/fundings", guard.Can("fundings:manage"), guard.Loan("id"), handler.FundLoan)
+	app.Get("/api/loans/:id/fundings", guard.Can("fundings:read"), guard.Loan("id"), handler.GetLoanFundings)
+}
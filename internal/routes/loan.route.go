@@ -2,20 +2,26 @@ package routes
 
 import (
 	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // SetupLoanRoutes sets up all loan related routes
-func SetupLoanRoutes(app *fiber.App, handler *handlers.LoanHandler) {
+func SetupLoanRoutes(app *fiber.App, handler *handlers.LoanHandler, guard *middleware.Guard) {
 	api := app.Group("/api")
 	loans := api.Group("/loans")
 
 	// Loan endpoints
-	loans.Post("/", handler.CreateLoan)
-	loans.Get("/:id", handler.GetLoan)
-	loans.Get("/:id/outstanding", handler.GetOutstanding)
-	loans.Get("/:id/delinquent", handler.IsDelinquent)
-	loans.Get("/:id/schedule", handler.GetLoanSchedule)
-	loans.Post("/:id/payment", handler.MakePayment)
+	loans.Post("/", guard.Can("loans:create"), handler.CreateLoan)
+	loans.Post("/quote", guard.Can("loans:read"), handler.QuoteLoan)
+	loans.Get("/:id", guard.Can("loans:read"), guard.Loan("id"), handler.GetLoan)
+	loans.Get("/:id/outstanding", guard.Can("loans:read"), guard.Loan("id"), handler.GetOutstanding)
+	loans.Get("/:id/delinquent", guard.Can("loans:read"), guard.Loan("id"), handler.IsDelinquent)
+	loans.Get("/:id/schedule", guard.Can("loans:read"), guard.Loan("id"), handler.GetLoanSchedule)
+	loans.Get("/:id/charges", guard.Can("loans:read"), guard.Loan("id"), handler.GetCharges)
+	loans.Get("/:id/payoff", guard.Can("loans:read"), guard.Loan("id"), handler.GetPayoff)
+	loans.Post("/:id/top-up", guard.Can("loans:top_up"), guard.Loan("id"), handler.TopUp)
+	loans.Post("/:id/payment", guard.Can("payments:post"), guard.Loan("id"), handler.MakePayment)
+	loans.Post("/:id/transactions/:transactionId/reverse", guard.Can("payments:reverse"), guard.Loan("id"), handler.ReversePayment)
 }
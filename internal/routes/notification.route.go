@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupNotificationRoutes sets up reminder template, opt-out and send history routes
+func SetupNotificationRoutes(app *fiber.App, handler *handlers.NotificationHandler, guard *middleware.Guard) {
+	api := app.Group("/api")
+
+	templates := api.Group("/notification-templates")
+	templates.Post("/", guard.Can("notifications:manage"), handler.CreateTemplate)
+	templates.Get("/", guard.Can("notifications:read"), handler.ListTemplates)
+	templates.Get("/:id", guard.Can("notifications:read"), handler.GetTemplate)
+	templates.Put("/:id", guard.Can("notifications:manage"), handler.UpdateTemplate)
+	templates.Delete("/:id", guard.Can("notifications:manage"), handler.DeleteTemplate)
+
+	api.Get("/notifications", guard.Can("notifications:read"), handler.ListNotifications)
+	api.Post("/notifications/run", guard.Can("notifications:run"), handler.SendReminders)
+	api.Get("/loans/:id/notifications", guard.Can("notifications:read"), guard.Loan("id"), handler.GetLoanNotifications)
+
+	borrowers := api.Group("/borrowers")
+	borrowers.Post("/:id/notification-opt-outs", guard.Can("notifications:opt_out"), guard.Borrower("id"), handler.OptOut)
+	borrowers.Get("/:id/notification-opt-outs", guard.Can("borrowers:read"), guard.Borrower("id"), handler.GetOptOuts)
+	borrowers.Delete("/:id/notification-opt-outs/:channel", guard.Can("notifications:opt_out"), guard.Borrower("id"), handler.OptIn)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupPaymentHolidayRoutes sets up payment holiday routes
+func SetupPaymentHolidayRoutes(app *fiber.App, handler *handlers.PaymentHolidayHandler, guard *middleware.Guard) {
+	api := app.Group("/api")
+
+	api.Post("/payment-holidays", guard.Can("payment_holidays:apply"), handler.ApplyToRegion)
+
+	loans := api.Group("/loans")
+	loans.Post("/:id/payment-holidays", guard.Can("payment_holidays:apply"), guard.Loan("id"), handler.ApplyToLoan)
+	loans.Get("/:id/payment-holidays", guard.Can("loans:read"), guard.Loan("id"), handler.GetHolidays)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupProductRoutes sets up loan product routes
+func SetupProductRoutes(app *fiber.App, handler *handlers.ProductHandler, guard *middleware.Guard) {
+	products := app.Group("/api/products")
+
+	products.Post("/", guard.Can("products:manage"), handler.CreateProduct)
+	products.Get("/", guard.Can("products:read"), handler.ListProducts)
+	products.Get("/:id", guard.Can("products:read"), handler.GetProduct)
+	products.Put("/:id", guard.Can("products:manage"), handler.UpdateProduct)
+	products.Post("/:id/fees", guard.Can("products:manage"), handler.AddFee)
+	products.Get("/:id/fees", guard.Can("products:read"), handler.GetFees)
+	products.Delete("/:id/fees/:feeId", guard.Can("products:manage"), handler.DeleteFee)
+}
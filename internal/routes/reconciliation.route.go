@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupReconciliationRoutes sets up end-of-day reconciliation routes
+func SetupReconciliationRoutes(app *fiber.App, handler *handlers.ReconciliationHandler, guard *middleware.Guard) {
+	runs := app.Group("/api/reconciliation/runs")
+
+	runs.Post("/", guard.Can("reconciliation:run"), handler.RunReconciliation)
+	runs.Get("/", guard.Can("reconciliation:read"), handler.ListRuns)
+	runs.Get("/:date", guard.Can("reconciliation:read"), handler.GetRun)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupRestructureRoutes sets up loan restructuring routes
+func SetupRestructureRoutes(app *fiber.App, handler *handlers.RestructureHandler, guard *middleware.Guard) {
+	loans := app.Group("/api/loans")
+
+	loans.Post("/:id/restructure", guard.Can("loans:restructure"), guard.Loan("id"), handler.RestructureLoan)
+	loans.Get("/:id/restructures", guard.Can("loans:read"), guard.Loan("id"), handler.GetRestructures)
+}
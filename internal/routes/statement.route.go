@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupStatementRoutes sets up bank statement import and reconciliation routes
+func SetupStatementRoutes(app *fiber.App, handler *handlers.StatementHandler, guard *middleware.Guard) {
+	statements := app.Group("/api/statements")
+
+	statements.Get("/formats", guard.Can("statements:read"), handler.ListFormats)
+	statements.Post("/import", guard.Can("statements:import"), handler.ImportStatement)
+	statements.Get("/", guard.Can("statements:read"), handler.ListImports)
+	statements.Get("/:id", guard.Can("statements:read"), handler.GetImport)
+
+	reconciliation := app.Group("/api/reconciliation")
+
+	reconciliation.Get("/queue", guard.Can("reconciliation:read"), handler.GetReconciliationQueue)
+	reconciliation.Post("/lines/:id/resolve", guard.Can("reconciliation:resolve"), handler.ResolveLine)
+	reconciliation.Post("/lines/:id/ignore", guard.Can("reconciliation:resolve"), handler.IgnoreLine)
+}
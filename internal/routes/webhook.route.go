@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupWebhookRoutes sets up outgoing webhook subscription and delivery routes
+func SetupWebhookRoutes(app *fiber.App, handler *handlers.WebhookHandler, guard *middleware.Guard) {
+	api := app.Group("/api")
+
+	subscriptions := api.Group("/webhook-subscriptions")
+	subscriptions.Post("/", guard.Can("webhooks:manage"), handler.CreateSubscription)
+	subscriptions.Get("/", guard.Can("webhooks:read"), handler.ListSubscriptions)
+	subscriptions.Get("/:id", guard.Can("webhooks:read"), handler.GetSubscription)
+	subscriptions.Put("/:id", guard.Can("webhooks:manage"), handler.UpdateSubscription)
+	subscriptions.Delete("/:id", guard.Can("webhooks:manage"), handler.DeleteSubscription)
+
+	deliveries := api.Group("/webhook-deliveries")
+	deliveries.Get("/", guard.Can("webhooks:read"), handler.ListDeliveries)
+	deliveries.Get("/:id", guard.Can("webhooks:read"), handler.GetDelivery)
+	deliveries.Post("/:id/redeliver", guard.Can("webhooks:manage"), handler.Redeliver)
+}
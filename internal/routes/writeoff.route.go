@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"AmarthaExample1/internal/handlers"
+	"AmarthaExample1/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupWriteOffRoutes sets up default, write-off and recovery routes
+func SetupWriteOffRoutes(app *fiber.App, handler *handlers.WriteOffHandler, guard *middleware.Guard) {
+	api := app.Group("/api")
+
+	api.Post("/defaults/evaluate", guard.Can("defaults:evaluate"), handler.EvaluateDefaults)
+
+	loans := api.Group("/loans")
+	loans.Post("/:id/write-off", guard.Can("write_offs:request"), guard.Loan("id"), handler.RequestWriteOff)
+	loans.Post("/:id/recoveries", guard.Can("recoveries:post"), guard.Loan("id"), handler.RecordRecovery)
+	loans.Get("/:id/recoveries", guard.Can("loans:read"), guard.Loan("id"), handler.GetRecoveries)
+
+	writeOffs := api.Group("/write-offs")
+	writeOffs.Get("/", guard.Can("write_offs:read"), handler.ListWriteOffs)
+	writeOffs.Post("/:id/approve", guard.Can("write_offs:approve"), handler.ApproveWriteOff)
+	writeOffs.Post("/:id/reject", guard.Can("write_offs:approve"), handler.RejectWriteOff)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+
+	"AmarthaExample1/internal/repositories"
+)
+
+// AccessService checks that a principal only reaches the records of its own
+// tenant and branch and, when officer scoped, of the groups its officer
+// runs. The principal is the one carried by ctx. Records are looked up
+// through the tenant scoped repositories, so a record of another tenant or
+// branch is not found; this also covers the records kept per loan, group or
+// officer that have no tenant of their own
+type AccessService struct {
+	loanRepo       *repositories.LoanRepository
+	groupRepo      *repositories.GroupRepository
+	borrowerRepo   *repositories.BorrowerRepository
+	officerRepo    *repositories.OfficerRepository
+	collectionRepo *repositories.CollectionRepository
+	caseRepo       *repositories.CollectionCaseRepository
+}
+
+// NewAccessService creates a new access service instance
+func NewAccessService(loanRepo *repositories.LoanRepository, groupRepo *repositories.GroupRepository, borrowerRepo *repositories.BorrowerRepository, officerRepo *repositories.OfficerRepository, collectionRepo *repositories.CollectionRepository, caseRepo *repositories.CollectionCaseRepository) *AccessService {
+	return &AccessService{loanRepo: loanRepo, groupRepo: groupRepo, borrowerRepo: borrowerRepo, officerRepo: officerRepo, collectionRepo: collectionRepo, caseRepo: caseRepo}
+}
+
+// CheckLoan checks the principal may reach a loan: an officer's loans are
+// the ones booked under the groups they run
+func (s *AccessService) CheckLoan(ctx context.Context, loanID uint) error {
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return err
+	}
+	if principal.Scope != "officer" {
+		return nil
+	}
+	if loan.GroupID == nil {
+		return ErrForbidden
+	}
+	return s.CheckGroup(ctx, *loan.GroupID)
+}
+
+// CheckGroup checks the principal may reach a group
+func (s *AccessService) CheckGroup(ctx context.Context, groupID uint) error {
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if principal.Scope != "officer" {
+		return nil
+	}
+	if group.OfficerID == nil || !principal.ActsFor(*group.OfficerID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// CheckBorrower checks the principal may reach a borrower through the group
+// the borrower currently belongs to
+func (s *AccessService) CheckBorrower(ctx context.Context, borrowerID uint) error {
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	if _, err := s.borrowerRepo.GetByID(ctx, borrowerID); err != nil {
+		return err
+	}
+	if principal.Scope != "officer" {
+		return nil
+	}
+	member, err := s.groupRepo.GetActiveMembership(ctx, borrowerID)
+	if err != nil {
+		return ErrForbidden
+	}
+	return s.CheckGroup(ctx, member.GroupID)
+}
+
+// CheckOfficer checks the principal may act for an officer
+func (s *AccessService) CheckOfficer(ctx context.Context, officerID uint) error {
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	if !principal.ActsFor(officerID) {
+		return ErrForbidden
+	}
+	_, err := s.officerRepo.GetByID(ctx, officerID)
+	return err
+}
+
+// CheckBatch checks the principal may reach a posted collection batch
+func (s *AccessService) CheckBatch(ctx context.Context, batchID uint) error {
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	batch, err := s.collectionRepo.GetBatchByID(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	return s.CheckOfficer(ctx, batch.OfficerID)
+}
+
+// CheckCase checks the principal may reach a collection case: an officer's
+// cases are the ones assigned to them
+func (s *AccessService) CheckCase(ctx context.Context, caseID uint) error {
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil
+	}
+	collectionCase, err := s.caseRepo.GetByID(ctx, caseID)
+	if err != nil {
+		return err
+	}
+	if principal.Scope != "officer" {
+		return nil
+	}
+	if collectionCase.CollectorID == nil || !principal.ActsFor(*collectionCase.CollectorID) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AccessPolicy maps each role to what it may do. Permissions are written
+// resource:action, and either part may be "*", so "*:read" grants every read
+// and "*" grants everything. Listing groups and officers is its own list
+// action, as those lists are not limited to an officer's own records
+type AccessPolicy struct {
+	Roles map[string]RolePolicy `json:"roles"`
+}
+
+// RolePolicy lists the permissions of a role and the records it is limited to
+type RolePolicy struct {
+	Permissions []string `json:"permissions"`
+	Scope       string   `json:"scope,omitempty"` // all (default), officer: only the groups the principal's officer runs
+}
+
+// DefaultAccessPolicy returns the built-in role policy
+func DefaultAccessPolicy() AccessPolicy {
+	return AccessPolicy{Roles: map[string]RolePolicy{
+		"field_officer": {
+			Scope: "officer",
+			Permissions: []string{
+				"loans:read", "payments:post", "borrowers:read", "groups:read",
+				"officers:read", "collections:read", "collections:post",
+				"products:read", "calendar:read", "notifications:opt_out",
+				"cases:read", "cases:work",
+			},
+		},
+		"branch_manager": {
+			Permissions: []string{
+				"*:read", "*:list", "loans:create", "loans:top_up", "loans:restructure",
+				"payments:post", "payment_holidays:apply", "write_offs:request",
+				"groups:manage", "officers:manage", "collections:post",
+				"reconciliation:resolve", "notifications:opt_out",
+				"cases:work", "cases:assign", "cases:evaluate",
+			},
+		},
+		"finance": {
+			Permissions: []string{
+				"*:read", "*:list", "payments:post", "payments:reverse", "write_offs:approve",
+				"recoveries:post", "defaults:evaluate", "arrears:evaluate", "lenders:manage",
+				"fundings:manage", "products:manage", "statements:import",
+				"reconciliation:resolve", "reconciliation:run",
+				"notifications:manage", "notifications:run", "cases:evaluate",
+			},
+		},
+		"auditor": {
+			Permissions: []string{"*:read", "*:list"},
+		},
+		"admin": {
+			Permissions: []string{"*"},
+		},
+	}}
+}
+
+// LoadAccessPolicy reads a role policy from a JSON file. Without a path the
+// built-in policy is returned
+func LoadAccessPolicy(path string) (AccessPolicy, error) {
+	if path == "" {
+		return DefaultAccessPolicy(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return AccessPolicy{}, err
+	}
+
+	var policy AccessPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return AccessPolicy{}, fmt.Errorf("parsing access policy: %w", err)
+	}
+	if err := policy.validate(); err != nil {
+		return AccessPolicy{}, err
+	}
+	return policy, nil
+}
+
+func (p AccessPolicy) validate() error {
+	if len(p.Roles) == 0 {
+		return errors.New("access policy needs at least one role")
+	}
+	for name, role := range p.Roles {
+		switch role.Scope {
+		case "", "all", "officer":
+		default:
+			return fmt.Errorf("role %s has an unknown scope %q", name, role.Scope)
+		}
+		for _, permission := range role.Permissions {
+			if permission != "*" && strings.Count(permission, ":") != 1 {
+				return fmt.Errorf("role %s has a malformed permission %q", name, permission)
+			}
+		}
+	}
+	return nil
+}
+
+// Role returns the policy of a role
+func (p AccessPolicy) Role(name string) (RolePolicy, bool) {
+	role, ok := p.Roles[name]
+	return role, ok
+}
+
+// RoleNames returns the roles the policy defines, sorted
+func (p AccessPolicy) RoleNames() []string {
+	names := make([]string, 0, len(p.Roles))
+	for name := range p.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ungranted returns the permissions the granted ones do not cover. A wildcard
+// permission is only covered by a grant at least as wide
+func ungranted(granted, permissions []string) []string {
+	var missing []string
+	for _, permission := range permissions {
+		if !grants(granted, permission) {
+			missing = append(missing, permission)
+		}
+	}
+	return missing
+}
+
+// grants reports whether any of the granted permissions covers the permission
+func grants(granted []string, permission string) bool {
+	resource, action, _ := strings.Cut(permission, ":")
+	for _, grant := range granted {
+		if grant == "*" {
+			return true
+		}
+		grantResource, grantAction, _ := strings.Cut(grant, ":")
+		if (grantResource == "*" || grantResource == resource) && (grantAction == "*" || grantAction == action) {
+			return true
+		}
+	}
+	return false
+}
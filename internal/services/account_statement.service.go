@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// AccountStatementService builds loan account statements from the schedule,
+// the fee charges and the payment transactions of a loan
+type AccountStatementService struct {
+	loanRepo     *repositories.LoanRepository
+	borrowerRepo *repositories.BorrowerRepository
+	currency     Currency
+}
+
+// NewAccountStatementService creates a new account statement service instance
+func NewAccountStatementService(loanRepo *repositories.LoanRepository, borrowerRepo *repositories.BorrowerRepository, currency Currency) *AccountStatementService {
+	return &AccountStatementService{loanRepo: loanRepo, borrowerRepo: borrowerRepo, currency: currency}
+}
+
+// entry ordering within a day: what falls due comes before what pays it
+var entryOrder = map[string]int{
+	"disbursement": 0,
+	"fee":          1,
+	"due":          2,
+	"payoff":       3,
+	"payment":      4,
+	"reversal":     5,
+	"write_off":    6,
+}
+
+// GetStatement returns the statement of a loan for the days from..to
+// inclusive. Zero dates default to the loan start and today. Dues and fees are
+// debits, payments credits, and the balance is what has fallen due and is
+// still unpaid
+func (s *AccountStatementService) GetStatement(ctx context.Context, loanID uint, from, to time.Time) (*dto.AccountStatementResponse, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() {
+		from = loan.StartDate
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from, to = truncateToDay(from), truncateToDay(to)
+	if to.Before(from) {
+		return nil, Validation("to must not be before from")
+	}
+
+	entries, err := s.entries(ctx, loan)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &dto.AccountStatementResponse{
+		LoanID:     loan.ID,
+		BorrowerID: loan.BorrowerID,
+		LoanAmount: loan.Amount,
+		LoanStatus: loan.Status,
+		From:       from,
+		To:         to,
+		Entries:    []dto.AccountStatementEntry{},
+	}
+	if borrower, err := s.borrowerRepo.GetByID(ctx, loan.BorrowerID); err == nil {
+		statement.BorrowerName = strings.TrimSpace(borrower.FirstName + " " + borrower.LastName)
+	}
+
+	end := to.AddDate(0, 0, 1)
+	balance := 0.0
+	for _, entry := range entries {
+		if !entry.Date.Before(end) {
+			break
+		}
+		balance = s.currency.Round(balance + entry.Debit - entry.Credit)
+		if entry.Date.Before(from) {
+			statement.OpeningBalance = balance
+			continue
+		}
+
+		entry.Balance = balance
+		statement.TotalDebits += entry.Debit
+		statement.TotalCredits += entry.Credit
+		statement.Entries = append(statement.Entries, entry)
+	}
+	statement.TotalDebits = s.currency.Round(statement.TotalDebits)
+	statement.TotalCredits = s.currency.Round(statement.TotalCredits)
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+// entries lists every event on the loan account in date order
+func (s *AccountStatementService) entries(ctx context.Context, loan *models.Loan) ([]dto.AccountStatementEntry, error) {
+	installments, err := s.loanRepo.GetPaymentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := s.loanRepo.GetCharges(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.loanRepo.GetTransactions(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	disbursement := fmt.Sprintf("Disbursed %.2f of a %.2f loan", loan.DisbursedAmount, loan.Amount)
+	if loan.RefinancesID != nil {
+		disbursement += fmt.Sprintf(" after paying off loan %d (%.2f)", *loan.RefinancesID, loan.PayoffAmount)
+	}
+	entries := []dto.AccountStatementEntry{{
+		Date:        loan.StartDate,
+		Type:        "disbursement",
+		Description: disbursement,
+	}}
+
+	for _, charge := range charges {
+		if charge.Collection != "deducted" {
+			continue
+		}
+		entries = append(entries,
+			dto.AccountStatementEntry{
+				Date:        loan.StartDate,
+				Type:        "fee",
+				Description: charge.Name,
+				Debit:       charge.TotalAmount,
+			},
+			dto.AccountStatementEntry{
+				Date:        loan.StartDate,
+				Type:        "payment",
+				Description: charge.Name + " deducted from disbursement",
+				Credit:      charge.TotalAmount,
+			},
+		)
+	}
+
+	settledBy := map[uint]float64{}
+	for _, installment := range installments {
+		if installment.Status == "refinanced" {
+			// Closed early by a payoff; the payoff entry covers its principal
+			continue
+		}
+		if installment.Status == "paid" && installment.TransactionID != nil {
+			settledBy[*installment.TransactionID] += installment.Amount
+		}
+
+		date := installment.DueDate
+		description := fmt.Sprintf("Installment week %d", installment.WeekNum)
+		// Installments written off before falling due are accelerated to the write-off date
+		if installment.Status == "written_off" && loan.WrittenOffAt != nil && date.After(*loan.WrittenOffAt) {
+			date = *loan.WrittenOffAt
+			description += " (accelerated on write-off)"
+		}
+
+		entries = append(entries, dto.AccountStatementEntry{
+			Date:        date,
+			Type:        "due",
+			Description: description,
+			Debit:       s.currency.Round(installment.Amount - installment.FeeAmount),
+		})
+		if installment.FeeAmount > 0 {
+			entries = append(entries, dto.AccountStatementEntry{
+				Date:        date,
+				Type:        "fee",
+				Description: fmt.Sprintf("Installment fees week %d", installment.WeekNum),
+				Debit:       s.currency.Round(installment.FeeAmount),
+			})
+		}
+	}
+
+	for i := range transactions {
+		transaction := transactions[i]
+		id := transaction.ID
+
+		description := "Payment via " + transaction.Channel
+		if transaction.Channel == "refinance" {
+			entries = append(entries, dto.AccountStatementEntry{
+				Date:          transaction.ReceivedAt,
+				Type:          "payoff",
+				Description:   "Remaining principal settled early, interest and fees waived",
+				TransactionID: &id,
+				Debit:         s.currency.Round(transaction.AppliedAmount - settledBy[id]),
+			})
+			description = "Paid off by top-up " + transaction.Reference
+		}
+		if transaction.UnappliedAmount > 0 {
+			description += fmt.Sprintf(", %.2f held unapplied", transaction.UnappliedAmount)
+		}
+		if transaction.SuspenseApplied > 0 {
+			description += fmt.Sprintf(", %.2f applied from earlier unapplied money", transaction.SuspenseApplied)
+		}
+
+		entries = append(entries, dto.AccountStatementEntry{
+			Date:          transaction.ReceivedAt,
+			Type:          "payment",
+			Description:   description,
+			Reference:     transaction.Reference,
+			TransactionID: &id,
+			Credit:        transaction.AppliedAmount,
+		})
+
+		if transaction.Status == "reversed" && transaction.ReversedAt != nil {
+			entries = append(entries, dto.AccountStatementEntry{
+				Date:          *transaction.ReversedAt,
+				Type:          "reversal",
+				Description:   fmt.Sprintf("Reversal of payment %d: %s", transaction.ID, transaction.ReversalReason),
+				Reference:     transaction.Reference,
+				TransactionID: &id,
+				Debit:         transaction.AppliedAmount,
+			})
+		}
+	}
+
+	if loan.Status == "written_off" && loan.WrittenOffAt != nil {
+		entries = append(entries, dto.AccountStatementEntry{
+			Date:        *loan.WrittenOffAt,
+			Type:        "write_off",
+			Description: "Outstanding balance written off",
+			Credit:      loan.WrittenOffAmount,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		di, dj := truncateToDay(entries[i].Date), truncateToDay(entries[j].Date)
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return entryOrder[entries[i].Type] < entryOrder[entries[j].Type]
+	})
+
+	return entries, nil
+}
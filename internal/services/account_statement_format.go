@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+)
+
+// WriteAccountStatementCSV writes a statement as CSV: the opening balance,
+// one row per entry and the closing balance
+func WriteAccountStatementCSV(w io.Writer, statement *dto.AccountStatementResponse) error {
+	writer := csv.NewWriter(w)
+	amount := func(value float64) string { return strconv.FormatFloat(value, 'f', 2, 64) }
+
+	rows := [][]string{
+		{"date", "type", "description", "reference", "transaction_id", "debit", "credit", "balance"},
+		{statement.From.Format("2006-01-02"), "opening_balance", "Opening balance", "", "", "", "", amount(statement.OpeningBalance)},
+	}
+	for _, entry := range statement.Entries {
+		transactionID := ""
+		if entry.TransactionID != nil {
+			transactionID = strconv.FormatUint(uint64(*entry.TransactionID), 10)
+		}
+		rows = append(rows, []string{
+			entry.Date.Format("2006-01-02"),
+			entry.Type,
+			entry.Description,
+			entry.Reference,
+			transactionID,
+			amount(entry.Debit),
+			amount(entry.Credit),
+			amount(entry.Balance),
+		})
+	}
+	rows = append(rows, []string{
+		statement.To.Format("2006-01-02"), "closing_balance", "Closing balance", "", "",
+		amount(statement.TotalDebits), amount(statement.TotalCredits), amount(statement.ClosingBalance),
+	})
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// accountStatementTemplate renders a statement as a printable HTML document;
+// printing it from a browser gives the PDF version
+var accountStatementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date":   func(t time.Time) string { return t.Format("2006-01-02") },
+	"amount": func(value float64) string { return strconv.FormatFloat(value, 'f', 2, 64) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Loan {{.LoanID}} statement</title>
+<style>
+  body { font-family: sans-serif; font-size: 12px; margin: 2em; }
+  h1 { font-size: 18px; margin-bottom: 0; }
+  table { border-collapse: collapse; width: 100%; margin-top: 1em; }
+  th, td { border-bottom: 1px solid #ccc; padding: 4px 6px; text-align: left; }
+  td.amount, th.amount { text-align: right; }
+  tr.total td { font-weight: bold; border-top: 2px solid #000; }
+  @media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Loan account statement</h1>
+<p>
+  Loan {{.LoanID}} ({{.LoanStatus}}), principal {{amount .LoanAmount}}<br>
+  Borrower {{.BorrowerID}}{{if .BorrowerName}} - {{.BorrowerName}}{{end}}<br>
+  Period {{date .From}} to {{date .To}}
+</p>
+<table>
+  <thead>
+    <tr><th>Date</th><th>Type</th><th>Description</th><th>Reference</th><th class="amount">Debit</th><th class="amount">Credit</th><th class="amount">Balance</th></tr>
+  </thead>
+  <tbody>
+    <tr><td>{{date .From}}</td><td></td><td>Opening balance</td><td></td><td></td><td></td><td class="amount">{{amount .OpeningBalance}}</td></tr>
+    {{range .Entries}}
+    <tr><td>{{date .Date}}</td><td>{{.Type}}</td><td>{{.Description}}</td><td>{{.Reference}}</td><td class="amount">{{amount .Debit}}</td><td class="amount">{{amount .Credit}}</td><td class="amount">{{amount .Balance}}</td></tr>
+    {{end}}
+    <tr class="total"><td>{{date .To}}</td><td></td><td>Closing balance</td><td></td><td class="amount">{{amount .TotalDebits}}</td><td class="amount">{{amount .TotalCredits}}</td><td class="amount">{{amount .ClosingBalance}}</td></tr>
+  </tbody>
+</table>
+<p>Balances show installments and fees that have fallen due and are still unpaid.</p>
+</body>
+</html>
+`))
+
+// WriteAccountStatementHTML writes a statement as a printable HTML document
+func WriteAccountStatementHTML(w io.Writer, statement *dto.AccountStatementResponse) error {
+	return accountStatementTemplate.Execute(w, statement)
+}
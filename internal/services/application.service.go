@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// DeclinedError is returned when a loan application scores below the cutoff
+type DeclinedError struct {
+	Application *models.LoanApplication
+	Result      *ScoreResult
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("loan application declined with a score of %.0f", e.Result.Score)
+}
+
+// ApplicationService scores loan applications and keeps a record of each one
+type ApplicationService struct {
+	repo      *repositories.ApplicationRepository
+	loanRepo  *repositories.LoanRepository
+	groupRepo *repositories.GroupRepository
+	scorer    Scorer
+}
+
+// NewApplicationService creates a new application service instance
+func NewApplicationService(repo *repositories.ApplicationRepository, loanRepo *repositories.LoanRepository, groupRepo *repositories.GroupRepository, scorer Scorer) *ApplicationService {
+	return &ApplicationService{repo: repo, loanRepo: loanRepo, groupRepo: groupRepo, scorer: scorer}
+}
+
+// Assess scores a loan request and records it as an application. A declined
+// application is recorded too and returned with a *DeclinedError
+func (s *ApplicationService) Assess(ctx context.Context, borrowerID uint, amount float64, productID, groupID *uint) (*models.LoanApplication, error) {
+	input, err := s.scoreInput(ctx, borrowerID, amount, productID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.scorer.Score(*input)
+	if err != nil {
+		return nil, fmt.Errorf("scoring application: %w", err)
+	}
+
+	reasons, err := json.Marshal(result.Reasons)
+	if err != nil {
+		return nil, err
+	}
+
+	application := &models.LoanApplication{
+		BorrowerID: borrowerID,
+		ProductID:  productID,
+		GroupID:    groupID,
+		Amount:     amount,
+		LoanCycle:  input.LoanCycle,
+		Score:      result.Score,
+		Band:       result.Band,
+		Decision:   result.Decision,
+		Scorer:     result.Scorer,
+		Reasons:    string(reasons),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := s.repo.Create(ctx, application); err != nil {
+		return nil, err
+	}
+
+	if result.Decision != "approved" {
+		return application, &DeclinedError{Application: application, Result: result}
+	}
+	return application, nil
+}
+
+// LinkLoan records the loan booked from an approved application
+func (s *ApplicationService) LinkLoan(ctx context.Context, application *models.LoanApplication, loanID uint) error {
+	application.LoanID = &loanID
+	application.UpdatedAt = time.Now()
+	return s.repo.Update(ctx, application)
+}
+
+// GetApplication retrieves a loan application by its ID
+func (s *ApplicationService) GetApplication(ctx context.Context, id uint) (*models.LoanApplication, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListApplications returns loan applications, optionally filtered by borrower and decision
+func (s *ApplicationService) ListApplications(ctx context.Context, borrowerID uint, decision string) ([]models.LoanApplication, error) {
+	return s.repo.List(ctx, borrowerID, decision)
+}
+
+// Reasons decodes the score reasons stored on an application
+func (s *ApplicationService) Reasons(application *models.LoanApplication) ([]ScoreReason, error) {
+	reasons := []ScoreReason{}
+	if application.Reasons == "" {
+		return reasons, nil
+	}
+	if err := json.Unmarshal([]byte(application.Reasons), &reasons); err != nil {
+		return nil, err
+	}
+	return reasons, nil
+}
+
+// scoreInput works out the characteristics of an application from the
+// borrower's repayment history and the performance of their group
+func (s *ApplicationService) scoreInput(ctx context.Context, borrowerID uint, amount float64, productID, groupID *uint) (*ScoreInput, error) {
+	loans, err := s.loanRepo.GetByBorrower(ctx, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &ScoreInput{
+		BorrowerID: borrowerID,
+		Amount:     amount,
+		ProductID:  productID,
+		GroupID:    groupID,
+		LoanCycle:  1,
+	}
+
+	now := time.Now()
+	onTime := 0
+	fallenDue := false
+	maxDPD := 0.0
+	for _, loan := range loans {
+		if loan.Status == "completed" {
+			input.LoanCycle++
+		}
+		for _, payment := range loan.Payments {
+			var lateness time.Duration
+			switch {
+			case payment.Status == "paid" && payment.PaidDate != nil:
+				input.PaidInstallments++
+				lateness = truncateToDay(*payment.PaidDate).Sub(truncateToDay(payment.DueDate))
+				if lateness <= 0 {
+					onTime++
+				}
+			case payment.Status == "pending" || payment.Status == "written_off":
+				if payment.HeldUntil != nil && now.Before(*payment.HeldUntil) {
+					continue
+				}
+				lateness = truncateToDay(now).Sub(truncateToDay(payment.DueDate))
+			default:
+				continue
+			}
+
+			if !payment.DueDate.After(now) {
+				fallenDue = true
+			}
+			if days := lateness.Hours() / 24; days > maxDPD {
+				maxDPD = days
+			}
+		}
+	}
+
+	if input.PaidInstallments > 0 {
+		rate := float64(onTime) / float64(input.PaidInstallments)
+		input.OnTimeRate = &rate
+	}
+	if fallenDue {
+		input.MaxDaysPastDue = &maxDPD
+	}
+
+	if groupID != nil {
+		rate, err := s.groupArrearsRate(ctx, *groupID, now)
+		if err != nil {
+			return nil, err
+		}
+		input.GroupArrearsRate = rate
+	}
+
+	return input, nil
+}
+
+// groupArrearsRate returns the share of the group's outstanding installments
+// that is in arrears, or nil when the group has nothing outstanding
+func (s *ApplicationService) groupArrearsRate(ctx context.Context, groupID uint, now time.Time) (*float64, error) {
+	loans, err := s.groupRepo.GetLoans(ctx, groupID, "active", "defaulted")
+	if err != nil {
+		return nil, err
+	}
+
+	loanIDs := make([]uint, len(loans))
+	for i, loan := range loans {
+		loanIDs[i] = loan.ID
+	}
+	installments, err := s.groupRepo.GetInstallments(ctx, loanIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	outstanding := 0.0
+	for _, payment := range installments {
+		if payment.Status == "pending" {
+			outstanding += payment.Amount
+		}
+	}
+	if outstanding == 0 {
+		return nil, nil
+	}
+
+	arrears := 0.0
+	for _, amount := range arrearsByLoan(installments, now) {
+		arrears += amount
+	}
+	rate := arrears / outstanding
+	return &rate, nil
+}
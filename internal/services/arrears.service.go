@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// ArrearsService tracks missed installments and delinquent loans, recording
+// a domain event for each change
+type ArrearsService struct {
+	loanRepo *repositories.LoanRepository
+}
+
+// NewArrearsService creates a new arrears service instance
+func NewArrearsService(loanRepo *repositories.LoanRepository) *ArrearsService {
+	return &ArrearsService{loanRepo: loanRepo}
+}
+
+// EvaluateArrears marks the installments of active loans that have become
+// overdue as missed, flags loans with 2 or more consecutive missed
+// installments as delinquent and clears the flag of loans that caught up
+func (s *ArrearsService) EvaluateArrears(ctx context.Context) (*dto.ArrearsEvaluationResponse, error) {
+	result := &dto.ArrearsEvaluationResponse{
+		MissedPaymentIDs:  []uint{},
+		DelinquentLoanIDs: []uint{},
+		CuredLoanIDs:      []uint{},
+	}
+
+	loans, err := s.loanRepo.GetByStatus(ctx, "active")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range loans {
+		loan := &loans[i]
+		now := time.Now()
+
+		missed, err := s.loanRepo.GetNewlyMissed(ctx, loan.ID, now)
+		if err != nil {
+			return result, err
+		}
+		if len(missed) > 0 {
+			if err := s.loanRepo.MarkMissed(ctx, loan, missed, now); err != nil {
+				return result, err
+			}
+			for _, payment := range missed {
+				result.MissedPaymentIDs = append(result.MissedPaymentIDs, payment.ID)
+			}
+		}
+
+		missedCount, err := s.loanRepo.GetMissedPaymentsCount(ctx, loan.ID)
+		if err != nil {
+			return result, err
+		}
+
+		switch delinquent := missedCount >= 2; {
+		case delinquent && loan.DelinquentSince == nil:
+			event, err := s.delinquentEvent(ctx, loan, missedCount, now)
+			if err != nil {
+				return result, err
+			}
+			if err := s.loanRepo.MarkDelinquent(ctx, loan, event); err != nil {
+				return result, err
+			}
+			result.DelinquentLoanIDs = append(result.DelinquentLoanIDs, loan.ID)
+		case !delinquent && loan.DelinquentSince != nil:
+			if err := s.loanRepo.ClearDelinquent(ctx, loan); err != nil {
+				return result, err
+			}
+			result.CuredLoanIDs = append(result.CuredLoanIDs, loan.ID)
+		}
+	}
+
+	return result, nil
+}
+
+// delinquentEvent builds the loan.delinquent event of a loan
+func (s *ArrearsService) delinquentEvent(ctx context.Context, loan *models.Loan, missedCount int, now time.Time) (*dto.LoanDelinquentEvent, error) {
+	payments, err := s.loanRepo.GetPaymentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+	overdue := 0.0
+	for _, payment := range payments {
+		if payment.Status == "pending" && payment.MissedAt != nil {
+			overdue += payment.Amount
+		}
+	}
+
+	dpd, err := s.loanRepo.GetDaysPastDue(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoanDelinquentEvent{
+		LoanID:             loan.ID,
+		BorrowerID:         loan.BorrowerID,
+		GroupID:            loan.GroupID,
+		MissedInstallments: missedCount,
+		OverdueAmount:      overdue,
+		DaysPastDue:        dpd,
+		DelinquentSince:    now,
+	}, nil
+}
+
+// StartArrearsEvaluator runs EvaluateArrears on the given interval until the process exits
+func (s *ArrearsService) StartArrearsEvaluator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result, err := s.EvaluateArrears(ctx)
+		if err != nil {
+			log.Printf("Error evaluating arrears: %v", err)
+			continue
+		}
+		if len(result.MissedPaymentIDs) > 0 || len(result.DelinquentLoanIDs) > 0 || len(result.CuredLoanIDs) > 0 {
+			log.Printf("Arrears: %d installment(s) missed, %d loan(s) delinquent, %d loan(s) cured",
+				len(result.MissedPaymentIDs), len(result.DelinquentLoanIDs), len(result.CuredLoanIDs))
+		}
+	}
+}
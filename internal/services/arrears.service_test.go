@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+func TestArrearsRecordEachChangeWithItsEvent(t *testing.T) {
+	now := time.Now()
+	loan := models.Loan{ID: 4, TenantID: 1, Amount: 1000, TotalAmount: 1100, WeeklyPayment: 22, Status: "active"}
+	overdue := paymentRows(
+		models.Payment{ID: 41, LoanID: 4, WeekNum: 2, Amount: 22, DueDate: now.AddDate(0, 0, -10), Status: "pending"},
+		models.Payment{ID: 40, LoanID: 4, WeekNum: 1, Amount: 22, DueDate: now.AddDate(0, 0, -17), Status: "pending"},
+	)
+
+	t.Run("newly delinquent", func(t *testing.T) {
+		conn, db := dbtest.Open(t)
+		s := NewArrearsService(repositories.NewLoanRepository(conn))
+		db.Returning(loanRows(loan), "FROM `loans`")
+		db.Returning(overdue, "FROM `payments`")
+
+		result, err := s.EvaluateArrears(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.MissedPaymentIDs) != 2 || len(result.DelinquentLoanIDs) != 1 {
+			t.Fatalf("%d installments missed and %d loans delinquent, want 2 and 1", len(result.MissedPaymentIDs), len(result.DelinquentLoanIDs))
+		}
+
+		statements := db.Statements()
+		for _, change := range []struct {
+			name   string
+			update []string
+			event  string
+			count  int
+		}{
+			{"missed installments", []string{"UPDATE `payments`", "missed_at"}, dto.EventInstallmentMissed, 2},
+			{"delinquent loan", []string{"UPDATE `loans`", "delinquent_since"}, dto.EventLoanDelinquent, 1},
+		} {
+			var events []int
+			for i, statement := range statements {
+				if statement.Has("INSERT INTO `outbox_events`") && statement.HasArg(change.event) {
+					events = append(events, i)
+				}
+			}
+			if len(events) != change.count {
+				t.Errorf("%d %s events written for the %s, want %d", len(events), change.event, change.name, change.count)
+				continue
+			}
+			update := indexOf(statements, change.update...)
+			begin := lastIndexBefore(statements, update, "BEGIN")
+			commit := -1
+			if begin >= 0 {
+				commit = indexOf(statements[begin:], "COMMIT") + begin
+			}
+			if begin < 0 || commit < update || events[0] < update || events[len(events)-1] > commit {
+				t.Errorf("the %s and their events are not written in one transaction:\n%v", change.name, statements)
+			}
+		}
+	})
+
+	t.Run("already delinquent", func(t *testing.T) {
+		conn, db := dbtest.Open(t)
+		s := NewArrearsService(repositories.NewLoanRepository(conn))
+		rows := loanRows(loan)
+		rows.Columns = append(rows.Columns, "delinquent_since")
+		rows.Values[0] = append(rows.Values[0], now.AddDate(0, 0, -3))
+		db.Returning(rows, "FROM `loans`")
+		db.Returning(overdue, "FROM `payments`")
+		db.Returning(dbtest.Rows{}, "FROM `payments`", "missed_at IS NULL")
+
+		result, err := s.EvaluateArrears(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.MissedPaymentIDs) != 0 || len(result.DelinquentLoanIDs) != 0 || len(result.CuredLoanIDs) != 0 {
+			t.Errorf("nothing changed but the result is %+v", result)
+		}
+		if events := db.Find("INSERT INTO `outbox_events`"); len(events) != 0 {
+			t.Errorf("events were written again for a loan already delinquent:\n%v", events)
+		}
+	})
+}
+
+// lastIndexBefore returns the position of the last statement before end
+// containing every fragment, or -1
+func lastIndexBefore(statements []dbtest.Statement, end int, fragments ...string) int {
+	for i := end - 1; i >= 0; i-- {
+		if statements[i].Has(fragments...) {
+			return i
+		}
+	}
+	return -1
+}
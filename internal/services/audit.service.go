@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// auditSealBatch is how many entries are sealed or verified at a time
+const auditSealBatch = 500
+
+// AuditService queries the audit log and keeps its hash chain sealed and verifiable
+type AuditService struct {
+	repo *repositories.AuditRepository
+}
+
+// NewAuditService creates a new audit service instance
+func NewAuditService(repo *repositories.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// ListEntries returns audit entries matching the filter, newest first. The
+// limit defaults to 100 and is capped at 1000
+func (s *AuditService) ListEntries(ctx context.Context, filter repositories.AuditFilter) ([]models.AuditLog, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	if filter.Limit > 1000 {
+		filter.Limit = 1000
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// GetEntry retrieves an audit entry by its ID
+func (s *AuditService) GetEntry(ctx context.Context, id uint) (*models.AuditLog, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Seal links every committed entry not yet in the hash chain into it
+func (s *AuditService) Seal(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		sealed, err := s.repo.Seal(ctx, auditSealBatch)
+		total += sealed
+		if err != nil || sealed < auditSealBatch {
+			return total, err
+		}
+	}
+}
+
+// StartSealer seals new audit entries every interval until the process exits
+func (s *AuditService) StartSealer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.Seal(ctx); err != nil {
+			log.Printf("Error sealing audit log: %v", err)
+		}
+	}
+}
+
+// Verify seals pending entries and then recomputes the whole hash chain. An
+// entry that was edited, removed or reordered breaks the chain at its position
+func (s *AuditService) Verify(ctx context.Context) (*dto.AuditVerificationResponse, error) {
+	if _, err := s.Seal(ctx); err != nil {
+		return nil, err
+	}
+
+	head, err := s.repo.GetHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &dto.AuditVerificationResponse{HeadSequence: head.Sequence, HeadHash: head.Hash}
+	if result.Unsealed, err = s.repo.CountUnsealed(ctx); err != nil {
+		return nil, err
+	}
+
+	broken := func(sequence uint64, reason string) (*dto.AuditVerificationResponse, error) {
+		result.BrokenAt = &sequence
+		result.Error = reason
+		return result, nil
+	}
+
+	var position uint64
+	prevHash := ""
+	for {
+		entries, err := s.repo.GetChain(ctx, position, auditSealBatch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range entries {
+			entry := &entries[i]
+			expected := position + 1
+			if reason := chainBreak(expected, prevHash, entry); reason != "" {
+				return broken(expected, reason)
+			}
+			position = expected
+			prevHash = entry.Hash
+			result.Checked++
+		}
+		if len(entries) < auditSealBatch {
+			break
+		}
+	}
+
+	if position != head.Sequence || prevHash != head.Hash {
+		return broken(position+1, fmt.Sprintf("chain ends at %d but its head is at %d", position, head.Sequence))
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// chainBreak returns why a sealed entry does not belong at the expected
+// position after the entry hashed prevHash, or "" when it does
+func chainBreak(expected uint64, prevHash string, entry *models.AuditLog) string {
+	switch {
+	case entry.Sequence == nil || *entry.Sequence != expected:
+		return "entry is missing from the chain"
+	case entry.PrevHash != prevHash:
+		return "entry does not link to the one before it"
+	case entry.Hash != repositories.AuditHash(prevHash, entry):
+		return "entry content does not match its hash"
+	}
+	return ""
+}
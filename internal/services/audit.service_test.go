@@ -0,0 +1,129 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// sealedChain returns n entries sealed into a hash chain the way the audit
+// repository seals them
+func sealedChain(n int) []models.AuditLog {
+	occurredAt := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	entries := make([]models.AuditLog, n)
+	prevHash := ""
+	for i := range entries {
+		sequence := uint64(i + 1)
+		entries[i] = models.AuditLog{
+			TenantID:   models.DefaultTenantID,
+			Sequence:   &sequence,
+			OccurredAt: occurredAt.Add(time.Duration(i) * time.Second),
+			Actor:      "staff:1",
+			ActorType:  "user",
+			Action:     "update",
+			Entity:     "loans",
+			EntityID:   "7",
+			Before:     `{"status":"active"}`,
+			After:      `{"status":"paid"}`,
+			Changes:    `{"status":["active","paid"]}`,
+			PrevHash:   prevHash,
+		}
+		entries[i].Hash = repositories.AuditHash(prevHash, &entries[i])
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+// firstBreak walks a chain like Verify does and returns where and why it breaks
+func firstBreak(entries []models.AuditLog) (uint64, string) {
+	var position uint64
+	prevHash := ""
+	for i := range entries {
+		expected := position + 1
+		if reason := chainBreak(expected, prevHash, &entries[i]); reason != "" {
+			return expected, reason
+		}
+		position = expected
+		prevHash = entries[i].Hash
+	}
+	return 0, ""
+}
+
+func TestChainBreak(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func([]models.AuditLog) []models.AuditLog
+		wantAt     uint64
+		wantReason string
+	}{
+		{
+			name:   "untouched chain",
+			tamper: func(entries []models.AuditLog) []models.AuditLog { return entries },
+		},
+		{
+			name: "edited content",
+			tamper: func(entries []models.AuditLog) []models.AuditLog {
+				entries[1].After = `{"status":"written_off"}`
+				return entries
+			},
+			wantAt:     2,
+			wantReason: "entry content does not match its hash",
+		},
+		{
+			name: "entry moved to another tenant",
+			tamper: func(entries []models.AuditLog) []models.AuditLog {
+				entries[2].TenantID = 2
+				return entries
+			},
+			wantAt:     3,
+			wantReason: "entry content does not match its hash",
+		},
+		{
+			name: "edited content with its hash recomputed",
+			tamper: func(entries []models.AuditLog) []models.AuditLog {
+				entries[1].Actor = "staff:2"
+				entries[1].Hash = repositories.AuditHash(entries[1].PrevHash, &entries[1])
+				return entries
+			},
+			wantAt:     3,
+			wantReason: "entry does not link to the one before it",
+		},
+		{
+			name: "removed entry",
+			tamper: func(entries []models.AuditLog) []models.AuditLog {
+				return append(entries[:1], entries[2:]...)
+			},
+			wantAt:     2,
+			wantReason: "entry is missing from the chain",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(entries []models.AuditLog) []models.AuditLog {
+				entries[1], entries[2] = entries[2], entries[1]
+				return entries
+			},
+			wantAt:     2,
+			wantReason: "entry is missing from the chain",
+		},
+		{
+			name: "unsealed entry",
+			tamper: func(entries []models.AuditLog) []models.AuditLog {
+				entries[3].Sequence = nil
+				return entries
+			},
+			wantAt:     4,
+			wantReason: "entry is missing from the chain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, reason := firstBreak(tt.tamper(sealedChain(4)))
+			if at != tt.wantAt || reason != tt.wantReason {
+				t.Errorf("broken at %d with %q, want %d with %q", at, reason, tt.wantAt, tt.wantReason)
+			}
+		})
+	}
+}
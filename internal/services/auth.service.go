@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// ErrUnauthenticated is returned when a request carries no valid credentials
+var ErrUnauthenticated = NewError(CodeUnauthenticated, "missing or invalid credentials")
+
+// ErrForbidden is returned when a principal may not do what it asked, or not
+// to the record it asked about
+var ErrForbidden = NewError(CodeForbidden, "not allowed for this role")
+
+// apiKeyPrefix marks a bearer credential as an API key rather than a JWT
+const apiKeyPrefix = "bek_"
+
+// AuthConfig holds the settings used to verify staff bearer tokens
+type AuthConfig struct {
+	JWTSecret string
+	Issuer    string // when set, tokens from other issuers are rejected
+}
+
+// Principal is the staff member or machine client a request is made by
+type Principal struct {
+	Type        string // user, api_key
+	Subject     string
+	Name        string
+	Role        string
+	OfficerID   *uint
+	APIKeyID    *uint
+	TenantID    uint
+	BranchID    *uint    // nil for principals that reach every branch of the tenant
+	Permissions []string // granted by the role
+	Scope       string   // all, officer
+}
+
+// Can reports whether the principal's role grants a permission
+func (p *Principal) Can(permission string) bool {
+	return grants(p.Permissions, permission)
+}
+
+// ActsFor reports whether the principal may work on the groups of an
+// officer: officer scoped principals only act for their own officer
+func (p *Principal) ActsFor(officerID uint) bool {
+	if p.Scope != "officer" {
+		return true
+	}
+	return p.OfficerID != nil && *p.OfficerID == officerID
+}
+
+// TenantScope returns the tenant and branch the principal's requests are limited to
+func (p *Principal) TenantScope() repositories.TenantScope {
+	return repositories.TenantScope{TenantID: p.TenantID, BranchID: p.BranchID}
+}
+
+// Actor returns the identity recorded against the changes a principal makes
+func (p *Principal) Actor() string {
+	return p.Subject
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal carried by ctx, or nil
+func PrincipalFrom(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// AuthService authenticates requests with staff bearer tokens or API keys and
+// manages the API keys issued to machine clients
+type AuthService struct {
+	apiKeyRepo *repositories.APIKeyRepository
+	branchRepo *repositories.BranchRepository
+	config     AuthConfig
+	policy     AccessPolicy
+}
+
+// NewAuthService creates a new auth service instance
+func NewAuthService(apiKeyRepo *repositories.APIKeyRepository, branchRepo *repositories.BranchRepository, config AuthConfig, policy AccessPolicy) *AuthService {
+	return &AuthService{apiKeyRepo: apiKeyRepo, branchRepo: branchRepo, config: config, policy: policy}
+}
+
+// Authenticate resolves a credential, either an API key or a staff JWT, to
+// the principal it belongs to, with the permissions of its role
+func (s *AuthService) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	var principal *Principal
+	var err error
+
+	credential = strings.TrimSpace(credential)
+	switch {
+	case credential == "":
+		return nil, ErrUnauthenticated
+	case strings.HasPrefix(credential, apiKeyPrefix):
+		principal, err = s.authenticateAPIKey(ctx, credential)
+	default:
+		principal, err = s.authenticateToken(credential)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role, ok := s.policy.Role(principal.Role)
+	if !ok {
+		return nil, NewError(CodeUnauthenticated, fmt.Sprintf("role %q is not recognised", principal.Role))
+	}
+	principal.Permissions = role.Permissions
+	principal.Scope = role.Scope
+	if principal.Scope == "" {
+		principal.Scope = "all"
+	}
+	if principal.Scope == "officer" && principal.OfficerID == nil {
+		return nil, NewError(CodeUnauthenticated, fmt.Sprintf("role %q needs an officer_id", principal.Role))
+	}
+	return principal, nil
+}
+
+// Roles returns the roles of the access policy
+func (s *AuthService) Roles() []string {
+	return s.policy.RoleNames()
+}
+
+// authenticateToken verifies a staff JWT
+func (s *AuthService) authenticateToken(token string) (*Principal, error) {
+	if s.config.JWTSecret == "" {
+		return nil, ErrUnauthenticated
+	}
+	claims, err := parseToken(s.config.JWTSecret, s.config.Issuer, token, time.Now())
+	if err != nil {
+		return nil, NewError(CodeUnauthenticated, err.Error())
+	}
+
+	tenantID := models.DefaultTenantID
+	if claims.TenantID != nil {
+		tenantID = *claims.TenantID
+	}
+
+	return &Principal{
+		Type:      "user",
+		Subject:   claims.Subject,
+		Name:      claims.Name,
+		Role:      claims.Role,
+		OfficerID: claims.OfficerID,
+		TenantID:  tenantID,
+		BranchID:  claims.BranchID,
+	}, nil
+}
+
+// authenticateAPIKey looks an API key up by its hash and checks it is still live
+func (s *AuthService) authenticateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		return nil, NewError(CodeUnauthenticated, "api key has been revoked")
+	}
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return nil, NewError(CodeUnauthenticated, "api key has expired")
+	}
+
+	// Recording every use would write on every request; a minute's precision is enough
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > time.Minute {
+		s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, now)
+	}
+
+	id := apiKey.ID
+	return &Principal{
+		Type:     "api_key",
+		Subject:  "api_key:" + apiKey.Prefix,
+		Name:     apiKey.Name,
+		Role:     apiKey.Role,
+		APIKeyID: &id,
+		TenantID: apiKey.TenantID,
+		BranchID: apiKey.BranchID,
+	}, nil
+}
+
+// CreateAPIKey issues a new API key. The key is returned once alongside its
+// record and cannot be recovered afterwards
+func (s *AuthService) CreateAPIKey(ctx context.Context, req dto.APIKeyRequest, createdBy string) (*models.APIKey, string, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, "", Validation("name is required")
+	}
+	role, ok := s.policy.Role(req.Role)
+	if !ok {
+		return nil, "", Validation(fmt.Sprintf("role must be one of %s", strings.Join(s.policy.RoleNames(), ", ")))
+	}
+	if role.Scope == "officer" {
+		return nil, "", Validation(fmt.Sprintf("role %s is limited to an officer's groups and cannot be given to an api key", req.Role))
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", Validation("expires_at must be in the future")
+	}
+
+	// The key belongs to the creator's tenant and never reaches further than
+	// the creator: its role grants nothing the creator's role does not, and a
+	// creator limited to a branch issues keys for that branch
+	principal := PrincipalFrom(ctx)
+	if principal == nil {
+		return nil, "", ErrUnauthenticated
+	}
+	if principal.Scope == "officer" {
+		return nil, "", NewError(CodeForbidden, "principals limited to an officer's groups cannot issue api keys")
+	}
+	if missing := ungranted(principal.Permissions, role.Permissions); len(missing) > 0 {
+		return nil, "", NewError(CodeForbidden, fmt.Sprintf("role %s grants permissions you do not have", req.Role)).
+			WithDetails(map[string]interface{}{"permissions": missing})
+	}
+	branchID := req.BranchID
+	if principal.BranchID != nil {
+		if branchID != nil && *branchID != *principal.BranchID {
+			return nil, "", NewError(CodeForbidden, "api keys can only be issued for your own branch")
+		}
+		branchID = principal.BranchID
+	}
+	if branchID != nil {
+		branch, err := s.branchRepo.GetByID(ctx, *branchID)
+		if err != nil {
+			return nil, "", err
+		}
+		if branch.TenantID != principal.TenantID {
+			return nil, "", NotFound("branch not found")
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := &models.APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    key[:12],
+		KeyHash:   hashAPIKey(key),
+		Role:      req.Role,
+		TenantID:  principal.TenantID,
+		BranchID:  branchID,
+		CreatedBy: createdBy,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
+
+// ListAPIKeys returns every API key issued, including revoked ones
+func (s *AuthService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.apiKeyRepo.List(ctx)
+}
+
+// RevokeAPIKey stops an API key from authenticating any further requests
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id uint, revokedBy string) (*models.APIKey, error) {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, Conflict("api key is already revoked")
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	apiKey.RevokedBy = revokedBy
+	apiKey.UpdatedAt = now
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of an API key. Keys carry 256 random
+// bits, so a plain hash is enough to make the stored value useless if leaked
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
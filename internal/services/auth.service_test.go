@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+func TestAuthenticateToken(t *testing.T) {
+	officerID, tenantID, branchID := uint(12), uint(3), uint(8)
+	expires := time.Now().Add(time.Hour).Unix()
+	s := &AuthService{config: AuthConfig{JWTSecret: "secret", Issuer: "hr"}, policy: DefaultAccessPolicy()}
+
+	tests := []struct {
+		name       string
+		claims     TokenClaims
+		wantTenant uint
+		wantBranch *uint
+		wantScope  string
+		wantCode   ErrorCode
+	}{
+		{
+			name:       "officer token",
+			claims:     TokenClaims{Subject: "staff:1", Role: "field_officer", OfficerID: &officerID, Issuer: "hr", ExpiresAt: expires},
+			wantTenant: models.DefaultTenantID,
+			wantScope:  "officer",
+		},
+		{
+			name:       "branch limited token of another tenant",
+			claims:     TokenClaims{Subject: "staff:2", Role: "branch_manager", TenantID: &tenantID, BranchID: &branchID, Issuer: "hr", ExpiresAt: expires},
+			wantTenant: tenantID,
+			wantBranch: &branchID,
+			wantScope:  "all",
+		},
+		{
+			name:     "officer role without an officer",
+			claims:   TokenClaims{Subject: "staff:3", Role: "field_officer", Issuer: "hr", ExpiresAt: expires},
+			wantCode: CodeUnauthenticated,
+		},
+		{
+			name:     "unknown role",
+			claims:   TokenClaims{Subject: "staff:4", Role: "janitor", Issuer: "hr", ExpiresAt: expires},
+			wantCode: CodeUnauthenticated,
+		},
+		{
+			name:     "expired",
+			claims:   TokenClaims{Subject: "staff:5", Role: "admin", Issuer: "hr", ExpiresAt: time.Now().Add(-time.Minute).Unix()},
+			wantCode: CodeUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := SignToken("secret", tt.claims)
+			if err != nil {
+				t.Fatalf("sign token: %v", err)
+			}
+
+			principal, err := s.Authenticate(context.Background(), "  "+token+" ")
+			if tt.wantCode != "" {
+				if CodeOf(err) != tt.wantCode {
+					t.Fatalf("got %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+			if principal.Type != "user" || principal.Subject != tt.claims.Subject || principal.Scope != tt.wantScope {
+				t.Errorf("got %s %s with scope %s", principal.Type, principal.Subject, principal.Scope)
+			}
+			if principal.TenantID != tt.wantTenant {
+				t.Errorf("tenant %d, want %d", principal.TenantID, tt.wantTenant)
+			}
+			if (principal.BranchID == nil) != (tt.wantBranch == nil) || (tt.wantBranch != nil && *principal.BranchID != *tt.wantBranch) {
+				t.Errorf("branch %v, want %v", principal.BranchID, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRefusesTokensWithoutASecret(t *testing.T) {
+	token, err := SignToken("secret", TokenClaims{Subject: "staff:1", Role: "admin", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	for _, credential := range []string{"", "   ", token} {
+		s := &AuthService{policy: DefaultAccessPolicy()}
+		if _, err := s.Authenticate(context.Background(), credential); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("credential %q: got %v, want %v", credential, err, ErrUnauthenticated)
+		}
+	}
+}
+
+func TestPrincipalPermissions(t *testing.T) {
+	officerID, otherID := uint(12), uint(13)
+
+	tests := []struct {
+		name       string
+		principal  Principal
+		permission string
+		officerID  uint
+		wantCan    bool
+		wantActs   bool
+	}{
+		{"exact permission", Principal{Permissions: []string{"loans:read"}, Scope: "all"}, "loans:read", otherID, true, true},
+		{"other action", Principal{Permissions: []string{"loans:read"}, Scope: "all"}, "loans:create", otherID, false, true},
+		{"any resource", Principal{Permissions: []string{"*:read"}, Scope: "all"}, "payments:read", otherID, true, true},
+		{"any action", Principal{Permissions: []string{"loans:*"}, Scope: "all"}, "loans:restructure", otherID, true, true},
+		{"everything", Principal{Permissions: []string{"*"}, Scope: "all"}, "write_offs:approve", otherID, true, true},
+		{"nothing", Principal{Scope: "all"}, "loans:read", otherID, false, true},
+		{"own officer", Principal{Permissions: []string{"groups:read"}, Scope: "officer", OfficerID: &officerID}, "groups:read", officerID, true, true},
+		{"another officer", Principal{Permissions: []string{"groups:read"}, Scope: "officer", OfficerID: &officerID}, "groups:read", otherID, true, false},
+		{"officer scope without an officer", Principal{Scope: "officer"}, "groups:read", officerID, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Can(tt.permission); got != tt.wantCan {
+				t.Errorf("Can(%s) = %v, want %v", tt.permission, got, tt.wantCan)
+			}
+			if got := tt.principal.ActsFor(tt.officerID); got != tt.wantActs {
+				t.Errorf("ActsFor(%d) = %v, want %v", tt.officerID, got, tt.wantActs)
+			}
+		})
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	key := apiKeyPrefix + "abc"
+	hash := hashAPIKey(key)
+	if len(hash) != 64 || hash == key {
+		t.Fatalf("hash %q is not a hex SHA-256", hash)
+	}
+	if hashAPIKey(key) != hash {
+		t.Error("the same key hashes differently")
+	}
+	if hashAPIKey(key+"d") == hash {
+		t.Error("different keys hash the same")
+	}
+}
+
+func TestAPIKeysGrantNoMoreThanTheirCreator(t *testing.T) {
+	policy := DefaultAccessPolicy()
+	creator := func(role string) *Principal {
+		return &Principal{Type: "user", Subject: "staff:1", Role: role, TenantID: models.DefaultTenantID,
+			Permissions: policy.Roles[role].Permissions, Scope: "all"}
+	}
+
+	tests := []struct {
+		name    string
+		creator *Principal
+		role    string
+		want    ErrorCode
+	}{
+		{"admin issues any role", creator("admin"), "finance", ""},
+		{"same role", creator("finance"), "finance", ""},
+		{"narrower role", creator("finance"), "auditor", ""},
+		{"wider role", creator("finance"), "admin", CodeForbidden},
+		{"role with other permissions", creator("finance"), "branch_manager", CodeForbidden},
+		{"custom role within wildcards", &Principal{Permissions: []string{"*:read", "*:list"}, Scope: "all"}, "auditor", ""},
+		{"creator limited to an officer", &Principal{Permissions: []string{"*"}, Scope: "officer"}, "auditor", CodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, db := dbtest.Open(t)
+			s := NewAuthService(repositories.NewAPIKeyRepository(conn), repositories.NewBranchRepository(conn), AuthConfig{}, policy)
+
+			_, _, err := s.CreateAPIKey(WithPrincipal(context.Background(), tt.creator), dto.APIKeyRequest{Name: "ci", Role: tt.role}, "staff:1")
+			issued := len(db.Find("INSERT INTO `api_keys`")) > 0
+			if tt.want == "" {
+				if err != nil || !issued {
+					t.Fatalf("got %v and issued %v, want the key issued", err, issued)
+				}
+				return
+			}
+			if CodeOf(err) != tt.want || issued {
+				t.Fatalf("got %v and issued %v, want %s", err, issued, tt.want)
+			}
+		})
+	}
+}
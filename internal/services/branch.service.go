@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// BranchService handles the branches of a tenant and reports across them
+type BranchService struct {
+	repo *repositories.BranchRepository
+}
+
+// NewBranchService creates a new branch service instance
+func NewBranchService(repo *repositories.BranchRepository) *BranchService {
+	return &BranchService{repo: repo}
+}
+
+// CreateBranch opens a new branch in the caller's tenant
+func (s *BranchService) CreateBranch(ctx context.Context, req dto.BranchRequest) (*models.Branch, error) {
+	code := strings.TrimSpace(req.Code)
+	name := strings.TrimSpace(req.Name)
+	if code == "" || name == "" {
+		return nil, Validation("code and name are required")
+	}
+
+	branch := &models.Branch{
+		Code:      code,
+		Name:      name,
+		Region:    req.Region,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+// GetBranch retrieves a branch of the caller's tenant by ID
+func (s *BranchService) GetBranch(ctx context.Context, id uint) (*models.Branch, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListBranches returns the branches of the caller's tenant, optionally filtered by region
+func (s *BranchService) ListBranches(ctx context.Context, region string) ([]models.Branch, error) {
+	return s.repo.List(ctx, region)
+}
+
+// GetBranchReport summarises the portfolio of each branch the caller can
+// reach as of now, with the collections received from from up to to. A
+// caller limited to one branch only sees that branch
+func (s *BranchService) GetBranchReport(ctx context.Context, from, to time.Time) (*dto.BranchReportResponse, error) {
+	if !to.After(from) {
+		return nil, Validation("to must be after from")
+	}
+
+	now := time.Now()
+	totals, err := s.repo.GetBranchTotals(ctx, now, from, to)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := s.repo.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byBranch := map[uint]repositories.BranchTotals{}
+	var unassigned *repositories.BranchTotals
+	for i := range totals {
+		if totals[i].BranchID == nil {
+			unassigned = &totals[i]
+			continue
+		}
+		byBranch[*totals[i].BranchID] = totals[i]
+	}
+
+	scope, _ := repositories.TenantScopeFrom(ctx)
+	response := &dto.BranchReportResponse{
+		AsOf:     now,
+		From:     from,
+		To:       to,
+		Branches: []dto.BranchReportItem{},
+		Total:    dto.BranchReportItem{Name: "Total"},
+	}
+	for i := range branches {
+		branch := &branches[i]
+		if scope.BranchID != nil && *scope.BranchID != branch.ID {
+			continue
+		}
+		id := branch.ID
+		item := toBranchReportItem(byBranch[branch.ID])
+		item.BranchID = &id
+		item.Code = branch.Code
+		item.Name = branch.Name
+		item.Region = branch.Region
+		response.Branches = append(response.Branches, item)
+	}
+	if unassigned != nil {
+		item := toBranchReportItem(*unassigned)
+		item.Name = "Unassigned"
+		response.Branches = append(response.Branches, item)
+	}
+
+	for _, item := range response.Branches {
+		total := &response.Total
+		total.Borrowers += item.Borrowers
+		total.Loans += item.Loans
+		total.ActiveLoans += item.ActiveLoans
+		total.DisbursedAmount += item.DisbursedAmount
+		total.OutstandingAmount += item.OutstandingAmount
+		total.OverdueAmount += item.OverdueAmount
+		total.CollectedAmount += item.CollectedAmount
+		total.WrittenOffAmount += item.WrittenOffAmount
+	}
+	response.Total.OverdueRate = overdueRate(response.Total.OverdueAmount, response.Total.OutstandingAmount)
+
+	return response, nil
+}
+
+func toBranchReportItem(totals repositories.BranchTotals) dto.BranchReportItem {
+	return dto.BranchReportItem{
+		Borrowers:         totals.Borrowers,
+		Loans:             totals.Loans,
+		ActiveLoans:       totals.ActiveLoans,
+		DisbursedAmount:   totals.DisbursedAmount,
+		OutstandingAmount: totals.OutstandingAmount,
+		OverdueAmount:     totals.OverdueAmount,
+		OverdueRate:       overdueRate(totals.OverdueAmount, totals.OutstandingAmount),
+		CollectedAmount:   totals.CollectedAmount,
+		WrittenOffAmount:  totals.WrittenOffAmount,
+	}
+}
+
+// overdueRate is the share of the outstanding amount that is overdue
+func overdueRate(overdue, outstanding float64) float64 {
+	if outstanding <= 0 {
+		return 0
+	}
+	return overdue / outstanding
+}
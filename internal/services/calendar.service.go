@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// CalendarService handles the holiday calendar and due date adjustment
+type CalendarService struct {
+	calendarRepo *repositories.CalendarRepository
+	productRepo  *repositories.ProductRepository
+	borrowerRepo *repositories.BorrowerRepository
+}
+
+// NewCalendarService creates a new calendar service instance
+func NewCalendarService(calendarRepo *repositories.CalendarRepository, productRepo *repositories.ProductRepository, borrowerRepo *repositories.BorrowerRepository) *CalendarService {
+	return &CalendarService{calendarRepo: calendarRepo, productRepo: productRepo, borrowerRepo: borrowerRepo}
+}
+
+// AdjustDueDates applies the due date rule of the loan's product to nominal due
+// dates, using the calendar of the borrower's region. Loans without a product
+// keep their nominal dates
+func (s *CalendarService) AdjustDueDates(ctx context.Context, borrowerID uint, productID *uint, dueDates []time.Time) ([]time.Time, error) {
+	if productID == nil || len(dueDates) == 0 {
+		return dueDates, nil
+	}
+
+	product, err := s.productRepo.GetByID(ctx, *productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.DueDateRule == "keep" {
+		return dueDates, nil
+	}
+
+	borrower, err := s.borrowerRepo.GetByID(ctx, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave room on both sides for a date to roll over a long run of holidays
+	from := dueDates[0].AddDate(0, 0, -31)
+	to := dueDates[len(dueDates)-1].AddDate(0, 0, 31)
+	calendar, err := s.calendarRepo.Load(ctx, borrower.TenantID, borrower.Region, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	adjusted := make([]time.Time, len(dueDates))
+	for i, dueDate := range dueDates {
+		adjusted[i] = calendar.Adjust(dueDate, product.DueDateRule)
+	}
+	return adjusted, nil
+}
+
+// CreateHoliday adds a holiday to the calendar
+func (s *CalendarService) CreateHoliday(ctx context.Context, date time.Time, name, region string) (*models.CalendarHoliday, error) {
+	if date.IsZero() || name == "" {
+		return nil, Validation("date and name are required")
+	}
+
+	holiday := &models.CalendarHoliday{
+		Date:      truncateToDay(date),
+		Region:    region,
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.calendarRepo.Create(ctx, holiday); err != nil {
+		return nil, err
+	}
+	return holiday, nil
+}
+
+// GetHoliday retrieves a holiday by its ID
+func (s *CalendarService) GetHoliday(ctx context.Context, id uint) (*models.CalendarHoliday, error) {
+	return s.calendarRepo.GetByID(ctx, id)
+}
+
+// UpdateHoliday changes the date, name or region of a holiday
+func (s *CalendarService) UpdateHoliday(ctx context.Context, id uint, date time.Time, name, region string) (*models.CalendarHoliday, error) {
+	holiday, err := s.calendarRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !date.IsZero() {
+		holiday.Date = truncateToDay(date)
+	}
+	if name != "" {
+		holiday.Name = name
+	}
+	holiday.Region = region
+	holiday.UpdatedAt = time.Now()
+
+	if err := s.calendarRepo.Update(ctx, holiday); err != nil {
+		return nil, err
+	}
+	return holiday, nil
+}
+
+// DeleteHoliday removes a holiday from the calendar
+func (s *CalendarService) DeleteHoliday(ctx context.Context, id uint) error {
+	if _, err := s.calendarRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return s.calendarRepo.Delete(ctx, id)
+}
+
+// ListHolidays returns the holidays observed in a region (national ones
+// included) for a year, or for every region when region is empty
+func (s *CalendarService) ListHolidays(ctx context.Context, region string, year int) ([]models.CalendarHoliday, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local)
+	return s.calendarRepo.List(ctx, region, from, to)
+}
+
+// ImportHolidays reads holidays from a CSV file with a date,name,region header
+// (region may be empty for national holidays) and upserts them
+func (s *CalendarService) ImportHolidays(ctx context.Context, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, Validation(fmt.Sprintf("reading header: %v", err))
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"date", "name"} {
+		if _, ok := columns[required]; !ok {
+			return 0, Validation(fmt.Sprintf("missing %q column", required))
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	imported := 0
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, Validation(fmt.Sprintf("line %d: %v", line, err))
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", field(record, "date"), time.Local)
+		if err != nil {
+			return imported, Validation(fmt.Sprintf("line %d: invalid date, expected YYYY-MM-DD", line))
+		}
+		name := field(record, "name")
+		if name == "" {
+			return imported, Validation(fmt.Sprintf("line %d: name is required", line))
+		}
+
+		holiday := &models.CalendarHoliday{
+			Date:      date,
+			Region:    field(record, "region"),
+			Name:      name,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if err := s.calendarRepo.Upsert(ctx, holiday); err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
+		imported++
+	}
+
+	return imported, nil
+}
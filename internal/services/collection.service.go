@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// CollectionService handles field officers, their collection sheets and the
+// posting of cash collected at group meetings
+type CollectionService struct {
+	officerRepo    *repositories.OfficerRepository
+	groupRepo      *repositories.GroupRepository
+	collectionRepo *repositories.CollectionRepository
+	loanService    *LoanService
+	groupService   *GroupService
+	transactor     *repositories.Transactor
+}
+
+// NewCollectionService creates a new collection service instance
+func NewCollectionService(officerRepo *repositories.OfficerRepository, groupRepo *repositories.GroupRepository, collectionRepo *repositories.CollectionRepository, loanService *LoanService, groupService *GroupService, transactor *repositories.Transactor) *CollectionService {
+	return &CollectionService{
+		officerRepo:    officerRepo,
+		groupRepo:      groupRepo,
+		collectionRepo: collectionRepo,
+		loanService:    loanService,
+		groupService:   groupService,
+		transactor:     transactor,
+	}
+}
+
+// CreateOfficer registers a new field officer
+func (s *CollectionService) CreateOfficer(ctx context.Context, req dto.CreateOfficerRequest) (*models.Officer, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, Validation("name is required")
+	}
+
+	officer := &models.Officer{
+		Name:      req.Name,
+		Phone:     req.Phone,
+		Region:    req.Region,
+		BranchID:  req.BranchID,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.officerRepo.Create(ctx, officer); err != nil {
+		return nil, err
+	}
+	return officer, nil
+}
+
+// GetOfficer retrieves a field officer by ID
+func (s *CollectionService) GetOfficer(ctx context.Context, id uint) (*models.Officer, error) {
+	return s.officerRepo.GetByID(ctx, id)
+}
+
+// ListOfficers lists field officers, optionally filtered by region
+func (s *CollectionService) ListOfficers(ctx context.Context, region string) ([]models.Officer, error) {
+	return s.officerRepo.List(ctx, region)
+}
+
+// GetCollectionSheet builds the officer's expected collections for a date from
+// the sheets of every group they run that meets on that weekday
+func (s *CollectionService) GetCollectionSheet(ctx context.Context, officerID uint, date time.Time) (*dto.OfficerCollectionSheetResponse, error) {
+	officer, err := s.officerRepo.GetByID(ctx, officerID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.officerRepo.GetGroups(ctx, officerID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.OfficerCollectionSheetResponse{
+		OfficerID:   officer.ID,
+		OfficerName: officer.Name,
+		Date:        truncateToDay(date),
+		Groups:      []dto.GroupCollectionSheetResponse{},
+	}
+	for _, group := range groups {
+		if weekdays[strings.ToLower(group.MeetingDay)] != date.Weekday() {
+			continue
+		}
+
+		sheet, err := s.groupService.GetCollectionSheet(ctx, group.ID, date)
+		if err != nil {
+			return nil, err
+		}
+		response.Groups = append(response.Groups, *sheet)
+		response.TotalDue += sheet.TotalDue
+	}
+
+	return response, nil
+}
+
+// PostBatch applies a collection sheet posted by an officer. An officer posts
+// one sheet per day: the batch is stored first and its lines are posted in
+// the same transaction, so a second sheet for the day is turned away before
+// any money is posted twice. A line that cannot be posted does not hold back
+// the rest of the sheet; the outcome of every line is stored on the batch and
+// returned. Posting the sheet again with only lines that failed retries those
+// lines on the existing batch
+func (s *CollectionService) PostBatch(ctx context.Context, req dto.CollectionBatchRequest) (*models.CollectionBatch, error) {
+	officer, err := s.officerRepo.GetByID(ctx, req.OfficerID)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		return nil, Validation("invalid date, expected YYYY-MM-DD")
+	}
+	if len(req.Lines) == 0 {
+		return nil, Validation("collection sheet has no lines")
+	}
+
+	// Every loan must be on one of the officer's groups and appear only once
+	groups, err := s.officerRepo.GetGroups(ctx, officer.ID)
+	if err != nil {
+		return nil, err
+	}
+	officerLoans := map[uint]bool{}
+	for _, group := range groups {
+		loans, err := s.groupRepo.GetLoans(ctx, group.ID, "active", "defaulted")
+		if err != nil {
+			return nil, err
+		}
+		for _, loan := range loans {
+			officerLoans[loan.ID] = true
+		}
+	}
+
+	seen := map[uint]bool{}
+	for _, line := range req.Lines {
+		if seen[line.LoanID] {
+			return nil, Validation(fmt.Sprintf("loan %d appears more than once on the sheet", line.LoanID))
+		}
+		seen[line.LoanID] = true
+	}
+
+	source := PaymentSource{
+		Channel:    "field",
+		Reference:  fmt.Sprintf("officer-%d/%s", officer.ID, date.Format("2006-01-02")),
+		ReceivedBy: officer.Name,
+		ReceivedAt: date,
+	}
+
+	if existing, err := s.collectionRepo.GetBatchBySheet(ctx, officer.ID, date); err == nil {
+		return s.retryLines(ctx, existing.ID, req.Lines, officerLoans, source)
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := &models.CollectionBatch{
+		OfficerID:      officer.ID,
+		CollectionDate: date,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	err = s.transactor.Run(ctx, func(ctx context.Context) error {
+		if err := s.collectionRepo.CreateBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		for i, item := range req.Lines {
+			line := models.CollectionBatchLine{
+				LineNo:    i + 1,
+				LoanID:    item.LoanID,
+				Result:    item.Result,
+				Amount:    item.Amount,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := s.postLine(ctx, &line, officerLoans[item.LoanID], source); err != nil {
+				return err
+			}
+
+			switch line.Status {
+			case "posted":
+				batch.PostedLines++
+				batch.TotalCollected += line.Amount
+			case "failed":
+				batch.FailedLines++
+			}
+			batch.Lines = append(batch.Lines, line)
+		}
+
+		return s.collectionRepo.CompleteBatch(ctx, batch)
+	})
+	if err != nil {
+		// A concurrent post of the same sheet won the unique index
+		if existing, lookupErr := s.collectionRepo.GetBatchBySheet(ctx, officer.ID, date); lookupErr == nil {
+			return nil, sheetPosted(existing)
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+// sheetPosted is the error for a collection sheet that was already posted
+func sheetPosted(batch *models.CollectionBatch) error {
+	return Conflict(fmt.Sprintf("collection sheet for %s was already posted as batch %d", batch.CollectionDate.Format("2006-01-02"), batch.ID)).
+		WithDetails(map[string]interface{}{"batch_id": batch.ID})
+}
+
+// retryLines posts the failed lines of a collection sheet again, on the batch
+// they were first posted with. The batch stays locked while they are posted,
+// so the same line is never retried twice at once. Every line sent must have
+// failed before; anything else is turned away as the sheet already posted
+func (s *CollectionService) retryLines(ctx context.Context, batchID uint, items []dto.CollectionBatchLineRequest, officerLoans map[uint]bool, source PaymentSource) (*models.CollectionBatch, error) {
+	var batch *models.CollectionBatch
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		var err error
+		batch, err = s.collectionRepo.GetBatchForUpdate(ctx, batchID)
+		if err != nil {
+			return err
+		}
+
+		byLoan := make(map[uint]*models.CollectionBatchLine, len(batch.Lines))
+		for i := range batch.Lines {
+			byLoan[batch.Lines[i].LoanID] = &batch.Lines[i]
+		}
+		for _, item := range items {
+			if line := byLoan[item.LoanID]; line == nil || line.Status != "failed" {
+				return Conflict(fmt.Sprintf("collection sheet for %s was already posted as batch %d and loan %d is not one of its failed lines", batch.CollectionDate.Format("2006-01-02"), batch.ID, item.LoanID)).
+					WithDetails(map[string]interface{}{"batch_id": batch.ID, "loan_id": item.LoanID})
+			}
+		}
+
+		now := time.Now()
+		retried := make([]*models.CollectionBatchLine, 0, len(items))
+		for _, item := range items {
+			line := byLoan[item.LoanID]
+			line.Result = item.Result
+			line.Amount = item.Amount
+			line.Error = ""
+			line.UpdatedAt = now
+			if err := s.postLine(ctx, line, officerLoans[item.LoanID], source); err != nil {
+				return err
+			}
+
+			batch.FailedLines--
+			switch line.Status {
+			case "posted":
+				batch.PostedLines++
+				batch.TotalCollected += line.Amount
+			case "failed":
+				batch.FailedLines++
+			}
+			retried = append(retried, line)
+		}
+
+		batch.UpdatedAt = now
+		return s.collectionRepo.UpdateBatch(ctx, batch, retried)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// postLine posts the money on one sheet line and records the outcome on it.
+// Failures such as a wrong amount are recorded on the line; others, such as
+// a database failure, are returned so that the whole sheet rolls back
+func (s *CollectionService) postLine(ctx context.Context, line *models.CollectionBatchLine, onOfficerGroup bool, source PaymentSource) error {
+	fail := func(err error) error {
+		if CodeOf(err) == CodeInternal {
+			return err
+		}
+		line.Status = "failed"
+		line.Error = err.Error()
+		return nil
+	}
+
+	if !onOfficerGroup {
+		return fail(Validation("loan is not on any of the officer's groups"))
+	}
+
+	var transaction *models.PaymentTransaction
+	var err error
+	switch line.Result {
+	case "unpaid":
+		// Nothing collected; the line is kept so the sheet shows the borrower was visited
+		line.Status = "recorded"
+		line.Amount = 0
+		return nil
+	case "paid":
+		transaction, err = s.loanService.PostPayment(ctx, line.LoanID, line.Amount, source)
+	case "partial":
+		transaction, err = s.loanService.PostPartialPayment(ctx, line.LoanID, line.Amount, source)
+	default:
+		err = Validation("result must be one of paid, unpaid, partial")
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	line.Status = "posted"
+	line.TransactionID = &transaction.ID
+	line.UnappliedAmount = transaction.UnappliedAmount
+	if transaction.AppliedAmount > 0 {
+		line.InstallmentsPaid = s.countInstallments(ctx, transaction.ID, line.LoanID)
+	}
+	return nil
+}
+
+// countInstallments counts the installments a transaction settled
+func (s *CollectionService) countInstallments(ctx context.Context, transactionID, loanID uint) int {
+	payments, err := s.loanService.GetPaymentsByLoanID(ctx, loanID)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	for _, payment := range payments {
+		if payment.TransactionID != nil && *payment.TransactionID == transactionID {
+			count++
+		}
+	}
+	return count
+}
+
+// GetBatch retrieves a posted collection sheet with its line results
+func (s *CollectionService) GetBatch(ctx context.Context, id uint) (*models.CollectionBatch, error) {
+	return s.collectionRepo.GetBatchByID(ctx, id)
+}
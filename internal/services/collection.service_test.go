@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/repositories"
+)
+
+func TestRetryLinesPostsOnlyFailedLines(t *testing.T) {
+	date := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	batchRows := dbtest.Row([]string{"id", "tenant_id", "officer_id", "collection_date", "total_collected", "posted_lines", "failed_lines"},
+		9, 1, 3, date, 50.0, 1, 1)
+	lineRows := dbtest.Rows{
+		Columns: []string{"id", "tenant_id", "batch_id", "line_no", "loan_id", "result", "amount", "status", "error"},
+		Values: [][]interface{}{
+			{90, 1, 9, 1, 1, "paid", 50.0, "posted", ""},
+			{91, 1, 9, 2, 2, "paid", 20.0, "failed", "payment amount must match the installment amount of 50"},
+		},
+	}
+	source := PaymentSource{Channel: "field", ReceivedAt: date}
+
+	newService := func(t *testing.T) (*CollectionService, *dbtest.DB) {
+		loans, db, conn := newLoanService(t)
+		db.Returning(batchRows, "FROM `collection_batches`")
+		db.Returning(lineRows, "FROM `collection_batch_lines`")
+		return NewCollectionService(nil, nil, repositories.NewCollectionRepository(conn), loans, nil, repositories.NewTransactor(conn)), db
+	}
+
+	t.Run("a failed line is posted again on its batch", func(t *testing.T) {
+		s, db := newService(t)
+		batch, err := s.retryLines(context.Background(), 9, []dto.CollectionBatchLineRequest{{LoanID: 2, Result: "unpaid"}}, map[uint]bool{1: true, 2: true}, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if batch.FailedLines != 0 || batch.PostedLines != 1 || batch.TotalCollected != 50 {
+			t.Errorf("batch totals %d failed, %d posted, %.2f collected; want 0, 1, 50.00", batch.FailedLines, batch.PostedLines, batch.TotalCollected)
+		}
+		if line := batch.Lines[1]; line.Status != "recorded" || line.Error != "" {
+			t.Errorf("retried line is %s (%q), want recorded without an error", line.Status, line.Error)
+		}
+		if len(db.Find("FROM `collection_batches`", "FOR UPDATE")) != 1 {
+			t.Error("the batch was not locked while its lines were retried")
+		}
+		updates := db.Find("UPDATE `collection_batch_lines`")
+		if len(updates) != 1 || !updates[0].HasArg(91) {
+			t.Errorf("updated lines %v, want only line 91", updates)
+		}
+	})
+
+	t.Run("a line that was posted is turned away", func(t *testing.T) {
+		s, db := newService(t)
+		_, err := s.retryLines(context.Background(), 9, []dto.CollectionBatchLineRequest{
+			{LoanID: 2, Result: "unpaid"},
+			{LoanID: 1, Result: "paid", Amount: 50},
+		}, map[uint]bool{1: true, 2: true}, source)
+		if CodeOf(err) != CodeConflict {
+			t.Fatalf("got %v, want a conflict", err)
+		}
+		if changes := db.Find("UPDATE `"); len(changes) != 0 {
+			t.Errorf("a refused retry changed %v", changes)
+		}
+	})
+
+	t.Run("a loan that was not on the sheet is turned away", func(t *testing.T) {
+		s, _ := newService(t)
+		_, err := s.retryLines(context.Background(), 9, []dto.CollectionBatchLineRequest{{LoanID: 5, Result: "unpaid"}}, map[uint]bool{5: true}, source)
+		if CodeOf(err) != CodeConflict {
+			t.Fatalf("got %v, want a conflict", err)
+		}
+	})
+
+	t.Run("a line that fails again stays failed", func(t *testing.T) {
+		s, _ := newService(t)
+		batch, err := s.retryLines(context.Background(), 9, []dto.CollectionBatchLineRequest{{LoanID: 2, Result: "paid", Amount: 50}}, map[uint]bool{1: true}, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batch.FailedLines != 1 || batch.Lines[1].Status != "failed" || batch.Lines[1].Amount != 50 {
+			t.Errorf("batch has %d failed line(s) and line 2 is %s for %.2f", batch.FailedLines, batch.Lines[1].Status, batch.Lines[1].Amount)
+		}
+	})
+}
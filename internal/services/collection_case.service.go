@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// CollectionCaseService runs the collection cases of delinquent loans: it
+// opens a case when the arrears evaluation flags a loan as delinquent, hands
+// it to the officer of the loan's group, tracks the promises to pay logged on
+// it against incoming payments and closes it once the loan cures or leaves
+// the active book
+type CollectionCaseService struct {
+	repo        *repositories.CollectionCaseRepository
+	loanRepo    *repositories.LoanRepository
+	groupRepo   *repositories.GroupRepository
+	officerRepo *repositories.OfficerRepository
+	currency    Currency
+}
+
+// NewCollectionCaseService creates a new collection case service instance
+func NewCollectionCaseService(repo *repositories.CollectionCaseRepository, loanRepo *repositories.LoanRepository, groupRepo *repositories.GroupRepository, officerRepo *repositories.OfficerRepository, currency Currency) *CollectionCaseService {
+	return &CollectionCaseService{repo: repo, loanRepo: loanRepo, groupRepo: groupRepo, officerRepo: officerRepo, currency: currency}
+}
+
+// caseCloseReasons maps the loan statuses that close a case to the reason
+// recorded on it
+var caseCloseReasons = map[string]string{
+	"completed":   "completed",
+	"refinanced":  "refinanced",
+	"written_off": "written_off",
+}
+
+// EvaluateCases settles the pending promises to pay, closes the cases of
+// loans that cured or left the active book, refreshes the arrears of the
+// rest and opens a case for every delinquent loan without one
+func (s *CollectionCaseService) EvaluateCases(ctx context.Context, now time.Time) (*dto.CaseEvaluationResponse, error) {
+	result := &dto.CaseEvaluationResponse{
+		OpenedCaseIDs:    []uint{},
+		ClosedCaseIDs:    []uint{},
+		KeptPromiseIDs:   []uint{},
+		BrokenPromiseIDs: []uint{},
+	}
+
+	cases, err := s.repo.GetOpen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.CollectionCase, len(cases))
+	for i := range cases {
+		byID[cases[i].ID] = &cases[i]
+	}
+
+	if err := s.evaluatePromises(ctx, byID, now, result); err != nil {
+		return result, err
+	}
+
+	covered := map[uint]bool{}
+	for i := range cases {
+		collectionCase := &cases[i]
+		covered[collectionCase.LoanID] = true
+
+		loan, err := s.loanRepo.GetByID(ctx, collectionCase.LoanID)
+		if err != nil {
+			return result, err
+		}
+
+		reason, leftBook := caseCloseReasons[loan.Status]
+		if !leftBook && loan.Status == "active" && loan.DelinquentSince == nil {
+			reason = "cured"
+		}
+		if reason != "" {
+			closedAt := now
+			collectionCase.Status = "closed"
+			collectionCase.ClosedAt = &closedAt
+			collectionCase.CloseReason = reason
+			collectionCase.ClosedBy = "system"
+			collectionCase.NextActionAt = nil
+		}
+
+		if err := s.refreshArrears(ctx, collectionCase, now); err != nil {
+			return result, err
+		}
+		if err := s.repo.Update(ctx, collectionCase); err != nil {
+			return result, err
+		}
+		if reason != "" {
+			result.ClosedCaseIDs = append(result.ClosedCaseIDs, collectionCase.ID)
+		}
+	}
+
+	loans, err := s.loanRepo.GetByStatus(ctx, "active", "defaulted")
+	if err != nil {
+		return result, err
+	}
+	for i := range loans {
+		loan := &loans[i]
+		if loan.DelinquentSince == nil || covered[loan.ID] {
+			continue
+		}
+
+		collectionCase, err := s.openCase(ctx, loan, now)
+		if err != nil {
+			return result, err
+		}
+		result.OpenedCaseIDs = append(result.OpenedCaseIDs, collectionCase.ID)
+	}
+
+	return result, nil
+}
+
+// openCase opens a case for a delinquent loan, assigned to the officer of
+// the loan's group when it has one
+func (s *CollectionCaseService) openCase(ctx context.Context, loan *models.Loan, now time.Time) (*models.CollectionCase, error) {
+	nextAction := now
+	collectionCase := &models.CollectionCase{
+		LoanID:       loan.ID,
+		BorrowerID:   loan.BorrowerID,
+		GroupID:      loan.GroupID,
+		Status:       "open",
+		NextActionAt: &nextAction,
+		OpenedAt:     now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if loan.GroupID != nil {
+		group, err := s.groupRepo.GetByID(ctx, *loan.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if group.OfficerID != nil {
+			assignedAt := now
+			collectionCase.CollectorID = group.OfficerID
+			collectionCase.AssignedAt = &assignedAt
+			collectionCase.AssignedBy = "system"
+		}
+	}
+
+	if err := s.refreshArrears(ctx, collectionCase, now); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, collectionCase); err != nil {
+		return nil, err
+	}
+	return collectionCase, nil
+}
+
+// refreshArrears updates a case's days past due and overdue amount: the
+// installments of the current schedule due before now that are neither paid
+// nor held by a payment holiday
+func (s *CollectionCaseService) refreshArrears(ctx context.Context, collectionCase *models.CollectionCase, now time.Time) error {
+	dpd, err := s.loanRepo.GetDaysPastDue(ctx, collectionCase.LoanID)
+	if err != nil {
+		return err
+	}
+	payments, err := s.loanRepo.GetPaymentsByLoanID(ctx, collectionCase.LoanID)
+	if err != nil {
+		return err
+	}
+
+	overdue := 0.0
+	for _, payment := range payments {
+		held := payment.HeldUntil != nil && payment.HeldUntil.After(now)
+		if payment.Status == "pending" && payment.DueDate.Before(now) && !held {
+			overdue += payment.Amount
+		}
+	}
+
+	collectionCase.DaysPastDue = dpd
+	collectionCase.OverdueAmount = s.currency.Round(overdue)
+	collectionCase.UpdatedAt = now
+	return nil
+}
+
+// evaluatePromises checks the pending promises to pay of the open cases
+// against the payments posted on their loans from when the promise was made
+// up to the end of the promised date. A promise is kept as soon as the
+// promised amount has come in, and broken when its date passes short of it
+func (s *CollectionCaseService) evaluatePromises(ctx context.Context, cases map[uint]*models.CollectionCase, now time.Time, result *dto.CaseEvaluationResponse) error {
+	promises, err := s.repo.GetPendingPromises(ctx)
+	if err != nil {
+		return err
+	}
+
+	transactions := map[uint][]models.PaymentTransaction{}
+	for i := range promises {
+		promise := &promises[i]
+		collectionCase, ok := cases[promise.CaseID]
+		if !ok || promise.PromiseDate == nil {
+			continue
+		}
+
+		loanTransactions, ok := transactions[collectionCase.LoanID]
+		if !ok {
+			loanTransactions, err = s.loanRepo.GetTransactions(ctx, collectionCase.LoanID)
+			if err != nil {
+				return err
+			}
+			transactions[collectionCase.LoanID] = loanTransactions
+		}
+
+		deadline := truncateToDay(*promise.PromiseDate).AddDate(0, 0, 1)
+		collected := 0.0
+		for _, transaction := range loanTransactions {
+			if transaction.Status == "posted" && !transaction.ReceivedAt.Before(promise.PerformedAt) && transaction.ReceivedAt.Before(deadline) {
+				collected += transaction.Amount
+			}
+		}
+		collected = s.currency.Round(collected)
+
+		switch {
+		case collected >= promise.PromiseAmount-amountTolerance:
+			promise.PromiseStatus = "kept"
+			result.KeptPromiseIDs = append(result.KeptPromiseIDs, promise.ID)
+		case !now.Before(deadline):
+			promise.PromiseStatus = "broken"
+			collectionCase.BrokenPromises++
+			nextAction := now
+			collectionCase.NextActionAt = &nextAction
+			result.BrokenPromiseIDs = append(result.BrokenPromiseIDs, promise.ID)
+		case collected == promise.PromiseCollected:
+			continue
+		}
+
+		promise.PromiseCollected = collected
+		if promise.PromiseStatus != "pending" {
+			evaluatedAt := now
+			promise.PromiseEvaluatedAt = &evaluatedAt
+		}
+		collectionCase.UpdatedAt = now
+		if err := s.repo.SettlePromise(ctx, collectionCase, promise); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartCaseEvaluator runs EvaluateCases on the given interval until the process exits
+func (s *CollectionCaseService) StartCaseEvaluator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result, err := s.EvaluateCases(ctx, time.Now())
+		if err != nil {
+			log.Printf("Error evaluating collection cases: %v", err)
+			continue
+		}
+		if len(result.OpenedCaseIDs) > 0 || len(result.ClosedCaseIDs) > 0 || len(result.KeptPromiseIDs) > 0 || len(result.BrokenPromiseIDs) > 0 {
+			log.Printf("Collection cases: %d opened, %d closed, %d promise(s) kept, %d broken",
+				len(result.OpenedCaseIDs), len(result.ClosedCaseIDs), len(result.KeptPromiseIDs), len(result.BrokenPromiseIDs))
+		}
+	}
+}
+
+// GetCase retrieves a case with its activities
+func (s *CollectionCaseService) GetCase(ctx context.Context, id uint) (*models.CollectionCase, []models.CollectionActivity, error) {
+	collectionCase, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	activities, err := s.repo.GetActivities(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return collectionCase, activities, nil
+}
+
+// ListCases retrieves the cases matching the filter
+func (s *CollectionCaseService) ListCases(ctx context.Context, filter repositories.CaseFilter) ([]models.CollectionCase, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// WorkQueue retrieves an officer's open cases in the order they should be worked
+func (s *CollectionCaseService) WorkQueue(ctx context.Context, officerID uint) ([]models.CollectionCase, error) {
+	return s.repo.GetWorkQueue(ctx, officerID)
+}
+
+// Assign hands an open case to a collector
+func (s *CollectionCaseService) Assign(ctx context.Context, id uint, req dto.AssignCaseRequest, by string) (*models.CollectionCase, error) {
+	collectionCase, err := s.openCaseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	officer, err := s.officerRepo.GetByID(ctx, req.CollectorID)
+	if err != nil {
+		return nil, err
+	}
+	if officer.Status != "active" {
+		return nil, Conflict("officer is not active")
+	}
+
+	now := time.Now()
+	collectionCase.CollectorID = &officer.ID
+	collectionCase.AssignedAt = &now
+	collectionCase.AssignedBy = by
+	collectionCase.UpdatedAt = now
+	if err := s.repo.Update(ctx, collectionCase); err != nil {
+		return nil, err
+	}
+	return collectionCase, nil
+}
+
+// LogActivity records a visit, call, promise to pay or note on an open case.
+// A promise to pay schedules the follow-up for the day after the promised
+// date, unless the request gives its own
+func (s *CollectionCaseService) LogActivity(ctx context.Context, id uint, req dto.CaseActivityRequest, by string) (*models.CollectionActivity, error) {
+	switch req.Type {
+	case "visit", "call", "promise_to_pay", "note":
+	default:
+		return nil, Validation("type must be one of visit, call, promise_to_pay, note")
+	}
+
+	collectionCase, err := s.openCaseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	activity := &models.CollectionActivity{
+		Type:        req.Type,
+		Outcome:     req.Outcome,
+		Notes:       req.Notes,
+		OfficerID:   collectionCase.CollectorID,
+		PerformedBy: by,
+		PerformedAt: now,
+		CreatedAt:   now,
+	}
+	if req.OfficerID != nil {
+		if _, err := s.officerRepo.GetByID(ctx, *req.OfficerID); err != nil {
+			return nil, err
+		}
+		activity.OfficerID = req.OfficerID
+	}
+
+	if req.Type == "promise_to_pay" {
+		if req.PromiseDate == "" {
+			return nil, Validation("promise_date is required for a promise to pay")
+		}
+		date, err := time.ParseInLocation("2006-01-02", req.PromiseDate, time.Local)
+		if err != nil {
+			return nil, Validation("invalid promise_date, expected YYYY-MM-DD")
+		}
+		if date.Before(truncateToDay(now)) {
+			return nil, Validation("promise_date must not be in the past")
+		}
+		if req.PromiseAmount <= 0 {
+			return nil, Validation("promise_amount must be greater than zero")
+		}
+		activity.PromiseDate = &date
+		activity.PromiseAmount = s.currency.Round(req.PromiseAmount)
+		activity.PromiseStatus = "pending"
+
+		followUp := date.AddDate(0, 0, 1)
+		collectionCase.NextActionAt = &followUp
+	}
+	if req.NextActionAt != nil {
+		collectionCase.NextActionAt = req.NextActionAt
+	}
+	collectionCase.UpdatedAt = now
+
+	if err := s.repo.AddActivity(ctx, collectionCase, activity); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// CloseCase closes an open case by hand, e.g. when the loan is handed over
+// to a recovery agency
+func (s *CollectionCaseService) CloseCase(ctx context.Context, id uint, req dto.CloseCaseRequest, by string) (*models.CollectionCase, error) {
+	if req.Reason == "" {
+		return nil, Validation("reason is required")
+	}
+	collectionCase, err := s.openCaseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	collectionCase.Status = "closed"
+	collectionCase.ClosedAt = &now
+	collectionCase.CloseReason = "manual"
+	collectionCase.ClosedBy = by
+	collectionCase.NextActionAt = nil
+	collectionCase.UpdatedAt = now
+
+	activity := &models.CollectionActivity{
+		Type:        "note",
+		Notes:       "Case closed: " + req.Reason,
+		PerformedBy: by,
+		PerformedAt: now,
+		CreatedAt:   now,
+	}
+	if err := s.repo.AddActivity(ctx, collectionCase, activity); err != nil {
+		return nil, err
+	}
+	return collectionCase, nil
+}
+
+func (s *CollectionCaseService) openCaseByID(ctx context.Context, id uint) (*models.CollectionCase, error) {
+	collectionCase, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if collectionCase.Status != "open" {
+		return nil, Conflict("collection case is closed")
+	}
+	return collectionCase, nil
+}
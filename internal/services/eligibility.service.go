@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// EligibilityPolicy configures the default eligibility rules
+type EligibilityPolicy struct {
+	// MaxActiveLoans is how many loans a borrower may have running at once
+	MaxActiveLoans int
+	// BaseCreditLimit is the credit limit of a borrower's first loan cycle
+	BaseCreditLimit float64
+	// CycleStepUp multiplies the largest completed loan to give the limit of
+	// the next cycle (e.g. 1.5 lets a 2nd loan be up to 1.5x the 1st)
+	CycleStepUp float64
+	// MaxCreditLimit caps the stepped-up limit, 0 for no cap
+	MaxCreditLimit float64
+	// MinPaidInstallments and MinOnTimeRate are the repayment history a
+	// returning borrower needs. First-time borrowers are not checked
+	MinPaidInstallments int
+	MinOnTimeRate       float64
+	// Currency rounds the amounts reported on rejections
+	Currency Currency
+}
+
+// Applicant is a loan request together with the borrower's loan history
+type Applicant struct {
+	Borrower  *models.Borrower
+	Amount    float64
+	ProductID *uint
+	Loans     []models.Loan // every loan of the borrower, with its current schedule
+	// Refinances is the loan a top-up would pay off. It no longer counts as running
+	Refinances *uint
+	// Outstanding and MissedPayments are known for the borrower's running loans
+	Outstanding    map[uint]float64
+	MissedPayments map[uint]int
+}
+
+// RunningLoans returns the borrower's active and defaulted loans
+func (a *Applicant) RunningLoans() []models.Loan {
+	var running []models.Loan
+	for _, loan := range a.Loans {
+		if a.Refinances != nil && loan.ID == *a.Refinances {
+			continue
+		}
+		if loan.Status == "active" || loan.Status == "defaulted" {
+			running = append(running, loan)
+		}
+	}
+	return running
+}
+
+// LoanCycle returns the cycle the requested loan would be in: one more than
+// the number of loans the borrower has repaid
+func (a *Applicant) LoanCycle() int {
+	cycle := 1
+	for _, loan := range a.Loans {
+		if loan.Status == "completed" {
+			cycle++
+		}
+	}
+	return cycle
+}
+
+// EligibilityRule is one check a borrower has to pass before a loan is booked.
+// Check returns nil when the applicant passes
+type EligibilityRule interface {
+	Name() string
+	Check(applicant *Applicant) *dto.EligibilityRejection
+}
+
+// EligibilityError is returned when a loan is refused by the eligibility rules
+type EligibilityError struct {
+	Rejections []dto.EligibilityRejection
+}
+
+func (e *EligibilityError) Error() string {
+	messages := make([]string, len(e.Rejections))
+	for i, rejection := range e.Rejections {
+		messages[i] = rejection.Message
+	}
+	return "borrower is not eligible: " + strings.Join(messages, "; ")
+}
+
+// EligibilityService runs the eligibility rules before a loan is booked
+type EligibilityService struct {
+	loanRepo     *repositories.LoanRepository
+	borrowerRepo *repositories.BorrowerRepository
+	rules        []EligibilityRule
+}
+
+// NewEligibilityService creates a new eligibility service instance running the given rules in order
+func NewEligibilityService(loanRepo *repositories.LoanRepository, borrowerRepo *repositories.BorrowerRepository, rules ...EligibilityRule) *EligibilityService {
+	return &EligibilityService{loanRepo: loanRepo, borrowerRepo: borrowerRepo, rules: rules}
+}
+
+// DefaultEligibilityRules returns the built-in rules configured by a policy
+func DefaultEligibilityRules(policy EligibilityPolicy) []EligibilityRule {
+	return []EligibilityRule{
+		MaxActiveLoansRule{Max: policy.MaxActiveLoans},
+		NoDelinquencyRule{},
+		CreditLimitRule{
+			BaseLimit: policy.BaseCreditLimit,
+			StepUp:    policy.CycleStepUp,
+			MaxLimit:  policy.MaxCreditLimit,
+			Currency:  policy.Currency,
+		},
+		RepaymentHistoryRule{
+			MinPaidInstallments: policy.MinPaidInstallments,
+			MinOnTimeRate:       policy.MinOnTimeRate,
+		},
+	}
+}
+
+// Check runs every rule against a loan request and reports all rejections
+func (s *EligibilityService) Check(ctx context.Context, borrowerID uint, amount float64, productID *uint) (*dto.EligibilityResponse, error) {
+	return s.check(ctx, borrowerID, amount, productID, nil)
+}
+
+// Require runs the rules and returns an *EligibilityError when any of them rejects the request
+func (s *EligibilityService) Require(ctx context.Context, borrowerID uint, amount float64, productID *uint) error {
+	return s.require(ctx, borrowerID, amount, productID, nil)
+}
+
+// RequireRefinance runs the rules for a top-up that pays off one of the
+// borrower's loans, treating that loan as already settled
+func (s *EligibilityService) RequireRefinance(ctx context.Context, borrowerID uint, amount float64, productID *uint, refinancedLoanID uint) error {
+	return s.require(ctx, borrowerID, amount, productID, &refinancedLoanID)
+}
+
+func (s *EligibilityService) require(ctx context.Context, borrowerID uint, amount float64, productID, refinances *uint) error {
+	result, err := s.check(ctx, borrowerID, amount, productID, refinances)
+	if err != nil {
+		return err
+	}
+	if !result.Eligible {
+		return &EligibilityError{Rejections: result.Rejections}
+	}
+	return nil
+}
+
+func (s *EligibilityService) check(ctx context.Context, borrowerID uint, amount float64, productID, refinances *uint) (*dto.EligibilityResponse, error) {
+	applicant, err := s.applicant(ctx, borrowerID, amount, productID, refinances)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.EligibilityResponse{
+		BorrowerID: borrowerID,
+		Amount:     amount,
+		LoanCycle:  applicant.LoanCycle(),
+		Rejections: []dto.EligibilityRejection{},
+	}
+	for _, rule := range s.rules {
+		if rejection := rule.Check(applicant); rejection != nil {
+			rejection.Rule = rule.Name()
+			response.Rejections = append(response.Rejections, *rejection)
+		}
+	}
+	response.Eligible = len(response.Rejections) == 0
+
+	return response, nil
+}
+
+// applicant loads the borrower and their loan history
+func (s *EligibilityService) applicant(ctx context.Context, borrowerID uint, amount float64, productID, refinances *uint) (*Applicant, error) {
+	borrower, err := s.borrowerRepo.GetByID(ctx, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+
+	loans, err := s.loanRepo.GetByBorrower(ctx, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+
+	applicant := &Applicant{
+		Borrower:       borrower,
+		Amount:         amount,
+		ProductID:      productID,
+		Loans:          loans,
+		Refinances:     refinances,
+		Outstanding:    map[uint]float64{},
+		MissedPayments: map[uint]int{},
+	}
+	for _, loan := range applicant.RunningLoans() {
+		outstanding, err := s.loanRepo.GetOutstandingAmount(ctx, loan.ID)
+		if err != nil {
+			return nil, err
+		}
+		missed, err := s.loanRepo.GetMissedPaymentsCount(ctx, loan.ID)
+		if err != nil {
+			return nil, err
+		}
+		applicant.Outstanding[loan.ID] = outstanding
+		applicant.MissedPayments[loan.ID] = missed
+	}
+
+	return applicant, nil
+}
+
+// MaxActiveLoansRule limits how many loans a borrower may have running at once
+type MaxActiveLoansRule struct {
+	Max int
+}
+
+// Name returns the rule name reported on rejections
+func (r MaxActiveLoansRule) Name() string { return "max_active_loans" }
+
+// Check rejects borrowers who already have the maximum number of running loans
+func (r MaxActiveLoansRule) Check(applicant *Applicant) *dto.EligibilityRejection {
+	running := applicant.RunningLoans()
+	if len(running) < r.Max {
+		return nil
+	}
+	return &dto.EligibilityRejection{
+		Code:    "too_many_active_loans",
+		Message: fmt.Sprintf("borrower already has %d running loan(s), the limit is %d", len(running), r.Max),
+		Details: map[string]interface{}{"active_loans": len(running), "max_active_loans": r.Max},
+	}
+}
+
+// NoDelinquencyRule refuses borrowers who are behind on a loan or have defaulted before
+type NoDelinquencyRule struct{}
+
+// Name returns the rule name reported on rejections
+func (r NoDelinquencyRule) Name() string { return "no_delinquency" }
+
+// Check rejects borrowers with a delinquent, defaulted or written-off loan
+func (r NoDelinquencyRule) Check(applicant *Applicant) *dto.EligibilityRejection {
+	for _, loan := range applicant.Loans {
+		switch {
+		case loan.Status == "written_off":
+			return &dto.EligibilityRejection{
+				Code:    "written_off_loan",
+				Message: fmt.Sprintf("loan %d was written off", loan.ID),
+				Details: map[string]interface{}{"loan_id": loan.ID},
+			}
+		case loan.Status == "defaulted":
+			return &dto.EligibilityRejection{
+				Code:    "defaulted_loan",
+				Message: fmt.Sprintf("loan %d is in default", loan.ID),
+				Details: map[string]interface{}{"loan_id": loan.ID},
+			}
+		case loan.Status == "active" && applicant.MissedPayments[loan.ID] >= 2:
+			return &dto.EligibilityRejection{
+				Code:    "delinquent_loan",
+				Message: fmt.Sprintf("loan %d has %d consecutive missed payments", loan.ID, applicant.MissedPayments[loan.ID]),
+				Details: map[string]interface{}{"loan_id": loan.ID, "missed_payments": applicant.MissedPayments[loan.ID]},
+			}
+		}
+	}
+	return nil
+}
+
+// CreditLimitRule caps a borrower's exposure. The first cycle gets the base
+// limit; later cycles may borrow up to the largest repaid loan times the
+// step-up. A limit set on the borrower takes precedence
+type CreditLimitRule struct {
+	BaseLimit float64
+	StepUp    float64
+	MaxLimit  float64
+	Currency  Currency
+}
+
+// Name returns the rule name reported on rejections
+func (r CreditLimitRule) Name() string { return "credit_limit" }
+
+// Limit returns the credit limit of the applicant's loan cycle
+func (r CreditLimitRule) Limit(applicant *Applicant) float64 {
+	if applicant.Borrower.CreditLimit > 0 {
+		return applicant.Borrower.CreditLimit
+	}
+
+	limit := r.BaseLimit
+	for _, loan := range applicant.Loans {
+		if loan.Status == "completed" && loan.Amount*r.StepUp > limit {
+			limit = loan.Amount * r.StepUp
+		}
+	}
+	if r.MaxLimit > 0 && limit > r.MaxLimit {
+		limit = r.MaxLimit
+	}
+	return limit
+}
+
+// Check rejects requests that would take the borrower's exposure, the
+// requested amount plus what is outstanding on running loans, over the limit
+func (r CreditLimitRule) Check(applicant *Applicant) *dto.EligibilityRejection {
+	limit := r.Limit(applicant)
+
+	exposure := applicant.Amount
+	for _, outstanding := range applicant.Outstanding {
+		exposure += outstanding
+	}
+	if exposure <= limit {
+		return nil
+	}
+	return &dto.EligibilityRejection{
+		Code:    "credit_limit_exceeded",
+		Message: fmt.Sprintf("exposure of %.2f exceeds the credit limit of %.2f", exposure, limit),
+		Details: map[string]interface{}{
+			"credit_limit": limit,
+			"exposure":     exposure,
+			"loan_cycle":   applicant.LoanCycle(),
+			"available":    r.Currency.Round(limit - (exposure - applicant.Amount)),
+		},
+	}
+}
+
+// RepaymentHistoryRule asks returning borrowers for a minimum record of paid,
+// and mostly on-time, installments
+type RepaymentHistoryRule struct {
+	MinPaidInstallments int
+	MinOnTimeRate       float64
+}
+
+// Name returns the rule name reported on rejections
+func (r RepaymentHistoryRule) Name() string { return "repayment_history" }
+
+// Check rejects returning borrowers whose repayment record is too short or too late
+func (r RepaymentHistoryRule) Check(applicant *Applicant) *dto.EligibilityRejection {
+	if len(applicant.Loans) == 0 {
+		return nil
+	}
+
+	paid, onTime := 0, 0
+	for _, loan := range applicant.Loans {
+		for _, payment := range loan.Payments {
+			if payment.Status != "paid" || payment.PaidDate == nil {
+				continue
+			}
+			paid++
+			if !truncateToDay(*payment.PaidDate).After(truncateToDay(payment.DueDate)) {
+				onTime++
+			}
+		}
+	}
+
+	onTimeRate := 0.0
+	if paid > 0 {
+		onTimeRate = float64(onTime) / float64(paid)
+	}
+	details := map[string]interface{}{
+		"paid_installments":     paid,
+		"min_paid_installments": r.MinPaidInstallments,
+		"on_time_rate":          math.Round(onTimeRate*100) / 100,
+		"min_on_time_rate":      r.MinOnTimeRate,
+	}
+
+	if paid < r.MinPaidInstallments {
+		return &dto.EligibilityRejection{
+			Code:    "insufficient_repayment_history",
+			Message: fmt.Sprintf("borrower has paid %d installment(s), at least %d are required", paid, r.MinPaidInstallments),
+			Details: details,
+		}
+	}
+	if paid > 0 && onTimeRate < r.MinOnTimeRate {
+		return &dto.EligibilityRejection{
+			Code:    "poor_repayment_history",
+			Message: fmt.Sprintf("%.0f%% of installments were paid on time, at least %.0f%% are required", onTimeRate*100, r.MinOnTimeRate*100),
+			Details: details,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/validation"
+)
+
+// ErrorCode identifies the kind of a failure, so that clients can branch on
+// the code instead of parsing the message
+type ErrorCode string
+
+const (
+	CodeValidation                ErrorCode = "validation"                  // the request is malformed or breaks a rule on its own
+	CodeNotFound                  ErrorCode = "not_found"                   // a record does not exist or lies outside the caller's scope
+	CodeConflict                  ErrorCode = "conflict"                    // the request clashes with the current state of a record
+	CodeUnauthenticated           ErrorCode = "unauthenticated"             // no valid credentials
+	CodeForbidden                 ErrorCode = "forbidden"                   // the caller may not do this
+	CodeLoanNotPayable            ErrorCode = "loan_not_payable"            // the loan takes no payments
+	CodeInstallmentAmountMismatch ErrorCode = "installment_amount_mismatch" // a payment differs from the installment due
+	CodeDelinquentAmountMismatch  ErrorCode = "delinquent_amount_mismatch"  // a payment on a delinquent loan does not clear every missed installment
+	CodeNotEligible               ErrorCode = "not_eligible"                // the borrower fails the eligibility rules
+	CodeApplicationDeclined       ErrorCode = "application_declined"        // the loan application scored too low
+	CodeInternal                  ErrorCode = "internal"                    // anything else, such as a database failure
+)
+
+// Error is a failure the caller can act on, with a code and, for some codes,
+// details such as the amount that was expected
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError creates an error with a code
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Validation creates a validation error
+func Validation(message string) *Error {
+	return NewError(CodeValidation, message)
+}
+
+// Invalid creates a validation error listing the fields that break their
+// rules in details.fields, or returns nil when there are none
+func Invalid(fields []validation.FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return Validation(strings.Join(messages, "; ")).WithDetails(map[string]interface{}{"fields": fields})
+}
+
+// NotFound creates a not found error
+func NotFound(message string) *Error {
+	return NewError(CodeNotFound, message)
+}
+
+// Conflict creates a conflict error
+func Conflict(message string) *Error {
+	return NewError(CodeConflict, message)
+}
+
+// WithDetails returns a copy of the error carrying details
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// CodeOf returns the code of an error: its own code for an Error, not_found
+// for records the repositories did not find, and internal for anything else
+func CodeOf(err error) ErrorCode {
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, repositories.ErrNotFound):
+		return CodeNotFound
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Event is a domain event handed to a publisher. Payload is the JSON event
+// envelope; Key is the aggregate it is about, for publishers that keep the
+// events of one aggregate in order
+type Event struct {
+	ID      string
+	Type    string
+	Key     string
+	Payload []byte
+}
+
+// EventPublisher delivers domain events to their consumers. Publish returns
+// nil once the event is accepted; the outbox retries it on error, so an event
+// may be published more than once
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NewEventPublisher returns the publisher of the given kind: log (the
+// default) or memory
+func NewEventPublisher(kind string) (EventPublisher, error) {
+	switch kind {
+	case "", "log":
+		return LogPublisher{}, nil
+	case "memory":
+		return NewMemoryPublisher(1000), nil
+	default:
+		return nil, fmt.Errorf("unknown event publisher %q", kind)
+	}
+}
+
+// LogPublisher writes every event to the process log
+type LogPublisher struct{}
+
+// Publish logs the event
+func (LogPublisher) Publish(ctx context.Context, event Event) error {
+	log.Printf("Event %s %s key=%s: %s", event.Type, event.ID, event.Key, event.Payload)
+	return nil
+}
+
+// MemoryPublisher keeps the most recent events in memory, for development and
+// for embedding the engine in another process
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	limit  int
+	events []Event
+}
+
+// NewMemoryPublisher creates a publisher keeping up to limit events
+func NewMemoryPublisher(limit int) *MemoryPublisher {
+	return &MemoryPublisher{limit: limit}
+}
+
+// Publish appends the event, dropping the oldest one when full
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+	if len(p.events) > p.limit {
+		p.events = p.events[len(p.events)-p.limit:]
+	}
+	return nil
+}
+
+// Events returns the events kept, oldest first
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Event(nil), p.events...)
+}
+
+// MultiPublisher hands every event to each of its publishers in turn. An
+// event is retried if any of them fails, so each must tolerate duplicates
+type MultiPublisher []EventPublisher
+
+// Publish publishes the event to every publisher, stopping at the first error
+func (p MultiPublisher) Publish(ctx context.Context, event Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// cents is the currency the tests book loans in, with two decimal places
+var cents = Currency{Code: "IDR", Decimals: 2}
+
+// newLoanService returns a loan service, and the lender service it credits,
+// backed by a scripted database in which tenant partitioning is enforced
+func newLoanService(t *testing.T) (*LoanService, *dbtest.DB, *gorm.DB) {
+	t.Helper()
+	conn, db := dbtest.Open(t)
+	if err := repositories.RegisterTenantCallbacks(conn); err != nil {
+		t.Fatal(err)
+	}
+	transactor := repositories.NewTransactor(conn)
+	loanRepo := repositories.NewLoanRepository(conn)
+	lenders := NewLenderService(repositories.NewLenderRepository(conn), loanRepo, transactor, FundingPolicy{}, cents)
+	loans := NewLoanService(loanRepo, repositories.NewGroupRepository(conn), repositories.NewProductRepository(conn),
+		transactor, nil, lenders, nil, nil, VirtualAccountPolicy{}, cents)
+	return loans, db, conn
+}
+
+// loanRows answers a read of loans with the given loans
+func loanRows(loans ...models.Loan) dbtest.Rows {
+	rows := dbtest.Rows{Columns: []string{
+		"id", "tenant_id", "branch_id", "borrower_id", "amount", "total_amount", "weekly_payment",
+		"fee_amount", "suspense_amount", "virtual_account", "status", "refinanced_by_id",
+	}}
+	for _, loan := range loans {
+		rows.Values = append(rows.Values, []interface{}{
+			int64(loan.ID), int64(loan.TenantID), loan.BranchID, int64(loan.BorrowerID), loan.Amount, loan.TotalAmount, loan.WeeklyPayment,
+			loan.FeeAmount, loan.SuspenseAmount, loan.VirtualAccount, loan.Status, loan.RefinancedByID,
+		})
+	}
+	return rows
+}
+
+// paymentRows answers a read of installments with the given installments
+func paymentRows(payments ...models.Payment) dbtest.Rows {
+	rows := dbtest.Rows{Columns: []string{
+		"id", "tenant_id", "loan_id", "week_num", "amount", "fee_amount", "due_date", "status", "transaction_id",
+	}}
+	for _, payment := range payments {
+		rows.Values = append(rows.Values, []interface{}{
+			int64(payment.ID), int64(payment.TenantID), int64(payment.LoanID), int64(payment.WeekNum), payment.Amount,
+			payment.FeeAmount, payment.DueDate, payment.Status, payment.TransactionID,
+		})
+	}
+	return rows
+}
+
+// transactionRows answers a read of payment transactions with the given ones
+func transactionRows(transactions ...models.PaymentTransaction) dbtest.Rows {
+	rows := dbtest.Rows{Columns: []string{
+		"id", "tenant_id", "loan_id", "amount", "applied_amount", "unapplied_amount", "suspense_applied", "channel", "status", "received_at",
+	}}
+	for _, transaction := range transactions {
+		rows.Values = append(rows.Values, []interface{}{
+			int64(transaction.ID), int64(1), int64(transaction.LoanID), transaction.Amount, transaction.AppliedAmount,
+			transaction.UnappliedAmount, transaction.SuspenseApplied, transaction.Channel, transaction.Status, transaction.ReceivedAt,
+		})
+	}
+	return rows
+}
+
+// indexOf returns the position of the first statement containing every
+// fragment, or -1
+func indexOf(statements []dbtest.Statement, fragments ...string) int {
+	for i, statement := range statements {
+		if statement.Has(fragments...) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// ErrInvalidSignature is returned when a webhook body does not carry a valid signature
+var ErrInvalidSignature = NewError(CodeUnauthenticated, "invalid webhook signature")
+
+// GatewayConfig holds the settings shared with the payment gateway
+type GatewayConfig struct {
+	Secret   string // HMAC-SHA256 key the gateway signs notifications with
+	Currency string // currency loans are repaid in
+}
+
+// GatewayService handles payment notifications pushed by the payment gateway
+type GatewayService struct {
+	gatewayRepo *repositories.GatewayRepository
+	loanRepo    *repositories.LoanRepository
+	loanService *LoanService
+	recoveries  *WriteOffService
+	transactor  *repositories.Transactor
+	config      GatewayConfig
+}
+
+// NewGatewayService creates a new gateway service instance
+func NewGatewayService(gatewayRepo *repositories.GatewayRepository, loanRepo *repositories.LoanRepository, loanService *LoanService, recoveries *WriteOffService, transactor *repositories.Transactor, config GatewayConfig) *GatewayService {
+	return &GatewayService{
+		gatewayRepo: gatewayRepo,
+		loanRepo:    loanRepo,
+		loanService: loanService,
+		recoveries:  recoveries,
+		transactor:  transactor,
+		config:      config,
+	}
+}
+
+// SignPayload returns the signature header value for a webhook body
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature header value against the raw webhook body
+func (s *GatewayService) VerifySignature(body []byte, signature string) error {
+	if s.config.Secret == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+	expected := SignPayload(s.config.Secret, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ReceivePayment records a verified payment notification and posts it to the
+// loan that owns the virtual account, both in one transaction. A notification
+// whose transaction ID was seen before is not posted again; the earlier
+// outcome is returned with duplicate set. One that is still received or that
+// failed is processed again instead
+func (s *GatewayService) ReceivePayment(ctx context.Context, body []byte) (*models.GatewayPayment, bool, error) {
+	var notification dto.GatewayPaymentNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, false, Validation("invalid notification body")
+	}
+	if err := validateNotification(notification); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.gatewayRepo.GetByGatewayTransactionID(ctx, notification.TransactionID)
+	switch {
+	case err == nil && !unfinished(existing):
+		return existing, true, nil
+	case err != nil && !errors.Is(err, repositories.ErrNotFound):
+		return nil, false, err
+	}
+
+	var payment *models.GatewayPayment
+	duplicate := false
+	err = s.transactor.Run(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		if existing == nil {
+			payment = &models.GatewayPayment{
+				GatewayTransactionID: notification.TransactionID,
+				VirtualAccount:       notification.VirtualAccount,
+				Amount:               notification.Amount,
+				Currency:             strings.ToUpper(notification.Currency),
+				PaidAt:               notification.PaidAt,
+				Payload:              string(body),
+				Status:               "received",
+				CreatedAt:            now,
+				UpdatedAt:            now,
+			}
+			if err := s.gatewayRepo.Create(ctx, payment); err != nil {
+				return err
+			}
+		} else {
+			// Another delivery may have processed it since it was read
+			locked, err := s.gatewayRepo.GetForUpdate(ctx, existing.ID)
+			if err != nil {
+				return err
+			}
+			payment = locked
+			if !unfinished(payment) {
+				duplicate = true
+				return nil
+			}
+			payment.LoanID = nil
+			payment.Error = ""
+		}
+
+		if err := s.post(ctx, payment); err != nil {
+			return err
+		}
+
+		payment.UpdatedAt = time.Now()
+		return s.gatewayRepo.Update(ctx, payment)
+	})
+	if err != nil {
+		if existing == nil {
+			// A concurrent delivery of the same notification won the unique index
+			if winner, lookupErr := s.gatewayRepo.GetByGatewayTransactionID(ctx, notification.TransactionID); lookupErr == nil {
+				return winner, true, nil
+			}
+		}
+		return nil, false, err
+	}
+	return payment, duplicate, nil
+}
+
+// unfinished reports whether a recorded notification should be processed
+// again when the gateway redelivers it
+func unfinished(payment *models.GatewayPayment) bool {
+	return payment.Status == "received" || payment.Status == "failed"
+}
+
+// post applies a recorded notification to its loan and stores the outcome on
+// it. Money paid for a written-off loan is booked as a recovery. Failures the gateway cannot fix by retrying, such as an unknown
+// currency or a loan that takes no payments, are stored as the outcome;
+// others are returned so that the whole notification rolls back
+func (s *GatewayService) post(ctx context.Context, payment *models.GatewayPayment) error {
+	if s.config.Currency != "" && payment.Currency != s.config.Currency {
+		payment.Status = "failed"
+		payment.Error = "unsupported currency " + payment.Currency
+		return nil
+	}
+
+	loans, err := s.loanRepo.FindByVirtualAccount(ctx, payment.VirtualAccount)
+	if err != nil {
+		return err
+	}
+	if len(loans) == 0 {
+		payment.Status = "unmatched"
+		payment.Error = "no active loan for virtual account"
+		return nil
+	}
+
+	loanID := loans[0].ID
+	payment.LoanID = &loanID
+	if loans[0].Status == "written_off" {
+		recovery, err := s.recoveries.recover(ctx, loanID, payment.Amount, payment.GatewayTransactionID, payment.PaidAt)
+		if err != nil {
+			if CodeOf(err) == CodeInternal {
+				return err
+			}
+			payment.Status = "failed"
+			payment.Error = err.Error()
+			return nil
+		}
+		payment.RecoveryID = &recovery.ID
+		payment.Status = "posted"
+		return nil
+	}
+
+	transaction, err := s.loanService.PostPartialPayment(ctx, loanID, payment.Amount, PaymentSource{
+		Channel:    "gateway",
+		Reference:  payment.GatewayTransactionID,
+		ReceivedBy: "payment-gateway",
+		ReceivedAt: payment.PaidAt,
+	})
+	if err != nil {
+		if CodeOf(err) == CodeInternal {
+			return err
+		}
+		payment.Status = "failed"
+		payment.Error = err.Error()
+		return nil
+	}
+
+	payment.TransactionID = &transaction.ID
+	payment.Status = "posted"
+	return nil
+}
+
+// ListPayments lists received gateway notifications, optionally filtered by status
+func (s *GatewayService) ListPayments(ctx context.Context, status string) ([]models.GatewayPayment, error) {
+	return s.gatewayRepo.List(ctx, status)
+}
+
+func validateNotification(notification dto.GatewayPaymentNotification) error {
+	switch {
+	case strings.TrimSpace(notification.TransactionID) == "":
+		return Validation("transaction_id is required")
+	case strings.TrimSpace(notification.VirtualAccount) == "":
+		return Validation("virtual_account is required")
+	case notification.Amount <= 0:
+		return Validation("amount must be greater than zero")
+	case len(notification.Currency) != 3:
+		return Validation("currency must be a 3-letter code")
+	case notification.PaidAt.IsZero():
+		return Validation("paid_at is required")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+)
+
+// knownGatewaySignature is the HMAC-SHA256 of the test body with the key
+// "secret", worked out independently of SignPayload
+const knownGatewaySignature = "6268d582802b56bf29ae2cd15149ba6d7767cd88ac70e833e18242b1ba1a3617"
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"transaction_id":"trx-1","virtual_account":"8801000001","amount":110000}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{"known signature", "secret", body, "sha256=" + knownGatewaySignature, false},
+		{"surrounding whitespace is ignored", "secret", body, " sha256=" + knownGatewaySignature + "\n", false},
+		{"signed with another secret", "secret", body, SignPayload("other", body), true},
+		{"body changed after signing", "secret", append([]byte(" "), body...), SignPayload("secret", body), true},
+		{"hex without its scheme", "secret", body, knownGatewaySignature, true},
+		{"no signature", "secret", body, "", true},
+		{"no secret configured", "", body, SignPayload("", body), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &GatewayService{config: GatewayConfig{Secret: tt.secret}}
+			err := s.VerifySignature(tt.body, tt.signature)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("got %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestValidateNotification(t *testing.T) {
+	valid := dto.GatewayPaymentNotification{
+		TransactionID:  "trx-1",
+		VirtualAccount: "8801000001",
+		Amount:         110000,
+		Currency:       "IDR",
+		PaidAt:         time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+	}
+	with := func(change func(*dto.GatewayPaymentNotification)) dto.GatewayPaymentNotification {
+		notification := valid
+		change(&notification)
+		return notification
+	}
+
+	tests := []struct {
+		name         string
+		notification dto.GatewayPaymentNotification
+		wantErr      string
+	}{
+		{"valid", valid, ""},
+		{"blank transaction", with(func(n *dto.GatewayPaymentNotification) { n.TransactionID = " " }), "transaction_id is required"},
+		{"no virtual account", with(func(n *dto.GatewayPaymentNotification) { n.VirtualAccount = "" }), "virtual_account is required"},
+		{"no amount", with(func(n *dto.GatewayPaymentNotification) { n.Amount = 0 }), "amount must be greater than zero"},
+		{"negative amount", with(func(n *dto.GatewayPaymentNotification) { n.Amount = -1 }), "amount must be greater than zero"},
+		{"bad currency", with(func(n *dto.GatewayPaymentNotification) { n.Currency = "RP" }), "currency must be a 3-letter code"},
+		{"no payment time", with(func(n *dto.GatewayPaymentNotification) { n.PaidAt = time.Time{} }), "paid_at is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotification(tt.notification)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			var coded *Error
+			if !errors.As(err, &coded) || coded.Code != CodeValidation || coded.Message != tt.wantErr {
+				t.Fatalf("got %v, want a validation error %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// parBuckets are the days-past-due thresholds group PAR is reported for
+var parBuckets = []int{1, 7, 30}
+
+// GroupService handles business logic for borrower groups
+type GroupService struct {
+	groupRepo    *repositories.GroupRepository
+	loanRepo     *repositories.LoanRepository
+	borrowerRepo *repositories.BorrowerRepository
+	officerRepo  *repositories.OfficerRepository
+}
+
+// NewGroupService creates a new group service instance
+func NewGroupService(groupRepo *repositories.GroupRepository, loanRepo *repositories.LoanRepository, borrowerRepo *repositories.BorrowerRepository, officerRepo *repositories.OfficerRepository) *GroupService {
+	return &GroupService{groupRepo: groupRepo, loanRepo: loanRepo, borrowerRepo: borrowerRepo, officerRepo: officerRepo}
+}
+
+// CreateGroup creates a new borrower group
+func (s *GroupService) CreateGroup(ctx context.Context, req dto.CreateGroupRequest) (*models.Group, error) {
+	meetingDay := strings.ToLower(req.MeetingDay)
+	if req.Name == "" {
+		return nil, Validation("name is required")
+	}
+	if _, ok := weekdays[meetingDay]; !ok {
+		return nil, Validation("meeting_day must be a day of the week")
+	}
+	if req.OfficerID != nil {
+		if _, err := s.officerRepo.GetByID(ctx, *req.OfficerID); err != nil {
+			return nil, err
+		}
+	}
+
+	group := &models.Group{
+		Name:       req.Name,
+		Region:     req.Region,
+		OfficerID:  req.OfficerID,
+		BranchID:   req.BranchID,
+		MeetingDay: meetingDay,
+		Status:     "active",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetGroup retrieves a group with its current members
+func (s *GroupService) GetGroup(ctx context.Context, id uint) (*models.Group, error) {
+	return s.groupRepo.GetByID(ctx, id)
+}
+
+// ListGroups returns groups, optionally filtered by region
+func (s *GroupService) ListGroups(ctx context.Context, region string) ([]models.Group, error) {
+	return s.groupRepo.List(ctx, region)
+}
+
+// AssignOfficer makes a field officer responsible for a group's meetings and collections
+func (s *GroupService) AssignOfficer(ctx context.Context, groupID, officerID uint) (*models.Group, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	officer, err := s.officerRepo.GetByID(ctx, officerID)
+	if err != nil {
+		return nil, err
+	}
+	if officer.Status != "active" {
+		return nil, Conflict("officer is not active")
+	}
+
+	group.OfficerID = &officer.ID
+	group.UpdatedAt = time.Now()
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// AddMember adds a borrower to a group. A borrower can only belong to one group at a time
+func (s *GroupService) AddMember(ctx context.Context, groupID uint, req dto.AddGroupMemberRequest) (*models.GroupMember, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.Status != "active" {
+		return nil, Conflict("group is not active")
+	}
+
+	if _, err := s.borrowerRepo.GetByID(ctx, req.BorrowerID); err != nil {
+		return nil, err
+	}
+
+	if membership, err := s.groupRepo.GetActiveMembership(ctx, req.BorrowerID); err == nil {
+		return nil, Conflict(fmt.Sprintf("borrower is already a member of group %d", membership.GroupID))
+	}
+
+	role := req.Role
+	if role == "" {
+		role = "member"
+	}
+
+	member := &models.GroupMember{
+		GroupID:    groupID,
+		BorrowerID: req.BorrowerID,
+		Role:       role,
+		JoinedAt:   time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.groupRepo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember ends a borrower's membership. Members with a running group loan
+// cannot leave because the group remains liable for it
+func (s *GroupService) RemoveMember(ctx context.Context, groupID, borrowerID uint) error {
+	member, err := s.groupRepo.GetActiveMembership(ctx, borrowerID)
+	if err != nil {
+		return err
+	}
+	if member.GroupID != groupID {
+		return NotFound("borrower is not a member of this group")
+	}
+
+	loans, err := s.groupRepo.GetLoans(ctx, groupID, "active", "defaulted")
+	if err != nil {
+		return err
+	}
+	for _, loan := range loans {
+		if loan.BorrowerID == borrowerID {
+			return Conflict(fmt.Sprintf("borrower still has running loan %d in this group", loan.ID))
+		}
+	}
+
+	now := time.Now()
+	member.LeftAt = &now
+	member.UpdatedAt = now
+	return s.groupRepo.UpdateMember(ctx, member)
+}
+
+// GetSchedule combines the schedules of the group's running loans by due date
+func (s *GroupService) GetSchedule(ctx context.Context, groupID uint) ([]dto.GroupScheduleItem, error) {
+	_, installments, err := s.runningLoans(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := map[time.Time]*dto.GroupScheduleItem{}
+	for _, payment := range installments {
+		if payment.Status != "pending" && payment.Status != "paid" {
+			continue
+		}
+
+		date := truncateToDay(payment.DueDate)
+		item, ok := byDate[date]
+		if !ok {
+			item = &dto.GroupScheduleItem{DueDate: date}
+			byDate[date] = item
+		}
+
+		item.AmountDue += payment.Amount
+		item.Installments++
+		if payment.Status == "paid" {
+			item.AmountPaid += payment.Amount
+			item.PaidInstallments++
+		}
+	}
+
+	schedule := make([]dto.GroupScheduleItem, 0, len(byDate))
+	for _, item := range byDate {
+		schedule = append(schedule, *item)
+	}
+	sort.Slice(schedule, func(i, j int) bool {
+		return schedule[i].DueDate.Before(schedule[j].DueDate)
+	})
+
+	return schedule, nil
+}
+
+// GetOutstanding returns the outstanding amount across the group's running loans
+func (s *GroupService) GetOutstanding(ctx context.Context, groupID uint) (*dto.GroupOutstandingResponse, error) {
+	loans, _, err := s.runningLoans(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.GroupOutstandingResponse{
+		GroupID: groupID,
+		Loans:   make([]dto.GroupLoanOutstanding, 0, len(loans)),
+	}
+	for _, loan := range loans {
+		outstanding, err := s.loanRepo.GetOutstandingAmount(ctx, loan.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		response.TotalAmount += loan.TotalAmount
+		response.OutstandingAmount += outstanding
+		response.Loans = append(response.Loans, dto.GroupLoanOutstanding{
+			LoanID:            loan.ID,
+			BorrowerID:        loan.BorrowerID,
+			TotalAmount:       loan.TotalAmount,
+			OutstandingAmount: outstanding,
+		})
+	}
+	response.AmountPaid = response.TotalAmount - response.OutstandingAmount
+
+	return response, nil
+}
+
+// GetDelinquency evaluates every running member loan and derives the group's
+// joint liability (arrears of delinquent members) and PAR from them
+func (s *GroupService) GetDelinquency(ctx context.Context, groupID uint) (*dto.GroupDelinquencyResponse, error) {
+	loans, installments, err := s.runningLoans(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	arrears := arrearsByLoan(installments, time.Now())
+	response := &dto.GroupDelinquencyResponse{
+		GroupID: groupID,
+		PAR:     map[string]float64{},
+		Loans:   make([]dto.GroupLoanDelinquency, 0, len(loans)),
+	}
+	atRisk := make([]float64, len(parBuckets))
+
+	for _, loan := range loans {
+		missed, err := s.loanRepo.GetMissedPaymentsCount(ctx, loan.ID)
+		if err != nil {
+			return nil, err
+		}
+		dpd, err := s.loanRepo.GetDaysPastDue(ctx, loan.ID)
+		if err != nil {
+			return nil, err
+		}
+		outstanding, err := s.loanRepo.GetOutstandingAmount(ctx, loan.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		item := dto.GroupLoanDelinquency{
+			LoanID:            loan.ID,
+			BorrowerID:        loan.BorrowerID,
+			IsDelinquent:      missed >= 2,
+			MissedPayments:    missed,
+			DaysPastDue:       dpd,
+			ArrearsAmount:     arrears[loan.ID],
+			OutstandingAmount: outstanding,
+		}
+		if item.IsDelinquent {
+			response.DelinquentMembers++
+			response.JointLiabilityAmount += item.ArrearsAmount
+		}
+		response.OutstandingAmount += outstanding
+
+		for i, days := range parBuckets {
+			if dpd >= days {
+				atRisk[i] += outstanding
+			}
+		}
+
+		response.Loans = append(response.Loans, item)
+	}
+
+	for i, days := range parBuckets {
+		ratio := 0.0
+		if response.OutstandingAmount > 0 {
+			ratio = atRisk[i] / response.OutstandingAmount
+		}
+		response.PAR[fmt.Sprintf("par_%d", days)] = ratio
+	}
+
+	return response, nil
+}
+
+// GetCollectionSheet lists what every member with a running loan is expected to
+// pay at the group meeting on the given date: installments falling due in the
+// week up to that date plus any older arrears
+func (s *GroupService) GetCollectionSheet(ctx context.Context, groupID uint, date time.Time) (*dto.GroupCollectionSheetResponse, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	loans, installments, err := s.runningLoans(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[uint]string{}
+	for _, member := range group.Members {
+		if member.Borrower != nil {
+			names[member.BorrowerID] = strings.TrimSpace(member.Borrower.FirstName + " " + member.Borrower.LastName)
+		}
+	}
+
+	lines := collectionSheetLines(loans, installments, date)
+	response := &dto.GroupCollectionSheetResponse{
+		GroupID:   group.ID,
+		GroupName: group.Name,
+		Date:      truncateToDay(date),
+		Lines:     lines,
+	}
+	for i := range response.Lines {
+		response.Lines[i].BorrowerName = names[response.Lines[i].BorrowerID]
+		response.TotalDue += response.Lines[i].TotalDue
+	}
+
+	return response, nil
+}
+
+// runningLoans loads the group's active and defaulted loans with their current installments
+func (s *GroupService) runningLoans(ctx context.Context, groupID uint) ([]models.Loan, []models.Payment, error) {
+	if _, err := s.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, nil, err
+	}
+
+	loans, err := s.groupRepo.GetLoans(ctx, groupID, "active", "defaulted")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loanIDs := make([]uint, len(loans))
+	for i, loan := range loans {
+		loanIDs[i] = loan.ID
+	}
+
+	installments, err := s.groupRepo.GetInstallments(ctx, loanIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loans, installments, nil
+}
+
+// collectionSheetLines works out, per loan, the installment due in the week
+// ending on the meeting date and the arrears from before that week
+func collectionSheetLines(loans []models.Loan, installments []models.Payment, date time.Time) []dto.CollectionSheetLine {
+	meetingEnd := truncateToDay(date).AddDate(0, 0, 1)
+	weekStart := meetingEnd.AddDate(0, 0, -7)
+
+	byLoan := map[uint]*dto.CollectionSheetLine{}
+	for _, payment := range installments {
+		if payment.Status != "pending" || !payment.DueDate.Before(meetingEnd) {
+			continue
+		}
+		if payment.HeldUntil != nil && date.Before(*payment.HeldUntil) {
+			continue
+		}
+
+		line, ok := byLoan[payment.LoanID]
+		if !ok {
+			line = &dto.CollectionSheetLine{LoanID: payment.LoanID}
+			byLoan[payment.LoanID] = line
+		}
+		if payment.DueDate.Before(weekStart) {
+			line.ArrearsDue += payment.Amount
+		} else {
+			line.InstallmentDue += payment.Amount
+		}
+		line.TotalDue += payment.Amount
+	}
+
+	lines := []dto.CollectionSheetLine{}
+	for _, loan := range loans {
+		line, ok := byLoan[loan.ID]
+		if !ok {
+			continue
+		}
+		line.BorrowerID = loan.BorrowerID
+		lines = append(lines, *line)
+	}
+	return lines
+}
+
+// arrearsByLoan sums the unpaid installments that fell due before the given time
+func arrearsByLoan(installments []models.Payment, now time.Time) map[uint]float64 {
+	arrears := map[uint]float64{}
+	for _, payment := range installments {
+		if payment.Status != "pending" || !payment.DueDate.Before(now) {
+			continue
+		}
+		if payment.HeldUntil != nil && now.Before(*payment.HeldUntil) {
+			continue
+		}
+		arrears[payment.LoanID] += payment.Amount
+	}
+	return arrears
+}
+
+// weekdays maps lower-case day names to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
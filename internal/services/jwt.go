@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TokenClaims are the claims carried by a staff bearer token
+type TokenClaims struct {
+	Subject   string `json:"sub"`
+	Name      string `json:"name,omitempty"`
+	Role      string `json:"role"`
+	OfficerID *uint  `json:"officer_id,omitempty"`
+	TenantID  *uint  `json:"tenant_id,omitempty"` // the default tenant when absent
+	BranchID  *uint  `json:"branch_id,omitempty"` // limits the token to one branch
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// jwtHeader is the only header accepted: HMAC-SHA256 signed JWTs
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignToken returns an HS256 JWT for the claims signed with the secret
+func SignToken(secret string, claims TokenClaims) (string, error) {
+	if secret == "" {
+		return "", errors.New("token secret is not set")
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + tokenSignature(secret, unsigned), nil
+}
+
+// parseToken verifies an HS256 JWT and returns its claims. The token must be
+// signed with the secret, be within its validity window and, when an issuer
+// is given, come from that issuer
+func parseToken(secret, issuer, token string, now time.Time) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm")
+	}
+
+	expected := tokenSignature(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+
+	switch {
+	case claims.Subject == "":
+		return nil, errors.New("token has no subject")
+	case claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt:
+		return nil, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Unix() < claims.NotBefore:
+		return nil, errors.New("token is not valid yet")
+	case issuer != "" && claims.Issuer != issuer:
+		return nil, errors.New("token issuer is not trusted")
+	}
+	return &claims, nil
+}
+
+// tokenSignature returns the base64url HMAC-SHA256 of the signing input
+func tokenSignature(secret, unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	now := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	officerID := uint(12)
+	claims := TokenClaims{
+		Subject:   "staff:42",
+		Role:      "field_officer",
+		OfficerID: &officerID,
+		Issuer:    "hr",
+		IssuedAt:  now.Add(-time.Minute).Unix(),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}
+	sign := func(secret string, claims TokenClaims) string {
+		token, err := SignToken(secret, claims)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return token
+	}
+	with := func(change func(*TokenClaims)) TokenClaims {
+		changed := claims
+		change(&changed)
+		return changed
+	}
+	// reheader swaps the header of a token and signs it again with the secret
+	reheader := func(header string) string {
+		parts := strings.Split(sign("secret", claims), ".")
+		unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + parts[1]
+		return unsigned + "." + tokenSignature("secret", unsigned)
+	}
+
+	tests := []struct {
+		name    string
+		issuer  string
+		token   string
+		wantErr string
+	}{
+		{name: "valid", issuer: "hr", token: sign("secret", claims)},
+		{name: "any issuer when none is required", token: sign("secret", with(func(c *TokenClaims) { c.Issuer = "other" }))},
+		{name: "not before has passed", token: sign("secret", with(func(c *TokenClaims) { c.NotBefore = now.Unix() }))},
+		{name: "signed with another secret", token: sign("other", claims), wantErr: "invalid token signature"},
+		{name: "tampered payload", token: func() string {
+			parts := strings.Split(sign("secret", claims), ".")
+			forged := sign("secret", with(func(c *TokenClaims) { c.Role = "admin" }))
+			return parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+		}(), wantErr: "invalid token signature"},
+		{name: "unsigned algorithm", token: reheader(`{"alg":"none","typ":"JWT"}`), wantErr: "unsupported token algorithm"},
+		{name: "other algorithm", token: reheader(`{"alg":"HS512","typ":"JWT"}`), wantErr: "unsupported token algorithm"},
+		{name: "expired", token: sign("secret", with(func(c *TokenClaims) { c.ExpiresAt = now.Unix() })), wantErr: "token has expired"},
+		{name: "no expiry", token: sign("secret", with(func(c *TokenClaims) { c.ExpiresAt = 0 })), wantErr: "token has expired"},
+		{name: "not valid yet", token: sign("secret", with(func(c *TokenClaims) { c.NotBefore = now.Add(time.Minute).Unix() })), wantErr: "token is not valid yet"},
+		{name: "untrusted issuer", issuer: "hr", token: sign("secret", with(func(c *TokenClaims) { c.Issuer = "other" })), wantErr: "token issuer is not trusted"},
+		{name: "no subject", token: sign("secret", with(func(c *TokenClaims) { c.Subject = "" })), wantErr: "token has no subject"},
+		{name: "not a jwt", token: "bearer", wantErr: "malformed token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseToken("secret", tt.issuer, tt.token, now)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse token: %v", err)
+			}
+			if got.Subject != claims.Subject || got.Role != claims.Role || got.OfficerID == nil || *got.OfficerID != officerID {
+				t.Errorf("got claims %+v, want %+v", got, claims)
+			}
+		})
+	}
+}
+
+func TestSignTokenNeedsASecret(t *testing.T) {
+	if _, err := SignToken("", TokenClaims{Subject: "staff:1", ExpiresAt: time.Now().Add(time.Hour).Unix()}); err == nil {
+		t.Fatal("signed a token without a secret")
+	}
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// FundingPolicy controls how collected installments are passed on to lenders
+type FundingPolicy struct {
+	ServiceFeeRate float64 // platform fee as a fraction of the interest paid to lenders
+}
+
+// LenderService handles lenders, the fundings of loans and the distribution of
+// collected installments to lenders
+type LenderService struct {
+	lenderRepo *repositories.LenderRepository
+	loanRepo   *repositories.LoanRepository
+	transactor *repositories.Transactor
+	policy     FundingPolicy
+	currency   Currency
+}
+
+// NewLenderService creates a new lender service instance
+func NewLenderService(lenderRepo *repositories.LenderRepository, loanRepo *repositories.LoanRepository, transactor *repositories.Transactor, policy FundingPolicy, currency Currency) *LenderService {
+	return &LenderService{lenderRepo: lenderRepo, loanRepo: loanRepo, transactor: transactor, policy: policy, currency: currency}
+}
+
+// CreateLender registers a new lender
+func (s *LenderService) CreateLender(ctx context.Context, req dto.CreateLenderRequest) (*models.Lender, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, Validation("name is required")
+	}
+
+	lenderType := req.Type
+	if lenderType == "" {
+		lenderType = "individual"
+	}
+	if lenderType != "individual" && lenderType != "institution" {
+		return nil, Validation("type must be individual or institution")
+	}
+
+	lender := &models.Lender{
+		Name:      req.Name,
+		Email:     req.Email,
+		Type:      lenderType,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.lenderRepo.Create(ctx, lender); err != nil {
+		return nil, err
+	}
+	return lender, nil
+}
+
+// GetLender retrieves a lender by ID
+func (s *LenderService) GetLender(ctx context.Context, id uint) (*models.Lender, error) {
+	return s.lenderRepo.GetByID(ctx, id)
+}
+
+// ListLenders lists all lenders
+func (s *LenderService) ListLenders(ctx context.Context) ([]models.Lender, error) {
+	return s.lenderRepo.List(ctx)
+}
+
+// FundLoan records a lender putting up part of an active loan's principal.
+// Fundings of a loan can never add up to more than its principal, and are
+// only taken until the first installment is paid
+func (s *LenderService) FundLoan(ctx context.Context, loanID uint, req dto.FundLoanRequest) (*models.Funding, error) {
+	if req.Amount <= 0 {
+		return nil, Validation("amount must be greater than zero")
+	}
+
+	lender, err := s.lenderRepo.GetByID(ctx, req.LenderID)
+	if err != nil {
+		return nil, err
+	}
+	if lender.Status != "active" {
+		return nil, Conflict("lender is not active")
+	}
+
+	var funding *models.Funding
+	err = s.transactor.Run(ctx, func(ctx context.Context) error {
+		// The lock holds off concurrent fundings until this one is stored
+		loan, err := s.loanRepo.GetForUpdate(ctx, loanID)
+		if err != nil {
+			return err
+		}
+		if loan.Status != "active" {
+			return Conflict(fmt.Sprintf("cannot fund a loan in %s status", loan.Status))
+		}
+		// Shares are of the whole principal, so a lender joining after
+		// repayments began would be paid for installments it did not fund
+		paid, err := s.loanRepo.HasPaidInstallments(ctx, loanID)
+		if err != nil {
+			return err
+		}
+		if paid {
+			return Conflict("cannot fund a loan once its repayments have started")
+		}
+
+		fundings, err := s.lenderRepo.GetFundingsByLoanID(ctx, loanID)
+		if err != nil {
+			return err
+		}
+		funded := 0.0
+		for _, funding := range fundings {
+			funded += funding.Amount
+		}
+		if remaining := loan.Amount - funded; req.Amount > remaining+amountTolerance {
+			return Conflict(fmt.Sprintf("loan has only %.2f left to fund", remaining))
+		}
+
+		now := time.Now()
+		funding = &models.Funding{
+			LoanID:    loan.ID,
+			LenderID:  lender.ID,
+			Amount:    req.Amount,
+			Share:     req.Amount / loan.Amount,
+			FundedAt:  now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return s.lenderRepo.CreateFunding(ctx, funding)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return funding, nil
+}
+
+// GetLoanFundings retrieves a loan with its fundings
+func (s *LenderService) GetLoanFundings(ctx context.Context, loanID uint) (*models.Loan, []models.Funding, error) {
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fundings, err := s.lenderRepo.GetFundingsByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return loan, fundings, nil
+}
+
+// Payouts splits collected installments, less their fees, between the loan's
+// lenders by their share of the principal. Each share is divided into
+// principal and interest in the loan's principal-to-repayable ratio and the
+// platform service fee is taken from the interest. The unfunded part of an
+// installment stays with the platform
+func (s *LenderService) Payouts(ctx context.Context, loan *models.Loan, installments []*models.Payment, paidAt time.Time) ([]*models.LenderPayout, error) {
+	if len(installments) == 0 {
+		return nil, nil
+	}
+
+	fundings, err := s.lenderRepo.GetFundingsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payouts []*models.LenderPayout
+	for _, installment := range installments {
+		for _, funding := range fundings {
+			payouts = append(payouts, s.payout(loan, funding, installment, paidAt))
+		}
+	}
+	return payouts, nil
+}
+
+// PrincipalPayouts returns each lender's share of the principal in installments
+// settled early by a payoff. The interest and fees of those installments are
+// waived, so the lenders are repaid principal only and no service fee is taken
+func (s *LenderService) PrincipalPayouts(ctx context.Context, loan *models.Loan, installments []*models.Payment, paidAt time.Time) ([]*models.LenderPayout, error) {
+	if len(installments) == 0 {
+		return nil, nil
+	}
+
+	fundings, err := s.lenderRepo.GetFundingsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payouts []*models.LenderPayout
+	for _, installment := range installments {
+		for _, funding := range fundings {
+			payout := s.payout(loan, funding, installment, paidAt)
+			payout.GrossAmount = payout.PrincipalAmount
+			payout.InterestAmount = 0
+			payout.FeeAmount = 0
+			payout.NetAmount = payout.PrincipalAmount
+			payouts = append(payouts, payout)
+		}
+	}
+	return payouts, nil
+}
+
+// ReversalPayouts offsets the payouts credited from a reversed payment
+// transaction. Payouts are never deleted; each one gets a negative twin
+func (s *LenderService) ReversalPayouts(ctx context.Context, transactionID uint, reversedAt time.Time) ([]*models.LenderPayout, error) {
+	payouts, err := s.lenderRepo.GetPayoutsByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reversals := make([]*models.LenderPayout, len(payouts))
+	for i, payout := range payouts {
+		reversals[i] = &models.LenderPayout{
+			LenderID:        payout.LenderID,
+			FundingID:       payout.FundingID,
+			LoanID:          payout.LoanID,
+			PaymentID:       payout.PaymentID,
+			TransactionID:   payout.TransactionID,
+			GrossAmount:     -payout.GrossAmount,
+			PrincipalAmount: -payout.PrincipalAmount,
+			InterestAmount:  -payout.InterestAmount,
+			FeeAmount:       -payout.FeeAmount,
+			NetAmount:       -payout.NetAmount,
+			PaidAt:          reversedAt,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+	}
+	return reversals, nil
+}
+
+// payout works out one lender's share of one installment. Fees carried by the
+// installment belong to the platform and are not shared
+func (s *LenderService) payout(loan *models.Loan, funding models.Funding, installment *models.Payment, paidAt time.Time) *models.LenderPayout {
+	principalRatio := 1.0
+	if repayable := loan.TotalAmount - loan.FeeAmount; repayable > 0 {
+		principalRatio = loan.Amount / repayable
+	}
+
+	gross := s.currency.Round((installment.Amount - installment.FeeAmount) * funding.Share)
+	principal := s.currency.Round(gross * principalRatio)
+	interest := s.currency.Round(gross - principal)
+	fee := s.currency.Round(interest * s.policy.ServiceFeeRate)
+
+	now := time.Now()
+	return &models.LenderPayout{
+		LenderID:        funding.LenderID,
+		FundingID:       funding.ID,
+		LoanID:          loan.ID,
+		PaymentID:       installment.ID,
+		GrossAmount:     gross,
+		PrincipalAmount: principal,
+		InterestAmount:  interest,
+		FeeAmount:       fee,
+		NetAmount:       s.currency.Round(gross - fee),
+		PaidAt:          paidAt,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// GetPortfolio summarises every loan a lender has funded
+func (s *LenderService) GetPortfolio(ctx context.Context, lenderID uint) (*dto.LenderPortfolioResponse, error) {
+	lender, err := s.lenderRepo.GetByID(ctx, lenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	fundings, err := s.lenderRepo.GetFundingsByLenderID(ctx, lenderID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := s.lenderRepo.GetPayoutsByLenderID(ctx, lenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	received := map[uint]models.LenderPayout{}
+	for _, payout := range payouts {
+		total := received[payout.FundingID]
+		total.PrincipalAmount += payout.PrincipalAmount
+		total.NetAmount += payout.NetAmount
+		received[payout.FundingID] = total
+	}
+
+	response := &dto.LenderPortfolioResponse{
+		LenderID:   lender.ID,
+		LenderName: lender.Name,
+		Loans:      []dto.LenderPortfolioItem{},
+	}
+	for _, funding := range fundings {
+		item := dto.LenderPortfolioItem{
+			FundingID:            funding.ID,
+			LoanID:               funding.LoanID,
+			FundedAmount:         funding.Amount,
+			Share:                funding.Share,
+			FundedAt:             funding.FundedAt,
+			PrincipalRepaid:      s.currency.Round(received[funding.ID].PrincipalAmount),
+			NetReceived:          s.currency.Round(received[funding.ID].NetAmount),
+			OutstandingPrincipal: s.currency.Round(funding.Amount - received[funding.ID].PrincipalAmount),
+		}
+		if funding.Loan != nil {
+			item.LoanStatus = funding.Loan.Status
+		}
+		// Nothing more is expected from written-off, completed or refinanced loans
+		if item.LoanStatus == "written_off" || item.LoanStatus == "completed" || item.LoanStatus == "refinanced" {
+			item.OutstandingPrincipal = 0
+		}
+
+		response.FundedAmount += item.FundedAmount
+		response.OutstandingPrincipal += item.OutstandingPrincipal
+		if item.LoanStatus == "active" || item.LoanStatus == "defaulted" {
+			response.ActiveLoans++
+		}
+		response.Loans = append(response.Loans, item)
+	}
+	response.FundedAmount = s.currency.Round(response.FundedAmount)
+	response.OutstandingPrincipal = s.currency.Round(response.OutstandingPrincipal)
+
+	return response, nil
+}
+
+// GetCashFlows projects a lender's payouts from the unpaid installments of the
+// running loans they fund, grouped by due date. Zero dates are open ends
+func (s *LenderService) GetCashFlows(ctx context.Context, lenderID uint, from, to time.Time) (*dto.LenderCashFlowResponse, error) {
+	if _, err := s.lenderRepo.GetByID(ctx, lenderID); err != nil {
+		return nil, err
+	}
+
+	fundings, err := s.lenderRepo.GetFundingsByLenderID(ctx, lenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	fundingsByLoan := map[uint][]models.Funding{}
+	var loanIDs []uint
+	for _, funding := range fundings {
+		if funding.Loan == nil || (funding.Loan.Status != "active" && funding.Loan.Status != "defaulted") {
+			continue
+		}
+		if _, ok := fundingsByLoan[funding.LoanID]; !ok {
+			loanIDs = append(loanIDs, funding.LoanID)
+		}
+		fundingsByLoan[funding.LoanID] = append(fundingsByLoan[funding.LoanID], funding)
+	}
+
+	installments, err := s.lenderRepo.GetPendingInstallments(ctx, loanIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	flows := map[time.Time]*dto.LenderCashFlowItem{}
+	for i := range installments {
+		installment := &installments[i]
+		dueDate := truncateToDay(installment.DueDate)
+		if (!from.IsZero() && dueDate.Before(truncateToDay(from))) || (!to.IsZero() && dueDate.After(truncateToDay(to))) {
+			continue
+		}
+
+		for _, funding := range fundingsByLoan[installment.LoanID] {
+			payout := s.payout(funding.Loan, funding, installment, dueDate)
+			flow, ok := flows[dueDate]
+			if !ok {
+				flow = &dto.LenderCashFlowItem{DueDate: dueDate}
+				flows[dueDate] = flow
+			}
+			flow.Installments++
+			flow.GrossAmount += payout.GrossAmount
+			flow.PrincipalAmount += payout.PrincipalAmount
+			flow.InterestAmount += payout.InterestAmount
+			flow.FeeAmount += payout.FeeAmount
+			flow.NetAmount += payout.NetAmount
+		}
+	}
+
+	response := &dto.LenderCashFlowResponse{LenderID: lenderID, CashFlows: []dto.LenderCashFlowItem{}}
+	for _, flow := range flows {
+		flow.GrossAmount = s.currency.Round(flow.GrossAmount)
+		flow.PrincipalAmount = s.currency.Round(flow.PrincipalAmount)
+		flow.InterestAmount = s.currency.Round(flow.InterestAmount)
+		flow.FeeAmount = s.currency.Round(flow.FeeAmount)
+		flow.NetAmount = s.currency.Round(flow.NetAmount)
+		response.TotalNet += flow.NetAmount
+		response.CashFlows = append(response.CashFlows, *flow)
+	}
+	sort.Slice(response.CashFlows, func(i, j int) bool {
+		return response.CashFlows[i].DueDate.Before(response.CashFlows[j].DueDate)
+	})
+	response.TotalNet = s.currency.Round(response.TotalNet)
+
+	return response, nil
+}
+
+// GetReturns reports what a lender has actually earned: payouts received,
+// fees paid and principal lost on written-off loans
+func (s *LenderService) GetReturns(ctx context.Context, lenderID uint) (*dto.LenderReturnsResponse, error) {
+	if _, err := s.lenderRepo.GetByID(ctx, lenderID); err != nil {
+		return nil, err
+	}
+
+	fundings, err := s.lenderRepo.GetFundingsByLenderID(ctx, lenderID)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := s.lenderRepo.GetPayoutsByLenderID(ctx, lenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.LenderReturnsResponse{LenderID: lenderID}
+	principalByFunding := map[uint]float64{}
+	for _, payout := range payouts {
+		response.GrossReceived += payout.GrossAmount
+		response.PrincipalRepaid += payout.PrincipalAmount
+		response.InterestEarned += payout.InterestAmount
+		response.FeesPaid += payout.FeeAmount
+		response.NetReceived += payout.NetAmount
+		principalByFunding[payout.FundingID] += payout.PrincipalAmount
+	}
+	for _, funding := range fundings {
+		response.FundedAmount += funding.Amount
+		if funding.Loan != nil && funding.Loan.Status == "written_off" {
+			response.PrincipalLost += funding.Amount - principalByFunding[funding.ID]
+		}
+	}
+
+	response.FundedAmount = s.currency.Round(response.FundedAmount)
+	response.GrossReceived = s.currency.Round(response.GrossReceived)
+	response.PrincipalRepaid = s.currency.Round(response.PrincipalRepaid)
+	response.InterestEarned = s.currency.Round(response.InterestEarned)
+	response.FeesPaid = s.currency.Round(response.FeesPaid)
+	response.NetReceived = s.currency.Round(response.NetReceived)
+	response.PrincipalLost = s.currency.Round(response.PrincipalLost)
+	response.NetIncome = s.currency.Round(response.InterestEarned - response.FeesPaid - response.PrincipalLost)
+	if response.FundedAmount > 0 {
+		response.ReturnOnFunded = response.NetIncome / response.FundedAmount
+	}
+
+	return response, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+)
+
+func TestPayoutSharesInstallmentsProRata(t *testing.T) {
+	paidAt := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	type share struct {
+		share                                float64
+		gross, principal, interest, fee, net float64
+	}
+	tests := []struct {
+		name        string
+		feeRate     float64
+		loan        models.Loan
+		installment models.Payment
+		shares      []share
+	}{
+		{
+			name:        "fees stay with the platform and the service fee comes from interest",
+			feeRate:     0.1,
+			loan:        models.Loan{ID: 1, Amount: 1000, TotalAmount: 1300, FeeAmount: 100},
+			installment: models.Payment{ID: 9, Amount: 325, FeeAmount: 25},
+			shares: []share{
+				{share: 0.5, gross: 150, principal: 125, interest: 25, fee: 2.5, net: 147.5},
+				{share: 0.3, gross: 90, principal: 75, interest: 15, fee: 1.5, net: 88.5},
+				{share: 0.2, gross: 60, principal: 50, interest: 10, fee: 1, net: 59},
+			},
+		},
+		{
+			name:        "the unfunded part is not paid out",
+			feeRate:     0,
+			loan:        models.Loan{ID: 2, Amount: 1000, TotalAmount: 1200},
+			installment: models.Payment{ID: 10, Amount: 300},
+			shares: []share{
+				{share: 0.6, gross: 180, principal: 150, interest: 30, fee: 0, net: 180},
+			},
+		},
+		{
+			name:        "loans without interest repay principal only",
+			feeRate:     0.2,
+			loan:        models.Loan{ID: 3, Amount: 1000, TotalAmount: 1000},
+			installment: models.Payment{ID: 11, Amount: 250},
+			shares: []share{
+				{share: 0.25, gross: 62.5, principal: 62.5, interest: 0, fee: 0, net: 62.5},
+				{share: 0.75, gross: 187.5, principal: 187.5, interest: 0, fee: 0, net: 187.5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LenderService{policy: FundingPolicy{ServiceFeeRate: tt.feeRate}, currency: cents}
+			for i, want := range tt.shares {
+				funding := models.Funding{ID: uint(i + 1), LenderID: uint(100 + i), Share: want.share}
+				payout := s.payout(&tt.loan, funding, &tt.installment, paidAt)
+
+				for _, check := range []struct {
+					field     string
+					got, want float64
+				}{
+					{"gross", payout.GrossAmount, want.gross},
+					{"principal", payout.PrincipalAmount, want.principal},
+					{"interest", payout.InterestAmount, want.interest},
+					{"service fee", payout.FeeAmount, want.fee},
+					{"net", payout.NetAmount, want.net},
+				} {
+					if !sameAmount(check.got, check.want) {
+						t.Errorf("share %.2f: %s %.2f, want %.2f", want.share, check.field, check.got, check.want)
+					}
+				}
+				if payout.LenderID != funding.LenderID || payout.FundingID != funding.ID ||
+					payout.LoanID != tt.loan.ID || payout.PaymentID != tt.installment.ID || !payout.PaidAt.Equal(paidAt) {
+					t.Errorf("share %.2f: payout %+v is not linked to its funding, loan and installment", want.share, payout)
+				}
+			}
+		})
+	}
+}
+
+func TestPayoutRoundsEachShare(t *testing.T) {
+	s := &LenderService{policy: FundingPolicy{ServiceFeeRate: 0.15}, currency: cents}
+	loan := &models.Loan{Amount: 1000, TotalAmount: 1237, FeeAmount: 37}
+	installment := &models.Payment{Amount: 103.09, FeeAmount: 3.09}
+
+	tests := []struct {
+		name  string
+		share float64
+	}{
+		{"a third", 1.0 / 3},
+		{"a seventh", 1.0 / 7},
+		{"most of the loan", 0.91},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := s.payout(loan, models.Funding{Share: tt.share}, installment, time.Now())
+			for _, amount := range []float64{payout.GrossAmount, payout.PrincipalAmount, payout.InterestAmount, payout.FeeAmount, payout.NetAmount} {
+				if amount != cents.Round(amount) {
+					t.Errorf("%v is not rounded to cents", amount)
+				}
+			}
+			if !sameAmount(payout.PrincipalAmount+payout.InterestAmount, payout.GrossAmount) {
+				t.Errorf("principal %.2f and interest %.2f do not add up to %.2f",
+					payout.PrincipalAmount, payout.InterestAmount, payout.GrossAmount)
+			}
+			if !sameAmount(payout.NetAmount+payout.FeeAmount, payout.GrossAmount) {
+				t.Errorf("net %.2f and fee %.2f do not add up to %.2f", payout.NetAmount, payout.FeeAmount, payout.GrossAmount)
+			}
+		})
+	}
+}
+
+func TestPayoutRoundsToTheCurrency(t *testing.T) {
+	loan := &models.Loan{Amount: 1000000, TotalAmount: 1237000, FeeAmount: 37000}
+	installment := &models.Payment{Amount: 103083.33, FeeAmount: 3083.33}
+
+	for _, decimals := range []int{0, 2, 3} {
+		t.Run(fmt.Sprintf("%d decimals", decimals), func(t *testing.T) {
+			currency := Currency{Code: "XXX", Decimals: decimals}
+			s := &LenderService{policy: FundingPolicy{ServiceFeeRate: 0.15}, currency: currency}
+			payout := s.payout(loan, models.Funding{Share: 1.0 / 3}, installment, time.Now())
+			for _, amount := range []float64{payout.GrossAmount, payout.PrincipalAmount, payout.InterestAmount, payout.FeeAmount, payout.NetAmount} {
+				if amount != currency.Round(amount) {
+					t.Errorf("%v is not rounded to %d decimal places", amount, decimals)
+				}
+			}
+		})
+	}
+}
+
+func TestFundingStopsOnceRepaymentsStart(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		paid int64
+		want ErrorCode
+	}{
+		{"no installment paid", 0, ""},
+		{"an installment paid", 1, CodeConflict},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			loans, db, _ := newLoanService(t)
+			db.Returning(dbtest.Row([]string{"id", "status"}, int64(2), "active"), "FROM `lenders`")
+			db.Returning(loanRows(models.Loan{ID: 4, TenantID: 1, Amount: 1000, Status: "active"}), "FROM `loans`")
+			db.Returning(dbtest.Row([]string{"count"}, tt.paid), "count(*)", "FROM `payments`")
+
+			_, err := loans.lenders.FundLoan(context.Background(), 4, dto.FundLoanRequest{LenderID: 2, Amount: 500})
+			funded := len(db.Find("INSERT INTO `fundings`")) > 0
+			if tt.want == "" {
+				if err != nil || !funded {
+					t.Fatalf("got %v and funded %v, want the funding recorded", err, funded)
+				}
+				return
+			}
+			if CodeOf(err) != tt.want || funded {
+				t.Fatalf("got %v and funded %v, want %s", err, funded, tt.want)
+			}
+		})
+	}
+}
@@ -1,70 +1,627 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"AmarthaExample1/internal/dto"
 	"AmarthaExample1/internal/models"
 	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/validation"
 )
 
 // LoanService handles business logic for loans
 type LoanService struct {
-	repo *repositories.LoanRepository
+	repo         *repositories.LoanRepository
+	groupRepo    *repositories.GroupRepository
+	productRepo  *repositories.ProductRepository
+	transactor   *repositories.Transactor
+	calendar     *CalendarService
+	lenders      *LenderService
+	eligibility  *EligibilityService
+	applications *ApplicationService
+	accounts     VirtualAccountPolicy
+	currency     Currency
+}
+
+// Currency is the currency loans are booked and repaid in
+type Currency struct {
+	Code     string // ISO 4217 code, such as IDR; payments are not checked when empty
+	Decimals int    // decimal places a payment amount may carry
+}
+
+// Round rounds an amount to the currency's decimal places
+func (c Currency) Round(amount float64) float64 {
+	scale := math.Pow10(c.Decimals)
+	return math.Round(amount*scale) / scale
+}
+
+// Split divides an amount into parts rounded to the currency's decimal
+// places. The last part takes the rounding remainder, so they add up to the amount
+func (c Currency) Split(amount float64, parts int) []float64 {
+	share := c.Round(amount / float64(parts))
+	amounts := make([]float64, parts)
+	for i := range amounts {
+		amounts[i] = share
+	}
+	amounts[parts-1] = c.Round(amount - share*float64(parts-1))
+	return amounts
 }
 
 // NewLoanService creates a new loan service instance
-func NewLoanService(repo *repositories.LoanRepository) *LoanService {
-	return &LoanService{repo: repo}
+func NewLoanService(repo *repositories.LoanRepository, groupRepo *repositories.GroupRepository, productRepo *repositories.ProductRepository, transactor *repositories.Transactor, calendar *CalendarService, lenders *LenderService, eligibility *EligibilityService, applications *ApplicationService, accounts VirtualAccountPolicy, currency Currency) *LoanService {
+	return &LoanService{
+		repo:         repo,
+		groupRepo:    groupRepo,
+		productRepo:  productRepo,
+		transactor:   transactor,
+		calendar:     calendar,
+		lenders:      lenders,
+		eligibility:  eligibility,
+		applications: applications,
+		accounts:     accounts,
+		currency:     currency,
+	}
+}
+
+// loanTerms are the amounts a loan is booked on
+type loanTerms struct {
+	interestRate    float64
+	totalWeeks      int
+	interestAmount  float64
+	deductedFees    float64
+	installmentFees float64
+	totalAmount     float64
+	weeklyPayment   float64
+	charges         []models.LoanCharge
+}
+
+// quote works out the terms of a loan: 10% flat interest over 50 weekly
+// installments plus the fees of its product. Deducted fees come off the
+// disbursement; installment fees are added to the amount repaid. The amount
+// must lie within the product's principal bounds
+func (s *LoanService) quote(ctx context.Context, amount float64, productID *uint) (*loanTerms, error) {
+	terms := &loanTerms{interestRate: 0.10, totalWeeks: 50}
+	terms.interestAmount = amount * terms.interestRate
+
+	if productID != nil {
+		product, err := s.productRepo.GetByID(ctx, *productID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPrincipal(product, amount); err != nil {
+			return nil, err
+		}
+
+		fees, err := s.productRepo.GetFees(ctx, *productID)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		for i, fee := range fees {
+			base := fee.Value
+			if fee.Type == "percentage" {
+				base = amount * fee.Value
+			}
+			base = s.currency.Round(base)
+			vat := s.currency.Round(base * fee.VATRate)
+
+			charge := models.LoanCharge{
+				ProductFeeID: &fees[i].ID,
+				Code:         fee.Code,
+				Name:         fee.Name,
+				Collection:   fee.Collection,
+				Amount:       base,
+				VATAmount:    vat,
+				TotalAmount:  base + vat,
+				Status:       "pending",
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}
+			if fee.Collection == "deducted" {
+				charge.PaidAmount = charge.TotalAmount
+				charge.Status = "paid"
+				charge.PaidAt = &now
+				terms.deductedFees += charge.TotalAmount
+			} else {
+				terms.installmentFees += charge.TotalAmount
+			}
+			terms.charges = append(terms.charges, charge)
+		}
+	}
+
+	if terms.deductedFees >= amount {
+		return nil, Validation("deducted fees leave nothing to disburse")
+	}
+
+	terms.totalAmount = s.currency.Round(amount + terms.interestAmount + terms.installmentFees)
+	terms.weeklyPayment = s.currency.Round(terms.totalAmount / float64(terms.totalWeeks))
+	return terms, nil
+}
+
+// QuoteLoan prices a loan without booking it
+func (s *LoanService) QuoteLoan(ctx context.Context, req dto.LoanQuoteRequest) (*dto.LoanQuoteResponse, error) {
+	if req.Amount <= 0 {
+		return nil, Validation("amount must be greater than zero")
+	}
+	if req.ProductID != nil {
+		if _, err := s.productRepo.GetByID(ctx, *req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	terms, err := s.quote(ctx, req.Amount, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.LoanQuoteResponse{
+		Amount:          req.Amount,
+		InterestRate:    terms.interestRate,
+		InterestAmount:  terms.interestAmount,
+		TotalWeeks:      terms.totalWeeks,
+		DeductedFees:    terms.deductedFees,
+		InstallmentFees: terms.installmentFees,
+		DisbursedAmount: req.Amount - terms.deductedFees,
+		TotalAmount:     terms.totalAmount,
+		WeeklyPayment:   terms.weeklyPayment,
+		WeeklyFee:       terms.installmentFees / float64(terms.totalWeeks),
+		Fees:            make([]dto.ChargeDTO, len(terms.charges)),
+	}
+	for i, charge := range terms.charges {
+		response.Fees[i] = dto.ChargeDTO{
+			Code:        charge.Code,
+			Name:        charge.Name,
+			Collection:  charge.Collection,
+			Amount:      charge.Amount,
+			VATAmount:   charge.VATAmount,
+			TotalAmount: charge.TotalAmount,
+		}
+	}
+	return response, nil
+}
+
+// CreateLoan creates a new loan with payment schedule. Due dates follow the
+// product's due date rule when the loan is booked under a product, and the
+// loan is linked to the borrower's group unless another group is given. A loan
+// without a virtual account is given a new one
+func (s *LoanService) CreateLoan(ctx context.Context, req dto.CreateLoanRequest) (*models.Loan, error) {
+	if s.eligibility != nil {
+		if err := s.eligibility.Require(ctx, req.BorrowerID, req.Amount, req.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	groupID, err := s.resolveGroup(ctx, req.BorrowerID, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := s.quote(ctx, req.Amount, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	application, err := s.assess(ctx, req.BorrowerID, req.Amount, req.ProductID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	loan, schedule, err := s.newLoan(ctx, req, groupID, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, loan, schedule, terms.charges); err != nil {
+		return nil, err
+	}
+
+	if err := s.linkApplication(ctx, application, loan); err != nil {
+		return nil, err
+	}
+
+	return loan, nil
 }
 
-// CreateLoan creates a new loan with payment schedule
-func (s *LoanService) CreateLoan(borrowerID uint, amount float64) (*models.Loan, error) {
-	interestRate := 0.10
-	totalWeeks := 50
+// assess scores the request when scoring is configured
+func (s *LoanService) assess(ctx context.Context, borrowerID uint, amount float64, productID, groupID *uint) (*models.LoanApplication, error) {
+	if s.applications == nil {
+		return nil, nil
+	}
+	return s.applications.Assess(ctx, borrowerID, amount, productID, groupID)
+}
 
-	totalAmount := amount * (1 + interestRate)
-	weeklyPayment := totalAmount / float64(totalWeeks)
+// linkApplication records the loan booked from an application
+func (s *LoanService) linkApplication(ctx context.Context, application *models.LoanApplication, loan *models.Loan) error {
+	if application == nil {
+		return nil
+	}
+	return s.applications.LinkLoan(ctx, application, loan.ID)
+}
 
+// newLoan builds an active loan on the given terms with its schedule, starting
+// today. Installments and their fees are rounded to the currency, the last
+// installment taking the rounding remainder
+func (s *LoanService) newLoan(ctx context.Context, req dto.CreateLoanRequest, groupID *uint, terms *loanTerms) (*models.Loan, []models.Payment, error) {
 	startDate := time.Now()
-	endDate := startDate.AddDate(0, 0, 7*totalWeeks)
+	endDate := startDate.AddDate(0, 0, 7*terms.totalWeeks)
+
+	dueDates := make([]time.Time, terms.totalWeeks)
+	for i := range dueDates {
+		dueDates[i] = startDate.AddDate(0, 0, 7*i)
+	}
+	dueDates, err := s.calendar.AdjustDueDates(ctx, req.BorrowerID, req.ProductID, dueDates)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	loan := &models.Loan{
-		BorrowerID:    borrowerID,
-		Amount:        amount,
-		InterestRate:  interestRate,
-		TotalAmount:   totalAmount,
-		WeeklyPayment: weeklyPayment,
-		TotalWeeks:    totalWeeks,
-		StartDate:     startDate,
-		EndDate:       endDate,
-		Status:        "active",
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		BorrowerID:      req.BorrowerID,
+		ProductID:       req.ProductID,
+		GroupID:         groupID,
+		Reference:       strings.TrimSpace(req.Reference),
+		Amount:          req.Amount,
+		InterestRate:    terms.interestRate,
+		TotalAmount:     terms.totalAmount,
+		FeeAmount:       terms.installmentFees,
+		DeductedFees:    terms.deductedFees,
+		DisbursedAmount: req.Amount - terms.deductedFees,
+		WeeklyPayment:   terms.weeklyPayment,
+		TotalWeeks:      terms.totalWeeks,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		Status:          "active",
+		ScheduleVersion: 1,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	account, err := s.virtualAccount(ctx, req.VirtualAccount)
+	if err != nil {
+		return nil, nil, err
+	}
+	loan.VirtualAccount = &account
+
+	now := time.Now()
+	amounts := s.currency.Split(terms.totalAmount, len(dueDates))
+	fees := s.currency.Split(terms.installmentFees, len(dueDates))
+	schedule := make([]models.Payment, len(dueDates))
+	for i, dueDate := range dueDates {
+		schedule[i] = models.Payment{
+			Amount:    amounts[i],
+			FeeAmount: fees[i],
+			WeekNum:   i + 1,
+			DueDate:   dueDate,
+			Status:    "pending",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	return loan, schedule, nil
+}
+
+// loanPayoff is what it takes to settle a loan early. Installments already due
+// are paid in full; for later ones only the principal is repaid and their
+// interest and fees are waived. Money held in suspense counts towards it
+type loanPayoff struct {
+	due            []*models.Payment
+	future         []*models.Payment
+	dueAmount      float64
+	dueFees        float64
+	principal      float64
+	interestWaived float64
+	feesWaived     float64
+	suspense       float64 // taken from the loan's suspense balance
+	amount         float64
+}
+
+// payoff works out the payoff of a loan's current schedule as of a date
+func (s *LoanService) payoff(loan *models.Loan, payments []models.Payment, asOf time.Time) *loanPayoff {
+	principalRatio := 1.0
+	if repayable := loan.TotalAmount - loan.FeeAmount; repayable > 0 {
+		principalRatio = loan.Amount / repayable
+	}
+	dueBefore := truncateToDay(asOf).AddDate(0, 0, 1)
+
+	result := &loanPayoff{}
+	for i := range payments {
+		payment := &payments[i]
+		switch {
+		case payment.Status != "pending":
+		case payment.DueDate.Before(dueBefore):
+			result.due = append(result.due, payment)
+			result.dueAmount += payment.Amount
+			result.dueFees += payment.FeeAmount
+		default:
+			principal := (payment.Amount - payment.FeeAmount) * principalRatio
+			result.future = append(result.future, payment)
+			result.principal += principal
+			result.interestWaived += payment.Amount - payment.FeeAmount - principal
+			result.feesWaived += payment.FeeAmount
+		}
 	}
 
-	if err := s.repo.Create(loan); err != nil {
+	result.dueAmount = s.currency.Round(result.dueAmount)
+	result.dueFees = s.currency.Round(result.dueFees)
+	result.principal = s.currency.Round(result.principal)
+	result.interestWaived = s.currency.Round(result.interestWaived)
+	result.feesWaived = s.currency.Round(result.feesWaived)
+	result.suspense = math.Min(loan.SuspenseAmount, s.currency.Round(result.dueAmount+result.principal))
+	result.amount = s.currency.Round(result.dueAmount + result.principal - result.suspense)
+	return result
+}
+
+// GetPayoff returns the amount that would settle an active loan today
+func (s *LoanService) GetPayoff(ctx context.Context, loanID uint) (*dto.PayoffResponse, error) {
+	loan, payments, err := s.loadPayable(ctx, loanID)
+	if err != nil {
 		return nil, err
 	}
+	if loan.Status != "active" && loan.Status != "defaulted" {
+		return nil, NewError(CodeLoanNotPayable, fmt.Sprintf("loan is %s and has nothing to pay off", loan.Status))
+	}
 
-	return loan, nil
+	now := time.Now()
+	payoff := s.payoff(loan, payments, now)
+	return &dto.PayoffResponse{
+		LoanID:                loan.ID,
+		AsOf:                  truncateToDay(now),
+		DueAmount:             payoff.dueAmount,
+		PrincipalRemaining:    payoff.principal,
+		InterestWaived:        payoff.interestWaived,
+		FeesWaived:            payoff.feesWaived,
+		SuspenseAmount:        payoff.suspense,
+		PayoffAmount:          payoff.amount,
+		InstallmentsDue:       len(payoff.due),
+		InstallmentsRemaining: len(payoff.future),
+	}, nil
+}
+
+// TopUp books a new loan for the borrower of an active loan. Its principal
+// first pays off the old loan, which is closed as refinanced, and the rest is
+// disbursed to the borrower. The new loan goes through the same eligibility
+// checks and scoring as any other, with the old loan treated as settled
+func (s *LoanService) TopUp(ctx context.Context, loanID uint, req dto.TopUpRequest) (*dto.TopUpResponse, error) {
+	var response *dto.TopUpResponse
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		var err error
+		response, err = s.topUp(ctx, loanID, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// topUp books a top-up for TopUp with the old loan locked
+func (s *LoanService) topUp(ctx context.Context, loanID uint, req dto.TopUpRequest) (*dto.TopUpResponse, error) {
+	old, payments, err := s.lockPayable(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if old.Status != "active" {
+		return nil, Conflict(fmt.Sprintf("only active loans can be topped up, loan is %s", old.Status))
+	}
+
+	missed, err := s.repo.GetMissedPaymentsCount(ctx, old.ID)
+	if err != nil {
+		return nil, err
+	}
+	if missed > 0 {
+		return nil, Conflict("loans with missed payments cannot be topped up")
+	}
+
+	productID := req.ProductID
+	if productID == nil {
+		productID = old.ProductID
+	}
+
+	if s.eligibility != nil {
+		if err := s.eligibility.RequireRefinance(ctx, old.BorrowerID, req.Amount, productID, old.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	groupID, err := s.resolveGroup(ctx, old.BorrowerID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	terms, err := s.quote(ctx, req.Amount, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payoff := s.payoff(old, payments, now)
+	net := s.currency.Round(req.Amount - terms.deductedFees - payoff.amount)
+	if net <= 0 {
+		return nil, Validation(fmt.Sprintf("top-up amount must exceed the payoff of %.2f plus deducted fees of %.2f", payoff.amount, terms.deductedFees))
+	}
+
+	application, err := s.assess(ctx, old.BorrowerID, req.Amount, productID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	loan, schedule, err := s.newLoan(ctx, dto.CreateLoanRequest{
+		BorrowerID: old.BorrowerID,
+		Amount:     req.Amount,
+		ProductID:  productID,
+		Reference:  req.Reference,
+	}, groupID, terms)
+	if err != nil {
+		return nil, err
+	}
+	loan.PayoffAmount = payoff.amount
+	loan.DisbursedAmount = net
+
+	settlement, err := s.payoffSettlement(ctx, old, payoff, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Refinance(ctx, &repositories.Refinancing{
+		Loan:     loan,
+		Schedule: schedule,
+		Charges:  terms.charges,
+		Payoff:   settlement,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.linkApplication(ctx, application, loan); err != nil {
+		return nil, err
+	}
+
+	return &dto.TopUpResponse{
+		LoanID:           loan.ID,
+		RefinancedLoanID: old.ID,
+		Amount:           loan.Amount,
+		PayoffAmount:     payoff.amount,
+		DeductedFees:     loan.DeductedFees,
+		NetDisbursement:  net,
+	}, nil
+}
+
+// payoffSettlement settles a payoff: due installments are paid, later ones
+// are closed as refinanced, unpaid installment fees beyond what the due
+// installments cover are waived and the lenders get their share
+func (s *LoanService) payoffSettlement(ctx context.Context, loan *models.Loan, payoff *loanPayoff, paidAt time.Time) (*repositories.Settlement, error) {
+	now := time.Now()
+	for _, payment := range payoff.due {
+		payment.Status = "paid"
+		payment.PaidDate = &paidAt
+		payment.PaymentDate = &now
+		payment.UpdatedAt = now
+	}
+	for _, payment := range payoff.future {
+		payment.Status = "refinanced"
+		payment.PaidDate = &paidAt
+		payment.PaymentDate = &now
+		payment.UpdatedAt = now
+	}
+
+	all, err := s.repo.GetCharges(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+	charges := payoffCharges(s.currency, all, loan.FeeAmount, payoff.dueFees, paidAt, now)
+
+	payouts, err := s.lenders.Payouts(ctx, loan, payoff.due, paidAt)
+	if err != nil {
+		return nil, err
+	}
+	principalPayouts, err := s.lenders.PrincipalPayouts(ctx, loan, payoff.future, paidAt)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := s.currency.Round(payoff.amount + payoff.suspense)
+	return &repositories.Settlement{
+		Loan: loan,
+		Transaction: &models.PaymentTransaction{
+			LoanID:          loan.ID,
+			Amount:          payoff.amount,
+			AppliedAmount:   applied,
+			FeeAmount:       payoff.dueFees,
+			UnappliedAmount: s.currency.Round(math.Max(payoff.amount-applied, 0)),
+			SuspenseApplied: s.currency.Round(math.Max(applied-payoff.amount, 0)),
+			Channel:         "refinance",
+			ReceivedAt:      paidAt,
+			Status:          "posted",
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		},
+		Installments: append(payoff.due, payoff.future...),
+		Payouts:      append(payouts, principalPayouts...),
+		Charges:      charges,
+	}, nil
+}
+
+// virtualAccount checks a requested virtual account is free, or issues a new
+// one. The unique index on the column backs this up for concurrent requests
+func (s *LoanService) virtualAccount(ctx context.Context, requested string) (string, error) {
+	if requested = strings.TrimSpace(requested); requested != "" {
+		taken, err := s.repo.VirtualAccountExists(ctx, requested)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", Conflict("virtual account is already assigned to another loan")
+		}
+		return requested, nil
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		number, err := s.accounts.generate()
+		if err != nil {
+			return "", err
+		}
+		taken, err := s.repo.VirtualAccountExists(ctx, number)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return number, nil
+		}
+	}
+	return "", errors.New("could not issue a free virtual account number")
+}
+
+// resolveGroup returns the group a new loan belongs to. An explicit group must
+// be the borrower's current group; without one the current group is used
+func (s *LoanService) resolveGroup(ctx context.Context, borrowerID uint, groupID *uint) (*uint, error) {
+	membership, err := s.groupRepo.GetActiveMembership(ctx, borrowerID)
+	if err != nil {
+		if groupID != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if groupID != nil && *groupID != membership.GroupID {
+		return nil, Validation("borrower is not a member of the given group")
+	}
+	return &membership.GroupID, nil
 }
 
 // GetLoanByID retrieves a loan by its ID
-func (s *LoanService) GetLoanByID(id uint) (*models.Loan, error) {
-	return s.repo.GetByID(id)
+func (s *LoanService) GetLoanByID(ctx context.Context, id uint) (*models.Loan, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
 // GetOutstanding returns the current outstanding amount on a loan
-func (s *LoanService) GetOutstanding(loanID uint) (float64, error) {
-	return s.repo.GetOutstandingAmount(loanID)
+func (s *LoanService) GetOutstanding(ctx context.Context, loanID uint) (float64, error) {
+	loan, err := s.repo.GetByID(ctx, loanID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Written-off receivables are reported as zero; the amount is kept on the loan
+	if loan.Status == "written_off" {
+		return 0, nil
+	}
+
+	// A refinanced loan was paid off by its top-up
+	if loan.Status == "refinanced" {
+		return 0, nil
+	}
+
+	return s.repo.GetOutstandingAmount(ctx, loanID)
 }
 
 // IsDelinquent checks if a borrower is delinquent (missed 2+ consecutive payments)
-func (s *LoanService) IsDelinquent(loanID uint) (bool, error) {
-	missedCount, err := s.repo.GetMissedPaymentsCount(loanID)
+func (s *LoanService) IsDelinquent(ctx context.Context, loanID uint) (bool, error) {
+	missedCount, err := s.repo.GetMissedPaymentsCount(ctx, loanID)
 	if err != nil {
 		return false, err
 	}
@@ -72,22 +629,85 @@ func (s *LoanService) IsDelinquent(loanID uint) (bool, error) {
 	return missedCount >= 2, nil
 }
 
-// MakePayment processes a payment for a loan
-func (s *LoanService) MakePayment(loanID uint, amount float64) error {
-	loan, err := s.repo.GetByID(loanID)
-	if err != nil {
+// PaymentSource describes where money posted against a loan came from
+type PaymentSource struct {
+	Channel    string // api, field
+	Reference  string
+	ReceivedBy string
+	ReceivedAt time.Time
+}
+
+// MakePayment processes a payment for a loan received through the API,
+// recorded as received by the principal carried by ctx. The payment must be
+// in the loans' currency, which it is assumed to be when none is given
+func (s *LoanService) MakePayment(ctx context.Context, loanID uint, amount float64, currency string) (*models.PaymentTransaction, error) {
+	if err := s.checkPayment(amount, currency); err != nil {
+		return nil, err
+	}
+
+	source := PaymentSource{Channel: "api"}
+	if principal := PrincipalFrom(ctx); principal != nil {
+		source.ReceivedBy = principal.Actor()
+	}
+	return s.PostPayment(ctx, loanID, amount, source)
+}
+
+// checkPayment checks a payment's currency and that its amount carries no
+// more decimal places than the currency has
+func (s *LoanService) checkPayment(amount float64, currency string) error {
+	if s.currency.Code == "" {
+		return nil
+	}
+
+	var fields []validation.FieldError
+	if currency != "" && !strings.EqualFold(currency, s.currency.Code) {
+		fields = append(fields, validation.FieldError{
+			Field:   "currency",
+			Rule:    "currency",
+			Param:   s.currency.Code,
+			Message: "currency must be " + s.currency.Code,
+		})
+	}
+	text := strconv.FormatFloat(amount, 'f', -1, 64)
+	if _, fraction, ok := strings.Cut(text, "."); ok && len(fraction) > s.currency.Decimals {
+		decimals := strconv.Itoa(s.currency.Decimals)
+		fields = append(fields, validation.FieldError{
+			Field:   "amount",
+			Rule:    "precision",
+			Param:   decimals,
+			Message: "amount must have at most " + decimals + " decimal places",
+		})
+	}
+	return Invalid(fields)
+}
+
+// PostPayment processes a payment for a loan and records the transaction it
+// came from. The amount must match the next installment, or every missed
+// installment when the loan is delinquent, less the loan's suspense balance
+func (s *LoanService) PostPayment(ctx context.Context, loanID uint, amount float64, source PaymentSource) (*models.PaymentTransaction, error) {
+	var transaction *models.PaymentTransaction
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = s.postPayment(ctx, loanID, amount, source)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return transaction, nil
+}
 
-	payments, err := s.repo.GetPaymentsByLoanID(loanID)
+// postPayment posts a payment for PostPayment with the loan locked
+func (s *LoanService) postPayment(ctx context.Context, loanID uint, amount float64, source PaymentSource) (*models.PaymentTransaction, error) {
+	loan, payments, err := s.lockPayable(ctx, loanID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check for delinquency
-	missedCount, err := s.repo.GetMissedPaymentsCount(loanID)
+	missedCount, err := s.repo.GetMissedPaymentsCount(ctx, loanID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Count pending payments that need to be paid
@@ -107,47 +727,165 @@ func (s *LoanService) MakePayment(loanID uint, amount float64) error {
 	}
 
 	if len(pendingPayments) == 0 {
-		return errors.New("no pending payments found")
+		return nil, NewError(CodeLoanNotPayable, "no pending payments found")
 	}
 
-	// Calculate required payment amount based on delinquency
-	requiredAmount := loan.WeeklyPayment
+	// Calculate required payment amount based on delinquency. Installment amounts
+	// are summed rather than derived from WeeklyPayment because a restructured
+	// schedule can carry arrears installments of a different size
+	requiredAmount := pendingPayments[0].Amount
 	if missedCount >= 2 {
-		requiredAmount = loan.WeeklyPayment * float64(len(pendingPayments))
+		requiredAmount = 0
+		for _, payment := range pendingPayments {
+			requiredAmount += payment.Amount
+		}
+	}
+
+	// Money held in suspense from earlier postings counts towards the amount due
+	if loan.SuspenseAmount != 0 {
+		requiredAmount = s.currency.Round(requiredAmount - loan.SuspenseAmount)
+		if requiredAmount <= 0 {
+			return s.postPartialPayment(ctx, loanID, amount, source)
+		}
 	}
 
 	if amount != requiredAmount {
+		details := map[string]interface{}{"required_amount": requiredAmount, "amount": amount}
+		if loan.SuspenseAmount != 0 {
+			details["suspense_amount"] = loan.SuspenseAmount
+		}
 		if missedCount >= 2 {
-			return fmt.Errorf("delinquent loan: payment amount must be %v for %d missed payments", requiredAmount, len(pendingPayments))
+			details["missed_installments"] = len(pendingPayments)
+			return nil, NewError(CodeDelinquentAmountMismatch, fmt.Sprintf("delinquent loan: payment amount must be %v for %d missed payments", requiredAmount, len(pendingPayments))).WithDetails(details)
 		}
-		return errors.New("payment amount must match the weekly payment amount")
+		return nil, NewError(CodeInstallmentAmountMismatch, fmt.Sprintf("payment amount must match the installment amount of %v", requiredAmount)).WithDetails(details)
 	}
 
-	// Process payment for the first pending payment or all missed payments if delinquent
-	now := time.Now()
-	
-	if missedCount >= 2 {
-		// Pay all missed payments if delinquent
-		for _, payment := range pendingPayments {
-			payment.Status = "paid"
-			payment.PaidDate = &now
-			payment.PaymentDate = &now
-			payment.UpdatedAt = now
+	// Pay the first pending payment, or all missed payments if delinquent
+	if missedCount < 2 {
+		pendingPayments = pendingPayments[:1]
+	}
 
-			if err := s.repo.UpdatePayment(payment); err != nil {
-				return err
-			}
-		}
-	} else {
-		// Pay just the first pending payment if not delinquent
-		pendingPayments[0].Status = "paid"
-		pendingPayments[0].PaidDate = &now
-		pendingPayments[0].PaymentDate = &now
-		pendingPayments[0].UpdatedAt = now
+	return s.settle(ctx, loan, payments, pendingPayments, amount, source)
+}
+
+// PostPartialPayment posts money that may not match the amount due, as happens
+// with cash collected in the field. Together with the loan's suspense balance
+// it pays off as many whole installments as it covers, oldest first, and the
+// remainder is held in suspense for the next posting
+func (s *LoanService) PostPartialPayment(ctx context.Context, loanID uint, amount float64, source PaymentSource) (*models.PaymentTransaction, error) {
+	if amount <= 0 {
+		return nil, Validation("payment amount must be greater than zero")
+	}
+
+	var transaction *models.PaymentTransaction
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = s.postPartialPayment(ctx, loanID, amount, source)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
 
-		if err := s.repo.UpdatePayment(pendingPayments[0]); err != nil {
-			return err
+// postPartialPayment posts money for PostPartialPayment with the loan locked
+func (s *LoanService) postPartialPayment(ctx context.Context, loanID uint, amount float64, source PaymentSource) (*models.PaymentTransaction, error) {
+	loan, payments, err := s.lockPayable(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	settled := coveredInstallments(s.currency, payments, s.currency.Round(amount+loan.SuspenseAmount))
+	return s.settle(ctx, loan, payments, settled, amount, source)
+}
+
+// coveredInstallments returns the pending installments money pays off in
+// full, oldest first. It stops at the first installment the money left over
+// does not cover, so installments are never paid out of order
+func coveredInstallments(currency Currency, payments []models.Payment, money float64) []*models.Payment {
+	remaining := money
+	var covered []*models.Payment
+	for i := range payments {
+		if payments[i].Status != "pending" {
+			continue
+		}
+		if payments[i].Amount > remaining+amountTolerance {
+			break
 		}
+		covered = append(covered, &payments[i])
+		remaining = currency.Round(remaining - payments[i].Amount)
+	}
+	return covered
+}
+
+// loadPayable loads a loan that can take payments together with its current schedule
+func (s *LoanService) loadPayable(ctx context.Context, loanID uint) (*models.Loan, []models.Payment, error) {
+	loan, err := s.repo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.payable(ctx, loan)
+}
+
+// lockPayable loads a payable loan like loadPayable and locks it until the
+// transaction carried by ctx ends. Everything that reads the schedule or the
+// suspense balance to settle installments does so under this lock, so that
+// concurrent postings to one loan settle one after the other
+func (s *LoanService) lockPayable(ctx context.Context, loanID uint) (*models.Loan, []models.Payment, error) {
+	loan, err := s.repo.GetForUpdate(ctx, loanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.payable(ctx, loan)
+}
+
+// payable checks a loan can take payments and loads its current schedule
+func (s *LoanService) payable(ctx context.Context, loan *models.Loan) (*models.Loan, []models.Payment, error) {
+	// Money received after write-off is booked as a recovery, not against the schedule
+	if loan.Status == "written_off" {
+		return nil, nil, NewError(CodeLoanNotPayable, "loan has been written off: record the payment as a recovery")
+	}
+	if loan.Status == "refinanced" && loan.RefinancedByID != nil {
+		return nil, nil, NewError(CodeLoanNotPayable, fmt.Sprintf("loan has been refinanced: pay loan %d instead", *loan.RefinancedByID)).
+			WithDetails(map[string]interface{}{"refinanced_by_id": *loan.RefinancedByID})
+	}
+
+	payments, err := s.repo.GetPaymentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loan, payments, nil
+}
+
+// settle marks the given installments paid by a new transaction for the full
+// amount received, credits the lenders their shares and completes the loan
+// once nothing is left unpaid. Money beyond the installments goes into the
+// loan's suspense balance, and installments beyond the money come out of it.
+// The loan must have been read with lockPayable in the same transaction
+func (s *LoanService) settle(ctx context.Context, loan *models.Loan, payments []models.Payment, installments []*models.Payment, amount float64, source PaymentSource) (*models.PaymentTransaction, error) {
+	now := time.Now()
+	receivedAt := source.ReceivedAt
+	if receivedAt.IsZero() {
+		receivedAt = now
+	}
+
+	applied := 0.0
+	feeAmount := 0.0
+	for _, payment := range installments {
+		payment.Status = "paid"
+		payment.PaidDate = &receivedAt
+		payment.PaymentDate = &now
+		payment.UpdatedAt = now
+		applied += payment.Amount
+		feeAmount += payment.FeeAmount
+	}
+
+	charges, err := s.allocateFees(ctx, loan, feeAmount, receivedAt)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if all payments are now paid
@@ -159,21 +897,303 @@ func (s *LoanService) MakePayment(loanID uint, amount float64) error {
 		}
 	}
 
-	if allPaid {
-		loan.Status = "completed"
-		loan.UpdatedAt = now
-		return s.repo.UpdateLoan(loan)
+	applied = s.currency.Round(applied)
+	transaction := &models.PaymentTransaction{
+		LoanID:          loan.ID,
+		Amount:          amount,
+		AppliedAmount:   applied,
+		FeeAmount:       feeAmount,
+		UnappliedAmount: s.currency.Round(math.Max(amount-applied, 0)),
+		SuspenseApplied: s.currency.Round(math.Max(applied-amount, 0)),
+		Channel:         source.Channel,
+		Reference:       source.Reference,
+		ReceivedBy:      source.ReceivedBy,
+		ReceivedAt:      receivedAt,
+		Status:          "posted",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	payouts, err := s.lenders.Payouts(ctx, loan, installments, receivedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Settle(ctx, &repositories.Settlement{
+		Loan:         loan,
+		Transaction:  transaction,
+		Installments: installments,
+		Payouts:      payouts,
+		Charges:      charges,
+		CompleteLoan: allPaid,
+	}); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// allocateFees spreads the fee part of settled installments over the loan's
+// unpaid installment charges in proportion to their size
+func (s *LoanService) allocateFees(ctx context.Context, loan *models.Loan, feeAmount float64, paidAt time.Time) ([]*models.LoanCharge, error) {
+	if feeAmount <= 0 || loan.FeeAmount <= 0 {
+		return nil, nil
+	}
+
+	charges, err := s.repo.GetCharges(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return allocateCharges(s.currency, charges, loan.FeeAmount, feeAmount, paidAt), nil
+}
+
+// allocateCharges spreads a fee amount over the unpaid installment charges
+// in proportion to their share of the loan's fees
+func allocateCharges(currency Currency, charges []models.LoanCharge, totalFees, feeAmount float64, paidAt time.Time) []*models.LoanCharge {
+	var allocated []*models.LoanCharge
+	for i := range charges {
+		charge := &charges[i]
+		if !openInstallmentCharge(charge) {
+			continue
+		}
+
+		portion := math.Min(feeAmount*charge.TotalAmount/totalFees, charge.TotalAmount-charge.PaidAmount)
+		charge.PaidAmount = currency.Round(charge.PaidAmount + portion)
+		charge.Status = "partially_paid"
+		if sameAmount(charge.PaidAmount, charge.TotalAmount) {
+			charge.PaidAmount = charge.TotalAmount
+			charge.Status = "paid"
+			charge.PaidAt = &paidAt
+		}
+		charge.UpdatedAt = time.Now()
+		allocated = append(allocated, charge)
+	}
+	return allocated
+}
+
+// payoffCharges pays the installment charges their share of the fees in the
+// installments a payoff settles and waives whatever is left of them
+func payoffCharges(currency Currency, charges []models.LoanCharge, totalFees, dueFees float64, paidAt, now time.Time) []*models.LoanCharge {
+	var settled []*models.LoanCharge
+	if dueFees > 0 && totalFees > 0 {
+		settled = allocateCharges(currency, charges, totalFees, dueFees, paidAt)
+	} else {
+		for i := range charges {
+			if openInstallmentCharge(&charges[i]) {
+				settled = append(settled, &charges[i])
+			}
+		}
+	}
+
+	for _, charge := range settled {
+		if charge.Status != "paid" {
+			charge.Status = "waived"
+			charge.UpdatedAt = now
+		}
+	}
+	return settled
+}
+
+// openInstallmentCharge reports whether a charge is collected with the
+// installments and not yet fully paid
+func openInstallmentCharge(charge *models.LoanCharge) bool {
+	return charge.Collection == "installment" && (charge.Status == "pending" || charge.Status == "partially_paid")
+}
+
+// ReversePayment reverses a posted payment transaction, for example when the
+// money bounced or was posted to the wrong loan. The installments it settled
+// become pending again, the fee charges they paid are reopened, the lender
+// payouts are offset and a loan it completed is made active again. Payments
+// are reversed latest first, and never when the suspense they added has
+// been used up
+func (s *LoanService) ReversePayment(ctx context.Context, loanID, transactionID uint, reason, reversedBy string) (*models.PaymentTransaction, error) {
+	if reason == "" || reversedBy == "" {
+		return nil, Validation("reason and reversed_by are required")
 	}
 
-	return nil
+	var transaction *models.PaymentTransaction
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		var err error
+		transaction, err = s.reversePayment(ctx, loanID, transactionID, reason, reversedBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// reversePayment reverses a payment under a lock on its loan, so that no
+// payment is posted or reversed in between
+func (s *LoanService) reversePayment(ctx context.Context, loanID, transactionID uint, reason, reversedBy string) (*models.PaymentTransaction, error) {
+	loan, err := s.repo.GetForUpdate(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.Status == "written_off" || loan.Status == "refinanced" {
+		return nil, Conflict(fmt.Sprintf("payments on a %s loan cannot be reversed", loan.Status))
+	}
+
+	transactions, err := s.repo.GetTransactions(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := latestReversible(transactions, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The suspense the payment added may have been used up since, for
+	// example by a payoff, and cannot be taken back
+	suspense := s.currency.Round(loan.SuspenseAmount + transaction.SuspenseApplied - transaction.UnappliedAmount)
+	if suspense < 0 {
+		return nil, Conflict(fmt.Sprintf("reversing this payment would leave a suspense balance of %v", suspense)).
+			WithDetails(map[string]interface{}{"suspense_amount": loan.SuspenseAmount, "unapplied_amount": transaction.UnappliedAmount})
+	}
+
+	installments, err := s.repo.GetSettledInstallments(ctx, transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	charges, err := s.deallocateFees(ctx, loan, transaction.FeeAmount, now)
+	if err != nil {
+		return nil, err
+	}
+
+	payouts, err := s.lenders.ReversalPayouts(ctx, transaction.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.Status = "reversed"
+	transaction.ReversedAt = &now
+	transaction.ReversedBy = reversedBy
+	transaction.ReversalReason = reason
+	transaction.UpdatedAt = now
+
+	if err := s.repo.Reverse(ctx, &repositories.Reversal{
+		Loan:         loan,
+		Transaction:  transaction,
+		Installments: installments,
+		Charges:      charges,
+		Payouts:      payouts,
+		ReopenLoan:   loan.Status == "completed" && len(installments) > 0,
+	}); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// latestReversible returns the transaction to reverse, which must be the
+// loan's last posted transaction. Later payments were settled against the
+// installments and suspense it left, so they are reversed first
+func latestReversible(transactions []models.PaymentTransaction, transactionID uint) (*models.PaymentTransaction, error) {
+	var latest, transaction *models.PaymentTransaction
+	for i := range transactions {
+		if transactions[i].ID == transactionID {
+			transaction = &transactions[i]
+		}
+		if transactions[i].Status == "posted" && (latest == nil || transactions[i].ID > latest.ID) {
+			latest = &transactions[i]
+		}
+	}
+
+	switch {
+	case transaction == nil:
+		return nil, NotFound("payment transaction not found")
+	case transaction.Status != "posted":
+		return nil, Conflict(fmt.Sprintf("payment transaction is %s", transaction.Status))
+	case transaction.Channel == "refinance":
+		return nil, Conflict("refinance payoffs cannot be reversed")
+	case latest.ID != transaction.ID:
+		return nil, Conflict(fmt.Sprintf("only the latest payment can be reversed: reverse transaction %d first", latest.ID)).
+			WithDetails(map[string]interface{}{"latest_transaction_id": latest.ID})
+	}
+	return transaction, nil
+}
+
+// deallocateFees takes back the fee part of a reversed payment from the
+// installment charges, in the same proportions allocateFees paid them
+func (s *LoanService) deallocateFees(ctx context.Context, loan *models.Loan, feeAmount float64, reversedAt time.Time) ([]*models.LoanCharge, error) {
+	if feeAmount <= 0 || loan.FeeAmount <= 0 {
+		return nil, nil
+	}
+
+	charges, err := s.repo.GetCharges(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reopened []*models.LoanCharge
+	for i := range charges {
+		charge := &charges[i]
+		if charge.Collection != "installment" || (charge.Status != "paid" && charge.Status != "partially_paid") {
+			continue
+		}
+
+		portion := math.Min(feeAmount*charge.TotalAmount/loan.FeeAmount, charge.PaidAmount)
+		charge.PaidAmount = s.currency.Round(charge.PaidAmount - portion)
+		charge.Status = "partially_paid"
+		if charge.PaidAmount <= 0 {
+			charge.PaidAmount = 0
+			charge.Status = "pending"
+		}
+		charge.PaidAt = nil
+		charge.UpdatedAt = reversedAt
+		reopened = append(reopened, charge)
+	}
+	return reopened, nil
+}
+
+// GetCharges returns the fee charges of a loan
+func (s *LoanService) GetCharges(ctx context.Context, loanID uint) ([]models.LoanCharge, error) {
+	if _, err := s.repo.GetByID(ctx, loanID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCharges(ctx, loanID)
+}
+
+// GetFeeOutstanding returns the part of a loan's outstanding amount that is fees
+func (s *LoanService) GetFeeOutstanding(ctx context.Context, loanID uint) (float64, error) {
+	loan, err := s.repo.GetByID(ctx, loanID)
+	if err != nil {
+		return 0, err
+	}
+	if loan.Status == "written_off" {
+		return 0, nil
+	}
+
+	outstanding := 0.0
+	for _, payment := range loan.Payments {
+		if payment.Status == "pending" {
+			outstanding += payment.FeeAmount
+		}
+	}
+	return s.currency.Round(outstanding), nil
 }
 
 // GetLoanSchedule returns the payment schedule for a loan
-func (s *LoanService) GetLoanSchedule(loanID uint) ([]dto.ScheduleItemDTO, error) {
-	return s.repo.GetLoanSchedule(loanID)
+func (s *LoanService) GetLoanSchedule(ctx context.Context, loanID uint) ([]dto.ScheduleItemDTO, error) {
+	return s.repo.GetLoanSchedule(ctx, loanID)
+}
+
+// GetLoanScheduleVersion returns the payment schedule for a loan as it stood at a given version
+func (s *LoanService) GetLoanScheduleVersion(ctx context.Context, loanID uint, version int) ([]dto.ScheduleItemDTO, error) {
+	loan, err := s.repo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == loan.ScheduleVersion {
+		return s.repo.GetLoanSchedule(ctx, loanID)
+	}
+	return s.repo.GetLoanScheduleVersion(ctx, loanID, version)
 }
 
 // GetPaymentsByLoanID returns all payments for a loan
-func (s *LoanService) GetPaymentsByLoanID(loanID uint) ([]models.Payment, error) {
-	return s.repo.GetPaymentsByLoanID(loanID)
+func (s *LoanService) GetPaymentsByLoanID(ctx context.Context, loanID uint) ([]models.Payment, error) {
+	return s.repo.GetPaymentsByLoanID(ctx, loanID)
 }
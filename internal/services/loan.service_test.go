@@ -0,0 +1,430 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/models"
+)
+
+func TestPayoffWaivesFutureInterestAndFees(t *testing.T) {
+	asOf := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+	week := func(n int) time.Time { return asOf.AddDate(0, 0, 7*n) }
+
+	// 1000 principal repaid as 1300: 200 interest and 100 installment fees
+	withFees := func() []models.Payment {
+		return []models.Payment{
+			{WeekNum: 1, Status: "paid", DueDate: week(-1), Amount: 325, FeeAmount: 25},
+			{WeekNum: 2, Status: "pending", DueDate: asOf, Amount: 325, FeeAmount: 25},
+			{WeekNum: 3, Status: "pending", DueDate: week(1), Amount: 325, FeeAmount: 25},
+			{WeekNum: 4, Status: "pending", DueDate: week(2), Amount: 325, FeeAmount: 25},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		loan         models.Loan
+		payments     []models.Payment
+		wantDue      float64
+		wantDueFees  float64
+		wantPrinc    float64
+		wantInterest float64
+		wantFees     float64
+		wantSuspense float64
+		wantAmount   float64
+	}{
+		{
+			name:         "installments due today are paid in full, later ones repay principal only",
+			loan:         models.Loan{Amount: 1000, TotalAmount: 1300, FeeAmount: 100},
+			payments:     withFees(),
+			wantDue:      325,
+			wantDueFees:  25,
+			wantPrinc:    500,
+			wantInterest: 100,
+			wantFees:     50,
+			wantAmount:   825,
+		},
+		{
+			name:         "suspense counts towards the payoff",
+			loan:         models.Loan{Amount: 1000, TotalAmount: 1300, FeeAmount: 100, SuspenseAmount: 30},
+			payments:     withFees(),
+			wantDue:      325,
+			wantDueFees:  25,
+			wantPrinc:    500,
+			wantInterest: 100,
+			wantFees:     50,
+			wantSuspense: 30,
+			wantAmount:   795,
+		},
+		{
+			name:         "suspense beyond the payoff is only used up to it",
+			loan:         models.Loan{Amount: 1000, TotalAmount: 1300, FeeAmount: 100, SuspenseAmount: 2000},
+			payments:     withFees(),
+			wantDue:      325,
+			wantDueFees:  25,
+			wantPrinc:    500,
+			wantInterest: 100,
+			wantFees:     50,
+			wantSuspense: 825,
+			wantAmount:   0,
+		},
+		{
+			name: "loans without fees",
+			loan: models.Loan{Amount: 1000, TotalAmount: 1200},
+			payments: []models.Payment{
+				{WeekNum: 1, Status: "pending", DueDate: week(-1), Amount: 300},
+				{WeekNum: 2, Status: "pending", DueDate: week(1), Amount: 300},
+				{WeekNum: 3, Status: "pending", DueDate: week(2), Amount: 300},
+				{WeekNum: 4, Status: "pending", DueDate: week(3), Amount: 300},
+			},
+			wantDue:      300,
+			wantPrinc:    750,
+			wantInterest: 150,
+			wantAmount:   1050,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LoanService{currency: cents}
+			payoff := s.payoff(&tt.loan, tt.payments, asOf)
+
+			for _, check := range []struct {
+				field     string
+				got, want float64
+			}{
+				{"due amount", payoff.dueAmount, tt.wantDue},
+				{"due fees", payoff.dueFees, tt.wantDueFees},
+				{"principal", payoff.principal, tt.wantPrinc},
+				{"interest waived", payoff.interestWaived, tt.wantInterest},
+				{"fees waived", payoff.feesWaived, tt.wantFees},
+				{"suspense", payoff.suspense, tt.wantSuspense},
+				{"payoff amount", payoff.amount, tt.wantAmount},
+			} {
+				if !sameAmount(check.got, check.want) {
+					t.Errorf("%s %.2f, want %.2f", check.field, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateChargesInProportion(t *testing.T) {
+	paidAt := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		charges    []models.LoanCharge
+		feeAmount  float64
+		wantPaid   []float64 // per allocated charge
+		wantStatus []string
+	}{
+		{
+			name: "spread by the size of each charge",
+			charges: []models.LoanCharge{
+				{Code: "admin", Collection: "installment", TotalAmount: 60, Status: "pending"},
+				{Code: "insurance", Collection: "installment", TotalAmount: 40, PaidAmount: 10, Status: "partially_paid"},
+			},
+			feeAmount:  25,
+			wantPaid:   []float64{15, 20},
+			wantStatus: []string{"partially_paid", "partially_paid"},
+		},
+		{
+			name: "a charge is never paid beyond its total",
+			charges: []models.LoanCharge{
+				{Code: "admin", Collection: "installment", TotalAmount: 60, PaidAmount: 50, Status: "partially_paid"},
+				{Code: "insurance", Collection: "installment", TotalAmount: 40, PaidAmount: 30, Status: "partially_paid"},
+			},
+			feeAmount:  25,
+			wantPaid:   []float64{60, 40},
+			wantStatus: []string{"paid", "paid"},
+		},
+		{
+			name: "deducted and settled charges are left alone",
+			charges: []models.LoanCharge{
+				{Code: "provision", Collection: "deducted", TotalAmount: 50, PaidAmount: 50, Status: "paid"},
+				{Code: "admin", Collection: "installment", TotalAmount: 60, PaidAmount: 60, Status: "paid"},
+				{Code: "insurance", Collection: "installment", TotalAmount: 40, Status: "pending"},
+			},
+			feeAmount:  10,
+			wantPaid:   []float64{4},
+			wantStatus: []string{"partially_paid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocated := allocateCharges(cents, tt.charges, 100, tt.feeAmount, paidAt)
+			if len(allocated) != len(tt.wantPaid) {
+				t.Fatalf("allocated to %d charges, want %d", len(allocated), len(tt.wantPaid))
+			}
+			for i, charge := range allocated {
+				if !sameAmount(charge.PaidAmount, tt.wantPaid[i]) || charge.Status != tt.wantStatus[i] {
+					t.Errorf("%s has %.2f paid and is %s, want %.2f and %s",
+						charge.Code, charge.PaidAmount, charge.Status, tt.wantPaid[i], tt.wantStatus[i])
+				}
+				if (charge.Status == "paid") != (charge.PaidAt != nil) {
+					t.Errorf("%s is %s with paid_at %v", charge.Code, charge.Status, charge.PaidAt)
+				}
+			}
+		})
+	}
+}
+
+func TestPayoffChargesWaiveWhatIsLeft(t *testing.T) {
+	paidAt := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		charges    []models.LoanCharge
+		dueFees    float64
+		wantPaid   map[string]float64
+		wantStatus map[string]string
+	}{
+		{
+			name: "fees of the due installments are paid, the rest waived",
+			charges: []models.LoanCharge{
+				{Code: "admin", Collection: "installment", TotalAmount: 60, Status: "pending"},
+				{Code: "insurance", Collection: "installment", TotalAmount: 40, Status: "pending"},
+			},
+			dueFees:    25,
+			wantPaid:   map[string]float64{"admin": 15, "insurance": 10},
+			wantStatus: map[string]string{"admin": "waived", "insurance": "waived"},
+		},
+		{
+			name: "a charge the due installments complete stays paid",
+			charges: []models.LoanCharge{
+				{Code: "admin", Collection: "installment", TotalAmount: 60, PaidAmount: 45, Status: "partially_paid"},
+				{Code: "insurance", Collection: "installment", TotalAmount: 40, PaidAmount: 10, Status: "partially_paid"},
+			},
+			dueFees:    25,
+			wantPaid:   map[string]float64{"admin": 60, "insurance": 20},
+			wantStatus: map[string]string{"admin": "paid", "insurance": "waived"},
+		},
+		{
+			name: "nothing due waives every open installment charge",
+			charges: []models.LoanCharge{
+				{Code: "provision", Collection: "deducted", TotalAmount: 50, PaidAmount: 50, Status: "paid"},
+				{Code: "admin", Collection: "installment", TotalAmount: 60, PaidAmount: 30, Status: "partially_paid"},
+				{Code: "insurance", Collection: "installment", TotalAmount: 40, Status: "pending"},
+			},
+			dueFees:    0,
+			wantPaid:   map[string]float64{"admin": 30, "insurance": 0},
+			wantStatus: map[string]string{"admin": "waived", "insurance": "waived"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settled := payoffCharges(cents, tt.charges, 100, tt.dueFees, paidAt, paidAt)
+			if len(settled) != len(tt.wantStatus) {
+				t.Fatalf("settled %d charges, want %d", len(settled), len(tt.wantStatus))
+			}
+			for _, charge := range settled {
+				if !sameAmount(charge.PaidAmount, tt.wantPaid[charge.Code]) || charge.Status != tt.wantStatus[charge.Code] {
+					t.Errorf("%s has %.2f paid and is %s, want %.2f and %s", charge.Code, charge.PaidAmount, charge.Status,
+						tt.wantPaid[charge.Code], tt.wantStatus[charge.Code])
+				}
+			}
+		})
+	}
+}
+
+func TestCurrencySplitAddsUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency Currency
+		amount   float64
+		parts    int
+		want     []float64
+	}{
+		{"whole units", Currency{Decimals: 0}, 1000, 3, []float64{333, 333, 334}},
+		{"cents", Currency{Decimals: 2}, 100, 3, []float64{33.33, 33.33, 33.34}},
+		{"even split", Currency{Decimals: 2}, 90, 3, []float64{30, 30, 30}},
+		{"rounding up leaves less for the last part", Currency{Decimals: 0}, 1001, 6, []float64{167, 167, 167, 167, 167, 166}},
+		{"one part", Currency{Decimals: 2}, 12.345, 1, []float64{12.35}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.currency.Split(tt.amount, tt.parts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !sameAmount(got[i], tt.want[i]) {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCoveredInstallments(t *testing.T) {
+	schedule := func(statuses ...string) []models.Payment {
+		payments := make([]models.Payment, len(statuses))
+		for i, status := range statuses {
+			payments[i] = models.Payment{WeekNum: i + 1, Status: status, Amount: 100}
+		}
+		return payments
+	}
+
+	tests := []struct {
+		name      string
+		payments  []models.Payment
+		money     float64
+		wantWeeks []int
+	}{
+		{"exactly one installment", schedule("pending", "pending", "pending"), 100, []int{1}},
+		{"the remainder is left over", schedule("pending", "pending", "pending"), 250, []int{1, 2}},
+		{"less than an installment", schedule("pending", "pending"), 99, nil},
+		{"within a rounding tolerance", schedule("pending", "pending"), 99.996, []int{1}},
+		{"paid installments are skipped", schedule("paid", "pending", "pending"), 100, []int{2}},
+		{"more than is owed", schedule("paid", "pending"), 500, []int{2}},
+		{"nothing pending", schedule("paid", "paid"), 100, nil},
+		{
+			name: "a larger installment is not skipped for a smaller one",
+			payments: []models.Payment{
+				{WeekNum: 1, Status: "pending", Amount: 150},
+				{WeekNum: 2, Status: "pending", Amount: 100},
+			},
+			money: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covered := coveredInstallments(cents, tt.payments, tt.money)
+
+			var weeks []int
+			for _, installment := range covered {
+				weeks = append(weeks, installment.WeekNum)
+				if installment != &tt.payments[installment.WeekNum-1] {
+					t.Errorf("week %d is a copy, not the loan's installment", installment.WeekNum)
+				}
+			}
+			if fmt.Sprint(weeks) != fmt.Sprint(tt.wantWeeks) {
+				t.Errorf("covered weeks %v, want %v", weeks, tt.wantWeeks)
+			}
+		})
+	}
+}
+
+func TestPostingsLockTheLoan(t *testing.T) {
+	due := time.Now().AddDate(0, 0, 3)
+	loan := models.Loan{ID: 4, TenantID: 1, Amount: 1000, TotalAmount: 1100, WeeklyPayment: 22, Status: "active"}
+	schedule := []models.Payment{
+		{ID: 40, LoanID: 4, WeekNum: 1, Amount: 22, DueDate: due, Status: "pending"},
+		{ID: 41, LoanID: 4, WeekNum: 2, Amount: 22, DueDate: due.AddDate(0, 0, 7), Status: "pending"},
+	}
+
+	tests := []struct {
+		name string
+		post func(s *LoanService) (*models.PaymentTransaction, error)
+	}{
+		{"exact payment", func(s *LoanService) (*models.PaymentTransaction, error) {
+			return s.PostPayment(context.Background(), loan.ID, 22, PaymentSource{Channel: "api"})
+		}},
+		{"partial payment", func(s *LoanService) (*models.PaymentTransaction, error) {
+			return s.PostPartialPayment(context.Background(), loan.ID, 30, PaymentSource{Channel: "cash"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, _ := newLoanService(t)
+			db.Returning(loanRows(loan), "FROM `loans`")
+			db.Returning(paymentRows(schedule...), "FROM `payments`")
+
+			if _, err := tt.post(s); err != nil {
+				t.Fatal(err)
+			}
+
+			statements := db.Statements()
+			begin := indexOf(statements, "BEGIN")
+			lock := indexOf(statements, "FROM `loans`", "FOR UPDATE")
+			schedule := indexOf(statements, "FROM `payments`")
+			insert := indexOf(statements, "INSERT INTO `payment_transactions`")
+			commit := indexOf(statements, "COMMIT")
+			if begin != 0 || lock < begin || schedule < lock || insert < schedule || commit < insert {
+				t.Errorf("the loan is not locked before its schedule is read and settled in one transaction:\n%v", statements)
+			}
+			if reads := db.Find("SELECT * FROM `loans`"); len(reads) != 1 {
+				t.Errorf("the loan was read %d times, want once under the lock:\n%v", len(reads), reads)
+			}
+		})
+	}
+}
+
+func TestChargesRoundToTheCurrency(t *testing.T) {
+	whole := Currency{Code: "IDR", Decimals: 0}
+	charges := []models.LoanCharge{
+		{Code: "insurance", Collection: "installment", TotalAmount: 1000, Status: "pending"},
+		{Code: "admin", Collection: "installment", TotalAmount: 2000, Status: "pending"},
+	}
+
+	for _, charge := range allocateCharges(whole, charges, 3000, 100, time.Now()) {
+		if charge.PaidAmount != whole.Round(charge.PaidAmount) {
+			t.Errorf("%s paid %v, not a whole amount", charge.Code, charge.PaidAmount)
+		}
+	}
+	if paid := charges[0].PaidAmount + charges[1].PaidAmount; !sameAmount(paid, 100) {
+		t.Errorf("charges paid %v in total, want 100", paid)
+	}
+}
+
+func TestReversePayment(t *testing.T) {
+	received := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+	first := models.PaymentTransaction{ID: 70, LoanID: 4, Amount: 22, AppliedAmount: 22, Channel: "api", Status: "posted", ReceivedAt: received}
+	second := models.PaymentTransaction{ID: 71, LoanID: 4, Amount: 30, AppliedAmount: 22, UnappliedAmount: 8, Channel: "bank", Status: "posted", ReceivedAt: received.AddDate(0, 0, 7)}
+	reversed := second
+	reversed.Status = "reversed"
+
+	tests := []struct {
+		name         string
+		suspense     float64
+		transactions []models.PaymentTransaction
+		reverse      uint
+		want         ErrorCode
+	}{
+		{"latest payment", 8, []models.PaymentTransaction{first, second}, 71, ""},
+		{"earlier payment", 8, []models.PaymentTransaction{first, second}, 70, CodeConflict},
+		{"earlier payment once the later one is reversed", 0, []models.PaymentTransaction{first, reversed}, 70, ""},
+		{"suspense it added used up", 3, []models.PaymentTransaction{first, second}, 71, CodeConflict},
+		{"already reversed", 0, []models.PaymentTransaction{first, reversed}, 71, CodeConflict},
+		{"another loan's payment", 8, []models.PaymentTransaction{first, second}, 99, CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, _ := newLoanService(t)
+			db.Returning(loanRows(models.Loan{ID: 4, TenantID: 1, Amount: 1000, TotalAmount: 1100, WeeklyPayment: 22, SuspenseAmount: tt.suspense, Status: "active"}), "FROM `loans`")
+			db.Returning(transactionRows(tt.transactions...), "FROM `payment_transactions`")
+
+			_, err := s.ReversePayment(context.Background(), 4, tt.reverse, "bounced", "finance")
+			reversals := db.Find("UPDATE `payment_transactions`")
+			if tt.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(reversals) != 1 || !reversals[0].HasArg(tt.reverse) {
+					t.Errorf("transaction %d was not reversed:\n%v", tt.reverse, reversals)
+				}
+				statements := db.Statements()
+				if lock := indexOf(statements, "FROM `loans`", "FOR UPDATE"); lock < indexOf(statements, "BEGIN") || lock > indexOf(statements, "FROM `payment_transactions`") {
+					t.Errorf("the loan is not locked before its payments are read:\n%v", statements)
+				}
+				return
+			}
+			if CodeOf(err) != tt.want {
+				t.Fatalf("got %v, want %s", err, tt.want)
+			}
+			if len(reversals) != 0 {
+				t.Errorf("a refused reversal changed a transaction:\n%v", reversals)
+			}
+		})
+	}
+}
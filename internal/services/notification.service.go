@@ -0,0 +1,485 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// NotificationConfig controls the reminder job
+type NotificationConfig struct {
+	MaxAttempts int // sends of a message that failed before it is given up
+	StaleDays   int // days after its send date a message is still sent, when the job did not run on the day
+}
+
+// NotificationService sends borrowers reminders before their installments
+// fall due, a notice on the due date and dunning messages after a miss,
+// following each product's templates
+type NotificationService struct {
+	repo        *repositories.NotificationRepository
+	productRepo *repositories.ProductRepository
+	senders     map[string]NotificationSender
+	config      NotificationConfig
+}
+
+// NewNotificationService creates a new notification service instance
+func NewNotificationService(repo *repositories.NotificationRepository, productRepo *repositories.ProductRepository, senders map[string]NotificationSender, config NotificationConfig) *NotificationService {
+	return &NotificationService{repo: repo, productRepo: productRepo, senders: senders, config: config}
+}
+
+// NotificationData is what templates are rendered with, e.g.
+// "Hi {{.FirstName}}, {{.Amount}} is due on {{.DueDate}}"
+type NotificationData struct {
+	FirstName      string
+	LastName       string
+	LoanID         uint
+	WeekNum        int
+	DueDate        string // 02 Jan 2006
+	Amount         string // without decimals, e.g. 110000
+	DaysUntilDue   int    // reminders
+	DaysPastDue    int    // dunning
+	VirtualAccount string
+	Reference      string
+}
+
+// CreateTemplate adds a step to a product's schedule, or to the default
+// schedule when no product is given
+func (s *NotificationService) CreateTemplate(ctx context.Context, req dto.NotificationTemplateRequest) (*models.NotificationTemplate, error) {
+	template := &models.NotificationTemplate{
+		Active:    true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.applyTemplateRequest(ctx, template, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// UpdateTemplate replaces a template
+func (s *NotificationService) UpdateTemplate(ctx context.Context, id uint, req dto.NotificationTemplateRequest) (*models.NotificationTemplate, error) {
+	template, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyTemplateRequest(ctx, template, req); err != nil {
+		return nil, err
+	}
+	template.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *NotificationService) applyTemplateRequest(ctx context.Context, t *models.NotificationTemplate, req dto.NotificationTemplateRequest) error {
+	switch req.Stage {
+	case "reminder", "dunning":
+		if req.OffsetDays < 1 {
+			return Validation(fmt.Sprintf("offset_days must be at least 1 for %s templates", req.Stage))
+		}
+	case "due":
+		if req.OffsetDays != 0 {
+			return Validation("offset_days must be 0 for due templates")
+		}
+	default:
+		return Validation("stage must be reminder, due or dunning")
+	}
+	if _, ok := s.senders[req.Channel]; !ok {
+		return Validation("channel must be sms, whatsapp or email")
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		return Validation("body is required")
+	}
+	if err := checkTemplate(req.Subject, req.Body); err != nil {
+		return err
+	}
+	if req.ProductID != nil {
+		if _, err := s.productRepo.GetByID(ctx, *req.ProductID); err != nil {
+			return err
+		}
+	}
+
+	t.ProductID = req.ProductID
+	t.Stage = req.Stage
+	t.OffsetDays = req.OffsetDays
+	t.Channel = req.Channel
+	t.Subject = req.Subject
+	t.Body = req.Body
+	if req.Active != nil {
+		t.Active = *req.Active
+	}
+
+	exists, err := s.repo.TemplateExists(ctx, t)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return Conflict(fmt.Sprintf("a %s template %d day(s) out on %s already exists for this product", t.Stage, t.OffsetDays, t.Channel))
+	}
+	return nil
+}
+
+// checkTemplate parses a template's subject and body and renders them with
+// sample data, so that mistakes surface when it is saved rather than when
+// it is sent
+func checkTemplate(subject, body string) error {
+	sample := NotificationData{FirstName: "Siti", LastName: "Aminah", LoanID: 1, WeekNum: 1, DueDate: "01 Jan 2024", Amount: "110000"}
+	if _, err := render("subject", subject, sample); err != nil {
+		return Validation(fmt.Sprintf("invalid subject: %v", err))
+	}
+	if _, err := render("body", body, sample); err != nil {
+		return Validation(fmt.Sprintf("invalid body: %v", err))
+	}
+	return nil
+}
+
+func render(name, text string, data NotificationData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// GetTemplate returns a template
+func (s *NotificationService) GetTemplate(ctx context.Context, id uint) (*models.NotificationTemplate, error) {
+	return s.repo.GetTemplate(ctx, id)
+}
+
+// ListTemplates returns a product's schedule, or the default schedule when no
+// product is given
+func (s *NotificationService) ListTemplates(ctx context.Context, productID *uint) ([]models.NotificationTemplate, error) {
+	return s.repo.ListTemplates(ctx, productID)
+}
+
+// DeleteTemplate removes a template. Its send history is kept
+func (s *NotificationService) DeleteTemplate(ctx context.Context, id uint) error {
+	template, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTemplate(ctx, template)
+}
+
+// OptOut stops reminders to a borrower on a channel, or on every channel
+func (s *NotificationService) OptOut(ctx context.Context, borrowerID uint, req dto.NotificationOptOutRequest, createdBy string) (*models.NotificationOptOut, error) {
+	if _, ok := s.senders[req.Channel]; !ok && req.Channel != "*" {
+		return nil, Validation("channel must be sms, whatsapp, email or *")
+	}
+
+	optOuts, err := s.repo.GetOptOuts(ctx, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range optOuts {
+		if optOuts[i].Channel == req.Channel {
+			return &optOuts[i], nil
+		}
+	}
+
+	optOut := &models.NotificationOptOut{
+		BorrowerID: borrowerID,
+		Channel:    req.Channel,
+		Reason:     strings.TrimSpace(req.Reason),
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.CreateOptOut(ctx, optOut); err != nil {
+		return nil, err
+	}
+	return optOut, nil
+}
+
+// GetOptOuts returns a borrower's opt-outs
+func (s *NotificationService) GetOptOuts(ctx context.Context, borrowerID uint) ([]models.NotificationOptOut, error) {
+	return s.repo.GetOptOuts(ctx, borrowerID)
+}
+
+// OptIn resumes reminders to a borrower on a channel they opted out of
+func (s *NotificationService) OptIn(ctx context.Context, borrowerID uint, channel string) error {
+	return s.repo.DeleteOptOut(ctx, borrowerID, channel)
+}
+
+// ListNotifications returns the send history matching the filter, newest first
+func (s *NotificationService) ListNotifications(ctx context.Context, filter repositories.NotificationFilter) ([]models.Notification, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.repo.ListNotifications(ctx, filter)
+}
+
+// scheduledMessage is a template due to be sent for an installment on a date
+type scheduledMessage struct {
+	template *models.NotificationTemplate
+	sendOn   time.Time
+}
+
+// SendReminders sends the messages that are due today. For each unpaid
+// installment and channel, the template whose send date is the latest one
+// reached is sent, unless it was sent before or its date is more than
+// StaleDays ago. Dunning templates are only sent for installments the arrears
+// run has marked missed
+func (s *NotificationService) SendReminders(ctx context.Context, now time.Time) (*dto.NotificationRunResponse, error) {
+	result := &dto.NotificationRunResponse{}
+
+	templates, err := s.repo.GetActiveTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return result, nil
+	}
+
+	// Products with templates of their own only follow those
+	schedules := map[uint][]*models.NotificationTemplate{}
+	maxBefore, maxAfter := 0, 0
+	for i := range templates {
+		t := &templates[i]
+		var productID uint
+		if t.ProductID != nil {
+			productID = *t.ProductID
+		}
+		schedules[productID] = append(schedules[productID], t)
+		switch t.Stage {
+		case "reminder":
+			maxBefore = max(maxBefore, t.OffsetDays)
+		case "dunning":
+			maxAfter = max(maxAfter, t.OffsetDays)
+		}
+	}
+
+	today := truncateToDay(now)
+	from := today.AddDate(0, 0, -maxAfter-s.config.StaleDays)
+	to := today.AddDate(0, 0, maxBefore+1)
+	targets, err := s.repo.GetReminderTargets(ctx, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentIDs := make([]uint, len(targets))
+	borrowerIDs := make([]uint, 0, len(targets))
+	for i, target := range targets {
+		paymentIDs[i] = target.PaymentID
+		borrowerIDs = append(borrowerIDs, target.BorrowerID)
+	}
+	history, err := s.repo.GetNotifications(ctx, paymentIDs)
+	if err != nil {
+		return nil, err
+	}
+	sent := map[[2]uint]*models.Notification{}
+	for i := range history {
+		sent[[2]uint{history[i].PaymentID, history[i].TemplateID}] = &history[i]
+	}
+	optOuts, err := s.repo.GetOptOuts(ctx, borrowerIDs...)
+	if err != nil {
+		return nil, err
+	}
+	optedOut := map[uint]map[string]bool{}
+	for _, optOut := range optOuts {
+		if optedOut[optOut.BorrowerID] == nil {
+			optedOut[optOut.BorrowerID] = map[string]bool{}
+		}
+		optedOut[optOut.BorrowerID][optOut.Channel] = true
+	}
+
+	for i := range targets {
+		target := &targets[i]
+		schedule, ok := []*models.NotificationTemplate(nil), false
+		if target.ProductID != nil {
+			schedule, ok = schedules[*target.ProductID]
+		}
+		if !ok {
+			schedule = schedules[0]
+		}
+
+		for _, message := range s.dueMessages(target, schedule, today) {
+			notification := sent[[2]uint{target.PaymentID, message.template.ID}]
+			if notification != nil && (notification.Status != "failed" || notification.Attempts >= s.config.MaxAttempts) {
+				continue
+			}
+			if notification == nil {
+				notification = &models.Notification{
+					TenantID:   target.TenantID,
+					BranchID:   target.BranchID,
+					BorrowerID: target.BorrowerID,
+					LoanID:     target.LoanID,
+					PaymentID:  target.PaymentID,
+					TemplateID: message.template.ID,
+					Stage:      message.template.Stage,
+					OffsetDays: message.template.OffsetDays,
+					Channel:    message.template.Channel,
+					CreatedAt:  now,
+				}
+			}
+
+			blocked := optedOut[target.BorrowerID]
+			if blocked[message.template.Channel] || blocked["*"] {
+				notification.Status = "opted_out"
+				notification.UpdatedAt = now
+				if err := s.repo.SaveNotification(ctx, notification); err != nil {
+					return result, err
+				}
+				result.OptedOut++
+				continue
+			}
+
+			s.send(ctx, notification, message.template, target, today)
+			if err := s.repo.SaveNotification(ctx, notification); err != nil {
+				return result, err
+			}
+			if notification.Status == "sent" {
+				result.Sent++
+			} else {
+				result.Failed++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// dueMessages picks, for each channel of the schedule, the template to send
+// for the installment today, if any
+func (s *NotificationService) dueMessages(target *repositories.ReminderTarget, schedule []*models.NotificationTemplate, today time.Time) []scheduledMessage {
+	dueDate := truncateToDay(target.DueDate.In(today.Location()))
+
+	latest := map[string]scheduledMessage{}
+	for _, t := range schedule {
+		var sendOn time.Time
+		switch t.Stage {
+		case "reminder":
+			sendOn = dueDate.AddDate(0, 0, -t.OffsetDays)
+		case "due":
+			sendOn = dueDate
+		case "dunning":
+			if target.MissedAt == nil {
+				continue
+			}
+			sendOn = dueDate.AddDate(0, 0, t.OffsetDays)
+		}
+		if sendOn.After(today) {
+			continue
+		}
+		if current, ok := latest[t.Channel]; !ok || sendOn.After(current.sendOn) {
+			latest[t.Channel] = scheduledMessage{template: t, sendOn: sendOn}
+		}
+	}
+
+	messages := []scheduledMessage{}
+	for _, message := range latest {
+		if !message.sendOn.Before(today.AddDate(0, 0, -s.config.StaleDays)) {
+			messages = append(messages, message)
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].template.ID < messages[j].template.ID })
+	return messages
+}
+
+// send renders and sends one message, recording the outcome on the notification
+func (s *NotificationService) send(ctx context.Context, notification *models.Notification, t *models.NotificationTemplate, target *repositories.ReminderTarget, today time.Time) {
+	now := time.Now()
+	notification.Attempts++
+	notification.UpdatedAt = now
+
+	fail := func(err error) {
+		notification.Status = "failed"
+		notification.Error = err.Error()
+	}
+
+	dueDate := truncateToDay(target.DueDate.In(today.Location()))
+	data := NotificationData{
+		FirstName: target.FirstName,
+		LastName:  target.LastName,
+		LoanID:    target.LoanID,
+		WeekNum:   target.WeekNum,
+		DueDate:   target.DueDate.Format("02 Jan 2006"),
+		Amount:    fmt.Sprintf("%.0f", target.Amount),
+		Reference: target.Reference,
+	}
+	if target.VirtualAccount != nil {
+		data.VirtualAccount = *target.VirtualAccount
+	}
+	if days := int(dueDate.Sub(today).Hours() / 24); days > 0 {
+		data.DaysUntilDue = days
+	} else {
+		data.DaysPastDue = -days
+	}
+
+	switch t.Channel {
+	case ChannelEmail:
+		notification.Recipient = target.Email
+	default:
+		notification.Recipient = target.Phone
+	}
+	if notification.Recipient == "" {
+		fail(fmt.Errorf("borrower has no %s recipient", t.Channel))
+		return
+	}
+
+	var err error
+	if notification.Subject, err = render("subject", t.Subject, data); err != nil {
+		fail(err)
+		return
+	}
+	if notification.Body, err = render("body", t.Body, data); err != nil {
+		fail(err)
+		return
+	}
+
+	sender, ok := s.senders[t.Channel]
+	if !ok {
+		fail(fmt.Errorf("no sender for channel %s", t.Channel))
+		return
+	}
+	ref, err := sender.Send(ctx, NotificationMessage{
+		Channel:   t.Channel,
+		Recipient: notification.Recipient,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+	})
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	notification.Status = "sent"
+	notification.Error = ""
+	notification.ProviderRef = ref
+	notification.SentAt = &now
+}
+
+// StartReminderJob runs SendReminders on the given interval until the process exits
+func (s *NotificationService) StartReminderJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result, err := s.SendReminders(ctx, time.Now())
+		if err != nil {
+			log.Printf("Error sending reminders: %v", err)
+			continue
+		}
+		if result.Sent > 0 || result.Failed > 0 {
+			log.Printf("Reminders: %d sent, %d failed, %d withheld for opt-outs", result.Sent, result.Failed, result.OptedOut)
+		}
+	}
+}
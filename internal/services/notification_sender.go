@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notification channels a template can be sent on
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+	ChannelEmail    = "email"
+)
+
+// NotificationMessage is a rendered message ready to be sent
+type NotificationMessage struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"` // phone number, or email address for email
+	Subject   string `json:"subject,omitempty"`
+	Body      string `json:"body"`
+}
+
+// NotificationSender delivers messages on a channel, such as an SMS gateway
+// or an email provider. Send returns the provider's message reference
+type NotificationSender interface {
+	Send(ctx context.Context, message NotificationMessage) (string, error)
+}
+
+// NewNotificationSenders returns the sender of each channel. The built-in
+// kinds send nothing: log (the default) writes messages to the process log,
+// and file appends them as JSON lines to path. Real providers are added by
+// putting their sender in the returned map
+func NewNotificationSenders(kind, path string) (map[string]NotificationSender, error) {
+	var sender NotificationSender
+	switch kind {
+	case "", "log":
+		sender = LogSender{}
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("the file notification sender needs a path")
+		}
+		sender = &FileSender{Path: path}
+	default:
+		return nil, fmt.Errorf("unknown notification sender %q", kind)
+	}
+
+	return map[string]NotificationSender{
+		ChannelSMS:      sender,
+		ChannelWhatsApp: sender,
+		ChannelEmail:    sender,
+	}, nil
+}
+
+// LogSender writes every message to the process log
+type LogSender struct{}
+
+// Send logs the message
+func (LogSender) Send(ctx context.Context, message NotificationMessage) (string, error) {
+	log.Printf("Notification %s to %s: %s", message.Channel, message.Recipient, message.Body)
+	return "", nil
+}
+
+// FileSender appends every message to a file as a JSON line, for local testing
+type FileSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send appends the message to the file
+func (s *FileSender) Send(ctx context.Context, message NotificationMessage) (string, error) {
+	line, err := json.Marshal(struct {
+		NotificationMessage
+		SentAt time.Time `json:"sent_at"`
+	}{message, time.Now()})
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return "", err
+	}
+	return "", nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// OutboxConfig controls the outbox relay
+type OutboxConfig struct {
+	BatchSize  int           // events published per relay run
+	RetryDelay time.Duration // delay after the first failed publish, doubled after each further one
+	MaxDelay   time.Duration
+}
+
+// OutboxService relays the domain events written to the outbox to the
+// event publisher
+type OutboxService struct {
+	repo      *repositories.OutboxRepository
+	publisher EventPublisher
+	config    OutboxConfig
+}
+
+// NewOutboxService creates a new outbox service instance
+func NewOutboxService(repo *repositories.OutboxRepository, publisher EventPublisher, config OutboxConfig) *OutboxService {
+	return &OutboxService{repo: repo, publisher: publisher, config: config}
+}
+
+// Relay publishes pending events in the order they were written and returns
+// how many were published. It stops at the first event that fails, or that is
+// waiting to be retried, so that no event overtakes an earlier one
+func (s *OutboxService) Relay(ctx context.Context) (int, error) {
+	events, err := s.repo.GetPending(ctx, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for i := range events {
+		event := &events[i]
+		now := time.Now()
+		if event.NextAttemptAt != nil && now.Before(*event.NextAttemptAt) {
+			break
+		}
+
+		if err := s.publisher.Publish(ctx, Event{
+			ID:      event.EventID,
+			Type:    event.Type,
+			Key:     event.AggregateType + ":" + event.AggregateID,
+			Payload: []byte(event.Payload),
+		}); err != nil {
+			retryAt := now.Add(s.retryDelay(event.Attempts + 1))
+			if markErr := s.repo.MarkFailed(ctx, event, err, retryAt); markErr != nil {
+				return published, markErr
+			}
+			return published, err
+		}
+
+		if err := s.repo.MarkPublished(ctx, event, now); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// retryDelay is the delay before retrying an event that failed attempts times
+func (s *OutboxService) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryDelay
+	for i := 1; i < attempts && delay < s.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.MaxDelay {
+		delay = s.config.MaxDelay
+	}
+	return delay
+}
+
+// StartRelay runs Relay on the given interval until the process exits
+func (s *OutboxService) StartRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.Relay(ctx); err != nil {
+			log.Printf("Error relaying outbox events: %v", err)
+		}
+	}
+}
+
+// ListEvents returns the outbox events matching the filter, newest first
+func (s *OutboxService) ListEvents(ctx context.Context, filter repositories.OutboxFilter) ([]dto.OutboxEventResponse, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	events, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.OutboxEventResponse, len(events))
+	for i := range events {
+		result[i] = toOutboxEventResponse(&events[i])
+	}
+	return result, nil
+}
+
+// GetEvent returns an outbox event
+func (s *OutboxService) GetEvent(ctx context.Context, id uint) (*dto.OutboxEventResponse, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toOutboxEventResponse(event)
+	return &response, nil
+}
+
+func toOutboxEventResponse(event *models.OutboxEvent) dto.OutboxEventResponse {
+	return dto.OutboxEventResponse{
+		ID:            event.ID,
+		EventID:       event.EventID,
+		Type:          event.Type,
+		Version:       event.Version,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OccurredAt:    event.OccurredAt,
+		PublishedAt:   event.PublishedAt,
+		Attempts:      event.Attempts,
+		LastError:     event.LastError,
+		NextAttemptAt: event.NextAttemptAt,
+		Event:         json.RawMessage(event.Payload),
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/repositories"
+)
+
+// failingPublisher records the events it accepts and refuses the one with
+// the given ID
+type failingPublisher struct {
+	failing   string
+	published []string
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event Event) error {
+	if event.ID == p.failing {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func TestRelayKeepsTheOrderOfEvents(t *testing.T) {
+	columns := []string{"id", "event_id", "type", "aggregate_type", "aggregate_id", "payload", "attempts", "next_attempt_at"}
+	later := time.Now().Add(time.Hour)
+	tests := []struct {
+		name          string
+		failing       string
+		nextAttemptAt map[string]*time.Time
+		want          []string
+		wantErr       bool
+		wantFailed    bool
+	}{
+		{"all published", "", nil, []string{"e1", "e2", "e3"}, false, false},
+		{"stops at a failure", "e2", nil, []string{"e1"}, true, true},
+		{"stops at an event waiting for its retry", "", map[string]*time.Time{"e2": &later}, []string{"e1"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, db := dbtest.Open(t)
+			rows := dbtest.Rows{Columns: columns}
+			for i, id := range []string{"e1", "e2", "e3"} {
+				rows.Values = append(rows.Values, []interface{}{int64(i + 1), id, "payment.received", "loan", "4", "{}", int64(0), tt.nextAttemptAt[id]})
+			}
+			db.Returning(rows, "FROM `outbox_events`")
+			publisher := &failingPublisher{failing: tt.failing}
+			s := NewOutboxService(repositories.NewOutboxRepository(conn), publisher, OutboxConfig{BatchSize: 10, RetryDelay: time.Second, MaxDelay: time.Minute})
+
+			published, err := s.Relay(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if published != len(tt.want) || fmt.Sprint(publisher.published) != fmt.Sprint(tt.want) {
+				t.Errorf("published %d events %v, want %v", published, publisher.published, tt.want)
+			}
+			var marked, failed []dbtest.Statement
+			for _, update := range db.Find("UPDATE `outbox_events`") {
+				if update.Has("published_at") {
+					marked = append(marked, update)
+				} else if update.HasArg("broker unavailable") {
+					failed = append(failed, update)
+				}
+			}
+			if len(marked) != len(tt.want) {
+				t.Errorf("%d events marked published, want %d", len(marked), len(tt.want))
+			}
+			if (len(failed) == 1) != tt.wantFailed || len(failed) > 1 {
+				t.Errorf("%d events marked failed:\n%v", len(failed), failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// HolidayPolicy configures how payment holidays treat interest
+type HolidayPolicy struct {
+	// AccrueInterest charges interest for the paused weeks unless a request overrides it
+	AccrueInterest bool
+}
+
+// PaymentHolidayService handles pausing loan repayments over a date range
+type PaymentHolidayService struct {
+	loanRepo    *repositories.LoanRepository
+	holidayRepo *repositories.PaymentHolidayRepository
+	calendar    *CalendarService
+	policy      HolidayPolicy
+	currency    Currency
+}
+
+// NewPaymentHolidayService creates a new payment holiday service instance
+func NewPaymentHolidayService(loanRepo *repositories.LoanRepository, holidayRepo *repositories.PaymentHolidayRepository, calendar *CalendarService, policy HolidayPolicy, currency Currency) *PaymentHolidayService {
+	return &PaymentHolidayService{loanRepo: loanRepo, holidayRepo: holidayRepo, calendar: calendar, policy: policy, currency: currency}
+}
+
+// ApplyToLoan pauses a single loan. Every unpaid installment due on or after the
+// start date moves forward by the length of the holiday rounded up to whole
+// weeks, and the ones originally due inside the range are held until it ends
+func (s *PaymentHolidayService) ApplyToLoan(ctx context.Context, loanID uint, req dto.PaymentHolidayRequest) (*models.PaymentHoliday, error) {
+	if err := validateHolidayRange(req); err != nil {
+		return nil, err
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apply(ctx, loan, req, "")
+}
+
+// ApplyToRegion pauses every active or defaulted loan whose borrower is in the
+// given region and reports the outcome for each loan
+func (s *PaymentHolidayService) ApplyToRegion(ctx context.Context, req dto.PaymentHolidayRequest) ([]dto.BulkPaymentHolidayResult, error) {
+	if req.Region == "" {
+		return nil, Validation("region is required")
+	}
+	if err := validateHolidayRange(req); err != nil {
+		return nil, err
+	}
+
+	loans, err := s.loanRepo.GetByBorrowerRegion(ctx, req.Region, "active", "defaulted")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]dto.BulkPaymentHolidayResult, len(loans))
+	for i := range loans {
+		results[i].LoanID = loans[i].ID
+
+		holiday, err := s.apply(ctx, &loans[i], req, req.Region)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		results[i].Applied = true
+		results[i].HolidayID = holiday.ID
+		results[i].ShiftWeeks = holiday.ShiftWeeks
+		results[i].AccruedInterest = holiday.AccruedInterest
+	}
+
+	return results, nil
+}
+
+// GetHolidays returns the payment holidays applied to a loan
+func (s *PaymentHolidayService) GetHolidays(ctx context.Context, loanID uint) ([]models.PaymentHoliday, error) {
+	return s.holidayRepo.GetByLoanID(ctx, loanID)
+}
+
+func (s *PaymentHolidayService) apply(ctx context.Context, loan *models.Loan, req dto.PaymentHolidayRequest, region string) (*models.PaymentHoliday, error) {
+	if loan.Status != "active" && loan.Status != "defaulted" {
+		return nil, Conflict(fmt.Sprintf("loan is %s and cannot be paused", loan.Status))
+	}
+
+	payments, err := s.loanRepo.GetPaymentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := truncateToDay(req.StartDate)
+	endDate := truncateToDay(req.EndDate).AddDate(0, 0, 1) // the range is inclusive of its last day
+	shiftWeeks := int(math.Ceil(endDate.Sub(startDate).Hours() / 24 / 7))
+
+	var affected []models.Payment
+	for _, payment := range payments {
+		if payment.Status == "pending" && !payment.DueDate.Before(startDate) {
+			affected = append(affected, payment)
+		}
+	}
+
+	if len(affected) == 0 {
+		return nil, Conflict("no unpaid installments fall due during or after the holiday")
+	}
+
+	accrueInterest := s.policy.AccrueInterest
+	if req.AccrueInterest != nil {
+		accrueInterest = *req.AccrueInterest
+	}
+
+	accruedInterest := 0.0
+	if accrueInterest && loan.TotalWeeks > 0 {
+		interest, err := s.originalInterest(ctx, loan)
+		if err != nil {
+			return nil, err
+		}
+		accruedInterest = s.accrue(affected, interest, loan.TotalWeeks, shiftWeeks)
+	}
+
+	dueDates := make([]time.Time, len(affected))
+	for i := range affected {
+		dueDates[i] = affected[i].DueDate.AddDate(0, 0, 7*shiftWeeks)
+	}
+	dueDates, err = s.calendar.AdjustDueDates(ctx, loan.BorrowerID, loan.ProductID, dueDates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range affected {
+		if affected[i].DueDate.Before(endDate) {
+			affected[i].HeldUntil = &endDate
+		}
+		affected[i].DueDate = dueDates[i]
+		affected[i].UpdatedAt = now
+	}
+
+	loan.TotalAmount += accruedInterest
+	loan.EndDate = affected[len(affected)-1].DueDate
+	loan.UpdatedAt = now
+
+	holiday := &models.PaymentHoliday{
+		LoanID:          loan.ID,
+		StartDate:       startDate,
+		EndDate:         truncateToDay(req.EndDate),
+		ShiftWeeks:      shiftWeeks,
+		AccrueInterest:  accrueInterest,
+		AccruedInterest: accruedInterest,
+		Region:          region,
+		Reason:          req.Reason,
+		RequestedBy:     req.RequestedBy,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := s.holidayRepo.Create(ctx, holiday, loan, affected); err != nil {
+		return nil, err
+	}
+
+	return holiday, nil
+}
+
+// accrue charges interest for the paused weeks at the loan's average weekly
+// interest and spreads it over the installments that moved, rounded to the
+// currency with the remainder on the last one. It returns the interest charged
+func (s *PaymentHolidayService) accrue(affected []models.Payment, interest float64, totalWeeks, shiftWeeks int) float64 {
+	accrued := s.currency.Round(interest / float64(totalWeeks) * float64(shiftWeeks))
+	shares := s.currency.Split(accrued, len(affected))
+	for i := range affected {
+		affected[i].Amount = s.currency.Round(affected[i].Amount + shares[i])
+	}
+	return accrued
+}
+
+// originalInterest is the interest a loan was booked with: what it repays
+// beyond the principal, less installment fees and the interest accrued by
+// earlier payment holidays
+func (s *PaymentHolidayService) originalInterest(ctx context.Context, loan *models.Loan) (float64, error) {
+	holidays, err := s.holidayRepo.GetByLoanID(ctx, loan.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	interest := loan.TotalAmount - loan.Amount - loan.FeeAmount
+	for _, holiday := range holidays {
+		interest -= holiday.AccruedInterest
+	}
+	return math.Max(interest, 0), nil
+}
+
+func validateHolidayRange(req dto.PaymentHolidayRequest) error {
+	if req.StartDate.IsZero() || req.EndDate.IsZero() {
+		return Validation("start_date and end_date are required")
+	}
+	if req.EndDate.Before(req.StartDate) {
+		return Validation("end_date must not be before start_date")
+	}
+	if truncateToDay(req.EndDate).Before(truncateToDay(time.Now())) {
+		return Validation("payment holiday cannot end in the past")
+	}
+	if req.RequestedBy == "" {
+		return Validation("requested_by is required")
+	}
+	return nil
+}
+
+// truncateToDay returns midnight at the start of the given date in its location
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
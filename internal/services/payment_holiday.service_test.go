@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+
+	"AmarthaExample1/internal/models"
+)
+
+func TestAccrueSpreadsRoundedInterest(t *testing.T) {
+	tests := []struct {
+		name        string
+		currency    Currency
+		amounts     []float64
+		interest    float64
+		totalWeeks  int
+		shiftWeeks  int
+		wantAccrued float64
+		wantAmounts []float64
+	}{
+		{
+			name:        "whole units with the remainder on the last installment",
+			currency:    Currency{Code: "IDR", Decimals: 0},
+			amounts:     []float64{1100, 1100, 1100},
+			interest:    1000,
+			totalWeeks:  50,
+			shiftWeeks:  2,
+			wantAccrued: 40,
+			wantAmounts: []float64{1113, 1113, 1114},
+		},
+		{
+			name:        "accrued interest is rounded before it is spread",
+			currency:    Currency{Code: "IDR", Decimals: 0},
+			amounts:     []float64{500, 500},
+			interest:    1000,
+			totalWeeks:  3,
+			shiftWeeks:  1,
+			wantAccrued: 333,
+			wantAmounts: []float64{667, 666},
+		},
+		{
+			name:        "cents",
+			currency:    Currency{Code: "USD", Decimals: 2},
+			amounts:     []float64{25.5, 25.5, 25.5},
+			interest:    10,
+			totalWeeks:  12,
+			shiftWeeks:  1,
+			wantAccrued: 0.83,
+			wantAmounts: []float64{25.78, 25.78, 25.77},
+		},
+		{
+			name:        "no interest leaves the installments as they are",
+			currency:    Currency{Code: "IDR", Decimals: 0},
+			amounts:     []float64{1000, 1000},
+			interest:    0,
+			totalWeeks:  10,
+			shiftWeeks:  4,
+			wantAccrued: 0,
+			wantAmounts: []float64{1000, 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PaymentHolidayService{currency: tt.currency}
+			affected := make([]models.Payment, len(tt.amounts))
+			for i, amount := range tt.amounts {
+				affected[i] = models.Payment{WeekNum: i + 1, Amount: amount}
+			}
+
+			accrued := s.accrue(affected, tt.interest, tt.totalWeeks, tt.shiftWeeks)
+			if !sameAmount(accrued, tt.wantAccrued) {
+				t.Errorf("accrued %.2f, want %.2f", accrued, tt.wantAccrued)
+			}
+
+			added := 0.0
+			for i, installment := range affected {
+				if !sameAmount(installment.Amount, tt.wantAmounts[i]) {
+					t.Errorf("installment %d is %.2f, want %.2f", i+1, installment.Amount, tt.wantAmounts[i])
+				}
+				if installment.Amount != tt.currency.Round(installment.Amount) {
+					t.Errorf("installment %d of %v is not rounded to the currency", i+1, installment.Amount)
+				}
+				added += installment.Amount - tt.amounts[i]
+			}
+			if !sameAmount(added, accrued) {
+				t.Errorf("installments gained %.2f, want the accrued %.2f", added, accrued)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+	"AmarthaExample1/internal/validation"
+)
+
+// ProductService handles business logic for loan products
+type ProductService struct {
+	repo *repositories.ProductRepository
+}
+
+// NewProductService creates a new product service instance
+func NewProductService(repo *repositories.ProductRepository) *ProductService {
+	return &ProductService{repo: repo}
+}
+
+// CreateProduct creates a new loan product
+func (s *ProductService) CreateProduct(ctx context.Context, req dto.ProductRequest) (*models.Product, error) {
+	if req.Code == "" || req.Name == "" {
+		return nil, Validation("code and name are required")
+	}
+
+	product := &models.Product{
+		Code:      req.Code,
+		Name:      req.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := applyProductRequest(product, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetProduct retrieves a product by its ID
+func (s *ProductService) GetProduct(ctx context.Context, id uint) (*models.Product, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListProducts returns all loan products
+func (s *ProductService) ListProducts(ctx context.Context) ([]models.Product, error) {
+	return s.repo.List(ctx)
+}
+
+// UpdateProduct changes the terms of a loan product. Loans already booked keep their schedules
+func (s *ProductService) UpdateProduct(ctx context.Context, id uint, req dto.ProductRequest) (*models.Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code != "" {
+		product.Code = req.Code
+	}
+	if req.Name != "" {
+		product.Name = req.Name
+	}
+	if err := applyProductRequest(product, req); err != nil {
+		return nil, err
+	}
+	product.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// applyProductRequest copies the optional product rules from a request
+func applyProductRequest(product *models.Product, req dto.ProductRequest) error {
+	switch req.DueDateRule {
+	case "":
+		if product.DueDateRule == "" {
+			product.DueDateRule = "keep"
+		}
+	case "roll_forward", "roll_backward", "keep":
+		product.DueDateRule = req.DueDateRule
+	default:
+		return Validation("due_date_rule must be roll_forward, roll_backward or keep")
+	}
+
+	if req.MinPrincipal != nil {
+		product.MinPrincipal = *req.MinPrincipal
+	}
+	if req.MaxPrincipal != nil {
+		product.MaxPrincipal = *req.MaxPrincipal
+	}
+	if product.MinPrincipal < 0 || product.MaxPrincipal < 0 {
+		return Validation("min_principal and max_principal cannot be negative")
+	}
+	if product.MaxPrincipal > 0 && product.MinPrincipal > product.MaxPrincipal {
+		return Validation("min_principal cannot exceed max_principal")
+	}
+	return nil
+}
+
+// checkPrincipal checks a loan amount lies within the bounds of its product
+func checkPrincipal(product *models.Product, amount float64) error {
+	var fields []validation.FieldError
+	if product.MinPrincipal > 0 && amount < product.MinPrincipal {
+		param := strconv.FormatFloat(product.MinPrincipal, 'f', -1, 64)
+		fields = append(fields, validation.FieldError{
+			Field:   "amount",
+			Rule:    "min_principal",
+			Param:   param,
+			Message: "amount must be at least " + param + " for product " + product.Code,
+		})
+	}
+	if product.MaxPrincipal > 0 && amount > product.MaxPrincipal {
+		param := strconv.FormatFloat(product.MaxPrincipal, 'f', -1, 64)
+		fields = append(fields, validation.FieldError{
+			Field:   "amount",
+			Rule:    "max_principal",
+			Param:   param,
+			Message: "amount must be at most " + param + " for product " + product.Code,
+		})
+	}
+	return Invalid(fields)
+}
+
+// AddFee adds a fee definition to a product. It applies to loans booked afterwards
+func (s *ProductService) AddFee(ctx context.Context, productID uint, req dto.ProductFeeRequest) (*models.ProductFee, error) {
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	if req.Code == "" || req.Name == "" {
+		return nil, Validation("code and name are required")
+	}
+	switch req.Type {
+	case "fixed":
+	case "percentage":
+		if req.Value > 1 {
+			return nil, Validation("percentage fees are a fraction of the principal and cannot exceed 1")
+		}
+	default:
+		return nil, Validation("type must be fixed or percentage")
+	}
+	if req.Value <= 0 {
+		return nil, Validation("value must be greater than zero")
+	}
+	if req.Collection != "deducted" && req.Collection != "installment" {
+		return nil, Validation("collection must be deducted or installment")
+	}
+	if req.VATRate < 0 || req.VATRate > 1 {
+		return nil, Validation("vat_rate must be between 0 and 1")
+	}
+
+	fee := &models.ProductFee{
+		ProductID:  productID,
+		Code:       req.Code,
+		Name:       req.Name,
+		Type:       req.Type,
+		Value:      req.Value,
+		Collection: req.Collection,
+		VATRate:    req.VATRate,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := s.repo.CreateFee(ctx, fee); err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+// GetFees returns the fee definitions of a product
+func (s *ProductService) GetFees(ctx context.Context, productID uint) ([]models.ProductFee, error) {
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetFees(ctx, productID)
+}
+
+// DeleteFee removes a fee definition from a product. Loans already booked keep their charges
+func (s *ProductService) DeleteFee(ctx context.Context, productID, feeID uint) error {
+	return s.repo.DeleteFee(ctx, productID, feeID)
+}
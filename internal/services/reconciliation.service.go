@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// amountTolerance absorbs float rounding when comparing money amounts
+const amountTolerance = 0.005
+
+// ReconciliationService handles the end-of-day reconciliation between bank
+// statements, payment transactions and the installment ledger
+type ReconciliationService struct {
+	reconciliationRepo *repositories.ReconciliationRepository
+	branchRepo         *repositories.BranchRepository
+	currency           Currency
+}
+
+// NewReconciliationService creates a new reconciliation service instance
+func NewReconciliationService(reconciliationRepo *repositories.ReconciliationRepository, branchRepo *repositories.BranchRepository, currency Currency) *ReconciliationService {
+	return &ReconciliationService{reconciliationRepo: reconciliationRepo, branchRepo: branchRepo, currency: currency}
+}
+
+// Run reconciles one business date of ctx's tenant and stores the report,
+// replacing the tenant's earlier run for that date. It checks that:
+//   - every statement credit and gateway notification was posted (missing_posting)
+//   - every bank transaction has a statement credit (missing_statement)
+//   - statement credits match the transactions they were posted as, and each
+//     transaction's applied amount matches the installments it settled (amount_difference)
+//   - the same money was not posted twice through different channels or with
+//     the same reference (duplicate)
+//   - every installment paid that day was settled by a transaction (unlinked_installment)
+func (s *ReconciliationService) Run(ctx context.Context, date time.Time, runBy string) (*models.ReconciliationRun, error) {
+	from := truncateToDay(date)
+	to := from.AddDate(0, 0, 1)
+
+	lines, err := s.reconciliationRepo.GetStatementLines(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	gatewayPayments, err := s.reconciliationRepo.GetGatewayPayments(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.reconciliationRepo.GetTransactions(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionIDs := make([]uint, len(transactions))
+	transactionsByID := make(map[uint]models.PaymentTransaction, len(transactions))
+	for i, transaction := range transactions {
+		transactionIDs[i] = transaction.ID
+		transactionsByID[transaction.ID] = transaction
+	}
+
+	installments, err := s.reconciliationRepo.GetPaidInstallments(ctx, from, to, transactionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	run := &models.ReconciliationRun{
+		BusinessDate: from,
+		RunBy:        runBy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	add := func(item models.ReconciliationItem) {
+		item.Difference = s.currency.Round(item.Actual - item.Expected)
+		item.CreatedAt = now
+		run.Items = append(run.Items, item)
+	}
+
+	// Statement credits against the transactions they were posted as
+	postedFromStatement := map[uint]bool{}
+	for _, line := range lines {
+		line := line
+		run.StatementCount++
+		run.StatementTotal += line.Amount
+
+		if line.TransactionID == nil {
+			if line.Status == "ignored" {
+				continue
+			}
+			add(models.ReconciliationItem{
+				Type:            "missing_posting",
+				StatementLineID: &line.ID,
+				LoanID:          line.LoanID,
+				Expected:        line.Amount,
+				Description:     fmt.Sprintf("statement credit is %s and was not posted", line.MatchStatus),
+			})
+			continue
+		}
+
+		postedFromStatement[*line.TransactionID] = true
+		transaction, ok := transactionsByID[*line.TransactionID]
+		if !ok {
+			// Posted on another business date, e.g. resolved from the queue later
+			continue
+		}
+		if !sameAmount(transaction.Amount, line.Amount) {
+			add(models.ReconciliationItem{
+				Type:            "amount_difference",
+				StatementLineID: &line.ID,
+				TransactionID:   &transaction.ID,
+				LoanID:          &transaction.LoanID,
+				Expected:        line.Amount,
+				Actual:          transaction.Amount,
+				Description:     "statement credit and posted transaction amounts differ",
+			})
+		}
+	}
+
+	// Gateway notifications that did not become a transaction
+	for _, payment := range gatewayPayments {
+		payment := payment
+		if payment.TransactionID != nil {
+			continue
+		}
+		add(models.ReconciliationItem{
+			Type:             "missing_posting",
+			GatewayPaymentID: &payment.ID,
+			LoanID:           payment.LoanID,
+			Expected:         payment.Amount,
+			Description:      fmt.Sprintf("gateway notification %s is %s", payment.GatewayTransactionID, payment.Status),
+		})
+	}
+
+	// Transactions against the installments they settled
+	settled := map[uint]float64{}
+	for _, installment := range installments {
+		if installment.TransactionID != nil {
+			settled[*installment.TransactionID] += installment.Amount
+		}
+	}
+
+	for _, transaction := range transactions {
+		transaction := transaction
+		run.TransactionCount++
+		run.TransactionTotal += transaction.Amount
+		run.AppliedTotal += transaction.AppliedAmount
+		run.UnappliedTotal += transaction.UnappliedAmount
+
+		if transaction.Channel == "bank" && !postedFromStatement[transaction.ID] {
+			add(models.ReconciliationItem{
+				Type:          "missing_statement",
+				TransactionID: &transaction.ID,
+				LoanID:        &transaction.LoanID,
+				Actual:        transaction.Amount,
+				Description:   "bank transaction has no statement credit",
+			})
+		}
+		// A refinance payoff waives the interest and fees of the installments it
+		// closes early, and a reversed transaction no longer settles anything
+		if transaction.Channel != "refinance" && transaction.Status != "reversed" && !sameAmount(settled[transaction.ID], transaction.AppliedAmount) {
+			add(models.ReconciliationItem{
+				Type:          "amount_difference",
+				TransactionID: &transaction.ID,
+				LoanID:        &transaction.LoanID,
+				Expected:      transaction.AppliedAmount,
+				Actual:        settled[transaction.ID],
+				Description:   "installments settled by the transaction differ from its applied amount",
+			})
+		}
+	}
+
+	for _, item := range duplicateTransactions(transactions) {
+		add(item)
+	}
+
+	// Installments paid on the day without a transaction of the day behind them
+	for _, installment := range installments {
+		installment := installment
+		if installment.PaidDate == nil || installment.PaidDate.Before(from) || !installment.PaidDate.Before(to) {
+			continue
+		}
+		run.InstallmentCount++
+		run.InstallmentTotal += installment.Amount
+
+		if installment.TransactionID == nil {
+			add(models.ReconciliationItem{
+				Type:        "unlinked_installment",
+				PaymentID:   &installment.ID,
+				LoanID:      &installment.LoanID,
+				Actual:      installment.Amount,
+				Description: "installment is paid without a payment transaction",
+			})
+		} else if _, ok := transactionsByID[*installment.TransactionID]; !ok {
+			add(models.ReconciliationItem{
+				Type:          "unlinked_installment",
+				PaymentID:     &installment.ID,
+				TransactionID: installment.TransactionID,
+				LoanID:        &installment.LoanID,
+				Actual:        installment.Amount,
+				Description:   "installment is paid by a transaction received on another date",
+			})
+		}
+	}
+
+	run.StatementTotal = s.currency.Round(run.StatementTotal)
+	run.TransactionTotal = s.currency.Round(run.TransactionTotal)
+	run.AppliedTotal = s.currency.Round(run.AppliedTotal)
+	run.UnappliedTotal = s.currency.Round(run.UnappliedTotal)
+	run.InstallmentTotal = s.currency.Round(run.InstallmentTotal)
+	run.MismatchCount = len(run.Items)
+	run.Status = "balanced"
+	if run.MismatchCount > 0 {
+		run.Status = "mismatched"
+	}
+
+	if err := s.reconciliationRepo.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// duplicateTransactions flags money that looks posted twice: transactions
+// sharing a channel reference, and the same amount on the same loan arriving
+// both from a bank statement and the payment gateway
+func duplicateTransactions(transactions []models.PaymentTransaction) []models.ReconciliationItem {
+	var items []models.ReconciliationItem
+
+	byReference := map[string]uint{}
+	type loanAmount struct {
+		loanID uint
+		amount string
+	}
+	byChannel := map[loanAmount]map[string]uint{}
+
+	for _, transaction := range transactions {
+		transaction := transaction
+		if transaction.Reference != "" && transaction.Channel != "field" {
+			key := transaction.Channel + "/" + transaction.Reference
+			if first, ok := byReference[key]; ok {
+				items = append(items, models.ReconciliationItem{
+					Type:          "duplicate",
+					TransactionID: &transaction.ID,
+					LoanID:        &transaction.LoanID,
+					Actual:        transaction.Amount,
+					Description:   fmt.Sprintf("reference %s was already posted as transaction %d", transaction.Reference, first),
+				})
+				continue
+			}
+			byReference[key] = transaction.ID
+		}
+
+		if transaction.Channel != "bank" && transaction.Channel != "gateway" {
+			continue
+		}
+		key := loanAmount{transaction.LoanID, fmt.Sprintf("%.2f", transaction.Amount)}
+		if byChannel[key] == nil {
+			byChannel[key] = map[string]uint{}
+		}
+		other := "gateway"
+		if transaction.Channel == "gateway" {
+			other = "bank"
+		}
+		if first, ok := byChannel[key][other]; ok {
+			items = append(items, models.ReconciliationItem{
+				Type:          "duplicate",
+				TransactionID: &transaction.ID,
+				LoanID:        &transaction.LoanID,
+				Actual:        transaction.Amount,
+				Description:   fmt.Sprintf("same amount was posted to the loan through %s as transaction %d", other, first),
+			})
+			continue
+		}
+		byChannel[key][transaction.Channel] = transaction.ID
+	}
+
+	return items
+}
+
+// GetRun retrieves the stored reconciliation report of a business date
+func (s *ReconciliationService) GetRun(ctx context.Context, date time.Time) (*models.ReconciliationRun, error) {
+	return s.reconciliationRepo.GetRunByDate(ctx, truncateToDay(date))
+}
+
+// ListRuns lists reconciliation runs between two business dates; zero dates are open ends
+func (s *ReconciliationService) ListRuns(ctx context.Context, from, to time.Time) ([]models.ReconciliationRun, error) {
+	return s.reconciliationRepo.ListRuns(ctx, from, to)
+}
+
+// StartEndOfDayRun reconciles the previous business date of every tenant on
+// the given interval until the process exits
+func (s *ReconciliationService) StartEndOfDayRun(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tenants, err := s.branchRepo.ListTenants(ctx)
+		if err != nil {
+			log.Printf("Error listing tenants for end-of-day reconciliation: %v", err)
+			continue
+		}
+		for _, tenant := range tenants {
+			scoped := repositories.WithTenantScope(ctx, repositories.TenantScope{TenantID: tenant.ID})
+			run, err := s.Run(scoped, time.Now().AddDate(0, 0, -1), "scheduler")
+			if err != nil {
+				log.Printf("Error running end-of-day reconciliation for tenant %s: %v", tenant.Code, err)
+				continue
+			}
+			if run.MismatchCount > 0 {
+				log.Printf("Reconciliation of tenant %s for %s found %d mismatch(es)", tenant.Code, run.BusinessDate.Format("2006-01-02"), run.MismatchCount)
+			}
+		}
+	}
+}
+
+func sameAmount(a, b float64) bool {
+	return math.Abs(a-b) < amountTolerance
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// RestructureService handles rescheduling loans for borrowers in hardship
+type RestructureService struct {
+	loanRepo        *repositories.LoanRepository
+	restructureRepo *repositories.RestructureRepository
+	calendar        *CalendarService
+	currency        Currency
+}
+
+// NewRestructureService creates a new restructure service instance
+func NewRestructureService(loanRepo *repositories.LoanRepository, restructureRepo *repositories.RestructureRepository, calendar *CalendarService, currency Currency) *RestructureService {
+	return &RestructureService{loanRepo: loanRepo, restructureRepo: restructureRepo, calendar: calendar, currency: currency}
+}
+
+// Restructure supersedes every unpaid installment of the loan's current schedule
+// and generates a new schedule version under the requested terms:
+//   - ExtendWeeks spreads the remaining amount over that many extra installments
+//   - HolidayWeeks pushes the first remaining installment back by that many weeks
+//   - CapitaliseArrears folds overdue installments into the remaining ones
+//     instead of carrying them over as separate, still overdue, installments
+func (s *RestructureService) Restructure(ctx context.Context, loanID uint, req dto.RestructureRequest) (*models.Restructure, error) {
+	if req.ExtendWeeks < 0 || req.HolidayWeeks < 0 {
+		return nil, Validation("extend_weeks and holiday_weeks cannot be negative")
+	}
+	if req.ExtendWeeks == 0 && req.HolidayWeeks == 0 && !req.CapitaliseArrears {
+		return nil, Validation("restructure must extend the tenor, add a payment holiday or capitalise arrears")
+	}
+	if req.RequestedBy == "" {
+		return nil, Validation("requested_by is required")
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if loan.Status != "active" && loan.Status != "defaulted" {
+		return nil, Conflict(fmt.Sprintf("loan is %s and cannot be restructured", loan.Status))
+	}
+
+	payments, err := s.loanRepo.GetPaymentsByLoanID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	restructure, superseded, schedule, err := s.reschedule(loan, payments, req, now, func(dueDates []time.Time) ([]time.Time, error) {
+		return s.calendar.AdjustDueDates(ctx, loan.BorrowerID, loan.ProductID, dueDates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loan.ScheduleVersion = restructure.ToVersion
+	loan.WeeklyPayment = restructure.NewWeeklyPayment
+	loan.TotalWeeks = schedule[len(schedule)-1].WeekNum
+	loan.EndDate = restructure.NewEndDate
+	loan.UpdatedAt = now
+
+	if err := s.restructureRepo.Create(ctx, restructure, loan, superseded, schedule); err != nil {
+		return nil, err
+	}
+
+	return restructure, nil
+}
+
+// reschedule works out a restructure of the loan's current installments:
+// the new schedule version, due on the dates adjust settles, and the
+// installments it supersedes
+func (s *RestructureService) reschedule(loan *models.Loan, payments []models.Payment, req dto.RestructureRequest, now time.Time, adjust func([]time.Time) ([]time.Time, error)) (*models.Restructure, []models.Payment, []models.Payment, error) {
+	lastWeek := 0
+	var arrears, remaining []models.Payment
+	for _, payment := range payments {
+		switch {
+		case payment.Status == "paid":
+			lastWeek = payment.WeekNum
+		case payment.Status != "pending":
+			continue
+		case payment.DueDate.Before(now):
+			arrears = append(arrears, payment)
+		default:
+			remaining = append(remaining, payment)
+		}
+	}
+
+	if len(arrears)+len(remaining) == 0 {
+		return nil, nil, nil, Conflict("loan has no unpaid installments to restructure")
+	}
+
+	newVersion := loan.ScheduleVersion + 1
+	var schedule []models.Payment
+	nextInstallment := func(amount, feeAmount float64, dueDate time.Time) {
+		lastWeek++
+		schedule = append(schedule, models.Payment{
+			LoanID:          loan.ID,
+			Amount:          amount,
+			FeeAmount:       feeAmount,
+			WeekNum:         lastWeek,
+			DueDate:         dueDate,
+			Status:          "pending",
+			ScheduleVersion: newVersion,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+	}
+
+	// Arrears that are not capitalised move over unchanged and stay overdue
+	// Fees carried by the installments move with the amounts they are part of
+	rescheduledAmount := 0.0
+	capitalisedAmount := 0.0
+	spreadFees := 0.0
+	for _, payment := range arrears {
+		if req.CapitaliseArrears {
+			capitalisedAmount += payment.Amount
+			spreadFees += payment.FeeAmount
+			continue
+		}
+		nextInstallment(payment.Amount, payment.FeeAmount, payment.DueDate)
+		rescheduledAmount += payment.Amount
+	}
+
+	spreadAmount := capitalisedAmount
+	for _, payment := range remaining {
+		spreadAmount += payment.Amount
+		spreadFees += payment.FeeAmount
+	}
+
+	installments := len(remaining) + req.ExtendWeeks
+	if installments == 0 {
+		return nil, nil, nil, Conflict("no installments are left to absorb the arrears: extend the tenor")
+	}
+
+	// The first rescheduled installment keeps the next regular due date; when
+	// nothing is left to fall due it is the first weekly date after today
+	firstDueDate := payments[len(payments)-1].DueDate
+	if len(remaining) > 0 {
+		firstDueDate = remaining[0].DueDate
+	} else {
+		for !firstDueDate.After(now) {
+			firstDueDate = firstDueDate.AddDate(0, 0, 7)
+		}
+	}
+	firstDueDate = firstDueDate.AddDate(0, 0, 7*req.HolidayWeeks)
+
+	dueDates := make([]time.Time, installments)
+	for i := range dueDates {
+		dueDates[i] = firstDueDate.AddDate(0, 0, 7*i)
+	}
+	dueDates, err := adjust(dueDates)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Installments are rounded to the currency so that they can be paid
+	// exactly; the last one takes the rounding remainder
+	spreadAmount = s.currency.Round(spreadAmount)
+	amounts := s.currency.Split(spreadAmount, installments)
+	fees := s.currency.Split(spreadFees, installments)
+	for i, dueDate := range dueDates {
+		nextInstallment(amounts[i], fees[i], dueDate)
+	}
+	installmentAmount := amounts[0]
+	rescheduledAmount += spreadAmount
+
+	superseded := append(arrears, remaining...)
+	for i := range superseded {
+		superseded[i].SupersededInVersion = newVersion
+		superseded[i].UpdatedAt = now
+	}
+
+	restructure := &models.Restructure{
+		LoanID:                loan.ID,
+		FromVersion:           loan.ScheduleVersion,
+		ToVersion:             newVersion,
+		ExtendWeeks:           req.ExtendWeeks,
+		HolidayWeeks:          req.HolidayWeeks,
+		CapitaliseArrears:     req.CapitaliseArrears,
+		CapitalisedAmount:     capitalisedAmount,
+		RescheduledAmount:     rescheduledAmount,
+		PreviousWeeklyPayment: loan.WeeklyPayment,
+		NewWeeklyPayment:      installmentAmount,
+		PreviousEndDate:       loan.EndDate,
+		NewEndDate:            schedule[len(schedule)-1].DueDate,
+		Reason:                req.Reason,
+		RequestedBy:           req.RequestedBy,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	return restructure, superseded, schedule, nil
+}
+
+// GetRestructures returns the restructure history of a loan
+func (s *RestructureService) GetRestructures(ctx context.Context, loanID uint) ([]models.Restructure, error) {
+	return s.restructureRepo.GetByLoanID(ctx, loanID)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+)
+
+func TestRescheduleRoundsInstallments(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	week := func(n int) time.Time { return now.AddDate(0, 0, 7*n) }
+
+	tests := []struct {
+		name         string
+		currency     Currency
+		payments     []models.Payment
+		req          dto.RestructureRequest
+		wantAmounts  []float64
+		wantFees     []float64
+		wantWeekly   float64
+		wantSupersed int
+	}{
+		{
+			name:     "extension rounds to whole units with the remainder last",
+			currency: Currency{Code: "IDR", Decimals: 0},
+			payments: []models.Payment{
+				{WeekNum: 1, Status: "paid", DueDate: week(-2), Amount: 100, FeeAmount: 10},
+				{WeekNum: 2, Status: "pending", DueDate: week(1), Amount: 100, FeeAmount: 10},
+				{WeekNum: 3, Status: "pending", DueDate: week(2), Amount: 100, FeeAmount: 10},
+				{WeekNum: 4, Status: "pending", DueDate: week(3), Amount: 100, FeeAmount: 10},
+			},
+			req:          dto.RestructureRequest{ExtendWeeks: 4},
+			wantAmounts:  []float64{43, 43, 43, 43, 43, 43, 42},
+			wantFees:     []float64{4, 4, 4, 4, 4, 4, 6},
+			wantWeekly:   43,
+			wantSupersed: 3,
+		},
+		{
+			name:     "cents go to the last installment",
+			currency: Currency{Code: "USD", Decimals: 2},
+			payments: []models.Payment{
+				{WeekNum: 1, Status: "pending", DueDate: week(1), Amount: 100},
+			},
+			req:          dto.RestructureRequest{ExtendWeeks: 2},
+			wantAmounts:  []float64{33.33, 33.33, 33.34},
+			wantFees:     []float64{0, 0, 0},
+			wantWeekly:   33.33,
+			wantSupersed: 1,
+		},
+		{
+			name:     "capitalised arrears are spread with the remaining installments",
+			currency: Currency{Code: "IDR", Decimals: 0},
+			payments: []models.Payment{
+				{WeekNum: 1, Status: "pending", DueDate: week(-1), Amount: 101},
+				{WeekNum: 2, Status: "pending", DueDate: week(1), Amount: 100},
+			},
+			req:          dto.RestructureRequest{CapitaliseArrears: true, ExtendWeeks: 1},
+			wantAmounts:  []float64{101, 100},
+			wantFees:     []float64{0, 0},
+			wantWeekly:   101,
+			wantSupersed: 2,
+		},
+		{
+			name:     "arrears that are not capitalised move over unchanged",
+			currency: Currency{Code: "IDR", Decimals: 0},
+			payments: []models.Payment{
+				{WeekNum: 1, Status: "pending", DueDate: week(-1), Amount: 100.5},
+				{WeekNum: 2, Status: "pending", DueDate: week(1), Amount: 100},
+			},
+			req:          dto.RestructureRequest{ExtendWeeks: 2},
+			wantAmounts:  []float64{100.5, 33, 33, 34},
+			wantFees:     []float64{0, 0, 0, 0},
+			wantWeekly:   33,
+			wantSupersed: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &RestructureService{currency: tt.currency}
+			loan := &models.Loan{ID: 7, ScheduleVersion: 1, WeeklyPayment: 100}
+
+			restructure, superseded, schedule, err := s.reschedule(loan, tt.payments, tt.req, now, keepDates)
+			if err != nil {
+				t.Fatalf("reschedule: %v", err)
+			}
+
+			if len(schedule) != len(tt.wantAmounts) {
+				t.Fatalf("got %d installments, want %d", len(schedule), len(tt.wantAmounts))
+			}
+			for i, installment := range schedule {
+				if !sameAmount(installment.Amount, tt.wantAmounts[i]) || !sameAmount(installment.FeeAmount, tt.wantFees[i]) {
+					t.Errorf("installment %d is %.2f with fee %.2f, want %.2f with fee %.2f",
+						i+1, installment.Amount, installment.FeeAmount, tt.wantAmounts[i], tt.wantFees[i])
+				}
+				if installment.ScheduleVersion != 2 || installment.Status != "pending" {
+					t.Errorf("installment %d is version %d %s, want version 2 pending", i+1, installment.ScheduleVersion, installment.Status)
+				}
+			}
+			if restructure.NewWeeklyPayment != tt.wantWeekly {
+				t.Errorf("new weekly payment %.2f, want %.2f", restructure.NewWeeklyPayment, tt.wantWeekly)
+			}
+			if restructure.NewEndDate != schedule[len(schedule)-1].DueDate {
+				t.Errorf("new end date %v is not the last due date %v", restructure.NewEndDate, schedule[len(schedule)-1].DueDate)
+			}
+
+			if len(superseded) != tt.wantSupersed {
+				t.Fatalf("superseded %d installments, want %d", len(superseded), tt.wantSupersed)
+			}
+			for _, installment := range superseded {
+				if installment.SupersededInVersion != 2 || installment.Status != "pending" {
+					t.Errorf("superseded week %d is %s in version %d, want pending superseded in version 2",
+						installment.WeekNum, installment.Status, installment.SupersededInVersion)
+				}
+			}
+		})
+	}
+}
+
+func TestRescheduleRefusesLoansWithNothingToRestructure(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := &RestructureService{currency: Currency{Decimals: 0}}
+
+	tests := []struct {
+		name     string
+		payments []models.Payment
+		req      dto.RestructureRequest
+	}{
+		{
+			name:     "every installment is paid",
+			payments: []models.Payment{{WeekNum: 1, Status: "paid", DueDate: now.AddDate(0, 0, -7), Amount: 100}},
+			req:      dto.RestructureRequest{ExtendWeeks: 2},
+		},
+		{
+			name:     "capitalised arrears with nothing left to fall due",
+			payments: []models.Payment{{WeekNum: 1, Status: "pending", DueDate: now.AddDate(0, 0, -7), Amount: 100}},
+			req:      dto.RestructureRequest{CapitaliseArrears: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := s.reschedule(&models.Loan{ScheduleVersion: 1}, tt.payments, tt.req, now, keepDates)
+			var coded *Error
+			if !errors.As(err, &coded) || coded.Code != CodeConflict {
+				t.Fatalf("got %v, want a conflict", err)
+			}
+		})
+	}
+}
+
+func keepDates(dueDates []time.Time) ([]time.Time, error) {
+	return dueDates, nil
+}
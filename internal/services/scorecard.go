@@ -0,0 +1,280 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Scorer scores a loan application. Loan origination only depends on this
+// interface so a remote scoring service can replace the local scorecard
+type Scorer interface {
+	Score(input ScoreInput) (*ScoreResult, error)
+}
+
+// ScoreInput holds the characteristics an application is scored on. Pointer
+// fields are nil when there is no data, e.g. for a first-time borrower
+type ScoreInput struct {
+	BorrowerID       uint
+	Amount           float64
+	ProductID        *uint
+	GroupID          *uint
+	LoanCycle        int
+	PaidInstallments int
+	OnTimeRate       *float64 // share of paid installments paid by their due date
+	MaxDaysPastDue   *float64 // worst lateness of any installment, paid or not
+	GroupArrearsRate *float64 // arrears over outstanding across the group's running loans
+}
+
+// value returns the value of a named characteristic and whether it is known
+func (in ScoreInput) value(characteristic string) (float64, bool) {
+	switch characteristic {
+	case "on_time_rate":
+		return optional(in.OnTimeRate)
+	case "max_days_past_due":
+		return optional(in.MaxDaysPastDue)
+	case "group_arrears_rate":
+		return optional(in.GroupArrearsRate)
+	case "loan_cycle":
+		return float64(in.LoanCycle), true
+	case "paid_installments":
+		return float64(in.PaidInstallments), true
+	}
+	return 0, false
+}
+
+func optional(value *float64) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+	return *value, true
+}
+
+// scoreCharacteristics are the characteristic names a scorecard may use
+var scoreCharacteristics = map[string]bool{
+	"on_time_rate":       true,
+	"max_days_past_due":  true,
+	"group_arrears_rate": true,
+	"loan_cycle":         true,
+	"paid_installments":  true,
+}
+
+// ScoreResult is the outcome of scoring an application
+type ScoreResult struct {
+	Score    float64       `json:"score"`
+	Band     string        `json:"band"`
+	Decision string        `json:"decision"` // approved, declined
+	Scorer   string        `json:"scorer"`
+	Reasons  []ScoreReason `json:"reasons"`
+}
+
+// ScoreReason explains the points one characteristic contributed
+type ScoreReason struct {
+	Characteristic string   `json:"characteristic"`
+	Code           string   `json:"code"`
+	Value          *float64 `json:"value"` // nil when the characteristic was missing
+	Points         float64  `json:"points"`
+}
+
+// Scorecard is a points-based scorecard. Every characteristic adds the points
+// of the first bin its value falls in, or its missing points when there is no
+// value. Applications scoring below the cutoff are declined
+type Scorecard struct {
+	Name            string                    `json:"name"`
+	Version         string                    `json:"version"`
+	BasePoints      float64                   `json:"base_points"`
+	Cutoff          float64                   `json:"cutoff"`
+	Bands           []ScoreBand               `json:"bands"` // highest first
+	Characteristics []ScorecardCharacteristic `json:"characteristics"`
+}
+
+// ScoreBand names a score range starting at MinScore
+type ScoreBand struct {
+	Name     string  `json:"name"`
+	MinScore float64 `json:"min_score"`
+}
+
+// ScorecardCharacteristic scores one characteristic of an application
+type ScorecardCharacteristic struct {
+	Name          string     `json:"name"`
+	MissingPoints float64    `json:"missing_points"`
+	MissingReason string     `json:"missing_reason"`
+	Bins          []ScoreBin `json:"bins"`
+}
+
+// ScoreBin is a value range [Min, Max) and the points it scores. An unset
+// bound is open
+type ScoreBin struct {
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Points float64  `json:"points"`
+	Reason string   `json:"reason"`
+}
+
+func (b ScoreBin) contains(value float64) bool {
+	return (b.Min == nil || value >= *b.Min) && (b.Max == nil || value < *b.Max)
+}
+
+// DefaultScorecard returns the scorecard used without a scorecard file
+func DefaultScorecard() Scorecard {
+	bound := func(v float64) *float64 { return &v }
+	return Scorecard{
+		Name:       "default",
+		Version:    "1",
+		BasePoints: 250,
+		Cutoff:     320,
+		Bands: []ScoreBand{
+			{Name: "A", MinScore: 520},
+			{Name: "B", MinScore: 420},
+			{Name: "C", MinScore: 320},
+			{Name: "D", MinScore: 0},
+		},
+		Characteristics: []ScorecardCharacteristic{
+			{
+				Name:          "on_time_rate",
+				MissingPoints: 40,
+				MissingReason: "no_repayment_history",
+				Bins: []ScoreBin{
+					{Min: bound(0.95), Points: 120, Reason: "excellent_on_time_repayment"},
+					{Min: bound(0.8), Points: 80, Reason: "good_on_time_repayment"},
+					{Min: bound(0.6), Points: 30, Reason: "fair_on_time_repayment"},
+					{Points: 0, Reason: "poor_on_time_repayment"},
+				},
+			},
+			{
+				Name:          "max_days_past_due",
+				MissingPoints: 40,
+				MissingReason: "no_installments_due",
+				Bins: []ScoreBin{
+					{Max: bound(1), Points: 100, Reason: "never_past_due"},
+					{Max: bound(8), Points: 70, Reason: "past_due_under_a_week"},
+					{Max: bound(30), Points: 30, Reason: "past_due_under_30_days"},
+					{Points: 0, Reason: "past_due_30_days_or_more"},
+				},
+			},
+			{
+				Name: "loan_cycle",
+				Bins: []ScoreBin{
+					{Min: bound(4), Points: 80, Reason: "established_borrower"},
+					{Min: bound(3), Points: 60, Reason: "third_cycle"},
+					{Min: bound(2), Points: 40, Reason: "repeat_borrower"},
+					{Points: 20, Reason: "first_loan"},
+				},
+			},
+			{
+				Name:          "group_arrears_rate",
+				MissingPoints: 30,
+				MissingReason: "no_group",
+				Bins: []ScoreBin{
+					{Max: bound(0.01), Points: 60, Reason: "group_performing"},
+					{Max: bound(0.05), Points: 40, Reason: "group_minor_arrears"},
+					{Max: bound(0.15), Points: 15, Reason: "group_in_arrears"},
+					{Points: 0, Reason: "group_heavy_arrears"},
+				},
+			},
+		},
+	}
+}
+
+// LoadScorecard reads a scorecard from a JSON file. An empty path returns the default scorecard
+func LoadScorecard(path string) (Scorecard, error) {
+	if path == "" {
+		return DefaultScorecard(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scorecard{}, err
+	}
+
+	var scorecard Scorecard
+	if err := json.Unmarshal(data, &scorecard); err != nil {
+		return Scorecard{}, fmt.Errorf("parsing scorecard: %w", err)
+	}
+	if err := scorecard.validate(); err != nil {
+		return Scorecard{}, err
+	}
+	return scorecard, nil
+}
+
+func (s Scorecard) validate() error {
+	if s.Name == "" {
+		return errors.New("scorecard needs a name")
+	}
+	if len(s.Bands) == 0 {
+		return errors.New("scorecard needs at least one band")
+	}
+	for i := 1; i < len(s.Bands); i++ {
+		if s.Bands[i].MinScore >= s.Bands[i-1].MinScore {
+			return errors.New("scorecard bands must be ordered from the highest score down")
+		}
+	}
+	for _, characteristic := range s.Characteristics {
+		if !scoreCharacteristics[characteristic.Name] {
+			return fmt.Errorf("scorecard characteristic %q is not known", characteristic.Name)
+		}
+		if len(characteristic.Bins) == 0 {
+			return fmt.Errorf("scorecard characteristic %q has no bins", characteristic.Name)
+		}
+	}
+	return nil
+}
+
+// ScorecardScorer scores applications locally with a scorecard
+type ScorecardScorer struct {
+	scorecard Scorecard
+}
+
+// NewScorecardScorer creates a scorer for a scorecard
+func NewScorecardScorer(scorecard Scorecard) *ScorecardScorer {
+	return &ScorecardScorer{scorecard: scorecard}
+}
+
+// Score adds up the points of every characteristic
+func (s *ScorecardScorer) Score(input ScoreInput) (*ScoreResult, error) {
+	result := &ScoreResult{
+		Score:   s.scorecard.BasePoints,
+		Scorer:  s.scorecard.Name,
+		Reasons: []ScoreReason{},
+	}
+	if s.scorecard.Version != "" {
+		result.Scorer += "@" + s.scorecard.Version
+	}
+
+	for _, characteristic := range s.scorecard.Characteristics {
+		reason := ScoreReason{Characteristic: characteristic.Name}
+
+		value, known := input.value(characteristic.Name)
+		if !known {
+			reason.Code = characteristic.MissingReason
+			reason.Points = characteristic.MissingPoints
+		} else {
+			reason.Value = &value
+			for _, bin := range characteristic.Bins {
+				if bin.contains(value) {
+					reason.Code = bin.Reason
+					reason.Points = bin.Points
+					break
+				}
+			}
+		}
+
+		result.Score += reason.Points
+		result.Reasons = append(result.Reasons, reason)
+	}
+
+	for _, band := range s.scorecard.Bands {
+		if result.Score >= band.MinScore {
+			result.Band = band.Name
+			break
+		}
+	}
+
+	result.Decision = "approved"
+	if result.Score < s.scorecard.Cutoff {
+		result.Decision = "declined"
+	}
+
+	return result, nil
+}
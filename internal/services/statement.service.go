@@ -0,0 +1,428 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// StatementService handles importing bank statements, matching their credits
+// to loans and the reconciliation queue for credits that could not be matched
+type StatementService struct {
+	statementRepo *repositories.StatementRepository
+	loanRepo      *repositories.LoanRepository
+	loanService   *LoanService
+	recoveries    *WriteOffService
+	transactor    *repositories.Transactor
+	formats       map[string]StatementFormat
+}
+
+// NewStatementService creates a new statement service instance
+func NewStatementService(statementRepo *repositories.StatementRepository, loanRepo *repositories.LoanRepository, loanService *LoanService, recoveries *WriteOffService, transactor *repositories.Transactor, formats map[string]StatementFormat) *StatementService {
+	return &StatementService{
+		statementRepo: statementRepo,
+		loanRepo:      loanRepo,
+		loanService:   loanService,
+		recoveries:    recoveries,
+		transactor:    transactor,
+		formats:       formats,
+	}
+}
+
+// Formats returns the statement formats that can be imported, by name
+func (s *StatementService) Formats() []StatementFormat {
+	formats := make([]StatementFormat, 0, len(s.formats))
+	for _, format := range s.formats {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i].Name < formats[j].Name })
+	return formats
+}
+
+// ImportStatement reads a CSV statement in the given format, matches every
+// credit to a loan by virtual account or payment reference and posts the ones
+// that match exactly one loan. The rest are queued for reconciliation, as are
+// matched credits that could not be posted. Lines already imported from an
+// earlier statement are skipped
+func (s *StatementService) ImportStatement(ctx context.Context, r io.Reader, formatName, fileName, source string) (*models.StatementImport, error) {
+	format, ok := s.formats[formatName]
+	if !ok {
+		return nil, Validation(fmt.Sprintf("unknown statement format %q", formatName))
+	}
+
+	lines, skipped, err := parseStatement(r, format)
+	if err != nil {
+		return nil, Validation(err.Error())
+	}
+
+	now := time.Now()
+	statement := &models.StatementImport{
+		Format:       format.Name,
+		FileName:     fileName,
+		Source:       source,
+		TotalLines:   len(lines) + skipped,
+		SkippedLines: skipped,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.statementRepo.CreateImport(ctx, statement); err != nil {
+		return nil, err
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		duplicate, err := s.statementRepo.HasFingerprint(ctx, line.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate {
+			statement.DuplicateLines++
+			continue
+		}
+
+		line.ImportID = statement.ID
+		line.CreatedAt = now
+		line.UpdatedAt = now
+		loan, err := s.match(ctx, line)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.createLine(ctx, line, loan); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case line.Status == "posted":
+			statement.AutoMatched++
+		case line.MatchStatus == "auto_matched":
+			statement.Failed++
+		case line.MatchStatus == "ambiguous":
+			statement.Ambiguous++
+		default:
+			statement.Unmatched++
+		}
+		statement.TotalAmount += line.Amount
+		statement.Lines = append(statement.Lines, *line)
+	}
+
+	statement.UpdatedAt = time.Now()
+	if err := s.statementRepo.UpdateImport(ctx, statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// createLine records a statement line and, when it matched the loan given,
+// posts its credit in the same transaction, so that a line is never recorded as posted
+// without its payment or posted twice. A credit that cannot be posted is
+// rolled back and its line recorded open, with the error, for reconciliation
+func (s *StatementService) createLine(ctx context.Context, line *models.StatementLine, loan *models.Loan) error {
+	if loan == nil {
+		line.Status = "open"
+		return s.statementRepo.CreateLine(ctx, line)
+	}
+
+	var postErr error
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		if postErr = s.post(ctx, line, loan); postErr != nil {
+			return postErr
+		}
+		return s.statementRepo.CreateLine(ctx, line)
+	})
+	if postErr == nil {
+		return err
+	}
+	return s.statementRepo.CreateLine(ctx, line)
+}
+
+// match looks up the loans a statement line may belong to and returns the
+// loan when exactly one matches. A line whose virtual account and reference
+// point at different loans is ambiguous
+func (s *StatementService) match(ctx context.Context, line *models.StatementLine) (*models.Loan, error) {
+	var candidates []models.Loan
+	if line.Account != "" {
+		loans, err := s.loanRepo.FindByVirtualAccount(ctx, line.Account)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, loans...)
+	}
+	if line.Reference != "" {
+		loans, err := s.loanRepo.FindByReference(ctx, line.Reference)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, loans...)
+	}
+
+	seen := map[uint]bool{}
+	var ids []string
+	for _, loan := range candidates {
+		if seen[loan.ID] {
+			continue
+		}
+		seen[loan.ID] = true
+		ids = append(ids, strconv.FormatUint(uint64(loan.ID), 10))
+	}
+
+	switch len(ids) {
+	case 0:
+		line.MatchStatus = "unmatched"
+	case 1:
+		line.MatchStatus = "auto_matched"
+		loanID := candidates[0].ID
+		line.LoanID = &loanID
+		return &candidates[0], nil
+	default:
+		line.MatchStatus = "ambiguous"
+		line.Candidates = strings.Join(ids, ",")
+	}
+	return nil, nil
+}
+
+// post applies a statement credit to a loan. Bank transfers rarely match the
+// installment exactly, so the money settles whole installments and any
+// remainder is held as unapplied. Money paid for a written-off loan is booked
+// as a recovery. A line that cannot be posted stays open, with the error
+// returned kept on it
+func (s *StatementService) post(ctx context.Context, line *models.StatementLine, loan *models.Loan) error {
+	reference := line.Reference
+	if reference == "" {
+		reference = line.Account
+	}
+
+	loanID := loan.ID
+	if loan.Status == "written_off" {
+		recovery, err := s.recoveries.recover(ctx, loanID, line.Amount, reference, line.ValueDate)
+		if err != nil {
+			line.Status = "open"
+			line.Error = err.Error()
+			return err
+		}
+		line.LoanID = &loanID
+		line.RecoveryID = &recovery.ID
+		line.Status = "posted"
+		line.Error = ""
+		return nil
+	}
+
+	transaction, err := s.loanService.PostPartialPayment(ctx, loanID, line.Amount, PaymentSource{
+		Channel:    "bank",
+		Reference:  reference,
+		ReceivedBy: "bank-statement",
+		ReceivedAt: line.ValueDate,
+	})
+	if err != nil {
+		line.Status = "open"
+		line.Error = err.Error()
+		return err
+	}
+
+	line.LoanID = &loanID
+	line.TransactionID = &transaction.ID
+	line.Status = "posted"
+	line.Error = ""
+	return nil
+}
+
+// GetImport retrieves a statement import with its lines
+func (s *StatementService) GetImport(ctx context.Context, id uint) (*models.StatementImport, error) {
+	return s.statementRepo.GetImportByID(ctx, id)
+}
+
+// ListImports lists statement imports, newest first
+func (s *StatementService) ListImports(ctx context.Context) ([]models.StatementImport, error) {
+	return s.statementRepo.ListImports(ctx)
+}
+
+// GetReconciliationQueue lists the statement lines waiting for manual
+// resolution, optionally limited to one import
+func (s *StatementService) GetReconciliationQueue(ctx context.Context, importID uint) ([]models.StatementLine, error) {
+	return s.statementRepo.GetOpenLines(ctx, importID)
+}
+
+// ResolveLine posts an open statement line to the loan chosen by a reviewer.
+// The payment and the resolved line are saved in one transaction
+func (s *StatementService) ResolveLine(ctx context.Context, lineID uint, req dto.ResolveStatementLineRequest) (*models.StatementLine, error) {
+	var line *models.StatementLine
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		var err error
+		if line, err = s.openLine(ctx, lineID, req.ResolvedBy); err != nil {
+			return err
+		}
+		loan, err := s.loanRepo.GetByID(ctx, req.LoanID)
+		if err != nil {
+			return err
+		}
+		if err := s.post(ctx, line, loan); err != nil {
+			return err
+		}
+
+		s.closeLine(line, "resolved", req.ResolvedBy, req.Note)
+		return s.statementRepo.UpdateLine(ctx, line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// IgnoreLine takes an open statement line off the queue without posting it,
+// e.g. a transfer that is not a loan repayment
+func (s *StatementService) IgnoreLine(ctx context.Context, lineID uint, req dto.IgnoreStatementLineRequest) (*models.StatementLine, error) {
+	line, err := s.openLine(ctx, lineID, req.ResolvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Note) == "" {
+		return nil, Validation("a note is required to ignore a statement line")
+	}
+
+	s.closeLine(line, "ignored", req.ResolvedBy, req.Note)
+	if err := s.statementRepo.UpdateLine(ctx, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+func (s *StatementService) openLine(ctx context.Context, lineID uint, resolvedBy string) (*models.StatementLine, error) {
+	if strings.TrimSpace(resolvedBy) == "" {
+		return nil, Validation("resolved_by is required")
+	}
+
+	line, err := s.statementRepo.GetLineByID(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.Status != "open" {
+		return nil, Conflict(fmt.Sprintf("statement line is already %s", line.Status))
+	}
+	return line, nil
+}
+
+func (s *StatementService) closeLine(line *models.StatementLine, status, resolvedBy, note string) {
+	now := time.Now()
+	line.Status = status
+	line.ResolvedBy = resolvedBy
+	line.ResolvedAt = &now
+	line.Note = note
+	line.UpdatedAt = now
+}
+
+// parseStatement reads the credits on a statement. Debits and zero amounts are
+// counted as skipped; any other unreadable line fails the whole import so that
+// a wrong format is caught before anything is posted
+func parseStatement(r io.Reader, format StatementFormat) ([]models.StatementLine, int, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = format.delimiter()
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	for i := 0; i < format.SkipLines; i++ {
+		if _, err := reader.Read(); err != nil {
+			return nil, 0, fmt.Errorf("reading preamble: %w", err)
+		}
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{format.Columns.Date, format.Columns.Amount} {
+		if _, ok := columns[strings.ToLower(required)]; !ok {
+			return nil, 0, fmt.Errorf("missing %q column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if name == "" {
+			return ""
+		}
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var lines []models.StatementLine
+	skipped := 0
+	for lineNo := format.SkipLines + 2; ; lineNo++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		if format.Columns.Direction != "" && !strings.EqualFold(field(record, format.Columns.Direction), format.CreditValue) {
+			skipped++
+			continue
+		}
+
+		date, err := format.parseDate(field(record, format.Columns.Date))
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: invalid date %q", lineNo, field(record, format.Columns.Date))
+		}
+		amount, err := format.parseAmount(field(record, format.Columns.Amount))
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: invalid amount %q", lineNo, field(record, format.Columns.Amount))
+		}
+		if amount <= 0 {
+			skipped++
+			continue
+		}
+
+		line := models.StatementLine{
+			LineNo:      lineNo,
+			Sequence:    field(record, format.Columns.Sequence),
+			ValueDate:   date,
+			Amount:      amount,
+			Account:     field(record, format.Columns.Account),
+			Reference:   field(record, format.Columns.Reference),
+			Description: field(record, format.Columns.Description),
+		}
+		line.Fingerprint = statementFingerprint(line)
+		lines = append(lines, line)
+	}
+
+	return lines, skipped, nil
+}
+
+// statementFingerprint identifies a credit so that re-imported statements do
+// not post twice. Two credits of the same amount, account and reference on
+// the same day are told apart by the bank's sequence number or, for formats
+// without one, by their line in the file
+func statementFingerprint(line models.StatementLine) string {
+	sequence := line.Sequence
+	if sequence == "" {
+		sequence = "line:" + strconv.Itoa(line.LineNo)
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		sequence,
+		line.ValueDate.Format("2006-01-02"),
+		strconv.FormatFloat(line.Amount, 'f', 2, 64),
+		line.Account,
+		line.Reference,
+		line.Description,
+	}, "|")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+const sameDayCredits = `date,amount,virtual_account,reference,description
+2024-01-05,22,8808000000000001,,transfer
+2024-01-05,22,8808000000000001,,transfer
+`
+
+func TestStatementFingerprintTellsSameDayCreditsApart(t *testing.T) {
+	format := DefaultStatementFormats()["generic"]
+
+	lines, _, err := parseStatement(strings.NewReader(sameDayCredits), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[0].Fingerprint == lines[1].Fingerprint {
+		t.Error("two credits on the same day share a fingerprint, so the second would be skipped as a duplicate")
+	}
+
+	again, _, err := parseStatement(strings.NewReader(sameDayCredits), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range lines {
+		if lines[i].Fingerprint != again[i].Fingerprint {
+			t.Errorf("line %d has another fingerprint when the file is read again", lines[i].LineNo)
+		}
+	}
+
+	t.Run("bank sequence", func(t *testing.T) {
+		format.Columns.Sequence = "seq"
+		first, _, err := parseStatement(strings.NewReader("date,amount,virtual_account,seq\n2024-01-05,22,8808000000000001,981\n"), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moved, _, err := parseStatement(strings.NewReader("date,amount,virtual_account,seq\n2024-01-04,10,8808000000000009,980\n2024-01-05,22,8808000000000001,981\n"), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first[0].Fingerprint != moved[1].Fingerprint {
+			t.Error("a credit with a bank sequence number is not recognised at another line of the file")
+		}
+	})
+}
+
+// newStatementService returns a statement service posting through the loan
+// service of newLoanService, and booking recoveries for written-off loans.
+// Lines already inserted are found by fingerprint
+func newStatementService(t *testing.T) (*StatementService, *dbtest.DB) {
+	t.Helper()
+	loans, db, conn := newLoanService(t)
+	db.On(func(statement dbtest.Statement) dbtest.Rows {
+		count := 0
+		for _, insert := range db.Find("INSERT INTO `statement_lines`") {
+			if insert.HasArg(statement.Args[0]) {
+				count++
+			}
+		}
+		return dbtest.Row([]string{"count"}, int64(count))
+	}, "count(*)", "FROM `statement_lines`")
+	loanRepo := repositories.NewLoanRepository(conn)
+	transactor := repositories.NewTransactor(conn)
+	recoveries := NewWriteOffService(loanRepo, repositories.NewWriteOffRepository(conn), transactor, DefaultRules{})
+	return NewStatementService(repositories.NewStatementRepository(conn), loanRepo, loans, recoveries, transactor, DefaultStatementFormats()), db
+}
+
+func TestStatementImport(t *testing.T) {
+	due := time.Now().AddDate(0, 0, 3)
+	account := "8808000000000001"
+	loan := models.Loan{ID: 4, TenantID: 1, Amount: 1000, TotalAmount: 1100, WeeklyPayment: 22, VirtualAccount: &account, Status: "active"}
+	schedule := paymentRows(
+		models.Payment{ID: 40, LoanID: 4, WeekNum: 1, Amount: 22, DueDate: due, Status: "pending"},
+		models.Payment{ID: 41, LoanID: 4, WeekNum: 2, Amount: 22, DueDate: due.AddDate(0, 0, 7), Status: "pending"},
+		models.Payment{ID: 42, LoanID: 4, WeekNum: 3, Amount: 22, DueDate: due.AddDate(0, 0, 14), Status: "pending"},
+	)
+	ctx := context.Background()
+
+	t.Run("re-importing posts nothing", func(t *testing.T) {
+		s, db := newStatementService(t)
+		db.Returning(loanRows(loan), "FROM `loans`")
+		db.Returning(schedule, "FROM `payments`")
+
+		first, err := s.ImportStatement(ctx, strings.NewReader(sameDayCredits), "generic", "day1.csv", "cli")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.AutoMatched != 2 || first.DuplicateLines != 0 {
+			t.Fatalf("first import matched %d and skipped %d duplicates, want 2 and 0", first.AutoMatched, first.DuplicateLines)
+		}
+		posted := len(db.Find("INSERT INTO `payment_transactions`"))
+
+		second, err := s.ImportStatement(ctx, strings.NewReader(sameDayCredits), "generic", "day1-again.csv", "cli")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second.DuplicateLines != 2 || second.AutoMatched != 0 || len(second.Lines) != 0 {
+			t.Errorf("second import: %d duplicates, %d matched, %d lines; want 2, 0 and 0", second.DuplicateLines, second.AutoMatched, len(second.Lines))
+		}
+		if again := len(db.Find("INSERT INTO `payment_transactions`")); again != posted {
+			t.Errorf("re-importing posted %d more payments", again-posted)
+		}
+	})
+
+	t.Run("line and payment commit together", func(t *testing.T) {
+		s, db := newStatementService(t)
+		db.Returning(loanRows(loan), "FROM `loans`")
+		db.Returning(schedule, "FROM `payments`")
+
+		if _, err := s.ImportStatement(ctx, strings.NewReader(sameDayCredits), "generic", "day1.csv", "cli"); err != nil {
+			t.Fatal(err)
+		}
+		statements := db.Statements()
+		begin := indexOf(statements, "BEGIN")
+		payment := indexOf(statements, "INSERT INTO `payment_transactions`")
+		line := indexOf(statements, "INSERT INTO `statement_lines`")
+		commit := indexOf(statements, "COMMIT")
+		if begin < 0 || payment < begin || line < payment || commit < line {
+			t.Errorf("the payment and its line are not saved in one transaction:\n%v", statements)
+		}
+	})
+
+	t.Run("a failed posting is rolled back and queued", func(t *testing.T) {
+		s, db := newStatementService(t)
+		db.Returning(loanRows(loan), "FROM `loans`")
+		successor := uint(5)
+		refinanced := loan
+		refinanced.Status, refinanced.RefinancedByID = "refinanced", &successor
+		db.Returning(loanRows(refinanced), "FROM `loans`", "FOR UPDATE")
+		db.Returning(schedule, "FROM `payments`")
+
+		statement, err := s.ImportStatement(ctx, strings.NewReader(sameDayCredits), "generic", "day1.csv", "cli")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statement.Failed != 2 || statement.AutoMatched != 0 {
+			t.Errorf("%d failed and %d matched, want 2 and 0", statement.Failed, statement.AutoMatched)
+		}
+		for _, line := range statement.Lines {
+			if line.Status != "open" || line.Error == "" || line.TransactionID != nil {
+				t.Errorf("line %d is %s with error %q, want open with the posting error", line.LineNo, line.Status, line.Error)
+			}
+		}
+
+		statements := db.Statements()
+		rollback := indexOf(statements, "ROLLBACK")
+		if rollback < 0 {
+			t.Fatalf("the failed posting was not rolled back:\n%v", statements)
+		}
+		if line := indexOf(statements[rollback:], "INSERT INTO `statement_lines`"); line < 0 {
+			t.Errorf("the line was not recorded after the rollback:\n%v", statements)
+		}
+		if payments := db.Find("INSERT INTO `payment_transactions`"); len(payments) != 0 {
+			t.Errorf("a payment was written for a failed posting:\n%v", payments)
+		}
+	})
+	t.Run("a credit for a written-off loan is recovered", func(t *testing.T) {
+		s, db := newStatementService(t)
+		writtenOff := loanRows(loan)
+		writtenOff.Columns = append(writtenOff.Columns, "written_off_amount")
+		writtenOff.Values[0][10] = "written_off"
+		writtenOff.Values[0] = append(writtenOff.Values[0], 1000.0)
+		db.Returning(writtenOff, "FROM `loans`")
+
+		statement, err := s.ImportStatement(ctx, strings.NewReader(sameDayCredits), "generic", "day1.csv", "cli")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statement.AutoMatched != 2 {
+			t.Fatalf("%d lines matched, want 2", statement.AutoMatched)
+		}
+		for _, line := range statement.Lines {
+			if line.Status != "posted" || line.RecoveryID == nil || line.TransactionID != nil {
+				t.Errorf("line %d is %s with recovery %v, want posted as a recovery", line.LineNo, line.Status, line.RecoveryID)
+			}
+		}
+		if recoveries := db.Find("INSERT INTO `recoveries`"); len(recoveries) != 2 {
+			t.Errorf("%d recoveries were booked, want 2", len(recoveries))
+		}
+		if payments := db.Find("INSERT INTO `payment_transactions`"); len(payments) != 0 {
+			t.Errorf("a written-off loan took a payment against its schedule:\n%v", payments)
+		}
+	})
+}
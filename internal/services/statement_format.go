@@ -0,0 +1,122 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementFormat describes the CSV layout of a bank statement. Columns are
+// looked up by header name, case-insensitively
+type StatementFormat struct {
+	Name             string           `json:"name"`
+	Delimiter        string           `json:"delimiter"`         // defaults to ","
+	SkipLines        int              `json:"skip_lines"`        // preamble lines before the header
+	DateLayout       string           `json:"date_layout"`       // Go time layout, defaults to 2006-01-02
+	DecimalSeparator string           `json:"decimal_separator"` // "." (default) or ","
+	CreditValue      string           `json:"credit_value"`      // value of the direction column marking credits
+	Columns          StatementColumns `json:"columns"`
+}
+
+// StatementColumns names the statement columns holding each field
+type StatementColumns struct {
+	Date        string `json:"date"`
+	Amount      string `json:"amount"`
+	Account     string `json:"account"`
+	Reference   string `json:"reference"`
+	Description string `json:"description"`
+	Direction   string `json:"direction"` // optional debit/credit indicator
+	Sequence    string `json:"sequence"`  // optional bank transaction number, unique within an account
+}
+
+// DefaultStatementFormats returns the formats available without a formats file
+func DefaultStatementFormats() map[string]StatementFormat {
+	return map[string]StatementFormat{
+		"generic": {
+			Name:       "generic",
+			DateLayout: "2006-01-02",
+			Columns: StatementColumns{
+				Date:        "date",
+				Amount:      "amount",
+				Account:     "virtual_account",
+				Reference:   "reference",
+				Description: "description",
+			},
+		},
+	}
+}
+
+// LoadStatementFormats returns the default formats together with those
+// defined in a JSON file holding an array of formats. An empty path loads the
+// defaults only; a format in the file replaces a default of the same name
+func LoadStatementFormats(path string) (map[string]StatementFormat, error) {
+	formats := DefaultStatementFormats()
+	if path == "" {
+		return formats, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var custom []StatementFormat
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("parsing statement formats: %w", err)
+	}
+	for _, format := range custom {
+		if err := format.validate(); err != nil {
+			return nil, err
+		}
+		formats[format.Name] = format
+	}
+	return formats, nil
+}
+
+func (f StatementFormat) validate() error {
+	if f.Name == "" {
+		return errors.New("statement format name is required")
+	}
+	if f.Columns.Date == "" || f.Columns.Amount == "" {
+		return fmt.Errorf("statement format %q: date and amount columns are required", f.Name)
+	}
+	if f.Columns.Account == "" && f.Columns.Reference == "" && f.Columns.Description == "" {
+		return fmt.Errorf("statement format %q: an account, reference or description column is required", f.Name)
+	}
+	if len([]rune(f.Delimiter)) > 1 {
+		return fmt.Errorf("statement format %q: delimiter must be a single character", f.Name)
+	}
+	return nil
+}
+
+func (f StatementFormat) delimiter() rune {
+	if f.Delimiter == "" {
+		return ','
+	}
+	return []rune(f.Delimiter)[0]
+}
+
+func (f StatementFormat) parseDate(value string) (time.Time, error) {
+	layout := f.DateLayout
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	return time.ParseInLocation(layout, value, time.Local)
+}
+
+// parseAmount reads an amount written with the format's decimal separator and
+// any thousands separators
+func (f StatementFormat) parseAmount(value string) (float64, error) {
+	value = strings.ReplaceAll(value, " ", "")
+	if f.DecimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// VirtualAccountPolicy controls the virtual account numbers given to new loans.
+// Numbers are the prefix followed by random digits and a Luhn check digit
+type VirtualAccountPolicy struct {
+	Prefix string
+	Length int // total length including prefix and check digit
+}
+
+// generate returns a new candidate virtual account number
+func (p VirtualAccountPolicy) generate() (string, error) {
+	random := p.Length - len(p.Prefix) - 1
+	if random < 4 {
+		return "", errors.New("virtual account length leaves too few random digits")
+	}
+
+	var b strings.Builder
+	b.WriteString(p.Prefix)
+	for i := 0; i < random; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + n.Int64()))
+	}
+
+	number := b.String()
+	return number + string(luhnCheckDigit(number)), nil
+}
+
+// luhnCheckDigit computes the check digit that makes number+digit pass the Luhn check
+func luhnCheckDigit(number string) byte {
+	sum := 0
+	double := true
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// WebhookEventTypes are the domain event types a subscription can choose
+var WebhookEventTypes = []string{
+	dto.EventLoanCreated, dto.EventPaymentReceived, dto.EventInstallmentMissed,
+	dto.EventLoanDelinquent, dto.EventLoanCompleted,
+}
+
+// WebhookConfig controls webhook delivery
+type WebhookConfig struct {
+	MaxAttempts int           // attempts before a delivery is dead-lettered
+	RetryDelay  time.Duration // delay after the first failed attempt, doubled after each further one
+	MaxDelay    time.Duration
+	Timeout     time.Duration // per request
+	BatchSize   int           // deliveries attempted per dispatcher run
+}
+
+// WebhookService manages partner webhook subscriptions and delivers domain
+// events to them. It is an EventPublisher: the outbox relay hands it every
+// event, and it queues a delivery for each matching subscription
+type WebhookService struct {
+	repo   *repositories.WebhookRepository
+	client *http.Client
+	config WebhookConfig
+}
+
+// NewWebhookService creates a new webhook service instance
+func NewWebhookService(repo *repositories.WebhookRepository, config WebhookConfig) *WebhookService {
+	return &WebhookService{repo: repo, client: &http.Client{Timeout: config.Timeout}, config: config}
+}
+
+// SignWebhook returns the X-Webhook-Signature of a delivery: the HMAC-SHA256
+// of the timestamp, a dot and the body
+func SignWebhook(secret, timestamp string, body []byte) string {
+	return SignPayload(secret, append([]byte(timestamp+"."), body...))
+}
+
+// CreateSubscription registers a partner endpoint and returns the secret its
+// deliveries are signed with
+func (s *WebhookService) CreateSubscription(ctx context.Context, req dto.WebhookSubscriptionRequest, createdBy string) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{
+		Status:    "active",
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := applySubscriptionRequest(subscription, req); err != nil {
+		return nil, err
+	}
+
+	subscription.Secret = req.Secret
+	if subscription.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		subscription.Secret = "whsec_" + base64.RawURLEncoding.EncodeToString(secret)
+	} else if len(subscription.Secret) < 16 {
+		return nil, Validation("secret must be at least 16 characters")
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// UpdateSubscription replaces the name, URL, event types and status of a
+// subscription. The secret is kept
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id uint, req dto.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySubscriptionRequest(subscription, req); err != nil {
+		return nil, err
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func applySubscriptionRequest(subscription *models.WebhookSubscription, req dto.WebhookSubscriptionRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return Validation("name is required")
+	}
+	endpoint, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return Validation("url must be an absolute http or https URL")
+	}
+	eventTypes, err := normalizeEventTypes(req.EventTypes)
+	if err != nil {
+		return err
+	}
+
+	switch req.Status {
+	case "":
+	case "active", "paused":
+		subscription.Status = req.Status
+	default:
+		return Validation("status must be active or paused")
+	}
+
+	subscription.Name = strings.TrimSpace(req.Name)
+	subscription.URL = endpoint.String()
+	subscription.EventTypes = strings.Join(eventTypes, ",")
+	return nil
+}
+
+// normalizeEventTypes checks the event types of a subscription and removes
+// duplicates
+func normalizeEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, Validation("event_types must name at least one event type, or *")
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "*" {
+			return []string{"*"}, nil
+		}
+		if !knownEventType(eventType) {
+			return nil, Validation(fmt.Sprintf("unknown event type %q, must be one of %s or *", eventType, strings.Join(WebhookEventTypes, ", ")))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			result = append(result, eventType)
+		}
+	}
+	return result, nil
+}
+
+func knownEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribes reports whether a subscription receives events of the type
+func subscribes(subscription *models.WebhookSubscription, eventType string) bool {
+	for _, subscribed := range strings.Split(subscription.EventTypes, ",") {
+		if subscribed == "*" || subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSubscription returns a subscription
+func (s *WebhookService) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	return s.repo.GetSubscription(ctx, id)
+}
+
+// ListSubscriptions returns every subscription
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+// DeleteSubscription removes a subscription. Its undelivered deliveries are
+// dead-lettered
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	subscription, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, subscription)
+}
+
+// Publish queues a delivery of the event to every active subscription of
+// the event's tenant that wants its type
+func (s *WebhookService) Publish(ctx context.Context, event Event) error {
+	var envelope dto.EventEnvelope
+	if err := json.Unmarshal(event.Payload, &envelope); err != nil {
+		return fmt.Errorf("reading event %s: %w", event.ID, err)
+	}
+
+	subscriptions, err := s.repo.GetActiveSubscriptions(ctx, envelope.TenantID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := []models.WebhookDelivery{}
+	for i := range subscriptions {
+		if !subscribes(&subscriptions[i], event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			TenantID:       envelope.TenantID,
+			SubscriptionID: subscriptions[i].ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(event.Payload),
+			Status:         "pending",
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// Dispatch attempts the deliveries that are due and returns how many were
+// delivered
+func (s *WebhookService) Dispatch(ctx context.Context) (int, error) {
+	due, err := s.repo.GetDueDeliveries(ctx, time.Now(), s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range due {
+		ok, err := s.attempt(ctx, &due[i].Delivery, &due[i].Subscription)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// attempt posts a delivery to its subscription's URL and records the outcome
+func (s *WebhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery, subscription *models.WebhookSubscription) (bool, error) {
+	started := time.Now()
+	statusCode, responseBody, sendErr := s.send(ctx, delivery, subscription, started)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &started
+	delivery.LastStatusCode = statusCode
+	delivery.UpdatedAt = time.Now()
+
+	attempt := &models.WebhookAttempt{
+		DeliveryID:   delivery.ID,
+		Attempt:      delivery.Attempts,
+		StatusCode:   statusCode,
+		ResponseBody: responseBody,
+		DurationMs:   time.Since(started).Milliseconds(),
+		AttemptedAt:  started,
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = "delivered"
+		delivery.DeliveredAt = &delivery.UpdatedAt
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case delivery.Attempts >= s.config.MaxAttempts:
+		attempt.Error = sendErr.Error()
+		delivery.Status = "dead"
+		delivery.NextAttemptAt = nil
+		delivery.LastError = sendErr.Error()
+	default:
+		attempt.Error = sendErr.Error()
+		retryAt := started.Add(s.retryDelay(delivery.Attempts))
+		delivery.Status = "retrying"
+		delivery.NextAttemptAt = &retryAt
+		delivery.LastError = sendErr.Error()
+	}
+
+	if err := s.repo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		return false, err
+	}
+	if delivery.Status == "dead" {
+		log.Printf("Webhook delivery %d of event %s to subscription %d dead-lettered after %d attempt(s): %v",
+			delivery.ID, delivery.EventID, subscription.ID, delivery.Attempts, sendErr)
+	}
+	return sendErr == nil, nil
+}
+
+// send makes the HTTP request of a delivery. Any 2xx response is a success
+func (s *WebhookService) send(ctx context.Context, delivery *models.WebhookDelivery, subscription *models.WebhookSubscription, now time.Time) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "billing-engine-webhooks")
+	req.Header.Set("X-Webhook-ID", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Event-ID", delivery.EventID)
+	req.Header.Set("X-Event-Type", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// Only the start of the response is kept in the attempt log
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(response), fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, string(response), nil
+}
+
+// retryDelay is the delay before retrying a delivery that failed attempts times
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryDelay
+	for i := 1; i < attempts && delay < s.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.MaxDelay {
+		delay = s.config.MaxDelay
+	}
+	return delay
+}
+
+// StartDispatcher runs Dispatch on the given interval until the process exits
+func (s *WebhookService) StartDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.Dispatch(ctx); err != nil {
+			log.Printf("Error dispatching webhooks: %v", err)
+		}
+	}
+}
+
+// ListDeliveries returns the deliveries matching the filter, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter repositories.DeliveryFilter) ([]dto.WebhookDeliveryResponse, error) {
+	switch filter.Status {
+	case "", "pending", "retrying", "delivered", "dead":
+	default:
+		return nil, Validation("status must be pending, retrying, delivered or dead")
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.WebhookDeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		result[i] = toWebhookDeliveryResponse(&deliveries[i])
+	}
+	return result, nil
+}
+
+// GetDelivery returns a delivery with the event sent and its attempt log
+func (s *WebhookService) GetDelivery(ctx context.Context, id uint) (*dto.WebhookDeliveryResponse, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.GetAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toWebhookDeliveryResponse(delivery)
+	response.Event = json.RawMessage(delivery.Payload)
+	response.AttemptLog = make([]dto.WebhookAttemptResponse, len(attempts))
+	for i, attempt := range attempts {
+		response.AttemptLog[i] = dto.WebhookAttemptResponse{
+			Attempt:      attempt.Attempt,
+			StatusCode:   attempt.StatusCode,
+			Error:        attempt.Error,
+			ResponseBody: attempt.ResponseBody,
+			DurationMs:   attempt.DurationMs,
+			AttemptedAt:  attempt.AttemptedAt,
+		}
+	}
+	return &response, nil
+}
+
+// Redeliver queues a delivery to be sent again straight away with a fresh
+// set of attempts, whether it was delivered, dead-lettered or is waiting to
+// be retried
+func (s *WebhookService) Redeliver(ctx context.Context, id uint, redeliveredBy string) (*dto.WebhookDeliveryResponse, error) {
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := s.repo.GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Status != "active" {
+		return nil, Conflict("webhook subscription is paused")
+	}
+
+	now := time.Now()
+	delivery.Status = "pending"
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.RedeliveredAt = &now
+	delivery.RedeliveredBy = redeliveredBy
+	delivery.UpdatedAt = now
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	response := toWebhookDeliveryResponse(delivery)
+	return &response, nil
+}
+
+func toWebhookDeliveryResponse(delivery *models.WebhookDelivery) dto.WebhookDeliveryResponse {
+	return dto.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		RedeliveredAt:  delivery.RedeliveredAt,
+		RedeliveredBy:  delivery.RedeliveredBy,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dto"
+	"AmarthaExample1/internal/models"
+)
+
+// knownWebhookSignature is the HMAC-SHA256 of `1710493200.{"id":"evt-1"}`
+// with the key "whsec", worked out independently of SignWebhook
+const knownWebhookSignature = "sha256=e6022f94551d893e17aa534320d2213540641d3dd261b95adcf41bb79a3b62ba"
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"id":"evt-1"}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		wantMatch bool
+	}{
+		{"known signature", "whsec", "1710493200", body, true},
+		{"another secret", "other", "1710493200", body, false},
+		{"another timestamp", "whsec", "1710493201", body, false},
+		{"another body", "whsec", "1710493200", []byte(`{"id":"evt-2"}`), false},
+		{"timestamp moved into the body", "whsec", "", []byte(`1710493200.{"id":"evt-1"}`), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SignWebhook(tt.secret, tt.timestamp, tt.body)
+			if (got == knownWebhookSignature) != tt.wantMatch {
+				t.Errorf("got %s, want a match %v", got, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestSendSignsDeliveries(t *testing.T) {
+	now := time.Unix(1710493200, 0)
+	subscription := &models.WebhookSubscription{Secret: "whsec"}
+	delivery := &models.WebhookDelivery{ID: 5, EventID: "evt-1", EventType: dto.EventPaymentReceived, Payload: `{"id":"evt-1"}`}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"accepted", http.StatusAccepted, false},
+		{"rejected", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, "ok")
+			}))
+			defer server.Close()
+			subscription.URL = server.URL
+
+			s := &WebhookService{client: server.Client()}
+			status, response, err := s.send(context.Background(), delivery, subscription, now)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+			if status != tt.status || response != "ok" {
+				t.Errorf("got %d %q, want %d %q", status, response, tt.status, "ok")
+			}
+
+			if string(gotBody) != delivery.Payload {
+				t.Errorf("body %s, want %s", gotBody, delivery.Payload)
+			}
+			for header, want := range map[string]string{
+				"X-Webhook-ID":        strconv.FormatUint(uint64(delivery.ID), 10),
+				"X-Event-ID":          delivery.EventID,
+				"X-Event-Type":        delivery.EventType,
+				"X-Webhook-Timestamp": "1710493200",
+				"X-Webhook-Signature": knownWebhookSignature,
+			} {
+				if got.Header.Get(header) != want {
+					t.Errorf("%s is %q, want %q", header, got.Header.Get(header), want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeEventTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes []string
+		want       []string
+		wantErr    bool
+	}{
+		{"known types", []string{dto.EventLoanCreated, dto.EventLoanCompleted}, []string{dto.EventLoanCreated, dto.EventLoanCompleted}, false},
+		{"duplicates and whitespace", []string{" loan.created", "loan.created "}, []string{dto.EventLoanCreated}, false},
+		{"wildcard wins", []string{dto.EventLoanCreated, "*"}, []string{"*"}, false},
+		{"unknown type", []string{dto.EventLoanCreated, "loan.deleted"}, nil, true},
+		{"none", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeEventTypes(tt.eventTypes)
+			if tt.wantErr {
+				if CodeOf(err) != CodeValidation {
+					t.Fatalf("got %v, want a validation error", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscribes(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes string
+		eventType  string
+		want       bool
+	}{
+		{"listed", "loan.created,payment.received", dto.EventPaymentReceived, true},
+		{"not listed", "loan.created,payment.received", dto.EventLoanCompleted, false},
+		{"every type", "*", dto.EventLoanDelinquent, true},
+		{"no partial match", "loan.created", "loan", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := &models.WebhookSubscription{EventTypes: tt.eventTypes}
+			if got := subscribes(subscription, tt.eventType); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	s := &WebhookService{config: WebhookConfig{RetryDelay: time.Minute, MaxDelay: 10 * time.Minute}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := s.retryDelay(tt.attempts); got != tt.want {
+				t.Errorf("after %d attempts got %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
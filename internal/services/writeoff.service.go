@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+// DefaultRules configures when an active loan is automatically moved to defaulted
+type DefaultRules struct {
+	// DaysPastDue is the number of days the oldest unpaid installment may be
+	// overdue before the loan defaults (e.g. 90 means DPD over 90)
+	DaysPastDue int
+}
+
+// defaults reports whether a loan this many days past due defaults
+func (r DefaultRules) defaults(dpd int) bool {
+	return dpd > r.DaysPastDue
+}
+
+// WriteOffService handles defaults, write-offs and post write-off recoveries
+type WriteOffService struct {
+	loanRepo     *repositories.LoanRepository
+	writeOffRepo *repositories.WriteOffRepository
+	transactor   *repositories.Transactor
+	rules        DefaultRules
+}
+
+// NewWriteOffService creates a new write-off service instance
+func NewWriteOffService(loanRepo *repositories.LoanRepository, writeOffRepo *repositories.WriteOffRepository, transactor *repositories.Transactor, rules DefaultRules) *WriteOffService {
+	return &WriteOffService{loanRepo: loanRepo, writeOffRepo: writeOffRepo, transactor: transactor, rules: rules}
+}
+
+// EvaluateDefaults moves every active loan whose DPD exceeds the configured
+// threshold to defaulted and returns the IDs of the loans it changed
+func (s *WriteOffService) EvaluateDefaults(ctx context.Context) ([]uint, error) {
+	loans, err := s.loanRepo.GetByStatus(ctx, "active")
+	if err != nil {
+		return nil, err
+	}
+
+	defaulted := []uint{}
+	for i := range loans {
+		loan := &loans[i]
+
+		dpd, err := s.loanRepo.GetDaysPastDue(ctx, loan.ID)
+		if err != nil {
+			return defaulted, err
+		}
+		if !s.rules.defaults(dpd) {
+			continue
+		}
+
+		now := time.Now()
+		loan.Status = "defaulted"
+		loan.DefaultedAt = &now
+		loan.UpdatedAt = now
+
+		if err := s.loanRepo.UpdateLoan(ctx, loan); err != nil {
+			return defaulted, err
+		}
+		defaulted = append(defaulted, loan.ID)
+	}
+
+	return defaulted, nil
+}
+
+// StartDefaultEvaluator runs EvaluateDefaults on the given interval until ctx is done
+func (s *WriteOffService) StartDefaultEvaluator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		defaulted, err := s.EvaluateDefaults(ctx)
+		if err != nil {
+			log.Printf("Error evaluating loan defaults: %v", err)
+			continue
+		}
+		if len(defaulted) > 0 {
+			log.Printf("Moved %d loan(s) to defaulted: %v", len(defaulted), defaulted)
+		}
+	}
+}
+
+// RequestWriteOff opens a write-off request for a defaulted loan
+func (s *WriteOffService) RequestWriteOff(ctx context.Context, loanID uint, reason, requestedBy string) (*models.WriteOff, error) {
+	if requestedBy == "" {
+		return nil, Validation("requested_by is required")
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if loan.Status != "defaulted" {
+		return nil, Conflict(fmt.Sprintf("only defaulted loans can be written off, loan is %s", loan.Status))
+	}
+
+	pending, err := s.writeOffRepo.HasPending(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, Conflict("loan already has a write-off awaiting approval")
+	}
+
+	outstanding, err := s.loanRepo.GetOutstandingAmount(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	writeOff := &models.WriteOff{
+		LoanID:      loanID,
+		Amount:      outstanding,
+		Reason:      reason,
+		RequestedBy: requestedBy,
+		Status:      "pending",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.writeOffRepo.Create(ctx, writeOff); err != nil {
+		return nil, err
+	}
+
+	return writeOff, nil
+}
+
+// ApproveWriteOff approves a pending write-off and writes the loan off
+func (s *WriteOffService) ApproveWriteOff(ctx context.Context, writeOffID uint, approvedBy, note string) (*models.WriteOff, error) {
+	writeOff, err := s.getPendingForReview(ctx, writeOffID, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := s.loanRepo.GetByID(ctx, writeOff.LoanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if loan.Status != "defaulted" {
+		return nil, Conflict(fmt.Sprintf("loan is %s and can no longer be written off", loan.Status))
+	}
+
+	// Re-read the receivable in case payments arrived while the request was pending
+	outstanding, err := s.loanRepo.GetOutstandingAmount(ctx, loan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	writeOff.Amount = outstanding
+	writeOff.Status = "approved"
+	writeOff.ReviewedBy = approvedBy
+	writeOff.ReviewNote = note
+	writeOff.ReviewedAt = &now
+	writeOff.UpdatedAt = now
+
+	loan.Status = "written_off"
+	loan.WrittenOffAt = &now
+	loan.WrittenOffAmount = outstanding
+	loan.UpdatedAt = now
+
+	if err := s.writeOffRepo.Approve(ctx, writeOff, loan); err != nil {
+		return nil, err
+	}
+
+	return writeOff, nil
+}
+
+// RejectWriteOff rejects a pending write-off, leaving the loan defaulted
+func (s *WriteOffService) RejectWriteOff(ctx context.Context, writeOffID uint, rejectedBy, note string) (*models.WriteOff, error) {
+	writeOff, err := s.getPendingForReview(ctx, writeOffID, rejectedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	writeOff.Status = "rejected"
+	writeOff.ReviewedBy = rejectedBy
+	writeOff.ReviewNote = note
+	writeOff.ReviewedAt = &now
+	writeOff.UpdatedAt = now
+
+	if err := s.writeOffRepo.Update(ctx, writeOff); err != nil {
+		return nil, err
+	}
+
+	return writeOff, nil
+}
+
+// ListWriteOffs returns write-off requests, optionally filtered by status
+func (s *WriteOffService) ListWriteOffs(ctx context.Context, status string) ([]models.WriteOff, error) {
+	return s.writeOffRepo.List(ctx, status)
+}
+
+// RecordRecovery books money received on a written-off loan
+func (s *WriteOffService) RecordRecovery(ctx context.Context, loanID uint, amount float64, reference string) (*models.Recovery, error) {
+	return s.recover(ctx, loanID, amount, reference, time.Now())
+}
+
+// recover books a recovery received at the given time. The loan stays locked
+// until the recovery is stored, so that concurrent recoveries cannot together
+// exceed the amount written off
+func (s *WriteOffService) recover(ctx context.Context, loanID uint, amount float64, reference string, receivedAt time.Time) (*models.Recovery, error) {
+	if amount <= 0 {
+		return nil, Validation("recovery amount must be greater than zero")
+	}
+
+	var recovery *models.Recovery
+	err := s.transactor.Run(ctx, func(ctx context.Context) error {
+		loan, err := s.loanRepo.GetForUpdate(ctx, loanID)
+		if err != nil {
+			return err
+		}
+
+		recovered, err := s.writeOffRepo.GetTotalRecovered(ctx, loanID)
+		if err != nil {
+			return err
+		}
+		if err := checkRecovery(loan, recovered, amount); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		recovery = &models.Recovery{
+			LoanID:     loanID,
+			Amount:     amount,
+			Reference:  reference,
+			ReceivedAt: receivedAt,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		return s.writeOffRepo.CreateRecovery(ctx, recovery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recovery, nil
+}
+
+// GetRecoveries returns all recoveries booked on a loan
+func (s *WriteOffService) GetRecoveries(ctx context.Context, loanID uint) ([]models.Recovery, error) {
+	return s.writeOffRepo.GetRecoveriesByLoanID(ctx, loanID)
+}
+
+// GetTotalRecovered returns the sum of recoveries booked on a loan
+func (s *WriteOffService) GetTotalRecovered(ctx context.Context, loanID uint) (float64, error) {
+	return s.writeOffRepo.GetTotalRecovered(ctx, loanID)
+}
+
+// getPendingForReview loads a write-off that is still awaiting review and
+// enforces that the reviewer is not the person who raised it
+func (s *WriteOffService) getPendingForReview(ctx context.Context, writeOffID uint, reviewer string) (*models.WriteOff, error) {
+	if reviewer == "" {
+		return nil, Validation("reviewer is required")
+	}
+
+	writeOff, err := s.writeOffRepo.GetByID(ctx, writeOffID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReviewable(writeOff, reviewer); err != nil {
+		return nil, err
+	}
+	return writeOff, nil
+}
+
+// checkReviewable checks that a write-off is still awaiting review and that
+// the reviewer is not the person who raised it
+func checkReviewable(writeOff *models.WriteOff, reviewer string) error {
+	if writeOff.Status != "pending" {
+		return Conflict(fmt.Sprintf("write-off has already been %s", writeOff.Status))
+	}
+	if writeOff.RequestedBy == reviewer {
+		return NewError(CodeForbidden, "a write-off must be reviewed by someone other than the requester")
+	}
+	return nil
+}
+
+// checkRecovery checks that a recovery is booked on a written-off loan and
+// that recoveries do not exceed the amount written off
+func checkRecovery(loan *models.Loan, recovered, amount float64) error {
+	if loan.Status != "written_off" {
+		return Conflict("recoveries can only be booked on written-off loans")
+	}
+	if recovered+amount > loan.WrittenOffAmount {
+		return Conflict(fmt.Sprintf("recovery exceeds the remaining written-off amount of %v", loan.WrittenOffAmount-recovered))
+	}
+	return nil
+}
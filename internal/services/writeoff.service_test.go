@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"AmarthaExample1/internal/dbtest"
+	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
+)
+
+func TestDefaultRules(t *testing.T) {
+	rules := DefaultRules{DaysPastDue: 90}
+
+	tests := []struct {
+		dpd  int
+		want bool
+	}{
+		{0, false},
+		{89, false},
+		{90, false},
+		{91, true},
+		{400, true},
+	}
+
+	for _, tt := range tests {
+		if got := rules.defaults(tt.dpd); got != tt.want {
+			t.Errorf("%d days past due defaults %v, want %v", tt.dpd, got, tt.want)
+		}
+	}
+}
+
+func TestCheckReviewable(t *testing.T) {
+	tests := []struct {
+		name     string
+		writeOff models.WriteOff
+		reviewer string
+		wantCode ErrorCode
+	}{
+		{"another reviewer", models.WriteOff{Status: "pending", RequestedBy: "manager"}, "finance", ""},
+		{"the requester", models.WriteOff{Status: "pending", RequestedBy: "manager"}, "manager", CodeForbidden},
+		{"already approved", models.WriteOff{Status: "approved", RequestedBy: "manager"}, "finance", CodeConflict},
+		{"already rejected", models.WriteOff{Status: "rejected", RequestedBy: "manager"}, "finance", CodeConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReviewable(&tt.writeOff, tt.reviewer)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			if CodeOf(err) != tt.wantCode {
+				t.Fatalf("got %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheckRecovery(t *testing.T) {
+	writtenOff := models.Loan{Status: "written_off", WrittenOffAmount: 100}
+
+	tests := []struct {
+		name      string
+		loan      models.Loan
+		recovered float64
+		amount    float64
+		wantCode  ErrorCode
+	}{
+		{"first recovery", writtenOff, 0, 40, ""},
+		{"recovers the rest", writtenOff, 60, 40, ""},
+		{"beyond the amount written off", writtenOff, 60, 40.01, CodeConflict},
+		{"after a full recovery", writtenOff, 100, 1, CodeConflict},
+		{"loan still defaulted", models.Loan{Status: "defaulted"}, 0, 40, CodeConflict},
+		{"loan still active", models.Loan{Status: "active", WrittenOffAmount: 100}, 0, 40, CodeConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRecovery(&tt.loan, tt.recovered, tt.amount)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			if CodeOf(err) != tt.wantCode {
+				t.Fatalf("got %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRecoveryLocksTheLoan(t *testing.T) {
+	conn, db := dbtest.Open(t)
+	loanRepo := repositories.NewLoanRepository(conn)
+	s := NewWriteOffService(loanRepo, repositories.NewWriteOffRepository(conn), repositories.NewTransactor(conn), DefaultRules{})
+	db.Returning(dbtest.Row([]string{"id", "status", "written_off_amount"}, int64(4), "written_off", 100.0), "FROM `loans`")
+	db.Returning(dbtest.Row([]string{"total"}, 60.0), "SUM(amount)", "FROM `recoveries`")
+
+	if _, err := s.RecordRecovery(context.Background(), 4, 40, "receipt-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	statements := db.Statements()
+	begin := indexOf(statements, "BEGIN")
+	lock := indexOf(statements, "FROM `loans`", "FOR UPDATE")
+	total := indexOf(statements, "FROM `recoveries`")
+	insert := indexOf(statements, "INSERT INTO `recoveries`")
+	commit := indexOf(statements, "COMMIT")
+	if begin != 0 || lock < begin || total < lock || insert < total || commit < insert {
+		t.Errorf("the recovered total is not read and extended under a lock on the loan:\n%v", statements)
+	}
+
+	db.Reset()
+	if _, err := s.RecordRecovery(context.Background(), 4, 40.01, "receipt-2"); CodeOf(err) != CodeConflict {
+		t.Fatalf("got %v, want a conflict beyond the amount written off", err)
+	}
+	if inserts := db.Find("INSERT INTO `recoveries`"); len(inserts) != 0 {
+		t.Errorf("a recovery beyond the amount written off was booked:\n%v", inserts)
+	}
+}
+
+func TestDefaultEvaluatorStopsWithItsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		(&WriteOffService{}).StartDefaultEvaluator(ctx, time.Hour)
+		close(stopped)
+	}()
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the default evaluator kept running after its context was cancelled")
+	}
+}
@@ -0,0 +1,267 @@
+// Package validation checks request structs against their validate struct
+// tags. It implements the subset of the go-playground/validator tags the
+// request DTOs use, with the same meaning:
+//
+//	required        the field is not its zero value (not nil for pointers, slices and maps)
+//	omitempty       skip the remaining rules when the field is empty
+//	gt, gte, lt, lte, min, max, len
+//	                compare numbers by value, and strings, slices and maps by length
+//	oneof           the field is one of the space-separated values
+//	email, url      the field is an email address or an absolute URL
+//	dive            apply the remaining rules to every element of a slice or map
+//
+// Nested structs and the elements of slices of structs are always checked.
+// Fields are named after their json tags, so errors point at the request body
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes a request field that breaks one of its rules
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Struct checks the fields of v, a struct or a pointer to one, against their
+// validate tags and returns every field that fails. A tag naming a rule this
+// package does not know panics, as it is a mistake in the request type
+func Struct(v interface{}) []FieldError {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	checkStruct(value, "", &errs)
+	return errs
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func checkStruct(value reflect.Value, prefix string, errs *[]FieldError) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous {
+			name = ""
+		}
+		var rules []string
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			rules = strings.Split(tag, ",")
+		}
+		checkValue(value.Field(i), join(prefix, name), rules, errs)
+	}
+}
+
+// checkValue applies rules to a value, then descends into it
+func checkValue(value reflect.Value, path string, rules []string, errs *[]FieldError) {
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+			if !hasValue(value) {
+				return
+			}
+			continue
+		case "required":
+			if !hasValue(value) {
+				*errs = append(*errs, FieldError{Field: path, Rule: name, Message: path + " is required"})
+				return
+			}
+			continue
+		case "dive":
+			each(indirect(value), path, rules[i+1:], errs)
+			return
+		}
+
+		target := indirect(value)
+		if !target.IsValid() {
+			// a nil pointer has nothing to compare; required catches it
+			return
+		}
+		if message, ok := check(name, param, target); !ok {
+			*errs = append(*errs, FieldError{Field: path, Rule: name, Param: param, Message: path + " " + message})
+			return
+		}
+	}
+
+	each(indirect(value), path, nil, errs)
+}
+
+// each descends into structs and the elements of slices, arrays and maps,
+// applying rules to every element
+func each(value reflect.Value, path string, rules []string, errs *[]FieldError) {
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() != timeType {
+			checkStruct(value, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			element := value.Index(i)
+			if len(rules) > 0 || descends(element) {
+				checkValue(element, fmt.Sprintf("%s[%d]", path, i), rules, errs)
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			element := iter.Value()
+			if len(rules) > 0 || descends(element) {
+				checkValue(element, fmt.Sprintf("%s[%v]", path, iter.Key()), rules, errs)
+			}
+		}
+	}
+}
+
+// descends reports whether a value may hold fields with rules of their own
+func descends(value reflect.Value) bool {
+	switch indirect(value).Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// check applies a comparison or format rule to a non-nil value and returns
+// what the value must be when it fails
+func check(rule, param string, value reflect.Value) (string, bool) {
+	switch rule {
+	case "gt", "gte", "lt", "lte", "min", "max", "len":
+		return compare(rule, param, value)
+	case "oneof":
+		options := strings.Fields(param)
+		text := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if text == option {
+				return "", true
+			}
+		}
+		return "must be one of " + strings.Join(options, ", "), false
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		return "must be an email address", err == nil && address.Address == value.String()
+	case "url":
+		u, err := url.Parse(value.String())
+		return "must be an absolute URL", err == nil && u.Scheme != "" && u.Host != ""
+	}
+	panic("validation: unknown rule " + strconv.Quote(rule))
+}
+
+// compare checks a number by its value, or a string, slice or map by its length
+func compare(rule, param string, value reflect.Value) (string, bool) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("validation: rule " + rule + " needs a number, got " + strconv.Quote(param))
+	}
+
+	var actual float64
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		actual, unit = float64(len([]rune(value.String()))), plural(" character", limit)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual, unit = float64(value.Len()), plural(" item", limit)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	default:
+		panic("validation: rule " + rule + " does not apply to " + value.Kind().String())
+	}
+
+	switch rule {
+	case "gt":
+		if unit == "" {
+			return "must be greater than " + param, actual > limit
+		}
+		return "must have more than " + param + unit, actual > limit
+	case "gte", "min":
+		return "must be at least " + param + unit, actual >= limit
+	case "lt":
+		if unit == "" {
+			return "must be less than " + param, actual < limit
+		}
+		return "must have fewer than " + param + unit, actual < limit
+	case "lte", "max":
+		return "must be at most " + param + unit, actual <= limit
+	default: // len
+		if unit == "" {
+			return "must be " + param, actual == limit
+		}
+		return "must be exactly " + param + unit, actual == limit
+	}
+}
+
+func plural(unit string, count float64) string {
+	if count == 1 {
+		return unit
+	}
+	return unit + "s"
+}
+
+// hasValue reports whether a field is set: not nil for pointers, slices,
+// maps and interfaces, and not the zero value otherwise
+func hasValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return !value.IsNil()
+	case reflect.Invalid:
+		return false
+	}
+	return !value.IsZero()
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func join(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,165 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City     string `json:"city" validate:"required"`
+	Postcode string `json:"postcode" validate:"omitempty,len=5"`
+}
+
+type member struct {
+	Name    string   `json:"name" validate:"required,max=5"`
+	Address *address `json:"address"`
+}
+
+type request struct {
+	Name      string            `json:"name" validate:"required"`
+	Amount    float64           `json:"amount" validate:"gt=0"`
+	Weeks     int               `json:"weeks" validate:"gte=1,lte=52"`
+	Rate      *float64          `json:"rate" validate:"omitempty,lt=1"`
+	Code      string            `json:"code" validate:"omitempty,min=2,max=3"`
+	Status    string            `json:"status" validate:"omitempty,oneof=active paused"`
+	Email     string            `json:"email" validate:"omitempty,email"`
+	URL       string            `json:"url" validate:"omitempty,url"`
+	Tags      []string          `json:"tags" validate:"omitempty,max=2,dive,required"`
+	Limits    map[string]int    `json:"limits" validate:"omitempty,dive,gte=0"`
+	Members   []member          `json:"members"`
+	Address   address           `json:"address"`
+	StartDate *time.Time        `json:"start_date"`
+	Labels    map[string]string `json:"-" validate:"required"`
+	internal  string            `validate:"required"`
+}
+
+func validRequest() request {
+	return request{
+		Name:    "loan",
+		Amount:  100,
+		Weeks:   50,
+		Address: address{City: "Bogor"},
+	}
+}
+
+func TestStruct(t *testing.T) {
+	rate := 1.5
+	zero := 0.0
+
+	tests := []struct {
+		name   string
+		change func(*request)
+		want   []string // field:rule of every error, in order
+	}{
+		{name: "valid", change: func(*request) {}},
+		{name: "required", change: func(r *request) { r.Name = "" }, want: []string{"name:required"}},
+		{name: "gt", change: func(r *request) { r.Amount = 0 }, want: []string{"amount:gt"}},
+		{name: "gte and lte", change: func(r *request) { r.Weeks = 53 }, want: []string{"weeks:lte"}},
+		{name: "first failing rule only", change: func(r *request) { r.Weeks = 0 }, want: []string{"weeks:gte"}},
+		{name: "omitempty skips nil pointers", change: func(r *request) { r.Rate = nil }},
+		{name: "rules apply through pointers", change: func(r *request) { r.Rate = &rate }, want: []string{"rate:lt"}},
+		{name: "a pointer to a zero value is set", change: func(r *request) { r.Rate = &zero }},
+		{name: "min on string length", change: func(r *request) { r.Code = "A" }, want: []string{"code:min"}},
+		{name: "max on string length", change: func(r *request) { r.Code = "ABCD" }, want: []string{"code:max"}},
+		{name: "length counts characters not bytes", change: func(r *request) { r.Code = "äöü" }},
+		{name: "oneof", change: func(r *request) { r.Status = "closed" }, want: []string{"status:oneof"}},
+		{name: "oneof accepts an option", change: func(r *request) { r.Status = "paused" }},
+		{name: "email", change: func(r *request) { r.Email = "Ana <ana@example.com>" }, want: []string{"email:email"}},
+		{name: "email accepts an address", change: func(r *request) { r.Email = "ana@example.com" }},
+		{name: "url must be absolute", change: func(r *request) { r.URL = "/hooks" }, want: []string{"url:url"}},
+		{name: "url accepts an absolute url", change: func(r *request) { r.URL = "https://partner.example.com/hooks" }},
+		{name: "max on slice length", change: func(r *request) { r.Tags = []string{"a", "b", "c"} }, want: []string{"tags:max"}},
+		{name: "dive into slices", change: func(r *request) { r.Tags = []string{"a", ""} }, want: []string{"tags[1]:required"}},
+		{name: "dive into maps", change: func(r *request) { r.Limits = map[string]int{"daily": -1} }, want: []string{"limits[daily]:gte"}},
+		{name: "nested struct", change: func(r *request) { r.Address = address{Postcode: "123"} }, want: []string{"address.city:required", "address.postcode:len"}},
+		{
+			name: "slices of structs and their pointers",
+			change: func(r *request) {
+				r.Members = []member{{Name: "Ana"}, {Name: "Bernadette", Address: &address{}}}
+			},
+			want: []string{"members[1].name:max", "members[1].address.city:required"},
+		},
+		{name: "every failing field is reported", change: func(r *request) { *r = request{} }, want: []string{"name:required", "amount:gt", "weeks:gte", "address.city:required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.change(&req)
+
+			var got []string
+			for _, err := range Struct(&req) {
+				got = append(got, err.Field+":"+err.Rule)
+				if !strings.HasPrefix(err.Message, err.Field+" ") {
+					t.Errorf("message %q does not name the field %s", err.Message, err.Field)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructMessages(t *testing.T) {
+	type limits struct {
+		Amount float64  `json:"amount" validate:"gt=0"`
+		Name   string   `json:"name" validate:"len=1"`
+		Items  []string `json:"items" validate:"min=2"`
+		Kind   string   `json:"kind" validate:"oneof=a b"`
+	}
+
+	want := []string{
+		"amount must be greater than 0",
+		"name must be exactly 1 character",
+		"items must be at least 2 items",
+		"kind must be one of a, b",
+	}
+	errs := Struct(limits{Name: "ab"})
+	if len(errs) != len(want) {
+		t.Fatalf("got %v, want %v", errs, want)
+	}
+	for i, err := range errs {
+		if err.Message != want[i] {
+			t.Errorf("got %q, want %q", err.Message, want[i])
+		}
+	}
+}
+
+func TestStructIgnoresNonStructs(t *testing.T) {
+	var nilRequest *request
+	for _, v := range []interface{}{nil, nilRequest, "text", 3} {
+		if errs := Struct(v); errs != nil {
+			t.Errorf("Struct(%v) = %v, want nil", v, errs)
+		}
+	}
+}
+
+func TestStructPanicsOnMistakenTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"unknown rule", struct {
+			Name string `validate:"alphanum"`
+		}{Name: "x"}},
+		{"rule without a number", struct {
+			Name string `validate:"max=ten"`
+		}{Name: "x"}},
+		{"comparison of a bool", struct {
+			Done bool `validate:"gt=0"`
+		}{Done: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			Struct(tt.v)
+		})
+	}
+}
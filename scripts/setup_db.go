@@ -3,6 +3,7 @@ package main
 import (
 	"AmarthaExample1/internal/config"
 	"AmarthaExample1/internal/models"
+	"AmarthaExample1/internal/repositories"
 	"fmt"
 	"log"
 	"os"
@@ -54,10 +55,27 @@ func main() {
 
 	fmt.Println("Successfully connected to database")
 
-	err := db.Conn.AutoMigrate(&models.Borrower{}, &models.Loan{}, &models.Payment{})
+	err := db.Conn.AutoMigrate(
+		&models.Borrower{}, &models.Loan{}, &models.Payment{},
+		&models.WriteOff{}, &models.Recovery{}, &models.Restructure{}, &models.PaymentHoliday{},
+		&models.Product{}, &models.CalendarHoliday{}, &models.Group{}, &models.GroupMember{},
+		&models.Officer{}, &models.PaymentTransaction{}, &models.CollectionBatch{}, &models.CollectionBatchLine{},
+		&models.StatementImport{}, &models.StatementLine{}, &models.GatewayPayment{},
+		&models.ReconciliationRun{}, &models.ReconciliationItem{},
+		&models.Lender{}, &models.Funding{}, &models.LenderPayout{},
+		&models.ProductFee{}, &models.LoanCharge{}, &models.LoanApplication{},
+		&models.APIKey{}, &models.AuditLog{}, &models.AuditChainHead{},
+		&models.Tenant{}, &models.Branch{}, &models.OutboxEvent{},
+		&models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.WebhookAttempt{},
+		&models.NotificationTemplate{}, &models.NotificationOptOut{}, &models.Notification{},
+		&models.CollectionCase{}, &models.CollectionActivity{},
+	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database schema: %v", err)
 	}
+	if err := repositories.InstallAuditGuards(db.Conn); err != nil {
+		log.Fatalf("Failed to install audit log guards: %v", err)
+	}
 
 	createDummyData(db.Conn)
 
@@ -65,11 +83,50 @@ func main() {
 }
 
 func createDummyData(db *gorm.DB) {
+	tenant := models.Tenant{
+		ID:     models.DefaultTenantID,
+		Code:   "default",
+		Name:   "Default",
+		Status: "active",
+	}
+
+	if err := db.Create(&tenant).Error; err != nil {
+		log.Fatalf("Failed to create tenant: %v", err)
+	}
+
+	jakarta := models.Branch{
+		ID:       1,
+		TenantID: tenant.ID,
+		Code:     "JKT",
+		Name:     "Jakarta",
+		Region:   "Jakarta",
+		Status:   "active",
+	}
+
+	if err := db.Create(&jakarta).Error; err != nil {
+		log.Fatalf("Failed to create branch: %v", err)
+	}
+
+	bogor := models.Branch{
+		ID:       2,
+		TenantID: tenant.ID,
+		Code:     "BGR",
+		Name:     "Bogor",
+		Region:   "Bogor",
+		Status:   "active",
+	}
+
+	if err := db.Create(&bogor).Error; err != nil {
+		log.Fatalf("Failed to create branch: %v", err)
+	}
+
 	borrower1 := models.Borrower{
 		ID:        1,
+		BranchID:  &jakarta.ID,
 		FirstName: "Test1",
 		LastName:  "Test1",
 		Email:     "test1@example.com",
+		Region:    "Jakarta",
 		Phone:     "1234567890",
 	}
 
@@ -79,9 +136,11 @@ func createDummyData(db *gorm.DB) {
 
 	borrower2 := models.Borrower{
 		ID:        2,
+		BranchID:  &bogor.ID,
 		FirstName: "Test2",
 		LastName:  "Test2",
 		Email:     "test2@example.com",
+		Region:    "Bogor",
 		Phone:     "0987654321",
 	}
 
@@ -91,9 +150,11 @@ func createDummyData(db *gorm.DB) {
 
 	borrower3 := models.Borrower{
 		ID:        3,
+		BranchID:  &bogor.ID,
 		FirstName: "Test3",
 		LastName:  "Test3",
 		Email:     "test3@example.com",
+		Region:    "Bogor",
 		Phone:     "5556667777",
 	}
 
@@ -101,9 +162,20 @@ func createDummyData(db *gorm.DB) {
 		log.Fatalf("Failed to create borrower: %v", err)
 	}
 
+	product := models.Product{
+		Code:        "WEEKLY-50",
+		Name:        "50-week group loan",
+		DueDateRule: "roll_forward",
+	}
+
+	if err := db.Create(&product).Error; err != nil {
+		log.Fatalf("Failed to create product: %v", err)
+	}
+
 	// Loan 1 - normal loan with first 3 weeks paid
 	loan1 := models.Loan{
 		BorrowerID:    1,
+		BranchID:      &jakarta.ID,
 		Amount:        5000000,
 		InterestRate:  10,
 		TotalAmount:   5500000,
@@ -122,11 +194,12 @@ func createDummyData(db *gorm.DB) {
 	for i := 1; i <= loan1.TotalWeeks; i++ {
 		dueDate := startDate.AddDate(0, 0, i*7)
 		payment := models.Payment{
-			LoanID:  loan1.ID,
-			WeekNum: i,
-			DueDate: dueDate,
-			Amount:  loan1.WeeklyPayment,
-			Status:  "pending",
+			LoanID:   loan1.ID,
+			BranchID: loan1.BranchID,
+			WeekNum:  i,
+			DueDate:  dueDate,
+			Amount:   loan1.WeeklyPayment,
+			Status:   "pending",
 		}
 
 		if i <= 3 {
@@ -144,6 +217,7 @@ func createDummyData(db *gorm.DB) {
 	// Loan 2 - with one missed payment (not delinquent)
 	loan2 := models.Loan{
 		BorrowerID:    2,
+		BranchID:      &bogor.ID,
 		Amount:        5000000,
 		InterestRate:  10,
 		TotalAmount:   5500000,
@@ -164,11 +238,12 @@ func createDummyData(db *gorm.DB) {
 
 		// Create payment record (pending by default)
 		payment := models.Payment{
-			LoanID:  loan2.ID,
-			Amount:  loan2.WeeklyPayment,
-			WeekNum: i,
-			DueDate: dueDate,
-			Status:  "pending",
+			LoanID:   loan2.ID,
+			BranchID: loan2.BranchID,
+			Amount:   loan2.WeeklyPayment,
+			WeekNum:  i,
+			DueDate:  dueDate,
+			Status:   "pending",
 		}
 
 		// For the first 2 weeks, mark as paid
@@ -187,6 +262,7 @@ func createDummyData(db *gorm.DB) {
 	// Loan 3 - delinquent loan with multiple consecutive missed payments
 	loan3 := models.Loan{
 		BorrowerID:    3,
+		BranchID:      &bogor.ID,
 		Amount:        5000000,
 		InterestRate:  10,
 		TotalAmount:   5500000,
@@ -205,11 +281,12 @@ func createDummyData(db *gorm.DB) {
 		dueDate := loan3.StartDate.AddDate(0, 0, i*7)
 
 		payment := models.Payment{
-			LoanID:  loan3.ID,
-			WeekNum: i,
-			DueDate: dueDate,
-			Amount:  loan3.WeeklyPayment,
-			Status:  "pending",
+			LoanID:   loan3.ID,
+			BranchID: loan3.BranchID,
+			WeekNum:  i,
+			DueDate:  dueDate,
+			Amount:   loan3.WeeklyPayment,
+			Status:   "pending",
 		}
 
 		// Mark first 2 payments as paid (weeks 3-5 will be missed)
@@ -226,4 +303,21 @@ func createDummyData(db *gorm.DB) {
 	}
 
 	fmt.Println("Created dummy loans and payments successfully")
+
+	// Default reminder schedule for products without one of their own
+	templates := []models.NotificationTemplate{
+		{Stage: "reminder", OffsetDays: 2, Channel: "sms", Body: "Hi {{.FirstName}}, your installment {{.WeekNum}} of Rp{{.Amount}} is due on {{.DueDate}}. Pay to VA {{.VirtualAccount}}."},
+		{Stage: "due", OffsetDays: 0, Channel: "whatsapp", Body: "Hi {{.FirstName}}, your installment of Rp{{.Amount}} is due today. Pay to VA {{.VirtualAccount}}."},
+		{Stage: "dunning", OffsetDays: 1, Channel: "sms", Body: "Hi {{.FirstName}}, your installment of Rp{{.Amount}} due {{.DueDate}} was missed. Please pay to VA {{.VirtualAccount}} today."},
+		{Stage: "dunning", OffsetDays: 7, Channel: "sms", Body: "{{.FirstName}}, your installment of Rp{{.Amount}} is {{.DaysPastDue}} days overdue. Your field officer will visit at the next meeting."},
+		{Stage: "dunning", OffsetDays: 14, Channel: "email", Subject: "Loan {{.LoanID}}: final notice", Body: "Dear {{.FirstName}} {{.LastName}}, your installment of Rp{{.Amount}} due {{.DueDate}} is {{.DaysPastDue}} days overdue. Please pay immediately to avoid your loan being declared in default."},
+	}
+	for i := range templates {
+		templates[i].Active = true
+		if err := db.Create(&templates[i]).Error; err != nil {
+			log.Fatalf("Failed to create notification template: %v", err)
+		}
+	}
+
+	fmt.Println("Created default notification templates successfully")
 }